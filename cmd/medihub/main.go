@@ -1,19 +1,24 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"log"
 	"os"
 	"time"
 
 	"github.com/Okemwag/medihub/internal/controllers"
+	"github.com/Okemwag/medihub/internal/middleware"
 	"github.com/Okemwag/medihub/internal/routes"
 	"github.com/Okemwag/medihub/internal/seeder"
 	"github.com/Okemwag/medihub/internal/services"
+	"github.com/Okemwag/medihub/internal/validation"
 	"github.com/Okemwag/medihub/pkg/config"
 	"github.com/Okemwag/medihub/pkg/database"
-	"github.com/gin-gonic/gin"
 	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
 	_ "github.com/lib/pq" // PostgreSQL driver
 	"github.com/pressly/goose/v3"
 )
@@ -47,33 +52,137 @@ func main() {
 		log.Println("Proceeding to start the server...")
 	}
 
+	// Run a maintenance command instead of the server when one is given, e.g. import-icd10
+	if len(os.Args) > 1 {
+		if err := runCommand(os.Args[1:]); err != nil {
+			log.Printf("Error: %v", err)
+			database.DB.Close()
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Seed database
 	log.Println("Seeding database...")
 	seeder.SeedUsers()
 
 	// Initialize AuthService and AuthController
 	jwtSecret := os.Getenv("JWT_SECRET") // Retrieve JWT secret from environment variables
-	tokenExpiry := 24 * time.Hour
-	authService := services.NewAuthService(jwtSecret, tokenExpiry)
+	accessTokenExpiry := config.Duration("ACCESS_TOKEN_TTL", 15*time.Minute)
+	refreshTokenExpiry := config.Duration("REFRESH_TOKEN_TTL", 7*24*time.Hour)
+	revocationService := services.NewTokenRevocationService(database.DB, 30*time.Second)
+	authService := services.NewAuthService(jwtSecret, accessTokenExpiry, refreshTokenExpiry, revocationService)
 	authController := controllers.NewAuthController(authService)
 
+	// Initialize audit logging
+	auditService := services.NewAuditService(database.DB)
+	auditController := controllers.NewAuditController(auditService)
+
 	// Initialize PatientController
-	patientController := controllers.NewPatientController(database.DB)
+	patientRetention := config.Duration("PATIENT_RETENTION_PERIOD", 10*365*24*time.Hour)
+	patientController := controllers.NewPatientController(services.NewPatientService(database.DB, auditService, patientRetention))
+
+	// Initialize AppointmentController
+	appointmentController := controllers.NewAppointmentController(services.NewAppointmentService(database.DB, auditService))
+
+	// Initialize ScheduleController
+	scheduleController := controllers.NewScheduleController(services.NewScheduleService(database.DB, auditService))
+
+	// Initialize EncounterController
+	encounterController := controllers.NewEncounterController(services.NewEncounterService(database.DB, auditService))
+
+	// Initialize VitalController
+	vitalController := controllers.NewVitalController(services.NewVitalService(database.DB, auditService))
+
+	// Initialize AllergyController
+	allergyController := controllers.NewAllergyController(services.NewAllergyService(database.DB, auditService))
+
+	// Initialize DrugController and PrescriptionController
+	drugController := controllers.NewDrugController(services.NewDrugService(database.DB, auditService))
+	prescriptionController := controllers.NewPrescriptionController(services.NewPrescriptionService(database.DB, auditService))
+
+	// Initialize LabTestController and LabOrderController
+	labTestController := controllers.NewLabTestController(services.NewLabTestService(database.DB, auditService))
+	labOrderController := controllers.NewLabOrderController(services.NewLabOrderService(database.DB, auditService))
+
+	// Initialize ICD10Controller and ProblemController
+	icd10Controller := controllers.NewICD10Controller(services.NewICD10Service(database.DB, auditService))
+	problemController := controllers.NewProblemController(services.NewProblemService(database.DB, auditService))
+
+	// Initialize ServiceCatalogueController and InvoiceController
+	serviceCatalogueController := controllers.NewServiceCatalogueController(services.NewServiceCatalogueService(database.DB, auditService))
+	invoiceController := controllers.NewInvoiceController(services.NewInvoiceService(database.DB, auditService))
+
+	// Initialize PayerController and InsurancePolicyController
+	payerController := controllers.NewPayerController(services.NewPayerService(database.DB, auditService))
+	insurancePolicyController := controllers.NewInsurancePolicyController(services.NewInsurancePolicyService(database.DB, auditService))
+
+	// Initialize ContactController
+	contactController := controllers.NewContactController(services.NewContactService(database.DB, auditService))
+
+	// Initialize UserController
+	userController := controllers.NewUserController(services.NewUserService(database.DB, authService, auditService))
+
+	// Resolve the permissions of authenticated principals from role_permissions
+	permissionService := services.NewPermissionService(database.DB, auditService, config.Duration("PERMISSION_CACHE_TTL", 5*time.Minute))
+
+	// Initialize RoleController
+	roleController := controllers.NewRoleController(permissionService)
+
+	// Register custom request validation rules (phone numbers, dates of birth, gender codes)
+	validation.Register()
 
 	// Initialize Gin router
 	router := gin.Default()
 
+	// Only take the client IP from X-Forwarded-For when the request comes through one of our own
+	// proxies, so that clients cannot forge the IP recorded in the audit trail
+	if err := router.SetTrustedProxies(config.List("TRUSTED_PROXIES")); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+
+	// Attach a request ID and client IP to every request for auditing
+	router.Use(middleware.RequestContext())
+
+	// Render errors reported by handlers as RFC 7807 problem+json
+	router.Use(middleware.ErrorHandler())
+
 	// Configure CORS middleware
 	router.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"http://localhost:3000"}, 
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}, 
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"}, 
-		ExposeHeaders:    []string{"Content-Length"}, // Exposed headers
-		AllowCredentials: true, // Allow credentials (e.g., cookies)
+		AllowOrigins:     []string{"http://localhost:3000"},
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "If-Match", middleware.RequestIDHeader},
+		ExposeHeaders:    []string{"Content-Length", "ETag", middleware.RequestIDHeader}, // Exposed headers
+		AllowCredentials: true,                                                           // Allow credentials (e.g., cookies)
 	}))
 
 	// Register routes
-	routes.RegisterRoutes(router, authController, patientController, jwtSecret)
+	routes.RegisterRoutes(router, routes.Dependencies{
+		AuthController:             authController,
+		PatientController:          patientController,
+		UserController:             userController,
+		RoleController:             roleController,
+		AuditController:            auditController,
+		AppointmentController:      appointmentController,
+		ScheduleController:         scheduleController,
+		EncounterController:        encounterController,
+		VitalController:            vitalController,
+		AllergyController:          allergyController,
+		DrugController:             drugController,
+		PrescriptionController:     prescriptionController,
+		LabTestController:          labTestController,
+		LabOrderController:         labOrderController,
+		ICD10Controller:            icd10Controller,
+		ProblemController:          problemController,
+		ServiceCatalogueController: serviceCatalogueController,
+		InvoiceController:          invoiceController,
+		PayerController:            payerController,
+		InsurancePolicyController:  insurancePolicyController,
+		ContactController:          contactController,
+		JWTSecret:                  jwtSecret,
+		Revocations:                revocationService,
+		Permissions:                permissionService,
+	})
 
 	// Start the server
 	port := "8000"
@@ -83,6 +192,35 @@ func main() {
 	}
 }
 
+// runCommand runs a maintenance command given on the command line.
+//
+// Supported commands:
+//
+//	import-icd10 <file.csv>  Load the ICD-10 code catalogue from a CSV file of code and description columns
+func runCommand(args []string) error {
+	switch args[0] {
+	case "import-icd10":
+		if len(args) != 2 {
+			return errors.New("usage: medihub import-icd10 <file.csv>")
+		}
+		f, err := os.Open(args[1])
+		if err != nil {
+			return fmt.Errorf("failed to open ICD-10 file: %w", err)
+		}
+		defer f.Close()
+
+		icd10Service := services.NewICD10Service(database.DB, services.NewAuditService(database.DB))
+		result, err := icd10Service.ImportCSV(context.Background(), f)
+		if err != nil {
+			return fmt.Errorf("failed to import ICD-10 codes: %w", err)
+		}
+		log.Printf("Imported %d ICD-10 codes, deactivated %d codes missing from the file", result.Imported, result.Deactivated)
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
+
 // runMigrations runs database migrations using Goose.
 func runMigrations(db *sql.DB, migrationsDir string) error {
 	// Set the dialect for Goose (PostgreSQL in this case)
@@ -97,4 +235,4 @@ func runMigrations(db *sql.DB, migrationsDir string) error {
 
 	log.Println("Migrations executed successfully.")
 	return nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,222 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Okemwag/medihub/internal/models"
+	"github.com/Okemwag/medihub/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// AllergyController handles HTTP requests for patients' allergies and adverse reactions.
+type AllergyController struct {
+	allergyService *services.AllergyService // Service for allergy operations
+}
+
+// NewAllergyController creates a new instance of AllergyController.
+//
+// @param allergyService *services.AllergyService: The allergy service.
+// @return *AllergyController: A new AllergyController instance.
+func NewAllergyController(allergyService *services.AllergyService) *AllergyController {
+	return &AllergyController{allergyService: allergyService}
+}
+
+// CreateAllergy records an allergy of a patient.
+//
+// @Summary Record an allergy
+// @Description Record an allergy or adverse reaction of a patient; category defaults to other and status to active
+// @Tags allergies
+// @Accept json
+// @Produce json
+// @Param id path int true "Patient ID"
+// @Param allergy body models.Allergy true "Allergy data"
+// @Success 201 {object} models.Allergy "The recorded allergy"
+// @Failure 400 {object} middleware.Problem "Invalid patient ID or request payload"
+// @Failure 404 {object} middleware.Problem "Patient not found"
+// @Failure 409 {object} middleware.Problem "The patient already has an active allergy to this substance"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /patients/{id}/allergies [post]
+func (c *AllergyController) CreateAllergy(ctx *gin.Context) {
+	patientID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(errInvalidPatientID)
+		return
+	}
+
+	var allergy models.Allergy
+	if err := ctx.ShouldBindJSON(&allergy); err != nil {
+		ctx.Error(services.InvalidInput(err))
+		return
+	}
+
+	// Retrieve the authenticated principal (set during authentication)
+	principal, ok := currentPrincipal(ctx)
+	if !ok {
+		return
+	}
+	allergy.RecordedBy = principal.UserID
+	allergy.UpdatedBy = principal.UserID
+
+	// Record the allergy using the service
+	created, err := c.allergyService.CreateAllergy(ctx.Request.Context(), patientID, &allergy)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, created)
+}
+
+// ListAllergies retrieves a patient's allergies.
+//
+// @Summary List a patient's allergies
+// @Description Retrieve a patient's allergies and adverse reactions, most severe first
+// @Tags allergies
+// @Produce json
+// @Param id path int true "Patient ID"
+// @Param status query string false "Only allergies in this status (active, inactive, resolved, entered_in_error)"
+// @Success 200 {array} models.Allergy "The patient's allergies"
+// @Failure 400 {object} middleware.Problem "Invalid patient ID"
+// @Failure 404 {object} middleware.Problem "Patient not found"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /patients/{id}/allergies [get]
+func (c *AllergyController) ListAllergies(ctx *gin.Context) {
+	patientID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(errInvalidPatientID)
+		return
+	}
+
+	// Retrieve the allergies using the service
+	allergies, err := c.allergyService.ListAllergies(ctx.Request.Context(), patientID, ctx.Query("status"))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, allergies)
+}
+
+// GetAllergy retrieves one of a patient's allergies.
+//
+// @Summary Get an allergy
+// @Description Retrieve one of a patient's allergies by its ID
+// @Tags allergies
+// @Produce json
+// @Param id path int true "Patient ID"
+// @Param allergyId path int true "Allergy ID"
+// @Success 200 {object} models.Allergy "The allergy"
+// @Failure 400 {object} middleware.Problem "Invalid patient or allergy ID"
+// @Failure 404 {object} middleware.Problem "Patient or allergy not found"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /patients/{id}/allergies/{allergyId} [get]
+func (c *AllergyController) GetAllergy(ctx *gin.Context) {
+	patientID, id, ok := allergyParams(ctx)
+	if !ok {
+		return
+	}
+
+	// Retrieve the allergy using the service
+	allergy, err := c.allergyService.GetAllergy(ctx.Request.Context(), patientID, id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, allergy)
+}
+
+// UpdateAllergy replaces the details of one of a patient's allergies.
+//
+// @Summary Update an allergy
+// @Description Replace the details of an allergy, e.g. to change its severity or mark it resolved
+// @Tags allergies
+// @Accept json
+// @Produce json
+// @Param id path int true "Patient ID"
+// @Param allergyId path int true "Allergy ID"
+// @Param allergy body models.Allergy true "Allergy data"
+// @Success 200 {object} models.Allergy "The updated allergy"
+// @Failure 400 {object} middleware.Problem "Invalid patient or allergy ID, or request payload"
+// @Failure 404 {object} middleware.Problem "Patient or allergy not found"
+// @Failure 409 {object} middleware.Problem "The patient already has an active allergy to this substance, or the allergy was entered in error"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /patients/{id}/allergies/{allergyId} [put]
+func (c *AllergyController) UpdateAllergy(ctx *gin.Context) {
+	patientID, id, ok := allergyParams(ctx)
+	if !ok {
+		return
+	}
+
+	var allergy models.Allergy
+	if err := ctx.ShouldBindJSON(&allergy); err != nil {
+		ctx.Error(services.InvalidInput(err))
+		return
+	}
+
+	// Retrieve the authenticated principal (set during authentication)
+	principal, ok := currentPrincipal(ctx)
+	if !ok {
+		return
+	}
+
+	// Update the allergy using the service
+	updated, err := c.allergyService.UpdateAllergy(ctx.Request.Context(), patientID, id, &allergy, principal.UserID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, updated)
+}
+
+// DeleteAllergy retires one of a patient's allergies that was recorded by mistake.
+//
+// @Summary Delete an allergy
+// @Description Mark an allergy recorded by mistake as entered_in_error; the record is kept for the audit trail. Mark allergies that no longer apply as inactive or resolved instead
+// @Tags allergies
+// @Param id path int true "Patient ID"
+// @Param allergyId path int true "Allergy ID"
+// @Success 204 "Allergy marked entered_in_error"
+// @Failure 400 {object} middleware.Problem "Invalid patient or allergy ID"
+// @Failure 401 {object} middleware.Problem "Unauthorized"
+// @Failure 404 {object} middleware.Problem "Patient or allergy not found"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /patients/{id}/allergies/{allergyId} [delete]
+func (c *AllergyController) DeleteAllergy(ctx *gin.Context) {
+	patientID, id, ok := allergyParams(ctx)
+	if !ok {
+		return
+	}
+
+	// Retrieve the authenticated principal (set during authentication)
+	principal, ok := currentPrincipal(ctx)
+	if !ok {
+		return
+	}
+
+	// Retire the allergy using the service
+	if err := c.allergyService.DeleteAllergy(ctx.Request.Context(), patientID, id, principal.UserID); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// allergyParams parses the id and allergyId path parameters, reporting a validation error when
+// either is invalid.
+func allergyParams(ctx *gin.Context) (patientID, id int64, ok bool) {
+	patientID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(errInvalidPatientID)
+		return 0, 0, false
+	}
+	id, err = strconv.ParseInt(ctx.Param("allergyId"), 10, 64)
+	if err != nil {
+		ctx.Error(services.NewValidationError("invalid_allergy_id", "Invalid allergy ID"))
+		return 0, 0, false
+	}
+	return patientID, id, true
+}
@@ -0,0 +1,348 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Okemwag/medihub/internal/models"
+	"github.com/Okemwag/medihub/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// errInvalidAppointmentID is reported when the id path parameter is not a valid appointment ID.
+var errInvalidAppointmentID = services.NewValidationError("invalid_appointment_id", "Invalid appointment ID")
+
+// AppointmentController handles HTTP requests for booking and managing appointments.
+type AppointmentController struct {
+	appointmentService *services.AppointmentService // Service for appointment operations
+}
+
+// NewAppointmentController creates a new instance of AppointmentController.
+//
+// @param appointmentService *services.AppointmentService: The appointment service.
+// @return *AppointmentController: A new AppointmentController instance.
+func NewAppointmentController(appointmentService *services.AppointmentService) *AppointmentController {
+	return &AppointmentController{appointmentService: appointmentService}
+}
+
+// CreateAppointment books a new appointment.
+//
+// @Summary Book an appointment
+// @Description Book a patient with a doctor; the doctor and the patient must both be free for the whole period
+// @Tags appointments
+// @Accept json
+// @Produce json
+// @Param appointment body models.Appointment true "Appointment data"
+// @Success 201 {object} models.Appointment "The booked appointment"
+// @Failure 400 {object} middleware.Problem "Invalid request payload, time or doctor"
+// @Failure 404 {object} middleware.Problem "Patient not found"
+// @Failure 409 {object} middleware.Problem "The doctor or patient is already booked at that time"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /appointments [post]
+func (c *AppointmentController) CreateAppointment(ctx *gin.Context) {
+	var appointment models.Appointment
+	if err := ctx.ShouldBindJSON(&appointment); err != nil {
+		ctx.Error(services.InvalidInput(err))
+		return
+	}
+
+	// Retrieve the authenticated principal (set during authentication)
+	principal, ok := currentPrincipal(ctx)
+	if !ok {
+		return
+	}
+	appointment.CreatedBy = principal.UserID
+	appointment.UpdatedBy = principal.UserID
+
+	// Book the appointment using the service
+	created, err := c.appointmentService.CreateAppointment(ctx.Request.Context(), &appointment)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, created)
+}
+
+// ListAppointments retrieves a filtered, paginated list of appointments.
+//
+// @Summary List appointments
+// @Description Retrieve appointments filtered by patient, doctor, status and time range, ordered by start time
+// @Tags appointments
+// @Produce json
+// @Param patient_id query int false "Only appointments of this patient"
+// @Param doctor_id query int false "Only appointments with this doctor"
+// @Param status query string false "Only appointments in this status (scheduled, checked_in, completed, cancelled, no_show)"
+// @Param from query string false "Only appointments ending after this time (RFC3339 or YYYY-MM-DD)"
+// @Param to query string false "Only appointments starting before this time (RFC3339 or YYYY-MM-DD)"
+// @Param page query int false "Page number (1-based)"
+// @Param page_size query int false "Number of appointments per page (max 100)"
+// @Success 200 {object} services.AppointmentListResult "A page of appointments"
+// @Failure 400 {object} middleware.Problem "Invalid query parameters"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /appointments [get]
+func (c *AppointmentController) ListAppointments(ctx *gin.Context) {
+	params := services.AppointmentListParams{Status: ctx.Query("status")}
+
+	var err error
+	if params.PatientID, err = queryInt64(ctx, "patient_id"); err != nil {
+		ctx.Error(services.NewValidationError("invalid_query", err.Error()))
+		return
+	}
+	if params.DoctorID, err = queryInt64(ctx, "doctor_id"); err != nil {
+		ctx.Error(services.NewValidationError("invalid_query", err.Error()))
+		return
+	}
+	if params.From, err = queryTime(ctx, "from"); err != nil {
+		ctx.Error(services.NewValidationError("invalid_query", err.Error()))
+		return
+	}
+	if params.To, err = queryTime(ctx, "to"); err != nil {
+		ctx.Error(services.NewValidationError("invalid_query", err.Error()))
+		return
+	}
+	if params.Page, err = queryInt(ctx, "page"); err != nil {
+		ctx.Error(services.NewValidationError("invalid_query", err.Error()))
+		return
+	}
+	if params.PageSize, err = queryInt(ctx, "page_size"); err != nil {
+		ctx.Error(services.NewValidationError("invalid_query", err.Error()))
+		return
+	}
+
+	// Retrieve the page using the service
+	result, err := c.appointmentService.ListAppointments(ctx.Request.Context(), params)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+// GetAppointment retrieves an appointment by ID.
+//
+// @Summary Get an appointment by ID
+// @Description Retrieve an appointment by its ID
+// @Tags appointments
+// @Produce json
+// @Param id path int true "Appointment ID"
+// @Success 200 {object} models.Appointment "The appointment"
+// @Failure 400 {object} middleware.Problem "Invalid appointment ID"
+// @Failure 404 {object} middleware.Problem "Appointment not found"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /appointments/{id} [get]
+func (c *AppointmentController) GetAppointment(ctx *gin.Context) {
+	id, ok := appointmentIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	// Retrieve the appointment using the service
+	appointment, err := c.appointmentService.GetAppointment(ctx.Request.Context(), id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, appointment)
+}
+
+// RescheduleAppointment moves a scheduled appointment to a new time.
+//
+// @Summary Reschedule an appointment
+// @Description Move a scheduled appointment to a new period; the doctor and the patient must both be free
+// @Tags appointments
+// @Accept json
+// @Produce json
+// @Param id path int true "Appointment ID"
+// @Param request body struct{StartsAt time.Time; EndsAt time.Time} true "New start and end time"
+// @Success 200 {object} models.Appointment "The rescheduled appointment"
+// @Failure 400 {object} middleware.Problem "Invalid appointment ID, request payload or time"
+// @Failure 404 {object} middleware.Problem "Appointment not found"
+// @Failure 409 {object} middleware.Problem "The appointment is not scheduled, or the new time is already booked"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /appointments/{id}/reschedule [post]
+func (c *AppointmentController) RescheduleAppointment(ctx *gin.Context) {
+	id, ok := appointmentIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		StartsAt time.Time `json:"starts_at" binding:"required"`
+		EndsAt   time.Time `json:"ends_at" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(services.InvalidInput(err))
+		return
+	}
+
+	// Retrieve the authenticated principal (set during authentication)
+	principal, ok := currentPrincipal(ctx)
+	if !ok {
+		return
+	}
+
+	// Reschedule the appointment using the service
+	appointment, err := c.appointmentService.RescheduleAppointment(ctx.Request.Context(), id, req.StartsAt, req.EndsAt, principal.UserID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, appointment)
+}
+
+// CancelAppointment cancels an appointment.
+//
+// @Summary Cancel an appointment
+// @Description Cancel an appointment that has not yet been completed, freeing its time for other bookings
+// @Tags appointments
+// @Accept json
+// @Produce json
+// @Param id path int true "Appointment ID"
+// @Param request body struct{Reason string} false "Reason for the cancellation"
+// @Success 200 {object} models.Appointment "The cancelled appointment"
+// @Failure 400 {object} middleware.Problem "Invalid appointment ID or request payload"
+// @Failure 404 {object} middleware.Problem "Appointment not found"
+// @Failure 409 {object} middleware.Problem "The appointment can no longer be cancelled"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /appointments/{id}/cancel [post]
+func (c *AppointmentController) CancelAppointment(ctx *gin.Context) {
+	id, ok := appointmentIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Reason string `json:"reason" binding:"max=500"`
+	}
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.Error(services.InvalidInput(err))
+			return
+		}
+	}
+
+	// Retrieve the authenticated principal (set during authentication)
+	principal, ok := currentPrincipal(ctx)
+	if !ok {
+		return
+	}
+
+	// Cancel the appointment using the service
+	appointment, err := c.appointmentService.CancelAppointment(ctx.Request.Context(), id, req.Reason, principal.UserID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, appointment)
+}
+
+// UpdateAppointmentStatus records check-in, completion or a no-show.
+//
+// @Summary Update an appointment's status
+// @Description Record that the patient checked in (scheduled to checked_in), was seen (checked_in to completed) or did not show up (scheduled to no_show)
+// @Tags appointments
+// @Accept json
+// @Produce json
+// @Param id path int true "Appointment ID"
+// @Param request body struct{Status string} true "New status: checked_in, completed or no_show"
+// @Success 200 {object} models.Appointment "The updated appointment"
+// @Failure 400 {object} middleware.Problem "Invalid appointment ID or request payload"
+// @Failure 404 {object} middleware.Problem "Appointment not found"
+// @Failure 409 {object} middleware.Problem "The appointment cannot move to the requested status"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /appointments/{id}/status [post]
+func (c *AppointmentController) UpdateAppointmentStatus(ctx *gin.Context) {
+	id, ok := appointmentIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Status string `json:"status" binding:"required,oneof=checked_in completed no_show"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(services.InvalidInput(err))
+		return
+	}
+
+	// Retrieve the authenticated principal (set during authentication)
+	principal, ok := currentPrincipal(ctx)
+	if !ok {
+		return
+	}
+
+	// Update the status using the service
+	appointment, err := c.appointmentService.UpdateAppointmentStatus(ctx.Request.Context(), id, req.Status, principal.UserID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, appointment)
+}
+
+// GetDoctorCalendar retrieves a doctor's appointments for a day.
+//
+// @Summary Get a doctor's daily calendar
+// @Description Retrieve every appointment of a doctor on the given day, including cancelled ones, ordered by start time
+// @Tags appointments
+// @Produce json
+// @Param id path int true "Doctor user ID"
+// @Param date query string false "Day (YYYY-MM-DD); defaults to today"
+// @Success 200 {array} models.Appointment "The doctor's appointments for the day"
+// @Failure 400 {object} middleware.Problem "Invalid doctor ID or date"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /doctors/{id}/calendar [get]
+func (c *AppointmentController) GetDoctorCalendar(ctx *gin.Context) {
+	doctorID, ok := doctorIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	day, err := queryDate(ctx, "date")
+	if err != nil {
+		ctx.Error(services.NewValidationError("invalid_query", err.Error()))
+		return
+	}
+
+	// Retrieve the calendar using the service
+	appointments, err := c.appointmentService.DoctorCalendar(ctx.Request.Context(), doctorID, day)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, appointments)
+}
+
+// appointmentIDParam parses the id path parameter, reporting a validation error when it is invalid.
+func appointmentIDParam(ctx *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(errInvalidAppointmentID)
+		return 0, false
+	}
+	return id, true
+}
+
+// queryDate parses an optional YYYY-MM-DD query parameter as midnight in the server's time zone,
+// defaulting to today when it is absent.
+func queryDate(ctx *gin.Context, key string) (time.Time, error) {
+	value := ctx.Query(key)
+	if value == "" {
+		now := time.Now()
+		return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local), nil
+	}
+	day, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s: expected YYYY-MM-DD", key)
+	}
+	return day, nil
+}
@@ -0,0 +1,95 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Okemwag/medihub/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// AuditController handles HTTP requests for querying the audit trail.
+type AuditController struct {
+	auditService *services.AuditService // Service for audit log operations
+}
+
+// NewAuditController creates a new instance of AuditController.
+//
+// @param auditService *services.AuditService: The audit service.
+// @return *AuditController: A new AuditController instance.
+func NewAuditController(auditService *services.AuditService) *AuditController {
+	return &AuditController{auditService: auditService}
+}
+
+// ListAuditEntries queries the audit trail.
+//
+// @Summary Query the audit trail
+// @Description Retrieve audit entries filtered by patient, user, action and time range, most recent first
+// @Tags audit
+// @Produce json
+// @Param patient_id query int false "Only entries about this patient"
+// @Param user_id query int false "Only entries made by this user"
+// @Param entity_type query string false "Only entries about this entity type (e.g. patient, user)"
+// @Param action query string false "Only entries with this action (e.g. patient.read)"
+// @Param from query string false "Earliest time (RFC3339 or YYYY-MM-DD)"
+// @Param to query string false "Latest time (RFC3339 or YYYY-MM-DD)"
+// @Param page query int false "Page number (1-based)"
+// @Param page_size query int false "Number of entries per page (max 100)"
+// @Success 200 {object} services.AuditPage "A page of audit entries"
+// @Failure 400 {object} middleware.Problem "Invalid query parameters"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /audit [get]
+func (c *AuditController) ListAuditEntries(ctx *gin.Context) {
+	q := services.AuditQuery{
+		EntityType: ctx.Query("entity_type"),
+		Action:     ctx.Query("action"),
+	}
+
+	var err error
+	if q.PatientID, err = queryInt64(ctx, "patient_id"); err != nil {
+		ctx.Error(services.NewValidationError("invalid_query", err.Error()))
+		return
+	}
+	if q.UserID, err = queryInt64(ctx, "user_id"); err != nil {
+		ctx.Error(services.NewValidationError("invalid_query", err.Error()))
+		return
+	}
+	if q.From, err = queryTime(ctx, "from"); err != nil {
+		ctx.Error(services.NewValidationError("invalid_query", err.Error()))
+		return
+	}
+	if q.To, err = queryTime(ctx, "to"); err != nil {
+		ctx.Error(services.NewValidationError("invalid_query", err.Error()))
+		return
+	}
+	if q.Page, err = queryInt(ctx, "page"); err != nil {
+		ctx.Error(services.NewValidationError("invalid_query", err.Error()))
+		return
+	}
+	if q.PageSize, err = queryInt(ctx, "page_size"); err != nil {
+		ctx.Error(services.NewValidationError("invalid_query", err.Error()))
+		return
+	}
+
+	page, err := c.auditService.Query(ctx.Request.Context(), q)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, page)
+}
+
+// queryInt64 parses an optional int64 query parameter, returning nil when it is absent.
+func queryInt64(ctx *gin.Context, key string) (*int64, error) {
+	value := ctx.Query(key)
+	if value == "" {
+		return nil, nil
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: must be an integer", key)
+	}
+	return &n, nil
+}
@@ -2,7 +2,10 @@ package controllers
 
 import (
 	"net/http"
+	"strconv"
 
+	"github.com/Okemwag/medihub/internal/middleware"
+	"github.com/Okemwag/medihub/internal/requestctx"
 	"github.com/Okemwag/medihub/internal/services"
 	"github.com/gin-gonic/gin"
 )
@@ -29,8 +32,8 @@ func NewAuthController(authService *services.AuthService) *AuthController {
 // @Produce json
 // @Param request body struct{Username string; Password string} true "Login credentials"
 // @Success 200 {object} services.LoginResponse "Returns the JWT token and user details"
-// @Failure 400 {object} map[string]string "Invalid request payload"
-// @Failure 401 {object} map[string]string "Invalid username or password"
+// @Failure 400 {object} middleware.Problem "Invalid request payload"
+// @Failure 401 {object} middleware.Problem "Invalid username or password"
 // @Router /login [post]
 func (ctrl *AuthController) Login(c *gin.Context) {
 	var req struct {
@@ -40,14 +43,14 @@ func (ctrl *AuthController) Login(c *gin.Context) {
 
 	// Bind the request body to the struct
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		c.Error(services.InvalidInput(err))
 		return
 	}
 
 	// Authenticate the user and generate a JWT token
 	response, err := ctrl.authService.Login(req.Username, req.Password)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -55,15 +58,128 @@ func (ctrl *AuthController) Login(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
-// Logout handles user logout. For stateless JWT, this typically involves client-side token invalidation.
+// Refresh exchanges a refresh token for a new access token and rotated refresh token.
+//
+// @Summary Refresh an access token
+// @Description Exchange a refresh token for a new access token. The refresh token is rotated; replaying an already used refresh token revokes the whole session.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body struct{RefreshToken string} true "Refresh token"
+// @Success 200 {object} services.LoginResponse "Returns the new tokens and user details"
+// @Failure 400 {object} middleware.Problem "Invalid request payload"
+// @Failure 401 {object} middleware.Problem "Invalid, expired or reused refresh token"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /auth/refresh [post]
+func (ctrl *AuthController) Refresh(c *gin.Context) {
+	var req struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
+	}
+
+	// Bind the request body to the struct
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(services.InvalidInput(err))
+		return
+	}
+
+	// Rotate the refresh token and issue a new access token
+	response, err := ctrl.authService.Refresh(req.RefreshToken)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Logout ends the current session by revoking the presented access token and its refresh tokens.
 //
 // @Summary Logout a user
-// @Description Logout the currently authenticated user
+// @Description Logout the currently authenticated session; the access token and its refresh tokens stop working immediately
 // @Tags auth
 // @Produce json
+// @Security BearerAuth
 // @Success 200 {object} map[string]string "Confirmation message"
-// @Router /logout [post]
+// @Failure 401 {object} middleware.Problem "Unauthorized"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /auth/logout [post]
 func (ctrl *AuthController) Logout(c *gin.Context) {
-	// Add session handling if needed
+	principal, ok := currentPrincipal(c)
+	if !ok {
+		return
+	}
+
+	if err := ctrl.authService.Logout(c.Request.Context(), principal.UserID, principal.TokenID, principal.SessionID, principal.ExpiresAt); err != nil {
+		c.Error(err)
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{"message": "logged out"})
-}
\ No newline at end of file
+}
+
+// LogoutAll ends every session of the current user.
+//
+// @Summary Logout all sessions
+// @Description Revoke every access and refresh token issued to the currently authenticated user
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]string "Confirmation message"
+// @Failure 401 {object} middleware.Problem "Unauthorized"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /auth/logout-all [post]
+func (ctrl *AuthController) LogoutAll(c *gin.Context) {
+	principal, ok := currentPrincipal(c)
+	if !ok {
+		return
+	}
+
+	if err := ctrl.authService.LogoutAll(c.Request.Context(), principal.UserID); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "all sessions logged out"})
+}
+
+// RevokeUserSessions ends every session of the given user.
+//
+// @Summary Revoke all sessions of a user
+// @Description Revoke every access and refresh token issued to the given user (administrators only)
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} map[string]string "Confirmation message"
+// @Failure 400 {object} middleware.Problem "Invalid user ID"
+// @Failure 403 {object} middleware.Problem "Forbidden"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /admin/users/{id}/revoke-sessions [post]
+func (ctrl *AuthController) RevokeUserSessions(c *gin.Context) {
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(errInvalidUserID)
+		return
+	}
+
+	if err := ctrl.authService.LogoutAll(c.Request.Context(), userID); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "all sessions revoked"})
+}
+
+// errUnauthenticated is reported when a handler behind AuthMiddleware finds no principal.
+var errUnauthenticated = services.NewUnauthorizedError("unauthenticated", "Unauthorized: no authenticated user")
+
+// currentPrincipal returns the authenticated principal set by AuthMiddleware, reporting an
+// unauthorized error when there is none.
+func currentPrincipal(c *gin.Context) (requestctx.Principal, bool) {
+	principal, ok := middleware.PrincipalFrom(c)
+	if !ok {
+		c.Error(errUnauthenticated)
+		return requestctx.Principal{}, false
+	}
+	return principal, true
+}
@@ -0,0 +1,214 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Okemwag/medihub/internal/models"
+	"github.com/Okemwag/medihub/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// ContactController handles HTTP requests for patients' next of kin, emergency contacts and guardians.
+type ContactController struct {
+	contactService *services.ContactService // Service for patient contact operations
+}
+
+// NewContactController creates a new instance of ContactController.
+//
+// @param contactService *services.ContactService: The patient contact service.
+// @return *ContactController: A new ContactController instance.
+func NewContactController(contactService *services.ContactService) *ContactController {
+	return &ContactController{contactService: contactService}
+}
+
+// CreateContact records a contact for a patient.
+//
+// @Summary Record a patient contact
+// @Description Record a next of kin, emergency contact or guardian of a patient, with their relationship, contact details and consent flags. Guardians can only be recorded for patients under 18, and a patient has at most one next of kin
+// @Tags contacts
+// @Accept json
+// @Produce json
+// @Param id path int true "Patient ID"
+// @Param contact body models.PatientContact true "Contact data"
+// @Success 201 {object} models.PatientContact "The recorded contact"
+// @Failure 400 {object} middleware.Problem "Invalid patient ID or request payload, no role set, or guardian for an adult"
+// @Failure 404 {object} middleware.Problem "Patient not found"
+// @Failure 409 {object} middleware.Problem "The patient already has a next of kin"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /patients/{id}/contacts [post]
+func (c *ContactController) CreateContact(ctx *gin.Context) {
+	patientID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(errInvalidPatientID)
+		return
+	}
+
+	var contact models.PatientContact
+	if err := ctx.ShouldBindJSON(&contact); err != nil {
+		ctx.Error(services.InvalidInput(err))
+		return
+	}
+
+	// Retrieve the authenticated principal (set during authentication)
+	principal, ok := currentPrincipal(ctx)
+	if !ok {
+		return
+	}
+	contact.CreatedBy = principal.UserID
+	contact.UpdatedBy = principal.UserID
+
+	// Record the contact using the service
+	created, err := c.contactService.CreateContact(ctx.Request.Context(), patientID, &contact)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, created)
+}
+
+// ListContacts retrieves a patient's contacts.
+//
+// @Summary List a patient's contacts
+// @Description Retrieve a patient's next of kin, guardians and emergency contacts, in that order
+// @Tags contacts
+// @Produce json
+// @Param id path int true "Patient ID"
+// @Success 200 {array} models.PatientContact "The patient's contacts"
+// @Failure 400 {object} middleware.Problem "Invalid patient ID"
+// @Failure 404 {object} middleware.Problem "Patient not found"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /patients/{id}/contacts [get]
+func (c *ContactController) ListContacts(ctx *gin.Context) {
+	patientID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(errInvalidPatientID)
+		return
+	}
+
+	// Retrieve the contacts using the service
+	contacts, err := c.contactService.ListContacts(ctx.Request.Context(), patientID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, contacts)
+}
+
+// GetContact retrieves one of a patient's contacts.
+//
+// @Summary Get a patient contact
+// @Description Retrieve one of a patient's contacts by its ID
+// @Tags contacts
+// @Produce json
+// @Param id path int true "Patient ID"
+// @Param contactId path int true "Contact ID"
+// @Success 200 {object} models.PatientContact "The contact"
+// @Failure 400 {object} middleware.Problem "Invalid patient or contact ID"
+// @Failure 404 {object} middleware.Problem "Contact not found"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /patients/{id}/contacts/{contactId} [get]
+func (c *ContactController) GetContact(ctx *gin.Context) {
+	patientID, id, ok := contactParams(ctx)
+	if !ok {
+		return
+	}
+
+	// Retrieve the contact using the service
+	contact, err := c.contactService.GetContact(ctx.Request.Context(), patientID, id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, contact)
+}
+
+// UpdateContact replaces the details of one of a patient's contacts.
+//
+// @Summary Update a patient contact
+// @Description Replace the details of a contact, including their roles and consent flags
+// @Tags contacts
+// @Accept json
+// @Produce json
+// @Param id path int true "Patient ID"
+// @Param contactId path int true "Contact ID"
+// @Param contact body models.PatientContact true "Contact data"
+// @Success 200 {object} models.PatientContact "The updated contact"
+// @Failure 400 {object} middleware.Problem "Invalid patient or contact ID or request payload, no role set, or guardian for an adult"
+// @Failure 404 {object} middleware.Problem "Contact not found"
+// @Failure 409 {object} middleware.Problem "The patient already has a next of kin"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /patients/{id}/contacts/{contactId} [put]
+func (c *ContactController) UpdateContact(ctx *gin.Context) {
+	patientID, id, ok := contactParams(ctx)
+	if !ok {
+		return
+	}
+
+	var contact models.PatientContact
+	if err := ctx.ShouldBindJSON(&contact); err != nil {
+		ctx.Error(services.InvalidInput(err))
+		return
+	}
+
+	// Retrieve the authenticated principal (set during authentication)
+	principal, ok := currentPrincipal(ctx)
+	if !ok {
+		return
+	}
+
+	// Update the contact using the service
+	updated, err := c.contactService.UpdateContact(ctx.Request.Context(), patientID, id, &contact, principal.UserID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, updated)
+}
+
+// DeleteContact removes one of a patient's contacts.
+//
+// @Summary Delete a patient contact
+// @Description Remove a person who is no longer a contact of the patient
+// @Tags contacts
+// @Param id path int true "Patient ID"
+// @Param contactId path int true "Contact ID"
+// @Success 204 "Contact deleted"
+// @Failure 400 {object} middleware.Problem "Invalid patient or contact ID"
+// @Failure 404 {object} middleware.Problem "Contact not found"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /patients/{id}/contacts/{contactId} [delete]
+func (c *ContactController) DeleteContact(ctx *gin.Context) {
+	patientID, id, ok := contactParams(ctx)
+	if !ok {
+		return
+	}
+
+	// Delete the contact using the service
+	if err := c.contactService.DeleteContact(ctx.Request.Context(), patientID, id); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// contactParams parses the id and contactId path parameters, reporting a validation error when
+// either is invalid.
+func contactParams(ctx *gin.Context) (patientID, id int64, ok bool) {
+	patientID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(errInvalidPatientID)
+		return 0, 0, false
+	}
+	id, err = strconv.ParseInt(ctx.Param("contactId"), 10, 64)
+	if err != nil {
+		ctx.Error(services.NewValidationError("invalid_contact_id", "Invalid contact ID"))
+		return 0, 0, false
+	}
+	return patientID, id, true
+}
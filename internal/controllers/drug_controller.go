@@ -0,0 +1,251 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Okemwag/medihub/internal/models"
+	"github.com/Okemwag/medihub/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// Errors reported for invalid drug catalogue path parameters.
+var (
+	errInvalidDrugID        = services.NewValidationError("invalid_drug_id", "Invalid drug ID")
+	errInvalidInteractionID = services.NewValidationError("invalid_interaction_id", "Invalid interaction ID")
+)
+
+// DrugController handles HTTP requests for the drug catalogue and the drug interaction table.
+type DrugController struct {
+	drugService *services.DrugService // Service for drug catalogue operations
+}
+
+// NewDrugController creates a new instance of DrugController.
+//
+// @param drugService *services.DrugService: The drug catalogue service.
+// @return *DrugController: A new DrugController instance.
+func NewDrugController(drugService *services.DrugService) *DrugController {
+	return &DrugController{drugService: drugService}
+}
+
+// SearchDrugs searches the drug catalogue.
+//
+// @Summary Search the drug catalogue
+// @Description Find drugs whose name or generic name contains the search term, closest matches first
+// @Tags drugs
+// @Produce json
+// @Param q query string false "Search term; all drugs are listed when empty"
+// @Param include_inactive query bool false "Include drugs that can no longer be prescribed"
+// @Param limit query int false "Maximum number of results (default 20, max 50)"
+// @Success 200 {array} models.Drug "The matching drugs"
+// @Failure 400 {object} middleware.Problem "Invalid query parameters"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /drugs [get]
+func (c *DrugController) SearchDrugs(ctx *gin.Context) {
+	includeInactive := false
+	if value := ctx.Query("include_inactive"); value != "" {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			ctx.Error(services.NewValidationError("invalid_query", "invalid include_inactive: must be true or false"))
+			return
+		}
+		includeInactive = b
+	}
+	limit, err := queryInt(ctx, "limit")
+	if err != nil {
+		ctx.Error(services.NewValidationError("invalid_query", err.Error()))
+		return
+	}
+
+	// Search the catalogue using the service
+	drugs, err := c.drugService.SearchDrugs(ctx.Request.Context(), ctx.Query("q"), includeInactive, limit)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, drugs)
+}
+
+// GetDrug retrieves a drug by ID.
+//
+// @Summary Get a drug by ID
+// @Description Retrieve a drug from the catalogue
+// @Tags drugs
+// @Produce json
+// @Param id path int true "Drug ID"
+// @Success 200 {object} models.Drug "The drug"
+// @Failure 400 {object} middleware.Problem "Invalid drug ID"
+// @Failure 404 {object} middleware.Problem "Drug not found"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /drugs/{id} [get]
+func (c *DrugController) GetDrug(ctx *gin.Context) {
+	id, ok := drugIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	// Retrieve the drug using the service
+	drug, err := c.drugService.GetDrug(ctx.Request.Context(), id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, drug)
+}
+
+// CreateDrug adds a drug to the catalogue.
+//
+// @Summary Add a drug
+// @Description Add a drug to the catalogue; new drugs can be prescribed immediately
+// @Tags drugs
+// @Accept json
+// @Produce json
+// @Param drug body models.Drug true "Drug data"
+// @Success 201 {object} models.Drug "The added drug"
+// @Failure 400 {object} middleware.Problem "Invalid request payload"
+// @Failure 409 {object} middleware.Problem "A drug with this name, form and strength already exists"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /drugs [post]
+func (c *DrugController) CreateDrug(ctx *gin.Context) {
+	var drug models.Drug
+	if err := ctx.ShouldBindJSON(&drug); err != nil {
+		ctx.Error(services.InvalidInput(err))
+		return
+	}
+
+	// Add the drug using the service
+	created, err := c.drugService.CreateDrug(ctx.Request.Context(), &drug)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, created)
+}
+
+// UpdateDrug replaces the details of a drug.
+//
+// @Summary Update a drug
+// @Description Replace the details of a drug; set active to false to stop it from being prescribed
+// @Tags drugs
+// @Accept json
+// @Produce json
+// @Param id path int true "Drug ID"
+// @Param drug body models.Drug true "Drug data"
+// @Success 200 {object} models.Drug "The updated drug"
+// @Failure 400 {object} middleware.Problem "Invalid drug ID or request payload"
+// @Failure 404 {object} middleware.Problem "Drug not found"
+// @Failure 409 {object} middleware.Problem "A drug with this name, form and strength already exists"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /drugs/{id} [put]
+func (c *DrugController) UpdateDrug(ctx *gin.Context) {
+	id, ok := drugIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	var drug models.Drug
+	if err := ctx.ShouldBindJSON(&drug); err != nil {
+		ctx.Error(services.InvalidInput(err))
+		return
+	}
+
+	// Update the drug using the service
+	updated, err := c.drugService.UpdateDrug(ctx.Request.Context(), id, &drug)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, updated)
+}
+
+// ListInteractions retrieves the drug interaction table.
+//
+// @Summary List drug interactions
+// @Description Retrieve the known interactions between generic names and drug classes
+// @Tags drugs
+// @Produce json
+// @Param substance query string false "Only interactions involving this generic name or drug class"
+// @Success 200 {array} models.DrugInteraction "The interactions"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /drug-interactions [get]
+func (c *DrugController) ListInteractions(ctx *gin.Context) {
+	// Retrieve the interactions using the service
+	interactions, err := c.drugService.ListInteractions(ctx.Request.Context(), ctx.Query("substance"))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, interactions)
+}
+
+// CreateInteraction adds a drug interaction.
+//
+// @Summary Add a drug interaction
+// @Description Record a known interaction between two generic names or drug classes; contraindicated interactions block prescriptions, others raise warnings
+// @Tags drugs
+// @Accept json
+// @Produce json
+// @Param interaction body models.DrugInteraction true "Interaction data"
+// @Success 201 {object} models.DrugInteraction "The added interaction"
+// @Failure 400 {object} middleware.Problem "Invalid request payload"
+// @Failure 409 {object} middleware.Problem "An interaction between these substances already exists"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /drug-interactions [post]
+func (c *DrugController) CreateInteraction(ctx *gin.Context) {
+	var interaction models.DrugInteraction
+	if err := ctx.ShouldBindJSON(&interaction); err != nil {
+		ctx.Error(services.InvalidInput(err))
+		return
+	}
+
+	// Add the interaction using the service
+	created, err := c.drugService.CreateInteraction(ctx.Request.Context(), &interaction)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, created)
+}
+
+// DeleteInteraction removes a drug interaction.
+//
+// @Summary Delete a drug interaction
+// @Description Remove an interaction from the interaction table
+// @Tags drugs
+// @Param id path int true "Interaction ID"
+// @Success 204 "Interaction deleted"
+// @Failure 400 {object} middleware.Problem "Invalid interaction ID"
+// @Failure 404 {object} middleware.Problem "Interaction not found"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /drug-interactions/{id} [delete]
+func (c *DrugController) DeleteInteraction(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(errInvalidInteractionID)
+		return
+	}
+
+	// Delete the interaction using the service
+	if err := c.drugService.DeleteInteraction(ctx.Request.Context(), id); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// drugIDParam parses the id path parameter, reporting a validation error when it is invalid.
+func drugIDParam(ctx *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(errInvalidDrugID)
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,278 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Okemwag/medihub/internal/models"
+	"github.com/Okemwag/medihub/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// errInvalidEncounterID is reported when the id path parameter is not a valid encounter ID.
+var errInvalidEncounterID = services.NewValidationError("invalid_encounter_id", "Invalid encounter ID")
+
+// EncounterController handles HTTP requests for clinical encounters and visit notes.
+type EncounterController struct {
+	encounterService *services.EncounterService // Service for encounter operations
+}
+
+// NewEncounterController creates a new instance of EncounterController.
+//
+// @param encounterService *services.EncounterService: The encounter service.
+// @return *EncounterController: A new EncounterController instance.
+func NewEncounterController(encounterService *services.EncounterService) *EncounterController {
+	return &EncounterController{encounterService: encounterService}
+}
+
+// CreateEncounter starts a draft encounter for a patient, attended by the authenticated doctor.
+//
+// @Summary Start an encounter
+// @Description Create a draft visit note for a patient; the authenticated doctor becomes the attending doctor
+// @Tags encounters
+// @Accept json
+// @Produce json
+// @Param id path int true "Patient ID"
+// @Param encounter body models.Encounter true "Visit date, SOAP notes and diagnoses"
+// @Success 201 {object} models.Encounter "The draft encounter"
+// @Failure 400 {object} middleware.Problem "Invalid patient ID or request payload, or the user is not a doctor"
+// @Failure 404 {object} middleware.Problem "Patient not found"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /patients/{id}/encounters [post]
+func (c *EncounterController) CreateEncounter(ctx *gin.Context) {
+	patientID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(errInvalidPatientID)
+		return
+	}
+
+	var encounter models.Encounter
+	if err := ctx.ShouldBindJSON(&encounter); err != nil {
+		ctx.Error(services.InvalidInput(err))
+		return
+	}
+
+	// Retrieve the authenticated principal (set during authentication)
+	principal, ok := currentPrincipal(ctx)
+	if !ok {
+		return
+	}
+	encounter.PatientID = patientID
+	encounter.DoctorID = principal.UserID
+	encounter.CreatedBy = principal.UserID
+	encounter.UpdatedBy = principal.UserID
+
+	// Create the encounter using the service
+	created, err := c.encounterService.CreateEncounter(ctx.Request.Context(), &encounter)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, created)
+}
+
+// ListPatientEncounters retrieves a patient's encounters.
+//
+// @Summary List a patient's encounters
+// @Description Retrieve a page of a patient's encounters, most recent visit first; vital signs and addenda are only included when fetching a single encounter
+// @Tags encounters
+// @Produce json
+// @Param id path int true "Patient ID"
+// @Param page query int false "Page number (1-based)"
+// @Param page_size query int false "Number of encounters per page (max 100)"
+// @Success 200 {object} services.EncounterListResult "A page of encounters"
+// @Failure 400 {object} middleware.Problem "Invalid patient ID or query parameters"
+// @Failure 404 {object} middleware.Problem "Patient not found"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /patients/{id}/encounters [get]
+func (c *EncounterController) ListPatientEncounters(ctx *gin.Context) {
+	patientID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(errInvalidPatientID)
+		return
+	}
+
+	page, err := queryInt(ctx, "page")
+	if err != nil {
+		ctx.Error(services.NewValidationError("invalid_query", err.Error()))
+		return
+	}
+	pageSize, err := queryInt(ctx, "page_size")
+	if err != nil {
+		ctx.Error(services.NewValidationError("invalid_query", err.Error()))
+		return
+	}
+
+	// Retrieve the page using the service
+	result, err := c.encounterService.ListPatientEncounters(ctx.Request.Context(), patientID, page, pageSize)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+// GetEncounter retrieves an encounter by ID.
+//
+// @Summary Get an encounter by ID
+// @Description Retrieve an encounter with its vital signs and addenda
+// @Tags encounters
+// @Produce json
+// @Param id path int true "Encounter ID"
+// @Success 200 {object} models.Encounter "The encounter"
+// @Failure 400 {object} middleware.Problem "Invalid encounter ID"
+// @Failure 404 {object} middleware.Problem "Encounter not found"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /encounters/{id} [get]
+func (c *EncounterController) GetEncounter(ctx *gin.Context) {
+	id, ok := encounterIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	// Retrieve the encounter using the service
+	encounter, err := c.encounterService.GetEncounter(ctx.Request.Context(), id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, encounter)
+}
+
+// UpdateEncounter replaces the notes of a draft encounter.
+//
+// @Summary Update a draft encounter
+// @Description Replace the visit date, SOAP notes and diagnoses of a draft encounter; only the attending doctor may do so
+// @Tags encounters
+// @Accept json
+// @Produce json
+// @Param id path int true "Encounter ID"
+// @Param encounter body models.Encounter true "Visit date, SOAP notes and diagnoses"
+// @Success 200 {object} models.Encounter "The updated encounter"
+// @Failure 400 {object} middleware.Problem "Invalid encounter ID or request payload"
+// @Failure 403 {object} middleware.Problem "The user is not the attending doctor"
+// @Failure 404 {object} middleware.Problem "Encounter not found"
+// @Failure 409 {object} middleware.Problem "The encounter has been signed"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /encounters/{id} [put]
+func (c *EncounterController) UpdateEncounter(ctx *gin.Context) {
+	id, ok := encounterIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	var changes models.Encounter
+	if err := ctx.ShouldBindJSON(&changes); err != nil {
+		ctx.Error(services.InvalidInput(err))
+		return
+	}
+
+	// Retrieve the authenticated principal (set during authentication)
+	principal, ok := currentPrincipal(ctx)
+	if !ok {
+		return
+	}
+
+	// Update the encounter using the service
+	encounter, err := c.encounterService.UpdateEncounter(ctx.Request.Context(), id, &changes, principal.UserID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, encounter)
+}
+
+// SignEncounter signs a draft encounter.
+//
+// @Summary Sign an encounter
+// @Description Sign a draft encounter, making its notes immutable; only the attending doctor may do so
+// @Tags encounters
+// @Produce json
+// @Param id path int true "Encounter ID"
+// @Success 200 {object} models.Encounter "The signed encounter"
+// @Failure 400 {object} middleware.Problem "Invalid encounter ID"
+// @Failure 403 {object} middleware.Problem "The user is not the attending doctor"
+// @Failure 404 {object} middleware.Problem "Encounter not found"
+// @Failure 409 {object} middleware.Problem "The encounter has already been signed"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /encounters/{id}/sign [post]
+func (c *EncounterController) SignEncounter(ctx *gin.Context) {
+	id, ok := encounterIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	// Retrieve the authenticated principal (set during authentication)
+	principal, ok := currentPrincipal(ctx)
+	if !ok {
+		return
+	}
+
+	// Sign the encounter using the service
+	encounter, err := c.encounterService.SignEncounter(ctx.Request.Context(), id, principal.UserID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, encounter)
+}
+
+// AddEncounterAddendum appends a note to a signed encounter.
+//
+// @Summary Add an addendum
+// @Description Append a correction or late note to a signed encounter
+// @Tags encounters
+// @Accept json
+// @Produce json
+// @Param id path int true "Encounter ID"
+// @Param request body struct{Content string} true "Text of the addendum"
+// @Success 201 {object} models.EncounterAddendum "The addendum"
+// @Failure 400 {object} middleware.Problem "Invalid encounter ID or request payload"
+// @Failure 404 {object} middleware.Problem "Encounter not found"
+// @Failure 409 {object} middleware.Problem "The encounter has not been signed"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /encounters/{id}/addenda [post]
+func (c *EncounterController) AddEncounterAddendum(ctx *gin.Context) {
+	id, ok := encounterIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Content string `json:"content" binding:"required,max=20000"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(services.InvalidInput(err))
+		return
+	}
+
+	// Retrieve the authenticated principal (set during authentication)
+	principal, ok := currentPrincipal(ctx)
+	if !ok {
+		return
+	}
+
+	// Add the addendum using the service
+	addendum, err := c.encounterService.AddAddendum(ctx.Request.Context(), id, req.Content, principal.UserID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, addendum)
+}
+
+// encounterIDParam parses the id path parameter, reporting a validation error when it is invalid.
+func encounterIDParam(ctx *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(errInvalidEncounterID)
+		return 0, false
+	}
+	return id, true
+}
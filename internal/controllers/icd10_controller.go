@@ -0,0 +1,77 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/Okemwag/medihub/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// ICD10Controller handles HTTP requests for the ICD-10 code catalogue.
+type ICD10Controller struct {
+	icd10Service *services.ICD10Service // Service for ICD-10 catalogue operations
+}
+
+// NewICD10Controller creates a new instance of ICD10Controller.
+//
+// @param icd10Service *services.ICD10Service: The ICD-10 catalogue service.
+// @return *ICD10Controller: A new ICD10Controller instance.
+func NewICD10Controller(icd10Service *services.ICD10Service) *ICD10Controller {
+	return &ICD10Controller{icd10Service: icd10Service}
+}
+
+// SearchCodes searches the ICD-10 catalogue for autocompletion.
+//
+// @Summary Search ICD-10 codes
+// @Description Find active ICD-10 codes by code prefix (e.g. "E11") or description (e.g. "diabetes"), best matches first
+// @Tags icd10
+// @Produce json
+// @Param q query string true "Code prefix or description"
+// @Param limit query int false "Maximum number of results (default 20, max 50)"
+// @Success 200 {array} models.ICD10Code "The matching codes"
+// @Failure 400 {object} middleware.Problem "Missing search term or invalid query parameters"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /icd10-codes [get]
+func (c *ICD10Controller) SearchCodes(ctx *gin.Context) {
+	q := ctx.Query("q")
+	if q == "" {
+		ctx.Error(services.NewValidationError("missing_search_term", "Missing search term"))
+		return
+	}
+	limit, err := queryInt(ctx, "limit")
+	if err != nil {
+		ctx.Error(services.NewValidationError("invalid_query", err.Error()))
+		return
+	}
+
+	// Search the catalogue using the service
+	codes, err := c.icd10Service.SearchCodes(ctx.Request.Context(), q, limit)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, codes)
+}
+
+// GetCode retrieves an ICD-10 code.
+//
+// @Summary Get an ICD-10 code
+// @Description Retrieve an ICD-10 code, including codes no longer in the catalogue's latest import
+// @Tags icd10
+// @Produce json
+// @Param code path string true "ICD-10 code, with or without its dot"
+// @Success 200 {object} models.ICD10Code "The code"
+// @Failure 404 {object} middleware.Problem "Code not found"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /icd10-codes/{code} [get]
+func (c *ICD10Controller) GetCode(ctx *gin.Context) {
+	// Retrieve the code using the service
+	code, err := c.icd10Service.GetCode(ctx.Request.Context(), ctx.Param("code"))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, code)
+}
@@ -0,0 +1,283 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Okemwag/medihub/internal/models"
+	"github.com/Okemwag/medihub/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// InsurancePolicyController handles HTTP requests for patients' insurance policies and eligibility checks.
+type InsurancePolicyController struct {
+	policyService *services.InsurancePolicyService // Service for insurance policy operations
+}
+
+// NewInsurancePolicyController creates a new instance of InsurancePolicyController.
+//
+// @param policyService *services.InsurancePolicyService: The insurance policy service.
+// @return *InsurancePolicyController: A new InsurancePolicyController instance.
+func NewInsurancePolicyController(policyService *services.InsurancePolicyService) *InsurancePolicyController {
+	return &InsurancePolicyController{policyService: policyService}
+}
+
+// CreatePolicy records an insurance policy for a patient.
+//
+// @Summary Record an insurance policy
+// @Description Record a patient's cover with a payer, including member number, scheme, validity dates, principal or dependant relationship and co-pay rules. Amounts are decimal strings such as "500.00"
+// @Tags insurance
+// @Accept json
+// @Produce json
+// @Param id path int true "Patient ID"
+// @Param policy body models.InsurancePolicy true "Policy data"
+// @Success 201 {object} models.InsurancePolicy "The recorded policy"
+// @Failure 400 {object} middleware.Problem "Invalid patient ID, request payload, payer, dates or co-pay rules"
+// @Failure 404 {object} middleware.Problem "Patient not found"
+// @Failure 409 {object} middleware.Problem "The patient already has a policy with this payer and member number"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /patients/{id}/policies [post]
+func (c *InsurancePolicyController) CreatePolicy(ctx *gin.Context) {
+	patientID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(errInvalidPatientID)
+		return
+	}
+
+	var policy models.InsurancePolicy
+	if err := ctx.ShouldBindJSON(&policy); err != nil {
+		ctx.Error(services.InvalidInput(err))
+		return
+	}
+
+	// Retrieve the authenticated principal (set during authentication)
+	principal, ok := currentPrincipal(ctx)
+	if !ok {
+		return
+	}
+	policy.CreatedBy = principal.UserID
+	policy.UpdatedBy = principal.UserID
+
+	// Record the policy using the service
+	created, err := c.policyService.CreatePolicy(ctx.Request.Context(), patientID, &policy)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, created)
+}
+
+// ListPolicies retrieves a patient's insurance policies.
+//
+// @Summary List a patient's insurance policies
+// @Description Retrieve a patient's insurance policies, active and most recent first
+// @Tags insurance
+// @Produce json
+// @Param id path int true "Patient ID"
+// @Success 200 {array} models.InsurancePolicy "The patient's policies"
+// @Failure 400 {object} middleware.Problem "Invalid patient ID"
+// @Failure 404 {object} middleware.Problem "Patient not found"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /patients/{id}/policies [get]
+func (c *InsurancePolicyController) ListPolicies(ctx *gin.Context) {
+	patientID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(errInvalidPatientID)
+		return
+	}
+
+	// Retrieve the policies using the service
+	policies, err := c.policyService.ListPolicies(ctx.Request.Context(), patientID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, policies)
+}
+
+// GetPolicy retrieves one of a patient's insurance policies.
+//
+// @Summary Get an insurance policy
+// @Description Retrieve one of a patient's insurance policies by its ID
+// @Tags insurance
+// @Produce json
+// @Param id path int true "Patient ID"
+// @Param policyId path int true "Policy ID"
+// @Success 200 {object} models.InsurancePolicy "The policy"
+// @Failure 400 {object} middleware.Problem "Invalid patient or policy ID"
+// @Failure 404 {object} middleware.Problem "Policy not found"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /patients/{id}/policies/{policyId} [get]
+func (c *InsurancePolicyController) GetPolicy(ctx *gin.Context) {
+	patientID, id, ok := policyParams(ctx)
+	if !ok {
+		return
+	}
+
+	// Retrieve the policy using the service
+	policy, err := c.policyService.GetPolicy(ctx.Request.Context(), patientID, id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, policy)
+}
+
+// UpdatePolicy replaces the details of one of a patient's insurance policies.
+//
+// @Summary Update an insurance policy
+// @Description Replace the details of a policy, e.g. to renew it or change its co-pay rules; set active to false to suspend cover
+// @Tags insurance
+// @Accept json
+// @Produce json
+// @Param id path int true "Patient ID"
+// @Param policyId path int true "Policy ID"
+// @Param policy body models.InsurancePolicy true "Policy data"
+// @Success 200 {object} models.InsurancePolicy "The updated policy"
+// @Failure 400 {object} middleware.Problem "Invalid patient or policy ID, request payload, payer, dates or co-pay rules"
+// @Failure 404 {object} middleware.Problem "Policy not found"
+// @Failure 409 {object} middleware.Problem "The patient already has a policy with this payer and member number"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /patients/{id}/policies/{policyId} [put]
+func (c *InsurancePolicyController) UpdatePolicy(ctx *gin.Context) {
+	patientID, id, ok := policyParams(ctx)
+	if !ok {
+		return
+	}
+
+	var policy models.InsurancePolicy
+	if err := ctx.ShouldBindJSON(&policy); err != nil {
+		ctx.Error(services.InvalidInput(err))
+		return
+	}
+
+	// Retrieve the authenticated principal (set during authentication)
+	principal, ok := currentPrincipal(ctx)
+	if !ok {
+		return
+	}
+
+	// Update the policy using the service
+	updated, err := c.policyService.UpdatePolicy(ctx.Request.Context(), patientID, id, &policy, principal.UserID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, updated)
+}
+
+// DeletePolicy removes one of a patient's insurance policies.
+//
+// @Summary Delete an insurance policy
+// @Description Permanently remove a policy recorded by mistake; suspend or end lapsed policies instead
+// @Tags insurance
+// @Param id path int true "Patient ID"
+// @Param policyId path int true "Policy ID"
+// @Success 204 "Policy deleted"
+// @Failure 400 {object} middleware.Problem "Invalid patient or policy ID"
+// @Failure 404 {object} middleware.Problem "Policy not found"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /patients/{id}/policies/{policyId} [delete]
+func (c *InsurancePolicyController) DeletePolicy(ctx *gin.Context) {
+	patientID, id, ok := policyParams(ctx)
+	if !ok {
+		return
+	}
+
+	// Delete the policy using the service
+	if err := c.policyService.DeletePolicy(ctx.Request.Context(), patientID, id); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// CheckEligibility checks a patient's insurance cover.
+//
+// @Summary Check a patient's eligibility
+// @Description Check which of a patient's policies cover them on a date, e.g. at registration for a visit; with an amount, each eligible policy's split between patient and payer is worked out
+// @Tags insurance
+// @Produce json
+// @Param id path int true "Patient ID"
+// @Param date query string false "Date of service (YYYY-MM-DD), defaults to today"
+// @Param amount query string false "Amount to split between patient and payer, e.g. 1500.00"
+// @Success 200 {object} models.EligibilityCheck "The eligibility of each policy"
+// @Failure 400 {object} middleware.Problem "Invalid patient ID, date or amount"
+// @Failure 404 {object} middleware.Problem "Patient not found"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /patients/{id}/eligibility [get]
+func (c *InsurancePolicyController) CheckEligibility(ctx *gin.Context) {
+	patientID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(errInvalidPatientID)
+		return
+	}
+
+	var amount *models.Money
+	if value := ctx.Query("amount"); value != "" {
+		parsed, err := models.ParseMoney(value)
+		if err != nil {
+			ctx.Error(services.NewValidationError("invalid_query", "invalid amount: "+err.Error()))
+			return
+		}
+		amount = &parsed
+	}
+
+	// Check the patient's cover using the service
+	check, err := c.policyService.CheckEligibility(ctx.Request.Context(), patientID, ctx.Query("date"), amount)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, check)
+}
+
+// CheckInvoiceEligibility checks the insurance cover for an invoice.
+//
+// @Summary Check an invoice's eligibility
+// @Description Check which of the patient's policies cover an invoice on the day it was raised, and how its total is split between the patient and each payer
+// @Tags insurance
+// @Produce json
+// @Param id path int true "Invoice ID"
+// @Success 200 {object} models.EligibilityCheck "The eligibility of each policy for the invoice"
+// @Failure 400 {object} middleware.Problem "Invalid invoice ID"
+// @Failure 404 {object} middleware.Problem "Invoice not found"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /invoices/{id}/eligibility [get]
+func (c *InsurancePolicyController) CheckInvoiceEligibility(ctx *gin.Context) {
+	id, ok := invoiceIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	// Check the invoice's cover using the service
+	check, err := c.policyService.CheckInvoiceEligibility(ctx.Request.Context(), id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, check)
+}
+
+// policyParams parses the id and policyId path parameters, reporting a validation error when
+// either is invalid.
+func policyParams(ctx *gin.Context) (patientID, id int64, ok bool) {
+	patientID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(errInvalidPatientID)
+		return 0, 0, false
+	}
+	id, err = strconv.ParseInt(ctx.Param("policyId"), 10, 64)
+	if err != nil {
+		ctx.Error(services.NewValidationError("invalid_policy_id", "Invalid policy ID"))
+		return 0, 0, false
+	}
+	return patientID, id, true
+}
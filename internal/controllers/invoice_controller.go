@@ -0,0 +1,376 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Okemwag/medihub/internal/models"
+	"github.com/Okemwag/medihub/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// errInvalidInvoiceID is reported when the id path parameter is not a valid invoice ID.
+var errInvalidInvoiceID = services.NewValidationError("invalid_invoice_id", "Invalid invoice ID")
+
+// InvoiceController handles HTTP requests for invoices, payments and receipts.
+type InvoiceController struct {
+	invoiceService *services.InvoiceService // Service for billing operations
+}
+
+// NewInvoiceController creates a new instance of InvoiceController.
+//
+// @param invoiceService *services.InvoiceService: The billing service.
+// @return *InvoiceController: A new InvoiceController instance.
+func NewInvoiceController(invoiceService *services.InvoiceService) *InvoiceController {
+	return &InvoiceController{invoiceService: invoiceService}
+}
+
+// CreateInvoice creates a draft invoice for a patient.
+//
+// @Summary Create an invoice
+// @Description Create a draft invoice for a patient. Items either refer to a catalogue service, whose name and price are copied, or give their own description and unit_price. Amounts are decimal strings such as "1500.00"
+// @Tags billing
+// @Accept json
+// @Produce json
+// @Param id path int true "Patient ID"
+// @Param invoice body models.Invoice true "Encounter, notes and items"
+// @Success 201 {object} models.Invoice "The draft invoice"
+// @Failure 400 {object} middleware.Problem "Invalid patient ID, request payload, encounter or items"
+// @Failure 404 {object} middleware.Problem "Patient not found"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /patients/{id}/invoices [post]
+func (c *InvoiceController) CreateInvoice(ctx *gin.Context) {
+	patientID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(errInvalidPatientID)
+		return
+	}
+
+	var invoice models.Invoice
+	if err := ctx.ShouldBindJSON(&invoice); err != nil {
+		ctx.Error(services.InvalidInput(err))
+		return
+	}
+
+	// Retrieve the authenticated principal (set during authentication)
+	principal, ok := currentPrincipal(ctx)
+	if !ok {
+		return
+	}
+	invoice.PatientID = patientID
+	invoice.CreatedBy = principal.UserID
+
+	// Create the invoice using the service
+	created, err := c.invoiceService.CreateInvoice(ctx.Request.Context(), &invoice)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, created)
+}
+
+// ListPatientInvoices retrieves a patient's invoices.
+//
+// @Summary List a patient's invoices
+// @Description Retrieve a page of a patient's invoices with their totals and balances, most recent first
+// @Tags billing
+// @Produce json
+// @Param id path int true "Patient ID"
+// @Param status query string false "Only invoices in this status (outstanding, draft, issued, partially_paid, paid, void)"
+// @Param page query int false "Page number (1-based)"
+// @Param page_size query int false "Number of invoices per page (max 100)"
+// @Success 200 {object} services.InvoiceListResult "A page of invoices"
+// @Failure 400 {object} middleware.Problem "Invalid patient ID or query parameters"
+// @Failure 404 {object} middleware.Problem "Patient not found"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /patients/{id}/invoices [get]
+func (c *InvoiceController) ListPatientInvoices(ctx *gin.Context) {
+	patientID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(errInvalidPatientID)
+		return
+	}
+
+	page, err := queryInt(ctx, "page")
+	if err != nil {
+		ctx.Error(services.NewValidationError("invalid_query", err.Error()))
+		return
+	}
+	pageSize, err := queryInt(ctx, "page_size")
+	if err != nil {
+		ctx.Error(services.NewValidationError("invalid_query", err.Error()))
+		return
+	}
+
+	// Retrieve the page using the service
+	result, err := c.invoiceService.ListPatientInvoices(ctx.Request.Context(), patientID, ctx.Query("status"), page, pageSize)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+// ListInvoices retrieves invoices across patients.
+//
+// @Summary List invoices
+// @Description Retrieve invoices filtered by patient and status, most recent first; use status=outstanding for the cashier's queue
+// @Tags billing
+// @Produce json
+// @Param patient_id query int false "Only invoices of this patient"
+// @Param status query string false "Only invoices in this status (outstanding, draft, issued, partially_paid, paid, void)"
+// @Param page query int false "Page number (1-based)"
+// @Param page_size query int false "Number of invoices per page (max 100)"
+// @Success 200 {object} services.InvoiceListResult "A page of invoices"
+// @Failure 400 {object} middleware.Problem "Invalid query parameters"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /invoices [get]
+func (c *InvoiceController) ListInvoices(ctx *gin.Context) {
+	params := services.InvoiceListParams{Status: ctx.Query("status")}
+
+	var err error
+	if params.PatientID, err = queryInt64(ctx, "patient_id"); err != nil {
+		ctx.Error(services.NewValidationError("invalid_query", err.Error()))
+		return
+	}
+	if params.Page, err = queryInt(ctx, "page"); err != nil {
+		ctx.Error(services.NewValidationError("invalid_query", err.Error()))
+		return
+	}
+	if params.PageSize, err = queryInt(ctx, "page_size"); err != nil {
+		ctx.Error(services.NewValidationError("invalid_query", err.Error()))
+		return
+	}
+
+	// Retrieve the page using the service
+	result, err := c.invoiceService.ListInvoices(ctx.Request.Context(), params)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+// GetInvoice retrieves an invoice by ID.
+//
+// @Summary Get an invoice by ID
+// @Description Retrieve an invoice with its items, payments and balance
+// @Tags billing
+// @Produce json
+// @Param id path int true "Invoice ID"
+// @Success 200 {object} models.Invoice "The invoice"
+// @Failure 400 {object} middleware.Problem "Invalid invoice ID"
+// @Failure 404 {object} middleware.Problem "Invoice not found"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /invoices/{id} [get]
+func (c *InvoiceController) GetInvoice(ctx *gin.Context) {
+	id, ok := invoiceIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	// Retrieve the invoice using the service
+	invoice, err := c.invoiceService.GetInvoice(ctx.Request.Context(), id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, invoice)
+}
+
+// UpdateInvoice replaces the details of a draft invoice.
+//
+// @Summary Update a draft invoice
+// @Description Replace the encounter, notes and items of an invoice that has not been issued yet
+// @Tags billing
+// @Accept json
+// @Produce json
+// @Param id path int true "Invoice ID"
+// @Param invoice body models.Invoice true "Encounter, notes and items"
+// @Success 200 {object} models.Invoice "The updated invoice"
+// @Failure 400 {object} middleware.Problem "Invalid invoice ID, request payload, encounter or items"
+// @Failure 404 {object} middleware.Problem "Invoice not found"
+// @Failure 409 {object} middleware.Problem "The invoice has been issued or voided"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /invoices/{id} [put]
+func (c *InvoiceController) UpdateInvoice(ctx *gin.Context) {
+	id, ok := invoiceIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	var invoice models.Invoice
+	if err := ctx.ShouldBindJSON(&invoice); err != nil {
+		ctx.Error(services.InvalidInput(err))
+		return
+	}
+
+	// Update the invoice using the service
+	updated, err := c.invoiceService.UpdateInvoice(ctx.Request.Context(), id, &invoice)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, updated)
+}
+
+// IssueInvoice issues a draft invoice.
+//
+// @Summary Issue an invoice
+// @Description Issue a draft invoice to the patient so that it can be paid; it can no longer be edited
+// @Tags billing
+// @Produce json
+// @Param id path int true "Invoice ID"
+// @Success 200 {object} models.Invoice "The issued invoice"
+// @Failure 400 {object} middleware.Problem "Invalid invoice ID"
+// @Failure 404 {object} middleware.Problem "Invoice not found"
+// @Failure 409 {object} middleware.Problem "The invoice is not a draft"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /invoices/{id}/issue [post]
+func (c *InvoiceController) IssueInvoice(ctx *gin.Context) {
+	id, ok := invoiceIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	// Retrieve the authenticated principal (set during authentication)
+	principal, ok := currentPrincipal(ctx)
+	if !ok {
+		return
+	}
+
+	// Issue the invoice using the service
+	invoice, err := c.invoiceService.IssueInvoice(ctx.Request.Context(), id, principal.UserID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, invoice)
+}
+
+// VoidInvoice voids an invoice.
+//
+// @Summary Void an invoice
+// @Description Void an invoice raised in error; invoices that have received payments cannot be voided
+// @Tags billing
+// @Accept json
+// @Produce json
+// @Param id path int true "Invoice ID"
+// @Param request body struct{Reason string} true "Why the invoice is voided"
+// @Success 200 {object} models.Invoice "The voided invoice"
+// @Failure 400 {object} middleware.Problem "Invalid invoice ID or request payload"
+// @Failure 404 {object} middleware.Problem "Invoice not found"
+// @Failure 409 {object} middleware.Problem "The invoice has received payments or is already void"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /invoices/{id}/void [post]
+func (c *InvoiceController) VoidInvoice(ctx *gin.Context) {
+	id, ok := invoiceIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Reason string `json:"reason" binding:"required,max=500"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(services.InvalidInput(err))
+		return
+	}
+
+	// Retrieve the authenticated principal (set during authentication)
+	principal, ok := currentPrincipal(ctx)
+	if !ok {
+		return
+	}
+
+	// Void the invoice using the service
+	invoice, err := c.invoiceService.VoidInvoice(ctx.Request.Context(), id, req.Reason, principal.UserID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, invoice)
+}
+
+// RecordPayment records a payment against an invoice.
+//
+// @Summary Record a payment
+// @Description Record a cash, card or mobile money payment received by the authenticated user against an issued invoice. Partial payments are allowed up to the balance; mobile money payments need their transaction code as reference
+// @Tags billing
+// @Accept json
+// @Produce json
+// @Param id path int true "Invoice ID"
+// @Param payment body models.Payment true "Amount, method and reference"
+// @Success 201 {object} models.Receipt "The receipt for the payment"
+// @Failure 400 {object} middleware.Problem "Invalid invoice ID, request payload, amount or reference"
+// @Failure 404 {object} middleware.Problem "Invoice not found"
+// @Failure 409 {object} middleware.Problem "The invoice is not payable, the amount exceeds its balance, or the mobile money reference was already used"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /invoices/{id}/payments [post]
+func (c *InvoiceController) RecordPayment(ctx *gin.Context) {
+	id, ok := invoiceIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	var payment models.Payment
+	if err := ctx.ShouldBindJSON(&payment); err != nil {
+		ctx.Error(services.InvalidInput(err))
+		return
+	}
+
+	// Retrieve the authenticated principal (set during authentication)
+	principal, ok := currentPrincipal(ctx)
+	if !ok {
+		return
+	}
+	payment.ReceivedBy = principal.UserID
+
+	// Record the payment using the service
+	receipt, err := c.invoiceService.RecordPayment(ctx.Request.Context(), id, &payment)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, receipt)
+}
+
+// GetReceipt retrieves the receipt for a payment.
+//
+// @Summary Get a receipt
+// @Description Retrieve the receipt for a payment by its receipt number, e.g. to print it again
+// @Tags billing
+// @Produce json
+// @Param number path string true "Receipt number, e.g. RCT-000456"
+// @Success 200 {object} models.Receipt "The receipt"
+// @Failure 404 {object} middleware.Problem "Receipt not found"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /receipts/{number} [get]
+func (c *InvoiceController) GetReceipt(ctx *gin.Context) {
+	// Retrieve the receipt using the service
+	receipt, err := c.invoiceService.GetReceipt(ctx.Request.Context(), ctx.Param("number"))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, receipt)
+}
+
+// invoiceIDParam parses the id path parameter, reporting a validation error when it is invalid.
+func invoiceIDParam(ctx *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(errInvalidInvoiceID)
+		return 0, false
+	}
+	return id, true
+}
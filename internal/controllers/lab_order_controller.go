@@ -0,0 +1,359 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Okemwag/medihub/internal/models"
+	"github.com/Okemwag/medihub/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// errInvalidLabOrderID is reported when the id path parameter is not a valid lab order ID.
+var errInvalidLabOrderID = services.NewValidationError("invalid_lab_order_id", "Invalid lab order ID")
+
+// LabOrderController handles HTTP requests for lab orders and their results.
+type LabOrderController struct {
+	labOrderService *services.LabOrderService // Service for lab order operations
+}
+
+// NewLabOrderController creates a new instance of LabOrderController.
+//
+// @param labOrderService *services.LabOrderService: The lab order service.
+// @return *LabOrderController: A new LabOrderController instance.
+func NewLabOrderController(labOrderService *services.LabOrderService) *LabOrderController {
+	return &LabOrderController{labOrderService: labOrderService}
+}
+
+// CreateLabOrder orders a lab investigation for a patient.
+//
+// @Summary Order a lab test
+// @Description Order a test from the lab catalogue for a patient; the authenticated doctor is the ordering doctor
+// @Tags lab
+// @Accept json
+// @Produce json
+// @Param id path int true "Patient ID"
+// @Param order body models.LabOrder true "Test, priority and clinical notes"
+// @Success 201 {object} models.LabOrder "The placed order"
+// @Failure 400 {object} middleware.Problem "Invalid patient ID or request payload, inactive test, or the user is not a doctor"
+// @Failure 404 {object} middleware.Problem "Patient not found"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /patients/{id}/lab-orders [post]
+func (c *LabOrderController) CreateLabOrder(ctx *gin.Context) {
+	patientID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(errInvalidPatientID)
+		return
+	}
+
+	var order models.LabOrder
+	if err := ctx.ShouldBindJSON(&order); err != nil {
+		ctx.Error(services.InvalidInput(err))
+		return
+	}
+
+	// Retrieve the authenticated principal (set during authentication)
+	principal, ok := currentPrincipal(ctx)
+	if !ok {
+		return
+	}
+	order.PatientID = patientID
+	order.OrderedBy = principal.UserID
+
+	// Place the order using the service
+	created, err := c.labOrderService.CreateLabOrder(ctx.Request.Context(), &order)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, created)
+}
+
+// ListPatientLabOrders retrieves a patient's lab orders with their results.
+//
+// @Summary List a patient's lab orders
+// @Description Retrieve a page of a patient's lab orders with their results, most recent first
+// @Tags lab
+// @Produce json
+// @Param id path int true "Patient ID"
+// @Param status query string false "Only orders in this status (open, ordered, collected, resulted, verified, cancelled)"
+// @Param page query int false "Page number (1-based)"
+// @Param page_size query int false "Number of orders per page (max 100)"
+// @Success 200 {object} services.LabOrderListResult "A page of lab orders"
+// @Failure 400 {object} middleware.Problem "Invalid patient ID or query parameters"
+// @Failure 404 {object} middleware.Problem "Patient not found"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /patients/{id}/lab-orders [get]
+func (c *LabOrderController) ListPatientLabOrders(ctx *gin.Context) {
+	patientID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(errInvalidPatientID)
+		return
+	}
+
+	page, err := queryInt(ctx, "page")
+	if err != nil {
+		ctx.Error(services.NewValidationError("invalid_query", err.Error()))
+		return
+	}
+	pageSize, err := queryInt(ctx, "page_size")
+	if err != nil {
+		ctx.Error(services.NewValidationError("invalid_query", err.Error()))
+		return
+	}
+
+	// Retrieve the page using the service
+	result, err := c.labOrderService.ListPatientLabOrders(ctx.Request.Context(), patientID, ctx.Query("status"), page, pageSize)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+// ListLabOrders retrieves the laboratory worklist.
+//
+// @Summary List lab orders
+// @Description Retrieve lab orders filtered by patient, ordering doctor, status and priority, most urgent and oldest first
+// @Tags lab
+// @Produce json
+// @Param patient_id query int false "Only orders of this patient"
+// @Param ordered_by query int false "Only orders placed by this doctor"
+// @Param status query string false "Only orders in this status (open, ordered, collected, resulted, verified, cancelled)"
+// @Param priority query string false "Only orders of this priority (routine, urgent, stat)"
+// @Param page query int false "Page number (1-based)"
+// @Param page_size query int false "Number of orders per page (max 100)"
+// @Success 200 {object} services.LabOrderListResult "A page of lab orders"
+// @Failure 400 {object} middleware.Problem "Invalid query parameters"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /lab-orders [get]
+func (c *LabOrderController) ListLabOrders(ctx *gin.Context) {
+	params := services.LabOrderListParams{Status: ctx.Query("status"), Priority: ctx.Query("priority")}
+
+	var err error
+	if params.PatientID, err = queryInt64(ctx, "patient_id"); err != nil {
+		ctx.Error(services.NewValidationError("invalid_query", err.Error()))
+		return
+	}
+	if params.OrderedBy, err = queryInt64(ctx, "ordered_by"); err != nil {
+		ctx.Error(services.NewValidationError("invalid_query", err.Error()))
+		return
+	}
+	if params.Page, err = queryInt(ctx, "page"); err != nil {
+		ctx.Error(services.NewValidationError("invalid_query", err.Error()))
+		return
+	}
+	if params.PageSize, err = queryInt(ctx, "page_size"); err != nil {
+		ctx.Error(services.NewValidationError("invalid_query", err.Error()))
+		return
+	}
+
+	// Retrieve the page using the service
+	result, err := c.labOrderService.ListLabOrders(ctx.Request.Context(), params)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+// GetLabOrder retrieves a lab order by ID.
+//
+// @Summary Get a lab order by ID
+// @Description Retrieve a lab order with its results
+// @Tags lab
+// @Produce json
+// @Param id path int true "Lab order ID"
+// @Success 200 {object} models.LabOrder "The lab order"
+// @Failure 400 {object} middleware.Problem "Invalid lab order ID"
+// @Failure 404 {object} middleware.Problem "Lab order not found"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /lab-orders/{id} [get]
+func (c *LabOrderController) GetLabOrder(ctx *gin.Context) {
+	id, ok := labOrderIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	// Retrieve the order using the service
+	order, err := c.labOrderService.GetLabOrder(ctx.Request.Context(), id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, order)
+}
+
+// CollectSpecimen records specimen collection for a lab order.
+//
+// @Summary Record specimen collection
+// @Description Record that the specimen for an ordered test has been collected by the authenticated user
+// @Tags lab
+// @Produce json
+// @Param id path int true "Lab order ID"
+// @Success 200 {object} models.LabOrder "The updated order"
+// @Failure 400 {object} middleware.Problem "Invalid lab order ID"
+// @Failure 404 {object} middleware.Problem "Lab order not found"
+// @Failure 409 {object} middleware.Problem "The order is not awaiting collection"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /lab-orders/{id}/collect [post]
+func (c *LabOrderController) CollectSpecimen(ctx *gin.Context) {
+	id, ok := labOrderIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	// Retrieve the authenticated principal (set during authentication)
+	principal, ok := currentPrincipal(ctx)
+	if !ok {
+		return
+	}
+
+	// Record the collection using the service
+	order, err := c.labOrderService.CollectSpecimen(ctx.Request.Context(), id, principal.UserID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, order)
+}
+
+// EnterLabResults records the results of a lab order.
+//
+// @Summary Enter lab results
+// @Description Enter one result per analyte of the ordered test; results are flagged against the reference ranges and may be re-entered until they are verified
+// @Tags lab
+// @Accept json
+// @Produce json
+// @Param id path int true "Lab order ID"
+// @Param request body struct{Results []models.LabResultEntry} true "One result per analyte"
+// @Success 200 {object} models.LabOrder "The order with its results"
+// @Failure 400 {object} middleware.Problem "Invalid lab order ID, request payload or results"
+// @Failure 404 {object} middleware.Problem "Lab order not found"
+// @Failure 409 {object} middleware.Problem "The specimen has not been collected, or the results have been verified"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /lab-orders/{id}/results [post]
+func (c *LabOrderController) EnterLabResults(ctx *gin.Context) {
+	id, ok := labOrderIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Results []models.LabResultEntry `json:"results" binding:"required,min=1,max=50,dive"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(services.InvalidInput(err))
+		return
+	}
+
+	// Retrieve the authenticated principal (set during authentication)
+	principal, ok := currentPrincipal(ctx)
+	if !ok {
+		return
+	}
+
+	// Enter the results using the service
+	order, err := c.labOrderService.EnterResults(ctx.Request.Context(), id, req.Results, principal.UserID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, order)
+}
+
+// VerifyLabResults verifies the results of a lab order.
+//
+// @Summary Verify lab results
+// @Description Verify the results of a lab order, making them final
+// @Tags lab
+// @Produce json
+// @Param id path int true "Lab order ID"
+// @Success 200 {object} models.LabOrder "The verified order with its results"
+// @Failure 400 {object} middleware.Problem "Invalid lab order ID"
+// @Failure 404 {object} middleware.Problem "Lab order not found"
+// @Failure 409 {object} middleware.Problem "The order has no results to verify, or the caller entered them"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /lab-orders/{id}/verify [post]
+func (c *LabOrderController) VerifyLabResults(ctx *gin.Context) {
+	id, ok := labOrderIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	// Retrieve the authenticated principal (set during authentication)
+	principal, ok := currentPrincipal(ctx)
+	if !ok {
+		return
+	}
+
+	// Verify the results using the service
+	order, err := c.labOrderService.VerifyResults(ctx.Request.Context(), id, principal.UserID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, order)
+}
+
+// CancelLabOrder cancels a lab order.
+//
+// @Summary Cancel a lab order
+// @Description Cancel a lab order whose results have not been entered yet
+// @Tags lab
+// @Accept json
+// @Produce json
+// @Param id path int true "Lab order ID"
+// @Param request body struct{Reason string} true "Why the order is cancelled"
+// @Success 200 {object} models.LabOrder "The cancelled order"
+// @Failure 400 {object} middleware.Problem "Invalid lab order ID or request payload"
+// @Failure 404 {object} middleware.Problem "Lab order not found"
+// @Failure 409 {object} middleware.Problem "The order already has results or has been cancelled"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /lab-orders/{id}/cancel [post]
+func (c *LabOrderController) CancelLabOrder(ctx *gin.Context) {
+	id, ok := labOrderIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Reason string `json:"reason" binding:"required,max=500"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(services.InvalidInput(err))
+		return
+	}
+
+	// Retrieve the authenticated principal (set during authentication)
+	principal, ok := currentPrincipal(ctx)
+	if !ok {
+		return
+	}
+
+	// Cancel the order using the service
+	order, err := c.labOrderService.CancelLabOrder(ctx.Request.Context(), id, req.Reason, principal.UserID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, order)
+}
+
+// labOrderIDParam parses the id path parameter, reporting a validation error when it is invalid.
+func labOrderIDParam(ctx *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(errInvalidLabOrderID)
+		return 0, false
+	}
+	return id, true
+}
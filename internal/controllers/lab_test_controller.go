@@ -0,0 +1,164 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Okemwag/medihub/internal/models"
+	"github.com/Okemwag/medihub/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// errInvalidLabTestID is reported when the id path parameter is not a valid lab test ID.
+var errInvalidLabTestID = services.NewValidationError("invalid_lab_test_id", "Invalid lab test ID")
+
+// LabTestController handles HTTP requests for the lab test catalogue.
+type LabTestController struct {
+	labTestService *services.LabTestService // Service for lab test catalogue operations
+}
+
+// NewLabTestController creates a new instance of LabTestController.
+//
+// @param labTestService *services.LabTestService: The lab test catalogue service.
+// @return *LabTestController: A new LabTestController instance.
+func NewLabTestController(labTestService *services.LabTestService) *LabTestController {
+	return &LabTestController{labTestService: labTestService}
+}
+
+// ListLabTests retrieves the lab test catalogue.
+//
+// @Summary List lab tests
+// @Description Retrieve the lab tests that can be ordered, with their analytes and reference ranges
+// @Tags lab
+// @Produce json
+// @Param q query string false "Only tests whose code or name contains this term"
+// @Param include_inactive query bool false "Include tests that can no longer be ordered"
+// @Success 200 {array} models.LabTest "The lab tests"
+// @Failure 400 {object} middleware.Problem "Invalid query parameters"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /lab-tests [get]
+func (c *LabTestController) ListLabTests(ctx *gin.Context) {
+	includeInactive := false
+	if value := ctx.Query("include_inactive"); value != "" {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			ctx.Error(services.NewValidationError("invalid_query", "invalid include_inactive: must be true or false"))
+			return
+		}
+		includeInactive = b
+	}
+
+	// Retrieve the catalogue using the service
+	tests, err := c.labTestService.ListLabTests(ctx.Request.Context(), ctx.Query("q"), includeInactive)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, tests)
+}
+
+// GetLabTest retrieves a lab test by ID.
+//
+// @Summary Get a lab test by ID
+// @Description Retrieve a lab test with its analytes and reference ranges
+// @Tags lab
+// @Produce json
+// @Param id path int true "Lab test ID"
+// @Success 200 {object} models.LabTest "The lab test"
+// @Failure 400 {object} middleware.Problem "Invalid lab test ID"
+// @Failure 404 {object} middleware.Problem "Lab test not found"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /lab-tests/{id} [get]
+func (c *LabTestController) GetLabTest(ctx *gin.Context) {
+	id, ok := labTestIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	// Retrieve the test using the service
+	test, err := c.labTestService.GetLabTest(ctx.Request.Context(), id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, test)
+}
+
+// CreateLabTest adds a test to the catalogue.
+//
+// @Summary Add a lab test
+// @Description Add a test with its analytes and reference ranges to the catalogue; new tests can be ordered immediately
+// @Tags lab
+// @Accept json
+// @Produce json
+// @Param test body models.LabTest true "Lab test data"
+// @Success 201 {object} models.LabTest "The added test"
+// @Failure 400 {object} middleware.Problem "Invalid request payload or analytes"
+// @Failure 409 {object} middleware.Problem "A lab test with this code already exists"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /lab-tests [post]
+func (c *LabTestController) CreateLabTest(ctx *gin.Context) {
+	var test models.LabTest
+	if err := ctx.ShouldBindJSON(&test); err != nil {
+		ctx.Error(services.InvalidInput(err))
+		return
+	}
+
+	// Add the test using the service
+	created, err := c.labTestService.CreateLabTest(ctx.Request.Context(), &test)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, created)
+}
+
+// UpdateLabTest replaces the details of a lab test.
+//
+// @Summary Update a lab test
+// @Description Replace the details and analytes of a test; set active to false to stop it from being ordered. Results already entered are not changed
+// @Tags lab
+// @Accept json
+// @Produce json
+// @Param id path int true "Lab test ID"
+// @Param test body models.LabTest true "Lab test data"
+// @Success 200 {object} models.LabTest "The updated test"
+// @Failure 400 {object} middleware.Problem "Invalid lab test ID, request payload or analytes"
+// @Failure 404 {object} middleware.Problem "Lab test not found"
+// @Failure 409 {object} middleware.Problem "A lab test with this code already exists"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /lab-tests/{id} [put]
+func (c *LabTestController) UpdateLabTest(ctx *gin.Context) {
+	id, ok := labTestIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	var test models.LabTest
+	if err := ctx.ShouldBindJSON(&test); err != nil {
+		ctx.Error(services.InvalidInput(err))
+		return
+	}
+
+	// Update the test using the service
+	updated, err := c.labTestService.UpdateLabTest(ctx.Request.Context(), id, &test)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, updated)
+}
+
+// labTestIDParam parses the id path parameter, reporting a validation error when it is invalid.
+func labTestIDParam(ctx *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(errInvalidLabTestID)
+		return 0, false
+	}
+	return id, true
+}
@@ -1,15 +1,22 @@
 package controllers
 
 import (
+	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
-	"database/sql"
+	"github.com/Okemwag/medihub/internal/middleware"
 	"github.com/Okemwag/medihub/internal/models"
 	"github.com/Okemwag/medihub/internal/services"
 	"github.com/gin-gonic/gin"
 )
 
+// errInvalidPatientID is reported when the id path parameter is not a valid patient ID.
+var errInvalidPatientID = services.NewValidationError("invalid_patient_id", "Invalid patient ID")
+
 // PatientController handles HTTP requests related to patient management.
 type PatientController struct {
 	patientService *services.PatientService // Service for patient-related operations
@@ -17,12 +24,10 @@ type PatientController struct {
 
 // NewPatientController creates a new instance of PatientController.
 //
-// @param db *sql.DB: A database connection.
+// @param patientService *services.PatientService: The patient service.
 // @return *PatientController: A new PatientController instance.
-func NewPatientController(db *sql.DB) *PatientController {
-	return &PatientController{
-		patientService: services.NewPatientService(db),
-	}
+func NewPatientController(patientService *services.PatientService) *PatientController {
+	return &PatientController{patientService: patientService}
 }
 
 // CreatePatient handles the creation of a new patient.
@@ -34,134 +39,555 @@ func NewPatientController(db *sql.DB) *PatientController {
 // @Produce json
 // @Param patient body models.Patient true "Patient data"
 // @Success 201 {object} map[string]int "Returns the ID of the created patient"
-// @Failure 400 {object} map[string]string "Invalid request payload"
-// @Failure 401 {object} map[string]string "Unauthorized: User ID not found"
-// @Failure 500 {object} map[string]string "Internal server error"
+// @Failure 400 {object} middleware.Problem "Invalid request payload, with field-level errors"
+// @Failure 401 {object} middleware.Problem "Unauthorized"
+// @Failure 500 {object} middleware.Problem "Internal server error"
 // @Router /patients [post]
 func (c *PatientController) CreatePatient(ctx *gin.Context) {
 	var patient models.Patient
 	if err := ctx.ShouldBindJSON(&patient); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		ctx.Error(services.InvalidInput(err))
 		return
 	}
 
-	// Retrieve user ID from the context (set during authentication)
-	userID, exists := ctx.Get("userID")
-	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: User ID not found"})
+	// Retrieve the authenticated principal (set during authentication)
+	principal, ok := currentPrincipal(ctx)
+	if !ok {
 		return
 	}
-	patient.CreatedBy = userID.(int64)
-	patient.UpdatedBy = userID.(int64)
+	patient.CreatedBy = principal.UserID
+	patient.UpdatedBy = principal.UserID
 
 	// Create the patient using the service
 	id, err := c.patientService.CreatePatient(ctx.Request.Context(), &patient)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.Error(err)
 		return
 	}
 
+	ctx.Header("ETag", patientETag(1))
 	ctx.JSON(http.StatusCreated, gin.H{"id": id})
 }
 
 // GetPatient retrieves a patient by ID.
 //
 // @Summary Get a patient by ID
-// @Description Retrieve a patient record by its ID
+// @Description Retrieve a patient record by its ID, with a summary of the patient's active allergies for callers holding allergy.read
 // @Tags patients
 // @Produce json
 // @Param id path int true "Patient ID"
-// @Success 200 {object} models.Patient "The patient record"
-// @Failure 400 {object} map[string]string "Invalid patient ID"
-// @Failure 404 {object} map[string]string "Patient not found"
+// @Success 200 {object} models.PatientRecord "The patient record"
+// @Header 200 {string} ETag "ETag of the patient version, to be sent as If-Match when updating"
+// @Failure 400 {object} middleware.Problem "Invalid patient ID"
+// @Failure 404 {object} middleware.Problem "Patient not found"
 // @Router /patients/{id} [get]
 func (c *PatientController) GetPatient(ctx *gin.Context) {
 	idStr := ctx.Param("id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid patient ID"})
+		ctx.Error(errInvalidPatientID)
 		return
 	}
 
 	// Retrieve the patient using the service
 	patient, err := c.patientService.GetPatient(ctx.Request.Context(), id)
 	if err != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		ctx.Error(err)
 		return
 	}
 
+	ctx.Header("ETag", patientETag(patient.Version))
 	ctx.JSON(http.StatusOK, patient)
 }
 
 // UpdatePatient updates an existing patient by ID.
 //
+// The request must carry an If-Match header with the ETag returned by GetPatient so that
+// concurrent edits are detected instead of silently overwriting each other.
+//
 // @Summary Update a patient by ID
-// @Description Update an existing patient record by its ID
+// @Description Update an existing patient record by its ID. Requires If-Match with the patient's current ETag.
 // @Tags patients
 // @Accept json
 // @Produce json
 // @Param id path int true "Patient ID"
+// @Param If-Match header string true "ETag of the patient version being updated"
 // @Param patient body models.Patient true "Updated patient data"
 // @Success 204 "No content"
-// @Failure 400 {object} map[string]string "Invalid patient ID or request payload"
-// @Failure 401 {object} map[string]string "Unauthorized: User ID not found"
-// @Failure 500 {object} map[string]string "Internal server error"
+// @Header 204 {string} ETag "ETag of the updated patient version"
+// @Failure 400 {object} middleware.Problem "Invalid patient ID, request payload or If-Match header"
+// @Failure 401 {object} middleware.Problem "Unauthorized"
+// @Failure 404 {object} middleware.Problem "Patient not found"
+// @Failure 412 {object} middleware.Problem "Patient has been modified since it was last read"
+// @Failure 428 {object} middleware.Problem "If-Match header with a patient ETag is required"
+// @Failure 500 {object} middleware.Problem "Internal server error"
 // @Router /patients/{id} [put]
 func (c *PatientController) UpdatePatient(ctx *gin.Context) {
 	idStr := ctx.Param("id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid patient ID"})
+		ctx.Error(errInvalidPatientID)
+		return
+	}
+
+	expectedVersion, ok := ifMatchVersion(ctx)
+	if !ok {
 		return
 	}
 
 	var patient models.Patient
 	if err := ctx.ShouldBindJSON(&patient); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		ctx.Error(services.InvalidInput(err))
 		return
 	}
 
-	// Retrieve user ID from the context (set during authentication)
-	userID, exists := ctx.Get("userID")
-	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: User ID not found"})
+	// Retrieve the authenticated principal (set during authentication)
+	principal, ok := currentPrincipal(ctx)
+	if !ok {
 		return
 	}
-	patient.UpdatedBy = userID.(int64)
+	patient.UpdatedBy = principal.UserID
 
 	// Update the patient using the service
-	if err := c.patientService.UpdatePatient(ctx.Request.Context(), id, &patient); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	version, err := c.patientService.UpdatePatient(ctx.Request.Context(), id, &patient, expectedVersion)
+	if err != nil {
+		ctx.Error(err)
 		return
 	}
 
+	ctx.Header("ETag", patientETag(version))
 	ctx.Status(http.StatusNoContent)
 }
 
-// DeletePatient deletes a patient by ID.
+// PatchPatient partially updates a patient by ID.
+//
+// The body is an RFC 7396 JSON Merge Patch (application/merge-patch+json or application/json) or an
+// RFC 6902 JSON Patch (application/json-patch+json). Only the fields named in the patch change.
+//
+// @Summary Partially update a patient by ID
+// @Description Apply a JSON Merge Patch or JSON Patch to a patient record. Requires If-Match with the patient's current ETag.
+// @Tags patients
+// @Accept application/merge-patch+json
+// @Accept application/json-patch+json
+// @Produce json
+// @Param id path int true "Patient ID"
+// @Param If-Match header string true "ETag of the patient version being updated"
+// @Param patch body object true "Merge patch object or JSON Patch operations"
+// @Success 200 {object} models.Patient "The updated patient record"
+// @Header 200 {string} ETag "ETag of the updated patient version"
+// @Failure 400 {object} middleware.Problem "Invalid patient ID, patch document or If-Match header"
+// @Failure 401 {object} middleware.Problem "Unauthorized"
+// @Failure 404 {object} middleware.Problem "Patient not found"
+// @Failure 409 {object} middleware.Problem "A JSON Patch test operation failed"
+// @Failure 412 {object} middleware.Problem "Patient has been modified since it was last read"
+// @Failure 415 {object} middleware.Problem "Unsupported patch format"
+// @Failure 428 {object} middleware.Problem "If-Match header with a patient ETag is required"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /patients/{id} [patch]
+func (c *PatientController) PatchPatient(ctx *gin.Context) {
+	idStr := ctx.Param("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		ctx.Error(errInvalidPatientID)
+		return
+	}
+
+	var format services.PatchFormat
+	switch ctx.ContentType() {
+	case "application/merge-patch+json", "application/json":
+		format = services.MergePatch
+	case "application/json-patch+json":
+		format = services.JSONPatch
+	default:
+		middleware.AbortWithProblem(ctx, http.StatusUnsupportedMediaType, "unsupported_patch_format", "Content-Type must be application/merge-patch+json or application/json-patch+json")
+		return
+	}
+
+	expectedVersion, ok := ifMatchVersion(ctx)
+	if !ok {
+		return
+	}
+
+	patch, err := ctx.GetRawData()
+	if err != nil {
+		ctx.Error(services.InvalidInput(err))
+		return
+	}
+
+	// Retrieve the authenticated principal (set during authentication)
+	principal, ok := currentPrincipal(ctx)
+	if !ok {
+		return
+	}
+
+	// Apply the patch using the service
+	patient, err := c.patientService.PatchPatient(ctx.Request.Context(), id, format, patch, expectedVersion, principal.UserID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Header("ETag", patientETag(patient.Version))
+	ctx.JSON(http.StatusOK, patient)
+}
+
+// DeletePatient soft-deletes a patient by ID.
 //
 // @Summary Delete a patient by ID
-// @Description Delete a patient record by its ID
+// @Description Soft-delete a patient record by its ID; the record is retained and can be restored until it is purged
 // @Tags patients
 // @Produce json
 // @Param id path int true "Patient ID"
 // @Success 204 "No content"
-// @Failure 400 {object} map[string]string "Invalid patient ID"
-// @Failure 500 {object} map[string]string "Internal server error"
+// @Failure 400 {object} middleware.Problem "Invalid patient ID"
+// @Failure 401 {object} middleware.Problem "Unauthorized"
+// @Failure 404 {object} middleware.Problem "Patient not found"
+// @Failure 500 {object} middleware.Problem "Internal server error"
 // @Router /patients/{id} [delete]
 func (c *PatientController) DeletePatient(ctx *gin.Context) {
 	idStr := ctx.Param("id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid patient ID"})
+		ctx.Error(errInvalidPatientID)
+		return
+	}
+
+	// Retrieve the authenticated principal (set during authentication)
+	principal, ok := currentPrincipal(ctx)
+	if !ok {
 		return
 	}
 
 	// Delete the patient using the service
-	if err := c.patientService.DeletePatient(ctx.Request.Context(), id); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := c.patientService.DeletePatient(ctx.Request.Context(), id, principal.UserID); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// RestorePatient restores a soft-deleted patient by ID.
+//
+// @Summary Restore a deleted patient
+// @Description Restore a soft-deleted patient record that has not yet been purged
+// @Tags patients
+// @Produce json
+// @Param id path int true "Patient ID"
+// @Success 204 "No content"
+// @Failure 400 {object} middleware.Problem "Invalid patient ID"
+// @Failure 401 {object} middleware.Problem "Unauthorized"
+// @Failure 404 {object} middleware.Problem "Deleted patient not found"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /patients/{id}/restore [post]
+func (c *PatientController) RestorePatient(ctx *gin.Context) {
+	idStr := ctx.Param("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		ctx.Error(errInvalidPatientID)
+		return
+	}
+
+	// Retrieve the authenticated principal (set during authentication)
+	principal, ok := currentPrincipal(ctx)
+	if !ok {
+		return
+	}
+
+	// Restore the patient using the service
+	if err := c.patientService.RestorePatient(ctx.Request.Context(), id, principal.UserID); err != nil {
+		ctx.Error(err)
 		return
 	}
 
 	ctx.Status(http.StatusNoContent)
-}
\ No newline at end of file
+}
+
+// GetPatientHistory lists the versions of a patient record.
+//
+// @Summary Get a patient's version history
+// @Description List every version of a patient record with what changed, by whom, and when
+// @Tags patients
+// @Produce json
+// @Param id path int true "Patient ID"
+// @Success 200 {array} models.PatientVersion "The versions of the patient record, oldest first"
+// @Failure 400 {object} middleware.Problem "Invalid patient ID"
+// @Failure 404 {object} middleware.Problem "Patient not found"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /patients/{id}/history [get]
+func (c *PatientController) GetPatientHistory(ctx *gin.Context) {
+	idStr := ctx.Param("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		ctx.Error(errInvalidPatientID)
+		return
+	}
+
+	// Retrieve the history using the service
+	versions, err := c.patientService.ListPatientHistory(ctx.Request.Context(), id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, versions)
+}
+
+// GetPatientVersion retrieves a single version of a patient record.
+//
+// @Summary Get a version of a patient record
+// @Description Retrieve a patient record as it was at the given version, with the fields changed in that version
+// @Tags patients
+// @Produce json
+// @Param id path int true "Patient ID"
+// @Param version path int true "Version number"
+// @Success 200 {object} models.PatientVersion "The patient record version"
+// @Failure 400 {object} middleware.Problem "Invalid patient ID or version"
+// @Failure 404 {object} middleware.Problem "Patient version not found"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /patients/{id}/history/{version} [get]
+func (c *PatientController) GetPatientVersion(ctx *gin.Context) {
+	idStr := ctx.Param("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		ctx.Error(errInvalidPatientID)
+		return
+	}
+	version, err := strconv.Atoi(ctx.Param("version"))
+	if err != nil {
+		ctx.Error(services.NewValidationError("invalid_version", "Invalid version"))
+		return
+	}
+
+	// Retrieve the version using the service
+	patientVersion, err := c.patientService.GetPatientVersion(ctx.Request.Context(), id, version)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, patientVersion)
+}
+
+// PurgeDeletedPatients permanently removes patients deleted longer ago than the retention period.
+//
+// @Summary Purge deleted patients
+// @Description Permanently remove soft-deleted patient records whose retention period has elapsed (administrators only). Patients with clinical or billing records are kept.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} map[string][]int64 "IDs of the purged patients"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /admin/patients/purge [post]
+func (c *PatientController) PurgeDeletedPatients(ctx *gin.Context) {
+	ids, err := c.patientService.PurgeDeletedPatients(ctx.Request.Context())
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"purged": ids})
+}
+
+// ListPatients retrieves a paginated, filterable list of patients.
+//
+// @Summary List patients
+// @Description Retrieve a page of patient records with optional filters and sorting
+// @Tags patients
+// @Produce json
+// @Param page query int false "Page number (1-based)"
+// @Param page_size query int false "Number of records per page (max 100)"
+// @Param sort query string false "Sort field (id, first_name, last_name, date_of_birth, created_at, updated_at); prefix with - for descending"
+// @Param name query string false "Partial first or last name"
+// @Param gender query string false "Gender"
+// @Param dob_from query string false "Earliest date of birth (YYYY-MM-DD)"
+// @Param dob_to query string false "Latest date of birth (YYYY-MM-DD)"
+// @Param created_from query string false "Earliest creation time (RFC3339 or YYYY-MM-DD)"
+// @Param created_to query string false "Latest creation time (RFC3339 or YYYY-MM-DD)"
+// @Success 200 {object} PatientListResponse "A page of patients"
+// @Failure 400 {object} middleware.Problem "Invalid query parameters"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /patients [get]
+func (c *PatientController) ListPatients(ctx *gin.Context) {
+	params, err := parsePatientListParams(ctx)
+	if err != nil {
+		ctx.Error(services.NewValidationError("invalid_query", err.Error()))
+		return
+	}
+
+	// Retrieve the page using the service
+	result, err := c.patientService.ListPatients(ctx.Request.Context(), params)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, PatientListResponse{
+		PatientListResult: result,
+		Links:             paginationLinks(ctx.Request.URL, result.Page, result.PageSize, result.Total),
+	})
+}
+
+// SearchPatients finds patients by partial name, phone number or email.
+//
+// @Summary Search patients
+// @Description Fuzzy search over patient names, phone numbers and emails, ranked by relevance
+// @Tags patients
+// @Produce json
+// @Param q query string true "Search term"
+// @Param limit query int false "Maximum number of results (max 50)"
+// @Success 200 {array} services.PatientSearchResult "Matching patients ordered by relevance"
+// @Failure 400 {object} middleware.Problem "Missing or invalid query parameters"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /patients/search [get]
+func (c *PatientController) SearchPatients(ctx *gin.Context) {
+	q := ctx.Query("q")
+	if q == "" {
+		ctx.Error(services.NewValidationError("missing_search_term", "Missing search term"))
+		return
+	}
+	limit, err := queryInt(ctx, "limit")
+	if err != nil {
+		ctx.Error(services.NewValidationError("invalid_query", err.Error()))
+		return
+	}
+
+	// Search for matching patients using the service
+	results, err := c.patientService.SearchPatients(ctx.Request.Context(), q, limit)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, results)
+}
+
+// PatientListResponse is the response body for a patient listing.
+type PatientListResponse struct {
+	*services.PatientListResult
+	Links PaginationLinks `json:"links"`
+}
+
+// PaginationLinks holds the URLs of the current and neighbouring pages.
+type PaginationLinks struct {
+	Self string `json:"self"`
+	Next string `json:"next,omitempty"`
+	Prev string `json:"prev,omitempty"`
+}
+
+// paginationLinks builds self/next/prev links for a paginated listing, preserving the other query parameters.
+func paginationLinks(u *url.URL, page, pageSize int, total int64) PaginationLinks {
+	link := func(p int) string {
+		query := u.Query()
+		query.Set("page", strconv.Itoa(p))
+		query.Set("page_size", strconv.Itoa(pageSize))
+		return u.Path + "?" + query.Encode()
+	}
+
+	links := PaginationLinks{Self: link(page)}
+	if int64(page*pageSize) < total {
+		links.Next = link(page + 1)
+	}
+	if page > 1 {
+		links.Prev = link(page - 1)
+	}
+	return links
+}
+
+// parsePatientListParams reads the listing filters and pagination settings from the query string.
+func parsePatientListParams(ctx *gin.Context) (services.PatientListParams, error) {
+	params := services.PatientListParams{
+		Name:   ctx.Query("name"),
+		Gender: ctx.Query("gender"),
+		Sort:   ctx.Query("sort"),
+	}
+
+	var err error
+	if params.Page, err = queryInt(ctx, "page"); err != nil {
+		return params, err
+	}
+	if params.PageSize, err = queryInt(ctx, "page_size"); err != nil {
+		return params, err
+	}
+	if params.DOBFrom, err = queryTime(ctx, "dob_from"); err != nil {
+		return params, err
+	}
+	if params.DOBTo, params.DOBBefore, err = queryUpperBound(ctx, "dob_to"); err != nil {
+		return params, err
+	}
+	if params.CreatedFrom, err = queryTime(ctx, "created_from"); err != nil {
+		return params, err
+	}
+	if params.CreatedTo, params.CreatedBefore, err = queryUpperBound(ctx, "created_to"); err != nil {
+		return params, err
+	}
+	return params, nil
+}
+
+// queryInt parses an optional integer query parameter, returning 0 when it is absent.
+func queryInt(ctx *gin.Context, key string) (int, error) {
+	value := ctx.Query(key)
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: must be an integer", key)
+	}
+	return n, nil
+}
+
+// queryTime parses an optional RFC3339 or YYYY-MM-DD query parameter, returning nil when it is absent.
+func queryTime(ctx *gin.Context, key string) (*time.Time, error) {
+	value := ctx.Query(key)
+	if value == "" {
+		return nil, nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid %s: expected RFC3339 or YYYY-MM-DD", key)
+}
+
+// queryUpperBound parses an optional RFC3339 or YYYY-MM-DD upper bound. An RFC3339 value is
+// returned as an inclusive bound; a date-only value covers the whole day, so it is returned as an
+// exclusive bound at the start of the next day.
+func queryUpperBound(ctx *gin.Context, key string) (inclusive, exclusive *time.Time, err error) {
+	value := ctx.Query(key)
+	if value == "" {
+		return nil, nil, nil
+	}
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		next := t.AddDate(0, 0, 1)
+		return nil, &next, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil, nil
+	}
+	return nil, nil, fmt.Errorf("invalid %s: expected RFC3339 or YYYY-MM-DD", key)
+}
+
+// patientETag formats a patient version as a strong entity tag.
+func patientETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// ifMatchVersion reads the patient version from the If-Match header, writing a 428 response when
+// the header is missing or is "*", which would skip the concurrency check, and a 400 response when
+// it is malformed.
+func ifMatchVersion(ctx *gin.Context) (int, bool) {
+	header := strings.TrimSpace(ctx.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		middleware.AbortWithProblem(ctx, http.StatusPreconditionRequired, "if_match_required", "If-Match header with the ETag of the patient version being updated is required")
+		return 0, false
+	}
+
+	tag := strings.Trim(strings.TrimPrefix(header, "W/"), `"`)
+	version, err := strconv.Atoi(tag)
+	if err != nil {
+		ctx.Error(services.NewValidationError("invalid_if_match", "Invalid If-Match header"))
+		return 0, false
+	}
+	return version, true
+}
@@ -0,0 +1,177 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Okemwag/medihub/internal/middleware"
+	"github.com/Okemwag/medihub/internal/requestctx"
+	"github.com/Okemwag/medihub/internal/services"
+	"github.com/gin-gonic/gin"
+	_ "github.com/lib/pq" // PostgreSQL driver
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// newUnreachablePatientController returns a controller whose database cannot be reached, so that
+// any request which gets past authentication fails with an internal error.
+func newUnreachablePatientController(t *testing.T) *PatientController {
+	t.Helper()
+	db, err := sql.Open("postgres", "host=/nonexistent-medihub-socket-dir sslmode=disable")
+	if err != nil {
+		t.Fatalf("opening database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return NewPatientController(services.NewPatientService(db, services.NewAuditService(db), time.Hour))
+}
+
+// patientWriteRoute describes a patient write endpoint and how to call it.
+type patientWriteRoute struct {
+	method  string
+	pattern string // Route pattern the handler is registered under
+	path    string // Path requested
+	handler func(*PatientController) gin.HandlerFunc
+	header  http.Header
+	body    string
+}
+
+// serve sends a request to the route, authenticated as principal unless it is nil.
+func (r patientWriteRoute) serve(c *PatientController, principal *requestctx.Principal) *httptest.ResponseRecorder {
+	router := gin.New()
+	router.Use(middleware.ErrorHandler())
+	router.Handle(r.method, r.pattern, func(ctx *gin.Context) {
+		if principal != nil {
+			ctx.Request = ctx.Request.WithContext(requestctx.WithPrincipal(ctx.Request.Context(), *principal))
+		}
+	}, r.handler(c))
+
+	req := httptest.NewRequest(r.method, r.path, strings.NewReader(r.body))
+	for key, values := range r.header {
+		req.Header[key] = values
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// patientWriteRoutes are the patient endpoints that attribute their change to the caller.
+var patientWriteRoutes = map[string]patientWriteRoute{
+	"delete": {
+		method:  http.MethodDelete,
+		pattern: "/patients/:id",
+		path:    "/patients/1",
+		handler: func(c *PatientController) gin.HandlerFunc { return c.DeletePatient },
+	},
+	"restore": {
+		method:  http.MethodPost,
+		pattern: "/patients/:id/restore",
+		path:    "/patients/1/restore",
+		handler: func(c *PatientController) gin.HandlerFunc { return c.RestorePatient },
+	},
+	"patch": {
+		method:  http.MethodPatch,
+		pattern: "/patients/:id",
+		path:    "/patients/1",
+		handler: func(c *PatientController) gin.HandlerFunc { return c.PatchPatient },
+		header:  http.Header{"Content-Type": {"application/merge-patch+json"}, "If-Match": {`"3"`}},
+		body:    `{"first_name":"Jane"}`,
+	},
+}
+
+func TestPatientWritesUseAuthenticatedPrincipal(t *testing.T) {
+	controller := newUnreachablePatientController(t)
+
+	for name, route := range patientWriteRoutes {
+		t.Run(name+" unauthenticated", func(t *testing.T) {
+			w := route.serve(controller, nil)
+			if w.Code != http.StatusUnauthorized {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusUnauthorized)
+			}
+			var problem middleware.Problem
+			if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+				t.Fatalf("decoding problem: %v", err)
+			}
+			if problem.Code != "unauthenticated" {
+				t.Errorf("problem code = %q, want unauthenticated", problem.Code)
+			}
+		})
+
+		t.Run(name+" authenticated", func(t *testing.T) {
+			// The request reaches the (unreachable) database instead of being rejected as unauthenticated
+			w := route.serve(controller, &requestctx.Principal{UserID: 9, Role: "admin"})
+			if w.Code != http.StatusInternalServerError {
+				t.Fatalf("status = %d, want %d (body %s)", w.Code, http.StatusInternalServerError, w.Body)
+			}
+		})
+	}
+}
+
+func TestPatientUpdatesRequireVersionedIfMatch(t *testing.T) {
+	controller := newUnreachablePatientController(t)
+	principal := &requestctx.Principal{UserID: 9, Role: "admin"}
+
+	for _, ifMatch := range []string{"", "*"} {
+		for _, route := range []patientWriteRoute{
+			{
+				method:  http.MethodPut,
+				pattern: "/patients/:id",
+				path:    "/patients/1",
+				handler: func(c *PatientController) gin.HandlerFunc { return c.UpdatePatient },
+				header:  http.Header{"Content-Type": {"application/json"}},
+				body:    `{}`,
+			},
+			{
+				method:  http.MethodPatch,
+				pattern: "/patients/:id",
+				path:    "/patients/1",
+				handler: func(c *PatientController) gin.HandlerFunc { return c.PatchPatient },
+				header:  http.Header{"Content-Type": {"application/merge-patch+json"}},
+				body:    `{"first_name":"Jane"}`,
+			},
+		} {
+			if ifMatch != "" {
+				route.header.Set("If-Match", ifMatch)
+			}
+			t.Run(route.method+" If-Match "+strconv.Quote(ifMatch), func(t *testing.T) {
+				w := route.serve(controller, principal)
+				if w.Code != http.StatusPreconditionRequired {
+					t.Fatalf("status = %d, want %d", w.Code, http.StatusPreconditionRequired)
+				}
+			})
+		}
+	}
+}
+
+func TestParsePatientListParamsUpperBounds(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/patients?dob_to=2020-01-31&created_to=2025-02-01T10:30:00Z", nil)
+
+	params, err := parsePatientListParams(c)
+	if err != nil {
+		t.Fatalf("parsePatientListParams() error = %v", err)
+	}
+
+	// A date-only bound includes the whole day
+	if params.DOBTo != nil {
+		t.Errorf("DOBTo = %v, want nil for a date-only bound", params.DOBTo)
+	}
+	if want := time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC); params.DOBBefore == nil || !params.DOBBefore.Equal(want) {
+		t.Errorf("DOBBefore = %v, want %v", params.DOBBefore, want)
+	}
+
+	// A timestamp bound is inclusive as given
+	if want := time.Date(2025, 2, 1, 10, 30, 0, 0, time.UTC); params.CreatedTo == nil || !params.CreatedTo.Equal(want) {
+		t.Errorf("CreatedTo = %v, want %v", params.CreatedTo, want)
+	}
+	if params.CreatedBefore != nil {
+		t.Errorf("CreatedBefore = %v, want nil for a timestamp bound", params.CreatedBefore)
+	}
+}
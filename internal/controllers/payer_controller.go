@@ -0,0 +1,163 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Okemwag/medihub/internal/models"
+	"github.com/Okemwag/medihub/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// errInvalidPayerID is reported when the id path parameter is not a valid payer ID.
+var errInvalidPayerID = services.NewValidationError("invalid_payer_id", "Invalid payer ID")
+
+// PayerController handles HTTP requests for insurers and public schemes.
+type PayerController struct {
+	payerService *services.PayerService // Service for payer operations
+}
+
+// NewPayerController creates a new instance of PayerController.
+//
+// @param payerService *services.PayerService: The payer service.
+// @return *PayerController: A new PayerController instance.
+func NewPayerController(payerService *services.PayerService) *PayerController {
+	return &PayerController{payerService: payerService}
+}
+
+// ListPayers retrieves the payers.
+//
+// @Summary List payers
+// @Description Retrieve the insurers and public schemes that cover patients, ordered by name
+// @Tags insurance
+// @Produce json
+// @Param include_inactive query bool false "Include payers that no longer cover patients"
+// @Success 200 {array} models.Payer "The payers"
+// @Failure 400 {object} middleware.Problem "Invalid query parameters"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /payers [get]
+func (c *PayerController) ListPayers(ctx *gin.Context) {
+	includeInactive := false
+	if value := ctx.Query("include_inactive"); value != "" {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			ctx.Error(services.NewValidationError("invalid_query", "invalid include_inactive: must be true or false"))
+			return
+		}
+		includeInactive = b
+	}
+
+	// Retrieve the payers using the service
+	payers, err := c.payerService.ListPayers(ctx.Request.Context(), includeInactive)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, payers)
+}
+
+// GetPayer retrieves a payer by ID.
+//
+// @Summary Get a payer by ID
+// @Description Retrieve an insurer or public scheme
+// @Tags insurance
+// @Produce json
+// @Param id path int true "Payer ID"
+// @Success 200 {object} models.Payer "The payer"
+// @Failure 400 {object} middleware.Problem "Invalid payer ID"
+// @Failure 404 {object} middleware.Problem "Payer not found"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /payers/{id} [get]
+func (c *PayerController) GetPayer(ctx *gin.Context) {
+	id, ok := payerIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	// Retrieve the payer using the service
+	payer, err := c.payerService.GetPayer(ctx.Request.Context(), id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, payer)
+}
+
+// CreatePayer adds a payer.
+//
+// @Summary Add a payer
+// @Description Add an insurer or public scheme; new payers can be used for policies immediately
+// @Tags insurance
+// @Accept json
+// @Produce json
+// @Param payer body models.Payer true "Payer data"
+// @Success 201 {object} models.Payer "The added payer"
+// @Failure 400 {object} middleware.Problem "Invalid request payload"
+// @Failure 409 {object} middleware.Problem "A payer with this code already exists"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /payers [post]
+func (c *PayerController) CreatePayer(ctx *gin.Context) {
+	var payer models.Payer
+	if err := ctx.ShouldBindJSON(&payer); err != nil {
+		ctx.Error(services.InvalidInput(err))
+		return
+	}
+
+	// Add the payer using the service
+	created, err := c.payerService.CreatePayer(ctx.Request.Context(), &payer)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, created)
+}
+
+// UpdatePayer replaces the details of a payer.
+//
+// @Summary Update a payer
+// @Description Replace the details of a payer; set active to false when it no longer covers patients, which makes its policies ineligible
+// @Tags insurance
+// @Accept json
+// @Produce json
+// @Param id path int true "Payer ID"
+// @Param payer body models.Payer true "Payer data"
+// @Success 200 {object} models.Payer "The updated payer"
+// @Failure 400 {object} middleware.Problem "Invalid payer ID or request payload"
+// @Failure 404 {object} middleware.Problem "Payer not found"
+// @Failure 409 {object} middleware.Problem "A payer with this code already exists"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /payers/{id} [put]
+func (c *PayerController) UpdatePayer(ctx *gin.Context) {
+	id, ok := payerIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	var payer models.Payer
+	if err := ctx.ShouldBindJSON(&payer); err != nil {
+		ctx.Error(services.InvalidInput(err))
+		return
+	}
+
+	// Update the payer using the service
+	updated, err := c.payerService.UpdatePayer(ctx.Request.Context(), id, &payer)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, updated)
+}
+
+// payerIDParam parses the id path parameter, reporting a validation error when it is invalid.
+func payerIDParam(ctx *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(errInvalidPayerID)
+		return 0, false
+	}
+	return id, true
+}
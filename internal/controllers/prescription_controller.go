@@ -0,0 +1,222 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Okemwag/medihub/internal/models"
+	"github.com/Okemwag/medihub/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// errInvalidPrescriptionID is reported when the id path parameter is not a valid prescription ID.
+var errInvalidPrescriptionID = services.NewValidationError("invalid_prescription_id", "Invalid prescription ID")
+
+// PrescriptionController handles HTTP requests for patients' prescriptions.
+type PrescriptionController struct {
+	prescriptionService *services.PrescriptionService // Service for prescription operations
+}
+
+// NewPrescriptionController creates a new instance of PrescriptionController.
+//
+// @param prescriptionService *services.PrescriptionService: The prescription service.
+// @return *PrescriptionController: A new PrescriptionController instance.
+func NewPrescriptionController(prescriptionService *services.PrescriptionService) *PrescriptionController {
+	return &PrescriptionController{prescriptionService: prescriptionService}
+}
+
+// CreatePrescription prescribes a medication to a patient.
+//
+// @Summary Prescribe a medication
+// @Description Prescribe a drug from the catalogue; the authenticated doctor is the prescriber. The prescription is checked against the patient's active allergies, current medications and the interaction table: blocking alerts refuse it, and warnings must be acknowledged with an override_reason
+// @Tags prescriptions
+// @Accept json
+// @Produce json
+// @Param id path int true "Patient ID"
+// @Param prescription body models.Prescription true "Drug, dose, route, frequency and duration"
+// @Success 201 {object} models.Prescription "The prescription, with the warnings that were overridden"
+// @Failure 400 {object} middleware.Problem "Invalid patient ID or request payload, inactive drug, or the user is not a doctor"
+// @Failure 404 {object} middleware.Problem "Patient not found"
+// @Failure 409 {object} middleware.Problem "The prescription is blocked, or raised warnings without an override reason"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /patients/{id}/prescriptions [post]
+func (c *PrescriptionController) CreatePrescription(ctx *gin.Context) {
+	patientID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(errInvalidPatientID)
+		return
+	}
+
+	var prescription models.Prescription
+	if err := ctx.ShouldBindJSON(&prescription); err != nil {
+		ctx.Error(services.InvalidInput(err))
+		return
+	}
+
+	// Retrieve the authenticated principal (set during authentication)
+	principal, ok := currentPrincipal(ctx)
+	if !ok {
+		return
+	}
+	prescription.PatientID = patientID
+	prescription.PrescriberID = principal.UserID
+
+	// Write the prescription using the service
+	created, err := c.prescriptionService.CreatePrescription(ctx.Request.Context(), &prescription)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, created)
+}
+
+// CheckPrescription reports the alerts a prescription would raise.
+//
+// @Summary Check a prescription
+// @Description Check a prescription against the patient's active allergies, current medications and the interaction table without writing it
+// @Tags prescriptions
+// @Accept json
+// @Produce json
+// @Param id path int true "Patient ID"
+// @Param prescription body models.Prescription true "Drug, dose, route, frequency and duration"
+// @Success 200 {object} services.PrescriptionCheck "The alerts raised by the prescription"
+// @Failure 400 {object} middleware.Problem "Invalid patient ID or request payload, or inactive drug"
+// @Failure 404 {object} middleware.Problem "Patient not found"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /patients/{id}/prescriptions/check [post]
+func (c *PrescriptionController) CheckPrescription(ctx *gin.Context) {
+	patientID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(errInvalidPatientID)
+		return
+	}
+
+	var prescription models.Prescription
+	if err := ctx.ShouldBindJSON(&prescription); err != nil {
+		ctx.Error(services.InvalidInput(err))
+		return
+	}
+
+	// Check the prescription using the service
+	check, err := c.prescriptionService.CheckPrescription(ctx.Request.Context(), patientID, &prescription)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, check)
+}
+
+// ListPatientPrescriptions retrieves a patient's prescriptions.
+//
+// @Summary List a patient's prescriptions
+// @Description Retrieve a patient's prescriptions, most recent first
+// @Tags prescriptions
+// @Produce json
+// @Param id path int true "Patient ID"
+// @Param status query string false "active, discontinued, or current (active and not yet ended)"
+// @Success 200 {array} models.Prescription "The patient's prescriptions"
+// @Failure 400 {object} middleware.Problem "Invalid patient ID or status"
+// @Failure 404 {object} middleware.Problem "Patient not found"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /patients/{id}/prescriptions [get]
+func (c *PrescriptionController) ListPatientPrescriptions(ctx *gin.Context) {
+	patientID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(errInvalidPatientID)
+		return
+	}
+
+	// Retrieve the prescriptions using the service
+	prescriptions, err := c.prescriptionService.ListPatientPrescriptions(ctx.Request.Context(), patientID, ctx.Query("status"))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, prescriptions)
+}
+
+// GetPrescription retrieves a prescription by ID.
+//
+// @Summary Get a prescription by ID
+// @Description Retrieve a prescription with the warnings that were overridden when it was written
+// @Tags prescriptions
+// @Produce json
+// @Param id path int true "Prescription ID"
+// @Success 200 {object} models.Prescription "The prescription"
+// @Failure 400 {object} middleware.Problem "Invalid prescription ID"
+// @Failure 404 {object} middleware.Problem "Prescription not found"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /prescriptions/{id} [get]
+func (c *PrescriptionController) GetPrescription(ctx *gin.Context) {
+	id, ok := prescriptionIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	// Retrieve the prescription using the service
+	prescription, err := c.prescriptionService.GetPrescription(ctx.Request.Context(), id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, prescription)
+}
+
+// DiscontinuePrescription stops an active prescription.
+//
+// @Summary Discontinue a prescription
+// @Description Stop an active prescription before its end date
+// @Tags prescriptions
+// @Accept json
+// @Produce json
+// @Param id path int true "Prescription ID"
+// @Param request body struct{Reason string} true "Why the prescription is stopped"
+// @Success 200 {object} models.Prescription "The discontinued prescription"
+// @Failure 400 {object} middleware.Problem "Invalid prescription ID or request payload"
+// @Failure 404 {object} middleware.Problem "Prescription not found"
+// @Failure 409 {object} middleware.Problem "The prescription has already been discontinued"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /prescriptions/{id}/discontinue [post]
+func (c *PrescriptionController) DiscontinuePrescription(ctx *gin.Context) {
+	id, ok := prescriptionIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Reason string `json:"reason" binding:"required,max=500"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(services.InvalidInput(err))
+		return
+	}
+
+	// Retrieve the authenticated principal (set during authentication)
+	principal, ok := currentPrincipal(ctx)
+	if !ok {
+		return
+	}
+
+	// Discontinue the prescription using the service
+	prescription, err := c.prescriptionService.DiscontinuePrescription(ctx.Request.Context(), id, req.Reason, principal.UserID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, prescription)
+}
+
+// prescriptionIDParam parses the id path parameter, reporting a validation error when it is invalid.
+func prescriptionIDParam(ctx *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(errInvalidPrescriptionID)
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,222 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Okemwag/medihub/internal/models"
+	"github.com/Okemwag/medihub/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// ProblemController handles HTTP requests for patients' coded problem lists.
+type ProblemController struct {
+	problemService *services.ProblemService // Service for problem list operations
+}
+
+// NewProblemController creates a new instance of ProblemController.
+//
+// @param problemService *services.ProblemService: The problem list service.
+// @return *ProblemController: A new ProblemController instance.
+func NewProblemController(problemService *services.ProblemService) *ProblemController {
+	return &ProblemController{problemService: problemService}
+}
+
+// CreateProblem adds a problem to a patient's problem list.
+//
+// @Summary Record a problem
+// @Description Add an ICD-10 coded problem to a patient's problem list; status defaults to active, and resolved problems default to resolving today
+// @Tags problems
+// @Accept json
+// @Produce json
+// @Param id path int true "Patient ID"
+// @Param problem body models.Problem true "Problem data"
+// @Success 201 {object} models.Problem "The recorded problem"
+// @Failure 400 {object} middleware.Problem "Invalid patient ID, request payload, code or dates"
+// @Failure 404 {object} middleware.Problem "Patient not found"
+// @Failure 409 {object} middleware.Problem "The patient already has an active problem with this code"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /patients/{id}/problems [post]
+func (c *ProblemController) CreateProblem(ctx *gin.Context) {
+	patientID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(errInvalidPatientID)
+		return
+	}
+
+	var problem models.Problem
+	if err := ctx.ShouldBindJSON(&problem); err != nil {
+		ctx.Error(services.InvalidInput(err))
+		return
+	}
+
+	// Retrieve the authenticated principal (set during authentication)
+	principal, ok := currentPrincipal(ctx)
+	if !ok {
+		return
+	}
+	problem.RecordedBy = principal.UserID
+	problem.UpdatedBy = principal.UserID
+
+	// Record the problem using the service
+	created, err := c.problemService.CreateProblem(ctx.Request.Context(), patientID, &problem)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, created)
+}
+
+// ListProblems retrieves a patient's problem list.
+//
+// @Summary List a patient's problems
+// @Description Retrieve a patient's problem list, active problems first and most recent onset first
+// @Tags problems
+// @Produce json
+// @Param id path int true "Patient ID"
+// @Param status query string false "Only problems in this status (active, inactive, resolved, entered_in_error)"
+// @Success 200 {array} models.Problem "The patient's problems"
+// @Failure 400 {object} middleware.Problem "Invalid patient ID"
+// @Failure 404 {object} middleware.Problem "Patient not found"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /patients/{id}/problems [get]
+func (c *ProblemController) ListProblems(ctx *gin.Context) {
+	patientID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(errInvalidPatientID)
+		return
+	}
+
+	// Retrieve the problems using the service
+	problems, err := c.problemService.ListProblems(ctx.Request.Context(), patientID, ctx.Query("status"))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, problems)
+}
+
+// GetProblem retrieves one of a patient's problems.
+//
+// @Summary Get a problem
+// @Description Retrieve one of a patient's problems by its ID
+// @Tags problems
+// @Produce json
+// @Param id path int true "Patient ID"
+// @Param problemId path int true "Problem ID"
+// @Success 200 {object} models.Problem "The problem"
+// @Failure 400 {object} middleware.Problem "Invalid patient or problem ID"
+// @Failure 404 {object} middleware.Problem "Problem not found"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /patients/{id}/problems/{problemId} [get]
+func (c *ProblemController) GetProblem(ctx *gin.Context) {
+	patientID, id, ok := problemParams(ctx)
+	if !ok {
+		return
+	}
+
+	// Retrieve the problem using the service
+	problem, err := c.problemService.GetProblem(ctx.Request.Context(), patientID, id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, problem)
+}
+
+// UpdateProblem replaces the details of one of a patient's problems.
+//
+// @Summary Update a problem
+// @Description Replace the details of a problem, e.g. to record its onset or mark it resolved
+// @Tags problems
+// @Accept json
+// @Produce json
+// @Param id path int true "Patient ID"
+// @Param problemId path int true "Problem ID"
+// @Param problem body models.Problem true "Problem data"
+// @Success 200 {object} models.Problem "The updated problem"
+// @Failure 400 {object} middleware.Problem "Invalid patient or problem ID, request payload, code or dates"
+// @Failure 404 {object} middleware.Problem "Problem not found"
+// @Failure 409 {object} middleware.Problem "The patient already has an active problem with this code"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /patients/{id}/problems/{problemId} [put]
+func (c *ProblemController) UpdateProblem(ctx *gin.Context) {
+	patientID, id, ok := problemParams(ctx)
+	if !ok {
+		return
+	}
+
+	var problem models.Problem
+	if err := ctx.ShouldBindJSON(&problem); err != nil {
+		ctx.Error(services.InvalidInput(err))
+		return
+	}
+
+	// Retrieve the authenticated principal (set during authentication)
+	principal, ok := currentPrincipal(ctx)
+	if !ok {
+		return
+	}
+
+	// Update the problem using the service
+	updated, err := c.problemService.UpdateProblem(ctx.Request.Context(), patientID, id, &problem, principal.UserID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, updated)
+}
+
+// DeleteProblem retires one of a patient's problems that was recorded by mistake.
+//
+// @Summary Delete a problem
+// @Description Mark a problem recorded by mistake as entered_in_error; the record is kept for the audit trail. Mark problems that no longer apply as inactive or resolved instead
+// @Tags problems
+// @Param id path int true "Patient ID"
+// @Param problemId path int true "Problem ID"
+// @Success 204 "Problem marked entered_in_error"
+// @Failure 400 {object} middleware.Problem "Invalid patient or problem ID"
+// @Failure 401 {object} middleware.Problem "Unauthorized"
+// @Failure 404 {object} middleware.Problem "Problem not found"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /patients/{id}/problems/{problemId} [delete]
+func (c *ProblemController) DeleteProblem(ctx *gin.Context) {
+	patientID, id, ok := problemParams(ctx)
+	if !ok {
+		return
+	}
+
+	// Retrieve the authenticated principal (set during authentication)
+	principal, ok := currentPrincipal(ctx)
+	if !ok {
+		return
+	}
+
+	// Retire the problem using the service
+	if err := c.problemService.DeleteProblem(ctx.Request.Context(), patientID, id, principal.UserID); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// problemParams parses the id and problemId path parameters, reporting a validation error when
+// either is invalid.
+func problemParams(ctx *gin.Context) (patientID, id int64, ok bool) {
+	patientID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(errInvalidPatientID)
+		return 0, 0, false
+	}
+	id, err = strconv.ParseInt(ctx.Param("problemId"), 10, 64)
+	if err != nil {
+		ctx.Error(services.NewValidationError("invalid_problem_id", "Invalid problem ID"))
+		return 0, 0, false
+	}
+	return patientID, id, true
+}
@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/Okemwag/medihub/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// RoleController handles HTTP requests for administering the permissions granted to roles.
+type RoleController struct {
+	permissionService *services.PermissionService // Service for role permission operations
+}
+
+// NewRoleController creates a new instance of RoleController.
+//
+// @param permissionService *services.PermissionService: The role permission service.
+// @return *RoleController: A new RoleController instance.
+func NewRoleController(permissionService *services.PermissionService) *RoleController {
+	return &RoleController{permissionService: permissionService}
+}
+
+// ListRolePermissions lists the permissions granted to a role.
+//
+// @Summary List role permissions
+// @Description List the permissions granted to a role (administrators only)
+// @Tags admin
+// @Produce json
+// @Param role path string true "Role name"
+// @Success 200 {array} string "The permission names"
+// @Failure 400 {object} middleware.Problem "Unknown role"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /admin/roles/{role}/permissions [get]
+func (c *RoleController) ListRolePermissions(ctx *gin.Context) {
+	permissions, err := c.permissionService.ListRolePermissions(ctx.Request.Context(), ctx.Param("role"))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, permissions)
+}
+
+// GrantPermission grants a permission to a role.
+//
+// @Summary Grant a permission to a role
+// @Description Grant a permission to a role; it takes effect on this instance immediately (administrators only)
+// @Tags admin
+// @Param role path string true "Role name"
+// @Param permission path string true "Permission name"
+// @Success 204 "No content"
+// @Failure 400 {object} middleware.Problem "Unknown role or permission"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /admin/roles/{role}/permissions/{permission} [put]
+func (c *RoleController) GrantPermission(ctx *gin.Context) {
+	if err := c.permissionService.GrantPermission(ctx.Request.Context(), ctx.Param("role"), ctx.Param("permission")); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// RevokePermission revokes a permission from a role.
+//
+// @Summary Revoke a permission from a role
+// @Description Revoke a permission from a role; it takes effect on this instance immediately (administrators only)
+// @Tags admin
+// @Param role path string true "Role name"
+// @Param permission path string true "Permission name"
+// @Success 204 "No content"
+// @Failure 400 {object} middleware.Problem "Unknown role or permission"
+// @Failure 409 {object} middleware.Problem "role.manage cannot be revoked from the admin role"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /admin/roles/{role}/permissions/{permission} [delete]
+func (c *RoleController) RevokePermission(ctx *gin.Context) {
+	if err := c.permissionService.RevokePermission(ctx.Request.Context(), ctx.Param("role"), ctx.Param("permission")); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// ReloadPermissions drops the cached permissions of every role on this instance.
+//
+// @Summary Reload role permissions
+// @Description Reload role permissions from the database, e.g. after they were changed outside the API (administrators only)
+// @Tags admin
+// @Success 204 "No content"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /admin/permissions/reload [post]
+func (c *RoleController) ReloadPermissions(ctx *gin.Context) {
+	if err := c.permissionService.ReloadPermissions(ctx.Request.Context()); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
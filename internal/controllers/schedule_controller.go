@@ -0,0 +1,298 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Okemwag/medihub/internal/models"
+	"github.com/Okemwag/medihub/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// errInvalidDoctorID is reported when the id path parameter is not a valid doctor ID.
+var errInvalidDoctorID = services.NewValidationError("invalid_doctor_id", "Invalid doctor ID")
+
+// ScheduleController handles HTTP requests for doctors' working hours, exceptions, leave and free slots.
+type ScheduleController struct {
+	scheduleService *services.ScheduleService // Service for schedule operations
+}
+
+// NewScheduleController creates a new instance of ScheduleController.
+//
+// @param scheduleService *services.ScheduleService: The schedule service.
+// @return *ScheduleController: A new ScheduleController instance.
+func NewScheduleController(scheduleService *services.ScheduleService) *ScheduleController {
+	return &ScheduleController{scheduleService: scheduleService}
+}
+
+// GetSchedule retrieves a doctor's schedule.
+//
+// @Summary Get a doctor's schedule
+// @Description Retrieve a doctor's slot length, time zone, weekly working hours, and upcoming exceptions and leave
+// @Tags schedules
+// @Produce json
+// @Param id path int true "Doctor user ID"
+// @Success 200 {object} models.DoctorSchedule "The doctor's schedule"
+// @Failure 400 {object} middleware.Problem "Invalid doctor ID"
+// @Failure 404 {object} middleware.Problem "The doctor has no schedule"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /doctors/{id}/schedule [get]
+func (c *ScheduleController) GetSchedule(ctx *gin.Context) {
+	doctorID, ok := doctorIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	// Retrieve the schedule using the service
+	schedule, err := c.scheduleService.GetSchedule(ctx.Request.Context(), doctorID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, schedule)
+}
+
+// SetSchedule creates or replaces a doctor's weekly schedule.
+//
+// @Summary Set a doctor's schedule
+// @Description Create or replace a doctor's slot length, time zone and weekly working hours; exceptions and leave are kept
+// @Tags schedules
+// @Accept json
+// @Produce json
+// @Param id path int true "Doctor user ID"
+// @Param schedule body models.DoctorSchedule true "Slot length, time zone and working hours"
+// @Success 200 {object} models.DoctorSchedule "The updated schedule"
+// @Failure 400 {object} middleware.Problem "Invalid doctor ID, request payload or working hours"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /doctors/{id}/schedule [put]
+func (c *ScheduleController) SetSchedule(ctx *gin.Context) {
+	doctorID, ok := doctorIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	var schedule models.DoctorSchedule
+	if err := ctx.ShouldBindJSON(&schedule); err != nil {
+		ctx.Error(services.InvalidInput(err))
+		return
+	}
+
+	// Retrieve the authenticated principal (set during authentication)
+	principal, ok := currentPrincipal(ctx)
+	if !ok {
+		return
+	}
+
+	// Save the schedule using the service
+	updated, err := c.scheduleService.SetSchedule(ctx.Request.Context(), doctorID, &schedule, principal.UserID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, updated)
+}
+
+// AddScheduleException adds a one-off change to a doctor's availability.
+//
+// @Summary Add a schedule exception
+// @Description Add extra working time on a date (available), or block time or the whole day (unavailable, times omitted)
+// @Tags schedules
+// @Accept json
+// @Produce json
+// @Param id path int true "Doctor user ID"
+// @Param exception body models.ScheduleException true "Exception data"
+// @Success 201 {object} models.ScheduleException "The added exception"
+// @Failure 400 {object} middleware.Problem "Invalid doctor ID or request payload"
+// @Failure 404 {object} middleware.Problem "The doctor has no schedule"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /doctors/{id}/schedule/exceptions [post]
+func (c *ScheduleController) AddScheduleException(ctx *gin.Context) {
+	doctorID, ok := doctorIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	var exception models.ScheduleException
+	if err := ctx.ShouldBindJSON(&exception); err != nil {
+		ctx.Error(services.InvalidInput(err))
+		return
+	}
+
+	// Retrieve the authenticated principal (set during authentication)
+	principal, ok := currentPrincipal(ctx)
+	if !ok {
+		return
+	}
+	exception.CreatedBy = &principal.UserID
+
+	// Add the exception using the service
+	created, err := c.scheduleService.AddException(ctx.Request.Context(), doctorID, &exception)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, created)
+}
+
+// DeleteScheduleException removes a schedule exception.
+//
+// @Summary Delete a schedule exception
+// @Description Remove a one-off change to a doctor's availability
+// @Tags schedules
+// @Param id path int true "Doctor user ID"
+// @Param exceptionId path int true "Exception ID"
+// @Success 204 "Exception deleted"
+// @Failure 400 {object} middleware.Problem "Invalid doctor or exception ID"
+// @Failure 404 {object} middleware.Problem "Exception not found"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /doctors/{id}/schedule/exceptions/{exceptionId} [delete]
+func (c *ScheduleController) DeleteScheduleException(ctx *gin.Context) {
+	doctorID, ok := doctorIDParam(ctx)
+	if !ok {
+		return
+	}
+	id, err := strconv.ParseInt(ctx.Param("exceptionId"), 10, 64)
+	if err != nil {
+		ctx.Error(services.NewValidationError("invalid_exception_id", "Invalid exception ID"))
+		return
+	}
+
+	// Delete the exception using the service
+	if err := c.scheduleService.DeleteException(ctx.Request.Context(), doctorID, id); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// AddLeave records leave for a doctor.
+//
+// @Summary Add leave
+// @Description Record a range of whole days, inclusive, on which the doctor cannot be booked
+// @Tags schedules
+// @Accept json
+// @Produce json
+// @Param id path int true "Doctor user ID"
+// @Param leave body models.DoctorLeave true "Leave data"
+// @Success 201 {object} models.DoctorLeave "The recorded leave"
+// @Failure 400 {object} middleware.Problem "Invalid doctor ID or request payload"
+// @Failure 404 {object} middleware.Problem "The doctor has no schedule"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /doctors/{id}/leave [post]
+func (c *ScheduleController) AddLeave(ctx *gin.Context) {
+	doctorID, ok := doctorIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	var leave models.DoctorLeave
+	if err := ctx.ShouldBindJSON(&leave); err != nil {
+		ctx.Error(services.InvalidInput(err))
+		return
+	}
+
+	// Retrieve the authenticated principal (set during authentication)
+	principal, ok := currentPrincipal(ctx)
+	if !ok {
+		return
+	}
+	leave.CreatedBy = &principal.UserID
+
+	// Record the leave using the service
+	created, err := c.scheduleService.AddLeave(ctx.Request.Context(), doctorID, &leave)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, created)
+}
+
+// DeleteLeave removes a leave entry.
+//
+// @Summary Delete leave
+// @Description Remove a leave entry, making the doctor bookable again on those days
+// @Tags schedules
+// @Param id path int true "Doctor user ID"
+// @Param leaveId path int true "Leave ID"
+// @Success 204 "Leave deleted"
+// @Failure 400 {object} middleware.Problem "Invalid doctor or leave ID"
+// @Failure 404 {object} middleware.Problem "Leave not found"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /doctors/{id}/leave/{leaveId} [delete]
+func (c *ScheduleController) DeleteLeave(ctx *gin.Context) {
+	doctorID, ok := doctorIDParam(ctx)
+	if !ok {
+		return
+	}
+	id, err := strconv.ParseInt(ctx.Param("leaveId"), 10, 64)
+	if err != nil {
+		ctx.Error(services.NewValidationError("invalid_leave_id", "Invalid leave ID"))
+		return
+	}
+
+	// Delete the leave using the service
+	if err := c.scheduleService.DeleteLeave(ctx.Request.Context(), doctorID, id); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// GetFreeSlots lists the slots in which a doctor can be booked.
+//
+// @Summary List a doctor's free slots
+// @Description List bookable slots between two dates, inclusive, in the doctor's time zone; at most 31 days
+// @Tags schedules
+// @Produce json
+// @Param id path int true "Doctor user ID"
+// @Param from query string false "First day (YYYY-MM-DD); defaults to today"
+// @Param to query string false "Last day (YYYY-MM-DD); defaults to from"
+// @Success 200 {array} models.Slot "Free slots in chronological order"
+// @Failure 400 {object} middleware.Problem "Invalid doctor ID or date range"
+// @Failure 404 {object} middleware.Problem "The doctor has no schedule"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /doctors/{id}/slots [get]
+func (c *ScheduleController) GetFreeSlots(ctx *gin.Context) {
+	doctorID, ok := doctorIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	from, err := queryDate(ctx, "from")
+	if err != nil {
+		ctx.Error(services.NewValidationError("invalid_query", err.Error()))
+		return
+	}
+	to := from
+	if ctx.Query("to") != "" {
+		if to, err = queryDate(ctx, "to"); err != nil {
+			ctx.Error(services.NewValidationError("invalid_query", err.Error()))
+			return
+		}
+	}
+
+	// Compute the free slots using the service
+	slots, err := c.scheduleService.FreeSlots(ctx.Request.Context(), doctorID, from, to)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, slots)
+}
+
+// doctorIDParam parses the id path parameter, reporting a validation error when it is invalid.
+func doctorIDParam(ctx *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(errInvalidDoctorID)
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,165 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Okemwag/medihub/internal/models"
+	"github.com/Okemwag/medihub/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// errInvalidBillableServiceID is reported when the id path parameter is not a valid service ID.
+var errInvalidBillableServiceID = services.NewValidationError("invalid_billable_service_id", "Invalid service ID")
+
+// ServiceCatalogueController handles HTTP requests for the priced service catalogue.
+type ServiceCatalogueController struct {
+	catalogueService *services.ServiceCatalogueService // Service for service catalogue operations
+}
+
+// NewServiceCatalogueController creates a new instance of ServiceCatalogueController.
+//
+// @param catalogueService *services.ServiceCatalogueService: The service catalogue service.
+// @return *ServiceCatalogueController: A new ServiceCatalogueController instance.
+func NewServiceCatalogueController(catalogueService *services.ServiceCatalogueService) *ServiceCatalogueController {
+	return &ServiceCatalogueController{catalogueService: catalogueService}
+}
+
+// ListServices retrieves the service catalogue.
+//
+// @Summary List billable services
+// @Description Retrieve the priced services that can be added to invoices, ordered by category and name
+// @Tags billing
+// @Produce json
+// @Param q query string false "Only services whose code or name contains this term"
+// @Param category query string false "Only services in this category"
+// @Param include_inactive query bool false "Include services that can no longer be billed"
+// @Success 200 {array} models.BillableService "The services"
+// @Failure 400 {object} middleware.Problem "Invalid query parameters"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /billable-services [get]
+func (c *ServiceCatalogueController) ListServices(ctx *gin.Context) {
+	includeInactive := false
+	if value := ctx.Query("include_inactive"); value != "" {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			ctx.Error(services.NewValidationError("invalid_query", "invalid include_inactive: must be true or false"))
+			return
+		}
+		includeInactive = b
+	}
+
+	// Retrieve the catalogue using the service
+	list, err := c.catalogueService.ListServices(ctx.Request.Context(), ctx.Query("q"), ctx.Query("category"), includeInactive)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, list)
+}
+
+// GetService retrieves a billable service by ID.
+//
+// @Summary Get a billable service by ID
+// @Description Retrieve a service and its current price
+// @Tags billing
+// @Produce json
+// @Param id path int true "Service ID"
+// @Success 200 {object} models.BillableService "The service"
+// @Failure 400 {object} middleware.Problem "Invalid service ID"
+// @Failure 404 {object} middleware.Problem "Service not found"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /billable-services/{id} [get]
+func (c *ServiceCatalogueController) GetService(ctx *gin.Context) {
+	id, ok := billableServiceIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	// Retrieve the service using the catalogue service
+	svc, err := c.catalogueService.GetService(ctx.Request.Context(), id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, svc)
+}
+
+// CreateService adds a service to the catalogue.
+//
+// @Summary Add a billable service
+// @Description Add a priced service to the catalogue; prices are decimal strings such as "1500.00". New services can be billed immediately
+// @Tags billing
+// @Accept json
+// @Produce json
+// @Param service body models.BillableService true "Service data"
+// @Success 201 {object} models.BillableService "The added service"
+// @Failure 400 {object} middleware.Problem "Invalid request payload or price"
+// @Failure 409 {object} middleware.Problem "A service with this code already exists"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /billable-services [post]
+func (c *ServiceCatalogueController) CreateService(ctx *gin.Context) {
+	var svc models.BillableService
+	if err := ctx.ShouldBindJSON(&svc); err != nil {
+		ctx.Error(services.InvalidInput(err))
+		return
+	}
+
+	// Add the service using the catalogue service
+	created, err := c.catalogueService.CreateService(ctx.Request.Context(), &svc)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, created)
+}
+
+// UpdateService replaces the details of a billable service.
+//
+// @Summary Update a billable service
+// @Description Replace the details and price of a service; set active to false to stop it from being billed. Existing invoice items keep their price
+// @Tags billing
+// @Accept json
+// @Produce json
+// @Param id path int true "Service ID"
+// @Param service body models.BillableService true "Service data"
+// @Success 200 {object} models.BillableService "The updated service"
+// @Failure 400 {object} middleware.Problem "Invalid service ID, request payload or price"
+// @Failure 404 {object} middleware.Problem "Service not found"
+// @Failure 409 {object} middleware.Problem "A service with this code already exists"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /billable-services/{id} [put]
+func (c *ServiceCatalogueController) UpdateService(ctx *gin.Context) {
+	id, ok := billableServiceIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	var svc models.BillableService
+	if err := ctx.ShouldBindJSON(&svc); err != nil {
+		ctx.Error(services.InvalidInput(err))
+		return
+	}
+
+	// Update the service using the catalogue service
+	updated, err := c.catalogueService.UpdateService(ctx.Request.Context(), id, &svc)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, updated)
+}
+
+// billableServiceIDParam parses the id path parameter, reporting a validation error when it is invalid.
+func billableServiceIDParam(ctx *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(errInvalidBillableServiceID)
+		return 0, false
+	}
+	return id, true
+}
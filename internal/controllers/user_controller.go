@@ -0,0 +1,239 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Okemwag/medihub/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// errInvalidUserID is reported when the id path parameter is not a valid user ID.
+var errInvalidUserID = services.NewValidationError("invalid_user_id", "Invalid user ID")
+
+// UserController handles HTTP requests for administering staff user accounts.
+type UserController struct {
+	userService *services.UserService // Service for user management operations
+}
+
+// NewUserController creates a new instance of UserController.
+//
+// @param userService *services.UserService: The user management service.
+// @return *UserController: A new UserController instance.
+func NewUserController(userService *services.UserService) *UserController {
+	return &UserController{userService: userService}
+}
+
+// CreateUser creates a new staff account.
+//
+// @Summary Create a user
+// @Description Create a new staff account with the given role (administrators only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param user body services.CreateUserInput true "User data"
+// @Success 201 {object} models.User "The created user"
+// @Failure 400 {object} middleware.Problem "Invalid request payload, unknown role or weak password"
+// @Failure 409 {object} middleware.Problem "Username already exists"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /admin/users [post]
+func (c *UserController) CreateUser(ctx *gin.Context) {
+	var input services.CreateUserInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.Error(services.InvalidInput(err))
+		return
+	}
+
+	user, err := c.userService.CreateUser(ctx.Request.Context(), input)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, user)
+}
+
+// ListUsers lists staff accounts.
+//
+// @Summary List users
+// @Description List staff accounts, optionally filtered by role and active state (administrators only)
+// @Tags admin
+// @Produce json
+// @Param role query string false "Role name"
+// @Param active query bool false "Active state"
+// @Success 200 {array} models.User "The matching users"
+// @Failure 400 {object} middleware.Problem "Invalid query parameters"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /admin/users [get]
+func (c *UserController) ListUsers(ctx *gin.Context) {
+	var active *bool
+	if value := ctx.Query("active"); value != "" {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			ctx.Error(services.NewValidationError("invalid_query", "invalid active: must be true or false"))
+			return
+		}
+		active = &b
+	}
+
+	users, err := c.userService.ListUsers(ctx.Request.Context(), ctx.Query("role"), active)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, users)
+}
+
+// GetUser retrieves a staff account by ID.
+//
+// @Summary Get a user by ID
+// @Description Retrieve a staff account by its ID (administrators only)
+// @Tags admin
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} models.User "The user"
+// @Failure 400 {object} middleware.Problem "Invalid user ID"
+// @Failure 404 {object} middleware.Problem "User not found"
+// @Router /admin/users/{id} [get]
+func (c *UserController) GetUser(ctx *gin.Context) {
+	id, ok := userIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	user, err := c.userService.GetUser(ctx.Request.Context(), id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, user)
+}
+
+// UpdateUser changes the name and/or role of a staff account.
+//
+// @Summary Update a user
+// @Description Change the name and/or role of a staff account; changing the role ends the user's sessions (administrators only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param user body services.UpdateUserInput true "Fields to change"
+// @Success 200 {object} models.User "The updated user"
+// @Failure 400 {object} middleware.Problem "Invalid user ID, request payload or role"
+// @Failure 404 {object} middleware.Problem "User not found"
+// @Failure 409 {object} middleware.Problem "Own role change or demotion of the last active administrator"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /admin/users/{id} [patch]
+func (c *UserController) UpdateUser(ctx *gin.Context) {
+	id, ok := userIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	var input services.UpdateUserInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.Error(services.InvalidInput(err))
+		return
+	}
+
+	user, err := c.userService.UpdateUser(ctx.Request.Context(), id, input)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, user)
+}
+
+// DeactivateUser disables a staff account and ends all of its sessions.
+//
+// @Summary Deactivate a user
+// @Description Disable a staff account so it can no longer log in (administrators only)
+// @Tags admin
+// @Param id path int true "User ID"
+// @Success 204 "No content"
+// @Failure 400 {object} middleware.Problem "Invalid user ID"
+// @Failure 404 {object} middleware.Problem "User not found"
+// @Failure 409 {object} middleware.Problem "Own account or last active administrator"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /admin/users/{id}/deactivate [post]
+func (c *UserController) DeactivateUser(ctx *gin.Context) {
+	c.setActive(ctx, false)
+}
+
+// ReactivateUser re-enables a deactivated staff account.
+//
+// @Summary Reactivate a user
+// @Description Re-enable a deactivated staff account (administrators only)
+// @Tags admin
+// @Param id path int true "User ID"
+// @Success 204 "No content"
+// @Failure 400 {object} middleware.Problem "Invalid user ID"
+// @Failure 404 {object} middleware.Problem "User not found"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /admin/users/{id}/reactivate [post]
+func (c *UserController) ReactivateUser(ctx *gin.Context) {
+	c.setActive(ctx, true)
+}
+
+// ResetPassword sets a new password for a staff account and ends all of its sessions.
+//
+// @Summary Reset a user's password
+// @Description Set a new password for a staff account (administrators only)
+// @Tags admin
+// @Accept json
+// @Param id path int true "User ID"
+// @Param request body struct{Password string} true "New password"
+// @Success 204 "No content"
+// @Failure 400 {object} middleware.Problem "Invalid user ID, request payload or weak password"
+// @Failure 404 {object} middleware.Problem "User not found"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /admin/users/{id}/reset-password [post]
+func (c *UserController) ResetPassword(ctx *gin.Context) {
+	id, ok := userIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Password string `json:"password" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(services.InvalidInput(err))
+		return
+	}
+
+	if err := c.userService.ResetPassword(ctx.Request.Context(), id, req.Password); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// setActive deactivates or reactivates the user identified by the id path parameter.
+func (c *UserController) setActive(ctx *gin.Context, active bool) {
+	id, ok := userIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.userService.SetActive(ctx.Request.Context(), id, active); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// userIDParam parses the id path parameter, reporting a validation error when it is invalid.
+func userIDParam(ctx *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(errInvalidUserID)
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,110 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Okemwag/medihub/internal/models"
+	"github.com/Okemwag/medihub/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// VitalController handles HTTP requests for recording and charting patients' vital signs.
+type VitalController struct {
+	vitalService *services.VitalService // Service for vital sign operations
+}
+
+// NewVitalController creates a new instance of VitalController.
+//
+// @param vitalService *services.VitalService: The vital sign service.
+// @return *VitalController: A new VitalController instance.
+func NewVitalController(vitalService *services.VitalService) *VitalController {
+	return &VitalController{vitalService: vitalService}
+}
+
+// RecordVitals records a set of vital sign measurements for a patient.
+//
+// @Summary Record vital signs
+// @Description Record measurements taken together, optionally during a draft encounter. Units default to the canonical unit of each type (systolic_bp/diastolic_bp mmHg or kPa, pulse bpm, respiratory_rate breaths/min, temperature C or F, spo2 %, weight kg, g or lb, height cm, m or in). BMI is derived when weight or height is recorded.
+// @Tags vitals
+// @Accept json
+// @Produce json
+// @Param id path int true "Patient ID"
+// @Param recording body models.VitalsRecording true "Measurements"
+// @Success 201 {array} models.VitalSign "The stored observations, in canonical units"
+// @Failure 400 {object} middleware.Problem "Invalid patient ID, request payload, unit or value"
+// @Failure 404 {object} middleware.Problem "Patient not found"
+// @Failure 409 {object} middleware.Problem "The encounter has been signed"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /patients/{id}/vitals [post]
+func (c *VitalController) RecordVitals(ctx *gin.Context) {
+	patientID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(errInvalidPatientID)
+		return
+	}
+
+	var recording models.VitalsRecording
+	if err := ctx.ShouldBindJSON(&recording); err != nil {
+		ctx.Error(services.InvalidInput(err))
+		return
+	}
+
+	// Retrieve the authenticated principal (set during authentication)
+	principal, ok := currentPrincipal(ctx)
+	if !ok {
+		return
+	}
+
+	// Record the measurements using the service
+	signs, err := c.vitalService.RecordVitals(ctx.Request.Context(), patientID, &recording, principal.UserID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, signs)
+}
+
+// ListVitals retrieves a patient's vital signs as time series.
+//
+// @Summary Get vital sign trends
+// @Description Retrieve a patient's vital signs as one time series per type, oldest observation first
+// @Tags vitals
+// @Produce json
+// @Param id path int true "Patient ID"
+// @Param type query string false "Only this type (systolic_bp, diastolic_bp, pulse, respiratory_rate, temperature, spo2, weight, height, bmi)"
+// @Param from query string false "Only observations recorded at or after this time (RFC3339 or YYYY-MM-DD)"
+// @Param to query string false "Only observations recorded before this time (RFC3339 or YYYY-MM-DD)"
+// @Success 200 {array} models.VitalSeries "One series per type"
+// @Failure 400 {object} middleware.Problem "Invalid patient ID or query parameters"
+// @Failure 404 {object} middleware.Problem "Patient not found"
+// @Failure 500 {object} middleware.Problem "Internal server error"
+// @Router /patients/{id}/vitals [get]
+func (c *VitalController) ListVitals(ctx *gin.Context) {
+	patientID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(errInvalidPatientID)
+		return
+	}
+
+	from, err := queryTime(ctx, "from")
+	if err != nil {
+		ctx.Error(services.NewValidationError("invalid_query", err.Error()))
+		return
+	}
+	to, err := queryTime(ctx, "to")
+	if err != nil {
+		ctx.Error(services.NewValidationError("invalid_query", err.Error()))
+		return
+	}
+
+	// Retrieve the series using the service
+	series, err := c.vitalService.ListVitals(ctx.Request.Context(), patientID, ctx.Query("type"), from, to)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, series)
+}
@@ -1,19 +1,31 @@
 package middleware
 
 import (
+	"context"
 	"errors"
+	"log"
 	"net/http"
 	"strings"
+	"time"
 
+	"github.com/Okemwag/medihub/internal/requestctx"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v4"
 )
 
-func AuthMiddleware(secret string) gin.HandlerFunc {
+// RevocationChecker reports whether an access token has been revoked before it expired.
+type RevocationChecker interface {
+	IsRevoked(ctx context.Context, jti string, userID int64, issuedAt time.Time) (bool, error)
+}
+
+// AuthMiddleware authenticates requests by their bearer access token. Tokens that are invalid,
+// expired or revoked are rejected; otherwise the caller's Principal, including the permissions of
+// their role, is stored for PrincipalFrom.
+func AuthMiddleware(secret string, revocations RevocationChecker, resolver PermissionResolver) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing authorization header"})
+			AbortWithProblem(c, http.StatusUnauthorized, "missing_token", "missing authorization header")
 			return
 		}
 
@@ -26,18 +38,53 @@ func AuthMiddleware(secret string) gin.HandlerFunc {
 		})
 
 		if err != nil || !token.Valid {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired token"})
+			AbortWithProblem(c, http.StatusUnauthorized, "invalid_token", "invalid or expired token")
 			return
 		}
 
 		claims, ok := token.Claims.(jwt.MapClaims)
 		if !ok {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token claims"})
+			AbortWithProblem(c, http.StatusUnauthorized, "invalid_token", "invalid token claims")
 			return
 		}
 
-		c.Set("user_id", claims["user_id"])
-		c.Set("role", claims["role"])
+		// Reject tokens that were revoked by logout before they expired
+		jti, _ := claims["jti"].(string)
+		userID, _ := claims["user_id"].(float64)
+		issuedAt, _ := claims["iat"].(float64)
+		expiresAt, _ := claims["exp"].(float64)
+		if jti == "" || userID == 0 {
+			AbortWithProblem(c, http.StatusUnauthorized, "invalid_token", "invalid token claims")
+			return
+		}
+		revoked, err := revocations.IsRevoked(c.Request.Context(), jti, int64(userID), time.Unix(int64(issuedAt), 0))
+		if err != nil {
+			log.Printf("Error checking token revocation: %v", err)
+			AbortWithProblem(c, http.StatusInternalServerError, "internal_error", "failed to verify token")
+			return
+		}
+		if revoked {
+			AbortWithProblem(c, http.StatusUnauthorized, "token_revoked", "token has been revoked")
+			return
+		}
+
+		role, _ := claims["role"].(string)
+		sessionID, _ := claims["sid"].(string)
+		permissions, err := resolver.PermissionsForRole(c.Request.Context(), role)
+		if err != nil {
+			log.Printf("Error resolving permissions for role %s: %v", role, err)
+			AbortWithProblem(c, http.StatusInternalServerError, "internal_error", "failed to resolve permissions")
+			return
+		}
+
+		setPrincipal(c, requestctx.Principal{
+			UserID:      int64(userID),
+			Role:        role,
+			Permissions: permissions,
+			SessionID:   sessionID,
+			TokenID:     jti,
+			ExpiresAt:   time.Unix(int64(expiresAt), 0),
+		})
 		c.Next()
 	}
 }
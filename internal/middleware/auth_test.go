@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const testSecret = "test-secret"
+
+// fakeRevocations reports the token IDs in revoked as revoked.
+type fakeRevocations struct {
+	revoked map[string]bool
+}
+
+func (f fakeRevocations) IsRevoked(_ context.Context, jti string, _ int64, _ time.Time) (bool, error) {
+	return f.revoked[jti], nil
+}
+
+// fakeResolver grants each role the permissions listed for it.
+type fakeResolver map[string][]string
+
+func (f fakeResolver) PermissionsForRole(_ context.Context, role string) ([]string, error) {
+	return f[role], nil
+}
+
+// signToken signs an access token carrying the given claims with testSecret.
+func signToken(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testSecret))
+	if err != nil {
+		t.Fatalf("signing token: %v", err)
+	}
+	return token
+}
+
+// accessClaims returns the claims of a valid access token for the given user and jti.
+func accessClaims(userID int64, jti string) jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"user_id": userID,
+		"role":    "doctor",
+		"sid":     "session-1",
+		"jti":     jti,
+		"iat":     now.Unix(),
+		"exp":     now.Add(time.Minute).Unix(),
+	}
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	revocations := fakeRevocations{revoked: map[string]bool{"revoked-jti": true}}
+	resolver := fakeResolver{"doctor": {"patient.read"}}
+
+	tests := []struct {
+		name       string
+		header     string
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "valid token", header: "Bearer " + signToken(t, accessClaims(5, "valid-jti")), wantStatus: http.StatusOK},
+		{name: "revoked token", header: "Bearer " + signToken(t, accessClaims(5, "revoked-jti")), wantStatus: http.StatusUnauthorized, wantCode: "token_revoked"},
+		{name: "zero user_id", header: "Bearer " + signToken(t, accessClaims(0, "valid-jti")), wantStatus: http.StatusUnauthorized, wantCode: "invalid_token"},
+		{name: "missing header", header: "", wantStatus: http.StatusUnauthorized, wantCode: "missing_token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/", AuthMiddleware(testSecret, revocations, resolver), func(c *gin.Context) {
+				principal, ok := PrincipalFrom(c)
+				if !ok {
+					t.Error("handler reached without a principal")
+					return
+				}
+				if principal.UserID != 5 || principal.Role != "doctor" || principal.SessionID != "session-1" || principal.TokenID != "valid-jti" {
+					t.Errorf("principal = %+v, want user 5, role doctor, session session-1, token valid-jti", principal)
+				}
+				if !principal.HasPermission("patient.read") {
+					t.Error("principal is missing the permissions of its role")
+				}
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body %s)", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantCode != "" {
+				assertProblemCode(t, w, tt.wantCode)
+			}
+		})
+	}
+}
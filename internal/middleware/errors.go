@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/Okemwag/medihub/internal/requestctx"
+	"github.com/Okemwag/medihub/internal/services"
+	"github.com/Okemwag/medihub/internal/validation"
+	"github.com/gin-gonic/gin"
+)
+
+// ProblemContentType is the media type of RFC 7807 problem details.
+const ProblemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details document, extended with a stable error code, the
+// request ID and, for validation errors, the fields that failed.
+type Problem struct {
+	Type      string                  `json:"type"`
+	Title     string                  `json:"title"`
+	Status    int                     `json:"status"`
+	Detail    string                  `json:"detail,omitempty"`
+	Instance  string                  `json:"instance,omitempty"`
+	Code      string                  `json:"code"`
+	RequestID string                  `json:"request_id,omitempty"`
+	Errors    []validation.FieldError `json:"errors,omitempty"`
+}
+
+// errorStatus maps domain error kinds to HTTP status codes.
+var errorStatus = map[services.ErrorKind]int{
+	services.KindValidation:         http.StatusBadRequest,
+	services.KindNotFound:           http.StatusNotFound,
+	services.KindConflict:           http.StatusConflict,
+	services.KindPreconditionFailed: http.StatusPreconditionFailed,
+	services.KindUnauthorized:       http.StatusUnauthorized,
+	services.KindForbidden:          http.StatusForbidden,
+}
+
+// ErrorHandler renders the last error attached to the context with c.Error as problem+json once
+// the handlers have run. Domain errors (*services.Error) are reported with their status and code;
+// any other error is logged and reported as a generic internal error so that database and other
+// internal messages never reach the client.
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		last := c.Errors.Last()
+		if last == nil || c.Writer.Written() {
+			return
+		}
+		err := last.Err
+
+		var domainErr *services.Error
+		status, known := 0, false
+		if errors.As(err, &domainErr) {
+			status, known = errorStatus[domainErr.Kind]
+		}
+		if !known {
+			log.Printf("Error handling %s %s: %v", c.Request.Method, c.FullPath(), err)
+			AbortWithProblem(c, http.StatusInternalServerError, "internal_error", "an unexpected error occurred")
+			return
+		}
+
+		problem := newProblem(c, status, domainErr.Code, err.Error())
+		problem.Errors = domainErr.Fields
+		writeProblem(c, problem)
+	}
+}
+
+// AbortWithProblem stops the handler chain and responds with a problem+json document. It is used
+// for failures that are not domain errors, such as missing credentials or request headers.
+//
+// @param c *gin.Context: The request context.
+// @param status int: The HTTP status code.
+// @param code string: The stable error code.
+// @param detail string: A human-readable explanation of the problem.
+func AbortWithProblem(c *gin.Context, status int, code, detail string) {
+	writeProblem(c, newProblem(c, status, code, detail))
+}
+
+// newProblem builds a problem document for the current request.
+func newProblem(c *gin.Context, status int, code, detail string) Problem {
+	problem := Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   detail,
+		Instance: c.Request.URL.Path,
+		Code:     code,
+	}
+	if metadata, ok := requestctx.MetadataFrom(c.Request.Context()); ok {
+		problem.RequestID = metadata.RequestID
+	}
+	return problem
+}
+
+// writeProblem aborts the request and writes the problem with the problem+json content type.
+func writeProblem(c *gin.Context, problem Problem) {
+	c.Header("Content-Type", ProblemContentType)
+	c.AbortWithStatusJSON(problem.Status, problem)
+}
@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PermissionResolver resolves the permissions a role has been granted.
+type PermissionResolver interface {
+	PermissionsForRole(ctx context.Context, role string) ([]string, error)
+}
+
+// RequirePermission allows the request through only if the caller's role holds the named permission.
+// The permissions are those resolved into the principal by AuthMiddleware.
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Extract the principal from context (set during authentication)
+		principal, ok := PrincipalFrom(c)
+		if !ok {
+			AbortWithProblem(c, http.StatusUnauthorized, "unauthenticated", "Unauthorized: Role not found")
+			return
+		}
+
+		if !principal.HasPermission(permission) {
+			AbortWithProblem(c, http.StatusForbidden, "forbidden", "Forbidden: Access denied")
+			return
+		}
+
+		c.Next()
+	}
+}
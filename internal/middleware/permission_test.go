@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Okemwag/medihub/internal/requestctx"
+	"github.com/gin-gonic/gin"
+)
+
+// newGuardedRouter returns an engine serving GET / through guard, authenticating as principal
+// unless it is nil.
+func newGuardedRouter(principal *requestctx.Principal, guard gin.HandlerFunc) *gin.Engine {
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if principal != nil {
+			setPrincipal(c, *principal)
+		}
+	})
+	router.GET("/", guard, func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return router
+}
+
+// assertProblemCode checks that the response is a problem document with the given code.
+func assertProblemCode(t *testing.T, w *httptest.ResponseRecorder, code string) {
+	t.Helper()
+	if ct := w.Header().Get("Content-Type"); ct != ProblemContentType {
+		t.Errorf("Content-Type = %q, want %q", ct, ProblemContentType)
+	}
+	var problem Problem
+	if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("decoding problem: %v", err)
+	}
+	if problem.Code != code {
+		t.Errorf("problem code = %q, want %q", problem.Code, code)
+	}
+}
+
+func TestRequirePermission(t *testing.T) {
+	tests := []struct {
+		name       string
+		principal  *requestctx.Principal
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "granted", principal: &requestctx.Principal{UserID: 1, Role: "doctor", Permissions: []string{"patient.read"}}, wantStatus: http.StatusOK},
+		{name: "not granted", principal: &requestctx.Principal{UserID: 1, Role: "cashier", Permissions: []string{"billing.read"}}, wantStatus: http.StatusForbidden, wantCode: "forbidden"},
+		{name: "unauthenticated", principal: nil, wantStatus: http.StatusUnauthorized, wantCode: "unauthenticated"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			newGuardedRouter(tt.principal, RequirePermission("patient.read")).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantCode != "" {
+				assertProblemCode(t, w, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestRoleMiddleware(t *testing.T) {
+	tests := []struct {
+		name       string
+		principal  *requestctx.Principal
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "allowed role", principal: &requestctx.Principal{UserID: 1, Role: "admin"}, wantStatus: http.StatusOK},
+		{name: "allowed role in another case", principal: &requestctx.Principal{UserID: 1, Role: "Doctor"}, wantStatus: http.StatusOK},
+		{name: "other role", principal: &requestctx.Principal{UserID: 1, Role: "cashier"}, wantStatus: http.StatusForbidden, wantCode: "forbidden"},
+		{name: "unauthenticated", principal: nil, wantStatus: http.StatusUnauthorized, wantCode: "unauthenticated"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			newGuardedRouter(tt.principal, RoleMiddleware("admin", "doctor")).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantCode != "" {
+				assertProblemCode(t, w, tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"github.com/Okemwag/medihub/internal/requestctx"
+	"github.com/gin-gonic/gin"
+)
+
+// principalKey is the gin context key under which AuthMiddleware stores the principal.
+const principalKey = "principal"
+
+// setPrincipal stores the principal in both the gin context and the request context, so that it
+// is available to handlers and to the services they call.
+func setPrincipal(c *gin.Context, principal requestctx.Principal) {
+	c.Set(principalKey, principal)
+	c.Request = c.Request.WithContext(requestctx.WithPrincipal(c.Request.Context(), principal))
+}
+
+// PrincipalFrom returns the authenticated principal stored by AuthMiddleware, if any.
+//
+// @param c *gin.Context: The request context.
+// @return requestctx.Principal: The authenticated principal.
+// @return bool: False if the request has not been authenticated.
+func PrincipalFrom(c *gin.Context) (requestctx.Principal, bool) {
+	if value, exists := c.Get(principalKey); exists {
+		principal, ok := value.(requestctx.Principal)
+		return principal, ok
+	}
+	return requestctx.PrincipalFrom(c.Request.Context())
+}
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Okemwag/medihub/internal/requestctx"
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// newTestContext returns a gin context for a GET request to /.
+func newTestContext() *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/", nil)
+	return c
+}
+
+func TestSetPrincipal(t *testing.T) {
+	c := newTestContext()
+	setPrincipal(c, requestctx.Principal{UserID: 42, Role: "nurse"})
+
+	got, ok := PrincipalFrom(c)
+	if !ok || got.UserID != 42 || got.Role != "nurse" {
+		t.Fatalf("PrincipalFrom() = %+v, %v; want user 42 with role nurse", got, ok)
+	}
+
+	// Services only see the request context, so the principal must be there too
+	fromRequest, ok := requestctx.PrincipalFrom(c.Request.Context())
+	if !ok || fromRequest.UserID != 42 {
+		t.Fatalf("requestctx.PrincipalFrom() = %+v, %v; want user 42", fromRequest, ok)
+	}
+}
+
+func TestPrincipalFrom(t *testing.T) {
+	t.Run("absent", func(t *testing.T) {
+		if _, ok := PrincipalFrom(newTestContext()); ok {
+			t.Fatal("PrincipalFrom() ok = true for an unauthenticated request")
+		}
+	})
+
+	t.Run("request context only", func(t *testing.T) {
+		c := newTestContext()
+		c.Request = c.Request.WithContext(requestctx.WithPrincipal(c.Request.Context(), requestctx.Principal{UserID: 3}))
+		got, ok := PrincipalFrom(c)
+		if !ok || got.UserID != 3 {
+			t.Fatalf("PrincipalFrom() = %+v, %v; want user 3", got, ok)
+		}
+	})
+
+	t.Run("wrong type", func(t *testing.T) {
+		c := newTestContext()
+		c.Set(principalKey, "admin")
+		if _, ok := PrincipalFrom(c); ok {
+			t.Fatal("PrincipalFrom() ok = true for a non-Principal value")
+		}
+	})
+}
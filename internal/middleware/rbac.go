@@ -1,26 +1,24 @@
 package middleware
 
 import (
-	"github.com/gin-gonic/gin"
+	"net/http"
 	"strings"
+
+	"github.com/gin-gonic/gin"
 )
 
 // RoleMiddleware enforces role-based access control for Gin-Gonic
 func RoleMiddleware(allowedRoles ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Extract user role from context (set during authentication)
-		role, exists := c.Get("role")
-		if !exists {
-			c.AbortWithStatusJSON(401, gin.H{"error": "Unauthorized: Role not found"})
+		// Extract the principal from context (set during authentication)
+		principal, ok := PrincipalFrom(c)
+		if !ok {
+			AbortWithProblem(c, http.StatusUnauthorized, "unauthenticated", "Unauthorized: Role not found")
 			return
 		}
 
 		// Check if role is allowed
-		roleStr, ok := role.(string)
-		if !ok {
-			c.AbortWithStatusJSON(401, gin.H{"error": "Unauthorized: Invalid role type"})
-			return
-		}
+		roleStr := principal.Role
 
 		for _, allowedRole := range allowedRoles {
 			if strings.EqualFold(roleStr, allowedRole) {
@@ -30,6 +28,6 @@ func RoleMiddleware(allowedRoles ...string) gin.HandlerFunc {
 		}
 
 		// Role not allowed
-		c.AbortWithStatusJSON(403, gin.H{"error": "Forbidden: Access denied"})
+		AbortWithProblem(c, http.StatusForbidden, "forbidden", "Forbidden: Access denied")
 	}
 }
@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// Allergy statuses. Only active allergies are shown in a patient's summary and checked against
+// new orders.
+const (
+	AllergyActive         = "active"
+	AllergyInactive       = "inactive"
+	AllergyResolved       = "resolved"
+	AllergyEnteredInError = "entered_in_error"
+)
+
+// Allergy is a known allergy or adverse reaction of a patient to a substance.
+type Allergy struct {
+	ID           int64     `json:"id"`
+	PatientID    int64     `json:"patient_id"`
+	Substance    string    `json:"substance" binding:"required,max=200"`
+	Category     string    `json:"category" binding:"omitempty,oneof=drug food environmental other"`
+	Reaction     string    `json:"reaction" binding:"max=500"`
+	Severity     string    `json:"severity" binding:"required,oneof=mild moderate severe life_threatening"`
+	Status       string    `json:"status" binding:"omitempty,oneof=active inactive resolved entered_in_error"`
+	Notes        string    `json:"notes" binding:"max=2000"`
+	RecordedBy   int64     `json:"recorded_by"`
+	RecorderName string    `json:"recorder_name,omitempty"`
+	UpdatedBy    int64     `json:"updated_by"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// AllergySummary is the short form of an active allergy shown with a patient's record.
+type AllergySummary struct {
+	ID        int64  `json:"id"`
+	Substance string `json:"substance"`
+	Category  string `json:"category"`
+	Reaction  string `json:"reaction,omitempty"`
+	Severity  string `json:"severity"`
+}
+
+// PatientRecord is a patient's demographics together with the clinical summaries shown alongside them.
+type PatientRecord struct {
+	Patient
+	Allergies []AllergySummary `json:"allergies"` // Active allergies, most severe first; nil unless the caller holds allergy.read
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// Appointment statuses.
+const (
+	AppointmentScheduled = "scheduled"
+	AppointmentCheckedIn = "checked_in"
+	AppointmentCompleted = "completed"
+	AppointmentCancelled = "cancelled"
+	AppointmentNoShow    = "no_show"
+)
+
+// Appointment is a booking of a patient with a doctor for a period of time.
+type Appointment struct {
+	ID                 int64      `json:"id"`
+	PatientID          int64      `json:"patient_id" binding:"required"`
+	PatientName        string     `json:"patient_name,omitempty"`
+	DoctorID           int64      `json:"doctor_id" binding:"required"`
+	DoctorName         string     `json:"doctor_name,omitempty"`
+	StartsAt           time.Time  `json:"starts_at" binding:"required"`
+	EndsAt             time.Time  `json:"ends_at" binding:"required"`
+	Status             string     `json:"status"`
+	Reason             string     `json:"reason" binding:"max=500"`
+	CancellationReason string     `json:"cancellation_reason,omitempty"`
+	CancelledAt        *time.Time `json:"cancelled_at,omitempty"`
+	CreatedBy          int64      `json:"created_by"`
+	UpdatedBy          int64      `json:"updated_by"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+}
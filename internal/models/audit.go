@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+type AuditEntry struct {
+	ID          int64                  `json:"id"`
+	OccurredAt  time.Time              `json:"occurred_at"`
+	ActorUserID *int64                 `json:"actor_user_id,omitempty"`
+	ActorRole   string                 `json:"actor_role,omitempty"`
+	Action      string                 `json:"action"`
+	EntityType  string                 `json:"entity_type"`
+	EntityID    *int64                 `json:"entity_id,omitempty"`
+	PatientID   *int64                 `json:"patient_id,omitempty"` // Patient the entry concerns, if any; defaults to EntityID for patient entries
+	Changes     map[string]FieldChange `json:"changes,omitempty"`
+	Details     map[string]interface{} `json:"details,omitempty"`
+	RequestID   string                 `json:"request_id,omitempty"`
+	ClientIP    string                 `json:"client_ip,omitempty"`
+}
+
+// FieldChange records the value of a field before and after a change.
+type FieldChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
@@ -0,0 +1,100 @@
+package models
+
+import "time"
+
+// Invoice statuses. A draft invoice can still be edited; once issued it can be paid, partially or
+// in full. Invoices without payments can be voided.
+const (
+	InvoiceDraft         = "draft"
+	InvoiceIssued        = "issued"
+	InvoicePartiallyPaid = "partially_paid"
+	InvoicePaid          = "paid"
+	InvoiceVoid          = "void"
+)
+
+// Payment methods.
+const (
+	PaymentCash        = "cash"
+	PaymentCard        = "card"
+	PaymentMobileMoney = "mobile_money"
+)
+
+// BillableService is a priced entry in the service catalogue, e.g. a consultation or a procedure.
+type BillableService struct {
+	ID        int64     `json:"id"`
+	Code      string    `json:"code" binding:"required,max=20"` // Local service code, e.g. "CONS-GP"
+	Name      string    `json:"name" binding:"required,max=200"`
+	Category  string    `json:"category" binding:"max=50"` // e.g. "consultation", "laboratory", "pharmacy"
+	Price     Money     `json:"price"`
+	Active    bool      `json:"active"` // Inactive services cannot be added to invoices
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Invoice is a bill for a patient made up of line items. Its total, amount paid and balance are
+// maintained by the billing service as items and payments are recorded.
+type Invoice struct {
+	ID            int64         `json:"id"`
+	InvoiceNumber string        `json:"invoice_number"` // e.g. "INV-000123"
+	PatientID     int64         `json:"patient_id"`
+	PatientName   string        `json:"patient_name,omitempty"`
+	EncounterID   *int64        `json:"encounter_id,omitempty"` // The visit being billed, if any
+	Status        string        `json:"status"`
+	Notes         string        `json:"notes" binding:"max=2000"`
+	Items         []InvoiceItem `json:"items" binding:"required,min=1,max=100,dive"`
+	Total         Money         `json:"total"`
+	AmountPaid    Money         `json:"amount_paid"`
+	Balance       Money         `json:"balance"`
+	Payments      []Payment     `json:"payments,omitempty" binding:"-"`
+	CreatedBy     int64         `json:"created_by"`
+	CreatedByName string        `json:"created_by_name,omitempty"`
+	IssuedAt      *time.Time    `json:"issued_at,omitempty"`
+	IssuedBy      *int64        `json:"issued_by,omitempty"`
+	VoidedAt      *time.Time    `json:"voided_at,omitempty"`
+	VoidedBy      *int64        `json:"voided_by,omitempty"`
+	VoidReason    string        `json:"void_reason,omitempty"`
+	CreatedAt     time.Time     `json:"created_at"`
+	UpdatedAt     time.Time     `json:"updated_at"`
+}
+
+// InvoiceItem is a line of an invoice. Items for catalogue services copy the service's name and
+// price when they are added; other items must give their own description and unit price.
+type InvoiceItem struct {
+	ID          int64  `json:"id"`
+	ServiceID   *int64 `json:"service_id,omitempty"`
+	ServiceCode string `json:"service_code,omitempty"`
+	Description string `json:"description" binding:"max=200"`
+	Quantity    int    `json:"quantity" binding:"required,min=1,max=1000"`
+	UnitPrice   *Money `json:"unit_price,omitempty"`
+	Amount      Money  `json:"amount"` // Quantity times unit price
+}
+
+// Payment is money received against an invoice. Every payment gets its own receipt number.
+type Payment struct {
+	ID             int64     `json:"id"`
+	InvoiceID      int64     `json:"invoice_id"`
+	ReceiptNumber  string    `json:"receipt_number"` // e.g. "RCT-000456"
+	Amount         Money     `json:"amount"`
+	Method         string    `json:"method" binding:"required,oneof=cash card mobile_money"`
+	Reference      string    `json:"reference" binding:"max=100"` // Card approval code or mobile money transaction code; required for mobile money
+	ReceivedBy     int64     `json:"received_by"`
+	ReceivedByName string    `json:"received_by_name,omitempty"`
+	ReceivedAt     time.Time `json:"received_at"`
+}
+
+// Receipt acknowledges a payment, with the state of the invoice right after it was received.
+type Receipt struct {
+	ReceiptNumber  string    `json:"receipt_number"`
+	InvoiceID      int64     `json:"invoice_id"`
+	InvoiceNumber  string    `json:"invoice_number"`
+	PatientID      int64     `json:"patient_id"`
+	PatientName    string    `json:"patient_name"`
+	Amount         Money     `json:"amount"`
+	Method         string    `json:"method"`
+	Reference      string    `json:"reference,omitempty"`
+	ReceivedAt     time.Time `json:"received_at"`
+	ReceivedByName string    `json:"received_by_name"`
+	InvoiceTotal   Money     `json:"invoice_total"`
+	PaidToDate     Money     `json:"paid_to_date"` // Including this payment
+	Balance        Money     `json:"balance"`      // Left to pay after this payment
+}
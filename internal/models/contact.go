@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// AgeOfMajority is the age from which a patient no longer needs a guardian.
+const AgeOfMajority = 18
+
+// PatientContact is a person related to a patient: their next of kin, someone to call in an
+// emergency or, for minors, a guardian. One person may fill several of these roles.
+type PatientContact struct {
+	ID                    int64     `json:"id"`
+	PatientID             int64     `json:"patient_id"`
+	Name                  string    `json:"name" binding:"required,max=200"`
+	Relationship          string    `json:"relationship" binding:"required,oneof=spouse partner parent child sibling relative guardian friend other"`
+	ContactNumber         string    `json:"contact_number" binding:"required,e164"`
+	AlternateNumber       string    `json:"alternate_number" binding:"omitempty,e164"`
+	Email                 string    `json:"email" binding:"omitempty,email,max=255"`
+	Address               string    `json:"address" binding:"max=500"`
+	NextOfKin             bool      `json:"next_of_kin"`              // A patient has at most one next of kin
+	EmergencyContact      bool      `json:"emergency_contact"`        // Called in an emergency
+	Guardian              bool      `json:"guardian"`                 // Only for patients under the age of majority
+	MayReceiveInformation bool      `json:"may_receive_information"`  // The patient consents to their health information being shared with this person
+	MayConsentToTreatment bool      `json:"may_consent_to_treatment"` // This person may consent to treatment on the patient's behalf
+	Notes                 string    `json:"notes" binding:"max=2000"`
+	CreatedBy             int64     `json:"created_by"`
+	UpdatedBy             int64     `json:"updated_by"`
+	CreatedAt             time.Time `json:"created_at"`
+	UpdatedAt             time.Time `json:"updated_at"`
+}
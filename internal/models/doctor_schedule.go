@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// DoctorSchedule describes when a doctor can be booked: their recurring weekly working hours,
+// one-off exceptions, leave, and the length of an appointment slot.
+type DoctorSchedule struct {
+	DoctorID     int64               `json:"doctor_id"`
+	SlotMinutes  int                 `json:"slot_minutes" binding:"required,min=5,max=480"`
+	Timezone     string              `json:"timezone" binding:"required,timezone"`
+	WorkingHours []WorkingHours      `json:"working_hours" binding:"dive"`
+	Exceptions   []ScheduleException `json:"exceptions,omitempty" binding:"-"`
+	Leave        []DoctorLeave       `json:"leave,omitempty" binding:"-"`
+	UpdatedBy    *int64              `json:"updated_by,omitempty"`
+	UpdatedAt    time.Time           `json:"updated_at"`
+}
+
+// WorkingHours is a recurring block of working time on a day of the week, in the schedule's time zone.
+type WorkingHours struct {
+	Weekday   int    `json:"weekday" binding:"min=0,max=6"` // 0 is Sunday
+	StartTime string `json:"start_time" binding:"required,clock"`
+	EndTime   string `json:"end_time" binding:"required,clock"`
+}
+
+// ScheduleException changes a doctor's availability on a single date. Available exceptions add
+// working time; unavailable ones block it, or the whole day when no times are given.
+type ScheduleException struct {
+	ID        int64     `json:"id"`
+	DoctorID  int64     `json:"doctor_id"`
+	Date      string    `json:"date" binding:"required,datetime=2006-01-02"`
+	StartTime *string   `json:"start_time,omitempty" binding:"omitempty,clock"`
+	EndTime   *string   `json:"end_time,omitempty" binding:"omitempty,clock"`
+	Available bool      `json:"available"`
+	Reason    string    `json:"reason" binding:"max=500"`
+	CreatedBy *int64    `json:"created_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// DoctorLeave is a range of whole days on which a doctor cannot be booked.
+type DoctorLeave struct {
+	ID        int64     `json:"id"`
+	DoctorID  int64     `json:"doctor_id"`
+	StartsOn  string    `json:"starts_on" binding:"required,datetime=2006-01-02"`
+	EndsOn    string    `json:"ends_on" binding:"required,datetime=2006-01-02"`
+	Reason    string    `json:"reason" binding:"max=500"`
+	CreatedBy *int64    `json:"created_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Slot is a free period in which an appointment can be booked.
+type Slot struct {
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+}
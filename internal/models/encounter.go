@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// Encounter statuses. Draft notes can be edited by the attending doctor; signed notes are
+// immutable and can only be amended with addenda.
+const (
+	EncounterDraft  = "draft"
+	EncounterSigned = "signed"
+)
+
+// Encounter is a clinical visit of a patient with a doctor, documented as SOAP notes. Vital signs
+// are recorded separately and linked to the encounter.
+type Encounter struct {
+	ID             int64               `json:"id"`
+	PatientID      int64               `json:"patient_id"`
+	DoctorID       int64               `json:"doctor_id"` // Attending doctor
+	DoctorName     string              `json:"doctor_name,omitempty"`
+	AppointmentID  *int64              `json:"appointment_id,omitempty"`
+	VisitDate      time.Time           `json:"visit_date" binding:"required"`
+	ChiefComplaint string              `json:"chief_complaint" binding:"max=1000"`
+	Subjective     string              `json:"subjective" binding:"max=20000"`
+	Objective      string              `json:"objective" binding:"max=20000"`
+	Assessment     string              `json:"assessment" binding:"max=20000"`
+	Plan           string              `json:"plan" binding:"max=20000"`
+	Diagnoses      []Diagnosis         `json:"diagnoses" binding:"max=50,dive"`
+	Vitals         []VitalSign         `json:"vitals,omitempty" binding:"-"` // Vital signs recorded during the encounter
+	Status         string              `json:"status"`
+	SignedAt       *time.Time          `json:"signed_at,omitempty"`
+	SignedBy       *int64              `json:"signed_by,omitempty"`
+	Addenda        []EncounterAddendum `json:"addenda,omitempty" binding:"-"`
+	CreatedBy      int64               `json:"created_by"`
+	UpdatedBy      int64               `json:"updated_by"`
+	CreatedAt      time.Time           `json:"created_at"`
+	UpdatedAt      time.Time           `json:"updated_at"`
+}
+
+// Diagnosis is a condition identified during an encounter.
+type Diagnosis struct {
+	Code        string `json:"code,omitempty" binding:"max=16"` // Optional diagnosis code, e.g. ICD-10
+	Description string `json:"description" binding:"required,max=500"`
+	Primary     bool   `json:"primary"`
+}
+
+// EncounterAddendum is a note appended to a signed encounter.
+type EncounterAddendum struct {
+	ID          int64     `json:"id"`
+	EncounterID int64     `json:"encounter_id"`
+	AuthorID    int64     `json:"author_id"`
+	AuthorName  string    `json:"author_name,omitempty"`
+	Content     string    `json:"content" binding:"required,max=20000"`
+	CreatedAt   time.Time `json:"created_at"`
+}
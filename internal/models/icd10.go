@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// Problem statuses. Resolved problems carry the date they resolved; problems recorded by mistake
+// are kept as entered_in_error.
+const (
+	ProblemActive         = "active"
+	ProblemInactive       = "inactive"
+	ProblemResolved       = "resolved"
+	ProblemEnteredInError = "entered_in_error"
+)
+
+// ICD10Code is an entry in the ICD-10 diagnosis code catalogue.
+type ICD10Code struct {
+	Code        string    `json:"code"` // e.g. "E11.9"
+	Description string    `json:"description"`
+	Active      bool      `json:"active"` // Codes missing from the latest import are kept but inactive
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Problem is an entry on a patient's problem list, coded with ICD-10.
+type Problem struct {
+	ID              int64     `json:"id"`
+	PatientID       int64     `json:"patient_id"`
+	Code            string    `json:"code" binding:"required,max=8"`
+	CodeDescription string    `json:"code_description,omitempty"`
+	Notes           string    `json:"notes" binding:"max=2000"`
+	Status          string    `json:"status" binding:"omitempty,oneof=active inactive resolved"` // Defaults to active
+	OnsetDate       string    `json:"onset_date" binding:"omitempty,datetime=2006-01-02"`        // When the problem started, if known
+	ResolvedDate    string    `json:"resolved_date" binding:"omitempty,datetime=2006-01-02"`     // Defaults to today when a problem is resolved
+	RecordedBy      int64     `json:"recorded_by"`
+	RecorderName    string    `json:"recorder_name,omitempty"`
+	UpdatedBy       int64     `json:"updated_by"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
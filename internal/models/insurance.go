@@ -0,0 +1,80 @@
+package models
+
+import "time"
+
+// Policy holder relationships. Dependants are covered under a principal member's policy.
+const (
+	PolicyPrincipal = "principal"
+	PolicyDependant = "dependant"
+)
+
+// Co-pay types. A fixed co-pay is an amount the patient pays per invoice; a percentage co-pay is
+// a share of each invoice.
+const (
+	CopayNone       = "none"
+	CopayFixed      = "fixed"
+	CopayPercentage = "percentage"
+)
+
+// Payer is an insurer or public scheme that covers patients, e.g. SHA or a private insurer.
+type Payer struct {
+	ID        int64     `json:"id"`
+	Code      string    `json:"code" binding:"required,max=20"` // e.g. "SHA"
+	Name      string    `json:"name" binding:"required,max=200"`
+	PayerType string    `json:"payer_type" binding:"required,oneof=public private"`
+	Phone     string    `json:"phone" binding:"max=30"`
+	Email     string    `json:"email" binding:"omitempty,email,max=200"`
+	Active    bool      `json:"active"` // Policies with inactive payers are not eligible
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// InsurancePolicy is a patient's cover with a payer, with the co-pay rules that decide how an
+// invoice is split between the patient and the payer.
+type InsurancePolicy struct {
+	ID            int64     `json:"id"`
+	PatientID     int64     `json:"patient_id"`
+	PayerID       int64     `json:"payer_id" binding:"required"`
+	PayerName     string    `json:"payer_name,omitempty"`
+	MemberNumber  string    `json:"member_number" binding:"required,max=50"`
+	Scheme        string    `json:"scheme" binding:"max=100"`                                   // e.g. "SHA Primary Care", "Corporate Gold"
+	Relationship  string    `json:"relationship" binding:"omitempty,oneof=principal dependant"` // Defaults to principal
+	PrincipalName string    `json:"principal_name" binding:"max=200"`                           // Required for dependants
+	ValidFrom     string    `json:"valid_from" binding:"required,datetime=2006-01-02"`          // First day of cover
+	ValidUntil    string    `json:"valid_until" binding:"omitempty,datetime=2006-01-02"`        // Last day of cover; open-ended when empty
+	CopayType     string    `json:"copay_type" binding:"omitempty,oneof=none fixed percentage"` // Defaults to none
+	CopayAmount   Money     `json:"copay_amount"`                                               // Fixed co-pay per invoice
+	CopayPercent  int       `json:"copay_percent" binding:"min=0,max=100"`                      // Percentage co-pay per invoice
+	CoverLimit    *Money    `json:"cover_limit,omitempty"`                                      // Most the payer covers per invoice; unlimited when empty
+	Active        bool      `json:"active"`                                                     // Set to false to suspend cover, e.g. for unpaid contributions
+	Notes         string    `json:"notes" binding:"max=2000"`
+	CreatedBy     int64     `json:"created_by"`
+	UpdatedBy     int64     `json:"updated_by"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// PolicyEligibility reports whether a policy covers a patient on a date and, when an amount is
+// given, how the amount is split between the patient and the payer.
+type PolicyEligibility struct {
+	PolicyID     int64    `json:"policy_id"`
+	PayerID      int64    `json:"payer_id"`
+	PayerName    string   `json:"payer_name"`
+	MemberNumber string   `json:"member_number"`
+	Scheme       string   `json:"scheme,omitempty"`
+	Eligible     bool     `json:"eligible"`
+	Reasons      []string `json:"reasons,omitempty"`       // Why the policy is not eligible
+	PatientShare *Money   `json:"patient_share,omitempty"` // Co-pay and anything above the cover limit
+	PayerShare   *Money   `json:"payer_share,omitempty"`
+}
+
+// EligibilityCheck is the result of checking a patient's cover on a date, e.g. at a visit or
+// when invoicing.
+type EligibilityCheck struct {
+	PatientID int64               `json:"patient_id"`
+	InvoiceID *int64              `json:"invoice_id,omitempty"` // The invoice checked, if any
+	Date      string              `json:"date"`
+	Amount    *Money              `json:"amount,omitempty"`
+	Eligible  bool                `json:"eligible"` // Whether any policy covers the patient
+	Policies  []PolicyEligibility `json:"policies"`
+}
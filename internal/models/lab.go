@@ -0,0 +1,101 @@
+package models
+
+import "time"
+
+// Lab order statuses. An order moves from ordered to collected, resulted and verified; it can be
+// cancelled until results are entered.
+const (
+	LabOrderOrdered   = "ordered"
+	LabOrderCollected = "collected"
+	LabOrderResulted  = "resulted"
+	LabOrderVerified  = "verified"
+	LabOrderCancelled = "cancelled"
+)
+
+// Lab result flags. Numeric results are flagged against the reference and critical ranges of
+// their analyte; text results are flagged abnormal when they differ from the expected text.
+const (
+	LabFlagNormal       = "normal"
+	LabFlagLow          = "low"
+	LabFlagHigh         = "high"
+	LabFlagCriticalLow  = "critical_low"
+	LabFlagCriticalHigh = "critical_high"
+	LabFlagAbnormal     = "abnormal"
+)
+
+// LabTest is an investigation in the lab test catalogue, made up of one or more analytes.
+type LabTest struct {
+	ID        int64        `json:"id"`
+	Code      string       `json:"code" binding:"required,max=20"` // Local test code, e.g. "CBC"
+	Name      string       `json:"name" binding:"required,max=200"`
+	Specimen  string       `json:"specimen" binding:"max=50"` // e.g. "blood", "urine"
+	Active    bool         `json:"active"`                    // Inactive tests cannot be ordered
+	Analytes  []LabAnalyte `json:"analytes" binding:"required,min=1,max=50,dive"`
+	CreatedAt time.Time    `json:"created_at"`
+	UpdatedAt time.Time    `json:"updated_at"`
+}
+
+// LabAnalyte is a single measured component of a lab test with its reference range. Numeric
+// analytes use the low and high limits; qualitative analytes use the expected text.
+type LabAnalyte struct {
+	Code          string   `json:"code" binding:"required,max=20"` // e.g. "HGB"
+	Name          string   `json:"name" binding:"required,max=200"`
+	Unit          string   `json:"unit" binding:"max=20"`
+	ReferenceLow  *float64 `json:"reference_low,omitempty"`
+	ReferenceHigh *float64 `json:"reference_high,omitempty"`
+	CriticalLow   *float64 `json:"critical_low,omitempty"`
+	CriticalHigh  *float64 `json:"critical_high,omitempty"`
+	ReferenceText string   `json:"reference_text,omitempty" binding:"max=100"` // Expected qualitative result, e.g. "negative"
+}
+
+// LabOrder is an investigation ordered for a patient, with its results once they are entered.
+type LabOrder struct {
+	ID                 int64       `json:"id"`
+	PatientID          int64       `json:"patient_id"`
+	EncounterID        *int64      `json:"encounter_id,omitempty"`
+	TestID             int64       `json:"test_id" binding:"required"`
+	TestCode           string      `json:"test_code,omitempty"`
+	TestName           string      `json:"test_name,omitempty"`
+	Priority           string      `json:"priority" binding:"omitempty,oneof=routine urgent stat"` // Defaults to routine
+	Status             string      `json:"status"`
+	ClinicalNotes      string      `json:"clinical_notes" binding:"max=2000"`
+	OrderedBy          int64       `json:"ordered_by"`
+	OrderedByName      string      `json:"ordered_by_name,omitempty"`
+	CollectedAt        *time.Time  `json:"collected_at,omitempty"`
+	CollectedBy        *int64      `json:"collected_by,omitempty"`
+	ResultedAt         *time.Time  `json:"resulted_at,omitempty"`
+	ResultedBy         *int64      `json:"resulted_by,omitempty"`
+	VerifiedAt         *time.Time  `json:"verified_at,omitempty"`
+	VerifiedBy         *int64      `json:"verified_by,omitempty"`
+	CancelledAt        *time.Time  `json:"cancelled_at,omitempty"`
+	CancelledBy        *int64      `json:"cancelled_by,omitempty"`
+	CancellationReason string      `json:"cancellation_reason,omitempty"`
+	Abnormal           bool        `json:"abnormal"` // Whether any result is flagged outside its normal range
+	Results            []LabResult `json:"results,omitempty" binding:"-"`
+	CreatedAt          time.Time   `json:"created_at"`
+	UpdatedAt          time.Time   `json:"updated_at"`
+}
+
+// LabResult is the result of one analyte of a lab order. The analyte's name, unit and reference
+// range are copied from the catalogue when the result is entered.
+type LabResult struct {
+	ID            int64    `json:"id"`
+	AnalyteCode   string   `json:"analyte_code"`
+	AnalyteName   string   `json:"analyte_name"`
+	Value         *float64 `json:"value,omitempty"`
+	ValueText     string   `json:"value_text,omitempty"`
+	Unit          string   `json:"unit,omitempty"`
+	ReferenceLow  *float64 `json:"reference_low,omitempty"`
+	ReferenceHigh *float64 `json:"reference_high,omitempty"`
+	ReferenceText string   `json:"reference_text,omitempty"`
+	Flag          string   `json:"flag,omitempty"`
+	Comment       string   `json:"comment,omitempty"`
+}
+
+// LabResultEntry is the result of one analyte as entered by the laboratory.
+type LabResultEntry struct {
+	AnalyteCode string   `json:"analyte_code" binding:"required,max=20"`
+	Value       *float64 `json:"value"`
+	ValueText   string   `json:"value_text" binding:"max=200"`
+	Comment     string   `json:"comment" binding:"max=500"`
+}
@@ -0,0 +1,112 @@
+package models
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Money is an amount in the clinic's currency held as a whole number of cents, so that totals
+// and balances add up exactly. It is written to JSON as a decimal string, e.g. "1500.00", and
+// read from a JSON string or number with at most two decimal places. In the database it maps to
+// NUMERIC(12,2).
+type Money int64
+
+// MaxMoney is the largest amount the database can store.
+const MaxMoney Money = 999999999999
+
+// errInvalidMoney is returned for amounts that are not decimal numbers with at most two decimal places.
+var errInvalidMoney = errors.New("amount must be a decimal number with at most two decimal places")
+
+// ParseMoney parses a decimal amount such as "1500", "1500.5" or "-20.75" without going through
+// floating point.
+func ParseMoney(s string) (Money, error) {
+	s = strings.TrimSpace(s)
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+
+	whole, fraction, hasPoint := strings.Cut(s, ".")
+	if whole == "" || (hasPoint && fraction == "") || len(fraction) > 2 || len(whole) > 15 {
+		return 0, errInvalidMoney
+	}
+	for _, part := range []string{whole, fraction} {
+		if strings.Trim(part, "0123456789") != "" {
+			return 0, errInvalidMoney
+		}
+	}
+	fraction += strings.Repeat("0", 2-len(fraction))
+
+	cents, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil {
+		return 0, errInvalidMoney
+	}
+	if negative {
+		cents = -cents
+	}
+	return Money(cents), nil
+}
+
+// String formats the amount with two decimal places, e.g. "1500.00".
+func (m Money) String() string {
+	sign := ""
+	cents := int64(m)
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+}
+
+// MarshalJSON writes the amount as a decimal string.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + m.String() + `"`), nil
+}
+
+// UnmarshalJSON reads the amount from a decimal string or number.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+	parsed, err := ParseMoney(s)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Scan reads the amount from a NUMERIC column.
+func (m *Money) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return m.scanString(string(v))
+	case string:
+		return m.scanString(v)
+	case int64:
+		*m = Money(v * 100)
+		return nil
+	default:
+		return fmt.Errorf("cannot scan %T into Money", src)
+	}
+}
+
+// scanString parses a NUMERIC value as returned by the database.
+func (m *Money) scanString(s string) error {
+	parsed, err := ParseMoney(s)
+	if err != nil {
+		return fmt.Errorf("scanning money %q: %w", s, err)
+	}
+	*m = parsed
+	return nil
+}
+
+// Value writes the amount as a decimal string for a NUMERIC column.
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
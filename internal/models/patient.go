@@ -3,17 +3,20 @@ package models
 import "time"
 
 type Patient struct {
-	ID             int64     `json:"id"`
-	FirstName      string    `json:"first_name"`
-	LastName       string    `json:"last_name"`
-	DateOfBirth    time.Time `json:"date_of_birth"`
-	Gender         string    `json:"gender"`
-	ContactNumber  string    `json:"contact_number"`
-	Email          string    `json:"email"`
-	Address        string    `json:"address"`
-	MedicalHistory string    `json:"medical_history"`
-	CreatedBy      int64     `json:"created_by"`
-	UpdatedBy      int64     `json:"updated_by"`
-	CreatedAt      time.Time `json:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at"`
+	ID             int64      `json:"id"`
+	FirstName      string     `json:"first_name" binding:"required,max=100"`
+	LastName       string     `json:"last_name" binding:"required,max=100"`
+	DateOfBirth    time.Time  `json:"date_of_birth" binding:"required,dob"`
+	Gender         string     `json:"gender" binding:"omitempty,gender"`
+	ContactNumber  string     `json:"contact_number" binding:"omitempty,e164"`
+	Email          string     `json:"email" binding:"omitempty,email,max=255"`
+	Address        string     `json:"address"`
+	MedicalHistory string     `json:"medical_history"`
+	CreatedBy      int64      `json:"created_by"`
+	UpdatedBy      int64      `json:"updated_by"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	DeletedAt      *time.Time `json:"deleted_at,omitempty"`
+	DeletedBy      *int64     `json:"deleted_by,omitempty"`
+	Version        int        `json:"version"`
 }
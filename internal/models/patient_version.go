@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// PatientVersion is a snapshot of a patient record as it was after a single change.
+type PatientVersion struct {
+	PatientID int64                  `json:"patient_id"`
+	Version   int                    `json:"version"`
+	Operation string                 `json:"operation"`
+	ChangedBy *int64                 `json:"changed_by"`
+	ChangedAt time.Time              `json:"changed_at"`
+	Changes   map[string]FieldChange `json:"changes,omitempty"`
+	Snapshot  *Patient               `json:"snapshot,omitempty"`
+}
@@ -0,0 +1,78 @@
+package models
+
+import "time"
+
+// Prescription statuses. An active prescription is current until its end date.
+const (
+	PrescriptionActive       = "active"
+	PrescriptionDiscontinued = "discontinued"
+)
+
+// Prescription alert types and severities. Blocking alerts prevent a prescription from being
+// written; warnings must be acknowledged with an override reason.
+const (
+	AlertAllergy          = "allergy"
+	AlertDuplicateTherapy = "duplicate_therapy"
+	AlertInteraction      = "interaction"
+
+	AlertWarning = "warning"
+	AlertBlock   = "block"
+)
+
+// Drug is an entry in the local drug catalogue.
+type Drug struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name" binding:"required,max=200"`         // Brand or product name
+	GenericName string    `json:"generic_name" binding:"required,max=200"` // Active ingredient, used for duplicate and interaction checks
+	DrugClass   string    `json:"drug_class" binding:"max=100"`            // Therapeutic class, e.g. "penicillins"
+	Form        string    `json:"form" binding:"max=50"`                   // e.g. "tablet"
+	Strength    string    `json:"strength" binding:"max=50"`               // e.g. "500 mg"
+	Active      bool      `json:"active"`                                  // Inactive drugs cannot be prescribed
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// DrugInteraction is a known interaction between two substances, each a generic name or a drug class.
+type DrugInteraction struct {
+	ID          int64     `json:"id"`
+	SubstanceA  string    `json:"substance_a" binding:"required,max=200"`
+	SubstanceB  string    `json:"substance_b" binding:"required,max=200"`
+	Severity    string    `json:"severity" binding:"required,oneof=minor moderate major contraindicated"`
+	Description string    `json:"description" binding:"max=1000"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Prescription is a medication prescribed to a patient.
+type Prescription struct {
+	ID                 int64               `json:"id"`
+	PatientID          int64               `json:"patient_id"`
+	EncounterID        *int64              `json:"encounter_id,omitempty"`
+	DrugID             int64               `json:"drug_id" binding:"required"`
+	DrugName           string              `json:"drug_name,omitempty"`
+	GenericName        string              `json:"generic_name,omitempty"`
+	Dose               string              `json:"dose" binding:"required,max=100"` // e.g. "500 mg"
+	Route              string              `json:"route" binding:"required,oneof=oral sublingual iv im sc topical inhaled nasal ophthalmic otic rectal vaginal other"`
+	Frequency          string              `json:"frequency" binding:"required,max=100"` // e.g. "every 8 hours"
+	DurationDays       int                 `json:"duration_days" binding:"required,min=1,max=365"`
+	Instructions       string              `json:"instructions" binding:"max=1000"`
+	StartsOn           string              `json:"starts_on" binding:"omitempty,datetime=2006-01-02"` // Defaults to today
+	EndsOn             string              `json:"ends_on"`
+	Status             string              `json:"status"`
+	OverrideReason     string              `json:"override_reason,omitempty" binding:"max=500"` // Why the prescriber proceeded despite warnings
+	Alerts             []PrescriptionAlert `json:"alerts"`                                      // Warnings acknowledged when prescribing
+	PrescriberID       int64               `json:"prescriber_id"`
+	PrescriberName     string              `json:"prescriber_name,omitempty"`
+	DiscontinuedAt     *time.Time          `json:"discontinued_at,omitempty"`
+	DiscontinuedBy     *int64              `json:"discontinued_by,omitempty"`
+	DiscontinuedReason string              `json:"discontinued_reason,omitempty"`
+	CreatedAt          time.Time           `json:"created_at"`
+	UpdatedAt          time.Time           `json:"updated_at"`
+}
+
+// PrescriptionAlert is a safety problem found when checking a prescription.
+type PrescriptionAlert struct {
+	Type      string `json:"type"`     // allergy, duplicate_therapy or interaction
+	Severity  string `json:"severity"` // warning or block
+	Message   string `json:"message"`
+	RelatedID *int64 `json:"related_id,omitempty"` // The allergy or prescription that caused the alert
+}
@@ -3,10 +3,14 @@ package models
 import "time"
 
 type User struct {
-	ID           int64     `json:"id"`
-	Username     string    `json:"username"`
-	PasswordHash string    `json:"-"`
-	RoleID       int64     `json:"role_id"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID            int64      `json:"id"`
+	Username      string     `json:"username"`
+	Name          string     `json:"name"`
+	PasswordHash  string     `json:"-"`
+	RoleID        int64      `json:"role_id"`
+	Role          string     `json:"role"`
+	Active        bool       `json:"active"`
+	DeactivatedAt *time.Time `json:"deactivated_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
 }
@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+// Vital sign types. Blood pressure is recorded as separate systolic and diastolic measurements.
+// VitalBMI is derived from weight and height and cannot be recorded directly.
+const (
+	VitalSystolicBP      = "systolic_bp"
+	VitalDiastolicBP     = "diastolic_bp"
+	VitalPulse           = "pulse"
+	VitalRespiratoryRate = "respiratory_rate"
+	VitalTemperature     = "temperature"
+	VitalSpO2            = "spo2"
+	VitalWeight          = "weight"
+	VitalHeight          = "height"
+	VitalBMI             = "bmi"
+)
+
+// VitalSign is a single observation of a patient's vital sign, stored in the canonical unit of
+// its type.
+type VitalSign struct {
+	ID           int64     `json:"id"`
+	PatientID    int64     `json:"patient_id"`
+	EncounterID  *int64    `json:"encounter_id,omitempty"`
+	Type         string    `json:"type"`
+	Value        float64   `json:"value"`
+	Unit         string    `json:"unit"`
+	RecordedAt   time.Time `json:"recorded_at"`
+	RecordedBy   int64     `json:"recorded_by"`
+	RecorderName string    `json:"recorder_name,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// VitalsRecording is a set of measurements taken together, optionally during an encounter.
+type VitalsRecording struct {
+	EncounterID  *int64        `json:"encounter_id,omitempty"`
+	RecordedAt   *time.Time    `json:"recorded_at,omitempty"` // Defaults to now
+	Measurements []Measurement `json:"measurements" binding:"required,min=1,max=20,dive"`
+}
+
+// Measurement is one value in a VitalsRecording. The unit defaults to the canonical unit of the type.
+type Measurement struct {
+	Type  string  `json:"type" binding:"required,oneof=systolic_bp diastolic_bp pulse respiratory_rate temperature spo2 weight height"`
+	Value float64 `json:"value" binding:"required"`
+	Unit  string  `json:"unit" binding:"max=16"`
+}
+
+// VitalSeries is the history of one vital sign type, oldest observation first.
+type VitalSeries struct {
+	Type   string       `json:"type"`
+	Unit   string       `json:"unit"`
+	Points []VitalPoint `json:"points"`
+}
+
+// VitalPoint is one observation in a VitalSeries.
+type VitalPoint struct {
+	ID          int64     `json:"id"`
+	Value       float64   `json:"value"`
+	RecordedAt  time.Time `json:"recorded_at"`
+	EncounterID *int64    `json:"encounter_id,omitempty"`
+}
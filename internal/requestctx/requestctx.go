@@ -0,0 +1,64 @@
+// Package requestctx carries per-request information such as the authenticated principal through
+// context.Context so that services can attribute their work without depending on gin.
+package requestctx
+
+import (
+	"context"
+	"time"
+)
+
+type contextKey int
+
+const (
+	principalKey contextKey = iota
+	metadataKey
+)
+
+// Principal is the authenticated user a request is made on behalf of, as established from the
+// access token presented with the request.
+type Principal struct {
+	UserID      int64     // ID of the authenticated user
+	Role        string    // Name of the user's role
+	Permissions []string  // Permissions granted to the role
+	SessionID   string    // Refresh token family the access token belongs to
+	TokenID     string    // Unique ID (jti) of the access token
+	ExpiresAt   time.Time // When the access token expires
+}
+
+// HasPermission reports whether the principal's role has been granted the named permission.
+func (p Principal) HasPermission(permission string) bool {
+	for _, granted := range p.Permissions {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// WithPrincipal returns a copy of ctx carrying the given principal.
+func WithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey, principal)
+}
+
+// PrincipalFrom returns the principal stored in ctx, if any.
+func PrincipalFrom(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalKey).(Principal)
+	return principal, ok
+}
+
+// Metadata describes where a request came from.
+type Metadata struct {
+	RequestID string
+	ClientIP  string
+}
+
+// WithMetadata returns a copy of ctx carrying the given request metadata.
+func WithMetadata(ctx context.Context, metadata Metadata) context.Context {
+	return context.WithValue(ctx, metadataKey, metadata)
+}
+
+// MetadataFrom returns the request metadata stored in ctx, if any.
+func MetadataFrom(ctx context.Context) (Metadata, bool) {
+	metadata, ok := ctx.Value(metadataKey).(Metadata)
+	return metadata, ok
+}
@@ -0,0 +1,45 @@
+package requestctx
+
+import (
+	"context"
+	"testing"
+)
+
+func TestPrincipalFrom(t *testing.T) {
+	principal := Principal{UserID: 7, Role: "doctor", Permissions: []string{"patient.read"}}
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		wantOK bool
+	}{
+		{name: "present", ctx: WithPrincipal(context.Background(), principal), wantOK: true},
+		{name: "absent", ctx: context.Background(), wantOK: false},
+		{name: "wrong type", ctx: context.WithValue(context.Background(), principalKey, "doctor"), wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := PrincipalFrom(tt.ctx)
+			if ok != tt.wantOK {
+				t.Fatalf("PrincipalFrom() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && got.UserID != principal.UserID {
+				t.Errorf("PrincipalFrom() UserID = %d, want %d", got.UserID, principal.UserID)
+			}
+		})
+	}
+}
+
+func TestPrincipalHasPermission(t *testing.T) {
+	principal := Principal{Permissions: []string{"patient.read", "patient.update"}}
+
+	if !principal.HasPermission("patient.update") {
+		t.Error("HasPermission(patient.update) = false, want true")
+	}
+	if principal.HasPermission("patient.delete") {
+		t.Error("HasPermission(patient.delete) = true, want false")
+	}
+	if (Principal{}).HasPermission("patient.read") {
+		t.Error("HasPermission on a principal without permissions = true, want false")
+	}
+}
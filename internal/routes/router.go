@@ -6,44 +6,363 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// Dependencies holds the controllers and middleware collaborators that routes are wired to.
+type Dependencies struct {
+	AuthController             *controllers.AuthController             // Authentication-related endpoints
+	PatientController          *controllers.PatientController          // Patient-related endpoints
+	UserController             *controllers.UserController             // Staff user administration endpoints
+	RoleController             *controllers.RoleController             // Role permission administration endpoints
+	AuditController            *controllers.AuditController            // Audit trail query endpoints
+	AppointmentController      *controllers.AppointmentController      // Appointment scheduling endpoints
+	ScheduleController         *controllers.ScheduleController         // Doctor schedule and availability endpoints
+	EncounterController        *controllers.EncounterController        // Clinical encounter and visit note endpoints
+	VitalController            *controllers.VitalController            // Vital sign recording and trend endpoints
+	AllergyController          *controllers.AllergyController          // Patient allergy registry endpoints
+	DrugController             *controllers.DrugController             // Drug catalogue and interaction table endpoints
+	PrescriptionController     *controllers.PrescriptionController     // Prescription endpoints
+	LabTestController          *controllers.LabTestController          // Lab test catalogue endpoints
+	LabOrderController         *controllers.LabOrderController         // Lab order and result endpoints
+	ICD10Controller            *controllers.ICD10Controller            // ICD-10 code search endpoints
+	ProblemController          *controllers.ProblemController          // Patient problem list endpoints
+	ServiceCatalogueController *controllers.ServiceCatalogueController // Priced service catalogue endpoints
+	InvoiceController          *controllers.InvoiceController          // Invoice, payment and receipt endpoints
+	PayerController            *controllers.PayerController            // Insurer and public scheme endpoints
+	InsurancePolicyController  *controllers.InsurancePolicyController  // Patient insurance policy and eligibility endpoints
+	ContactController          *controllers.ContactController          // Patient next of kin, emergency contact and guardian endpoints
+	JWTSecret                  string                                  // Secret key used for signing and validating JWT tokens
+	Revocations                middleware.RevocationChecker            // Store consulted to reject revoked tokens
+	Permissions                middleware.PermissionResolver           // Resolves the permissions of the authenticated principal
+}
+
 // RegisterRoutes sets up all the API routes for the application.
 //
 // This function defines the public and protected routes, including authentication and patient management endpoints.
-// Protected routes require a valid JWT token, and some routes enforce role-based access control.
+// Protected routes require a valid JWT token, and some routes enforce permission-based access control
+// driven by the role_permissions table.
 //
 // @param router *gin.Engine: The Gin router instance.
-// @param authController *controllers.AuthController: The controller for authentication-related endpoints.
-// @param patientController *controllers.PatientController: The controller for patient-related endpoints.
-// @param jwtSecret string: The secret key used for signing and validating JWT tokens.
-func RegisterRoutes(router *gin.Engine, authController *controllers.AuthController, patientController *controllers.PatientController, jwtSecret string) {
+// @param deps Dependencies: The controllers and middleware collaborators to wire the routes to.
+func RegisterRoutes(router *gin.Engine, deps Dependencies) {
+	authController := deps.AuthController
+	patientController := deps.PatientController
+	userController := deps.UserController
+	roleController := deps.RoleController
+	auditController := deps.AuditController
+	appointmentController := deps.AppointmentController
+	scheduleController := deps.ScheduleController
+	encounterController := deps.EncounterController
+	vitalController := deps.VitalController
+	allergyController := deps.AllergyController
+	drugController := deps.DrugController
+	prescriptionController := deps.PrescriptionController
+	labTestController := deps.LabTestController
+	labOrderController := deps.LabOrderController
+	icd10Controller := deps.ICD10Controller
+	problemController := deps.ProblemController
+	serviceCatalogueController := deps.ServiceCatalogueController
+	invoiceController := deps.InvoiceController
+	payerController := deps.PayerController
+	insurancePolicyController := deps.InsurancePolicyController
+	contactController := deps.ContactController
+
 	// Public Routes
 	router.POST("/login", authController.Login)
+	router.POST("/auth/refresh", authController.Refresh)
 
 	// Protected Routes
 	protected := router.Group("/")
-	protected.Use(middleware.AuthMiddleware(jwtSecret))
+	protected.Use(middleware.AuthMiddleware(deps.JWTSecret, deps.Revocations, deps.Permissions))
 	{
 		// Auth routes
 		authGroup := protected.Group("/auth")
 		{
 			// Logout endpoint
 			authGroup.POST("/logout", authController.Logout)
+
+			// Logout of every session of the current user
+			authGroup.POST("/logout-all", authController.LogoutAll)
 		}
 
+		// Admin routes
+		adminGroup := protected.Group("/admin")
+		{
+			// Staff user administration
+			adminGroup.POST("/users", middleware.RequirePermission("user.create"), userController.CreateUser)
+			adminGroup.GET("/users", middleware.RequirePermission("user.read"), userController.ListUsers)
+			adminGroup.GET("/users/:id", middleware.RequirePermission("user.read"), userController.GetUser)
+			adminGroup.PATCH("/users/:id", middleware.RequirePermission("user.update"), userController.UpdateUser)
+			adminGroup.POST("/users/:id/deactivate", middleware.RequirePermission("user.deactivate"), userController.DeactivateUser)
+			adminGroup.POST("/users/:id/reactivate", middleware.RequirePermission("user.deactivate"), userController.ReactivateUser)
+			adminGroup.POST("/users/:id/reset-password", middleware.RequirePermission("user.reset_password"), userController.ResetPassword)
+
+			// Permanently remove patients deleted longer ago than the retention period
+			adminGroup.POST("/patients/purge", middleware.RequirePermission("patient.purge"), patientController.PurgeDeletedPatients)
+
+			// Revoke all sessions of a user
+			adminGroup.POST("/users/:id/revoke-sessions", middleware.RequirePermission("session.revoke"), authController.RevokeUserSessions)
+
+			// Role permission administration
+			adminGroup.GET("/roles/:role/permissions", middleware.RequirePermission("role.manage"), roleController.ListRolePermissions)
+			adminGroup.PUT("/roles/:role/permissions/:permission", middleware.RequirePermission("role.manage"), roleController.GrantPermission)
+			adminGroup.DELETE("/roles/:role/permissions/:permission", middleware.RequirePermission("role.manage"), roleController.RevokePermission)
+			adminGroup.POST("/permissions/reload", middleware.RequirePermission("role.manage"), roleController.ReloadPermissions)
+		}
+
+		// Audit trail query (accessible to holders of audit.read)
+		protected.GET("/audit", middleware.RequirePermission("audit.read"), auditController.ListAuditEntries)
+
 		// Patient routes
 		patientGroup := protected.Group("/patients")
 		{
-			// Create a new patient (only accessible to receptionists)
-			patientGroup.POST("", middleware.RoleMiddleware("receptionist"), patientController.CreatePatient)
+			// Create a new patient
+			patientGroup.POST("", middleware.RequirePermission("patient.create"), patientController.CreatePatient)
+
+			// Update an existing patient
+			patientGroup.PUT("/:id", middleware.RequirePermission("patient.update"), patientController.UpdatePatient)
+
+			// Partially update an existing patient
+			patientGroup.PATCH("/:id", middleware.RequirePermission("patient.update"), patientController.PatchPatient)
+
+			// Delete a patient (soft delete)
+			patientGroup.DELETE("/:id", middleware.RequirePermission("patient.delete"), patientController.DeletePatient)
+
+			// Restore a deleted patient
+			patientGroup.POST("/:id/restore", middleware.RequirePermission("patient.restore"), patientController.RestorePatient)
+
+			// List patients with filters and pagination
+			patientGroup.GET("", middleware.RequirePermission("patient.read"), patientController.ListPatients)
+
+			// Search patients by name, phone or email
+			patientGroup.GET("/search", middleware.RequirePermission("patient.read"), patientController.SearchPatients)
+
+			// Get a patient by ID
+			patientGroup.GET("/:id", middleware.RequirePermission("patient.read"), patientController.GetPatient)
+
+			// Version history of a patient record
+			patientGroup.GET("/:id/history", middleware.RequirePermission("patient.read"), patientController.GetPatientHistory)
+			patientGroup.GET("/:id/history/:version", middleware.RequirePermission("patient.read"), patientController.GetPatientVersion)
+
+			// Clinical encounters of a patient
+			patientGroup.POST("/:id/encounters", middleware.RequirePermission("encounter.write"), encounterController.CreateEncounter)
+			patientGroup.GET("/:id/encounters", middleware.RequirePermission("encounter.read"), encounterController.ListPatientEncounters)
+
+			// Vital signs of a patient
+			patientGroup.POST("/:id/vitals", middleware.RequirePermission("vitals.record"), vitalController.RecordVitals)
+			patientGroup.GET("/:id/vitals", middleware.RequirePermission("vitals.read"), vitalController.ListVitals)
+
+			// Allergies and adverse reactions of a patient
+			patientGroup.POST("/:id/allergies", middleware.RequirePermission("allergy.write"), allergyController.CreateAllergy)
+			patientGroup.GET("/:id/allergies", middleware.RequirePermission("allergy.read"), allergyController.ListAllergies)
+			patientGroup.GET("/:id/allergies/:allergyId", middleware.RequirePermission("allergy.read"), allergyController.GetAllergy)
+			patientGroup.PUT("/:id/allergies/:allergyId", middleware.RequirePermission("allergy.write"), allergyController.UpdateAllergy)
+			patientGroup.DELETE("/:id/allergies/:allergyId", middleware.RequirePermission("allergy.write"), allergyController.DeleteAllergy)
+
+			// Prescriptions of a patient, checked against allergies, current medications and interactions
+			patientGroup.POST("/:id/prescriptions", middleware.RequirePermission("prescription.write"), prescriptionController.CreatePrescription)
+			patientGroup.POST("/:id/prescriptions/check", middleware.RequirePermission("prescription.write"), prescriptionController.CheckPrescription)
+			patientGroup.GET("/:id/prescriptions", middleware.RequirePermission("prescription.read"), prescriptionController.ListPatientPrescriptions)
+
+			// Lab orders and results of a patient
+			patientGroup.POST("/:id/lab-orders", middleware.RequirePermission("lab.order"), labOrderController.CreateLabOrder)
+			patientGroup.GET("/:id/lab-orders", middleware.RequirePermission("lab.read"), labOrderController.ListPatientLabOrders)
+
+			// Coded problem list of a patient
+			patientGroup.POST("/:id/problems", middleware.RequirePermission("problem.write"), problemController.CreateProblem)
+			patientGroup.GET("/:id/problems", middleware.RequirePermission("problem.read"), problemController.ListProblems)
+			patientGroup.GET("/:id/problems/:problemId", middleware.RequirePermission("problem.read"), problemController.GetProblem)
+			patientGroup.PUT("/:id/problems/:problemId", middleware.RequirePermission("problem.write"), problemController.UpdateProblem)
+			patientGroup.DELETE("/:id/problems/:problemId", middleware.RequirePermission("problem.write"), problemController.DeleteProblem)
+
+			// Invoices of a patient
+			patientGroup.POST("/:id/invoices", middleware.RequirePermission("billing.invoice"), invoiceController.CreateInvoice)
+			patientGroup.GET("/:id/invoices", middleware.RequirePermission("billing.read"), invoiceController.ListPatientInvoices)
+
+			// Insurance policies of a patient
+			patientGroup.POST("/:id/policies", middleware.RequirePermission("insurance.write"), insurancePolicyController.CreatePolicy)
+			patientGroup.GET("/:id/policies", middleware.RequirePermission("insurance.read"), insurancePolicyController.ListPolicies)
+			patientGroup.GET("/:id/policies/:policyId", middleware.RequirePermission("insurance.read"), insurancePolicyController.GetPolicy)
+			patientGroup.PUT("/:id/policies/:policyId", middleware.RequirePermission("insurance.write"), insurancePolicyController.UpdatePolicy)
+			patientGroup.DELETE("/:id/policies/:policyId", middleware.RequirePermission("insurance.write"), insurancePolicyController.DeletePolicy)
+
+			// Check a patient's cover, e.g. at registration for a visit
+			patientGroup.GET("/:id/eligibility", middleware.RequirePermission("insurance.read"), insurancePolicyController.CheckEligibility)
+
+			// Next of kin, emergency contacts and guardians of a patient
+			patientGroup.POST("/:id/contacts", middleware.RequirePermission("contact.write"), contactController.CreateContact)
+			patientGroup.GET("/:id/contacts", middleware.RequirePermission("contact.read"), contactController.ListContacts)
+			patientGroup.GET("/:id/contacts/:contactId", middleware.RequirePermission("contact.read"), contactController.GetContact)
+			patientGroup.PUT("/:id/contacts/:contactId", middleware.RequirePermission("contact.write"), contactController.UpdateContact)
+			patientGroup.DELETE("/:id/contacts/:contactId", middleware.RequirePermission("contact.write"), contactController.DeleteContact)
+		}
+
+		// Appointment routes
+		appointmentGroup := protected.Group("/appointments")
+		{
+			// Book an appointment
+			appointmentGroup.POST("", middleware.RequirePermission("appointment.create"), appointmentController.CreateAppointment)
+
+			// List appointments with filters and pagination
+			appointmentGroup.GET("", middleware.RequirePermission("appointment.read"), appointmentController.ListAppointments)
+
+			// Get an appointment by ID
+			appointmentGroup.GET("/:id", middleware.RequirePermission("appointment.read"), appointmentController.GetAppointment)
+
+			// Move an appointment to a new time
+			appointmentGroup.POST("/:id/reschedule", middleware.RequirePermission("appointment.update"), appointmentController.RescheduleAppointment)
+
+			// Record check-in, completion or a no-show
+			appointmentGroup.POST("/:id/status", middleware.RequirePermission("appointment.update"), appointmentController.UpdateAppointmentStatus)
+
+			// Cancel an appointment
+			appointmentGroup.POST("/:id/cancel", middleware.RequirePermission("appointment.cancel"), appointmentController.CancelAppointment)
+		}
+
+		// Encounter routes
+		encounterGroup := protected.Group("/encounters")
+		{
+			// Get an encounter with its addenda
+			encounterGroup.GET("/:id", middleware.RequirePermission("encounter.read"), encounterController.GetEncounter)
+
+			// Edit a draft encounter
+			encounterGroup.PUT("/:id", middleware.RequirePermission("encounter.write"), encounterController.UpdateEncounter)
+
+			// Sign an encounter, making it immutable
+			encounterGroup.POST("/:id/sign", middleware.RequirePermission("encounter.sign"), encounterController.SignEncounter)
+
+			// Amend a signed encounter
+			encounterGroup.POST("/:id/addenda", middleware.RequirePermission("encounter.write"), encounterController.AddEncounterAddendum)
+		}
+
+		// Prescription routes
+		prescriptionGroup := protected.Group("/prescriptions")
+		{
+			// Get a prescription by ID
+			prescriptionGroup.GET("/:id", middleware.RequirePermission("prescription.read"), prescriptionController.GetPrescription)
+
+			// Stop an active prescription
+			prescriptionGroup.POST("/:id/discontinue", middleware.RequirePermission("prescription.write"), prescriptionController.DiscontinuePrescription)
+		}
+
+		// Drug catalogue routes
+		drugGroup := protected.Group("/drugs")
+		{
+			// Search the catalogue
+			drugGroup.GET("", middleware.RequirePermission("drug.read"), drugController.SearchDrugs)
+
+			// Get a drug by ID
+			drugGroup.GET("/:id", middleware.RequirePermission("drug.read"), drugController.GetDrug)
+
+			// Maintain the catalogue
+			drugGroup.POST("", middleware.RequirePermission("drug.manage"), drugController.CreateDrug)
+			drugGroup.PUT("/:id", middleware.RequirePermission("drug.manage"), drugController.UpdateDrug)
+		}
+
+		// Drug interaction table routes
+		interactionGroup := protected.Group("/drug-interactions")
+		{
+			interactionGroup.GET("", middleware.RequirePermission("drug.read"), drugController.ListInteractions)
+			interactionGroup.POST("", middleware.RequirePermission("drug.manage"), drugController.CreateInteraction)
+			interactionGroup.DELETE("/:id", middleware.RequirePermission("drug.manage"), drugController.DeleteInteraction)
+		}
+
+		// Lab test catalogue routes
+		labTestGroup := protected.Group("/lab-tests")
+		{
+			labTestGroup.GET("", middleware.RequirePermission("lab.read"), labTestController.ListLabTests)
+			labTestGroup.GET("/:id", middleware.RequirePermission("lab.read"), labTestController.GetLabTest)
+			labTestGroup.POST("", middleware.RequirePermission("lab.manage"), labTestController.CreateLabTest)
+			labTestGroup.PUT("/:id", middleware.RequirePermission("lab.manage"), labTestController.UpdateLabTest)
+		}
+
+		// ICD-10 code catalogue routes; the catalogue is loaded with the import-icd10 command
+		icd10Group := protected.Group("/icd10-codes")
+		{
+			icd10Group.GET("", middleware.RequirePermission("icd10.read"), icd10Controller.SearchCodes)
+			icd10Group.GET("/:code", middleware.RequirePermission("icd10.read"), icd10Controller.GetCode)
+		}
+
+		// Priced service catalogue routes
+		billableServiceGroup := protected.Group("/billable-services")
+		{
+			billableServiceGroup.GET("", middleware.RequirePermission("billing.read"), serviceCatalogueController.ListServices)
+			billableServiceGroup.GET("/:id", middleware.RequirePermission("billing.read"), serviceCatalogueController.GetService)
+			billableServiceGroup.POST("", middleware.RequirePermission("billing.manage"), serviceCatalogueController.CreateService)
+			billableServiceGroup.PUT("/:id", middleware.RequirePermission("billing.manage"), serviceCatalogueController.UpdateService)
+		}
+
+		// Invoice routes
+		invoiceGroup := protected.Group("/invoices")
+		{
+			// Invoices across patients, e.g. the outstanding queue
+			invoiceGroup.GET("", middleware.RequirePermission("billing.read"), invoiceController.ListInvoices)
+
+			// Get an invoice with its items and payments
+			invoiceGroup.GET("/:id", middleware.RequirePermission("billing.read"), invoiceController.GetInvoice)
+
+			// Edit and issue draft invoices
+			invoiceGroup.PUT("/:id", middleware.RequirePermission("billing.invoice"), invoiceController.UpdateInvoice)
+			invoiceGroup.POST("/:id/issue", middleware.RequirePermission("billing.invoice"), invoiceController.IssueInvoice)
+
+			// Void an unpaid invoice
+			invoiceGroup.POST("/:id/void", middleware.RequirePermission("billing.void"), invoiceController.VoidInvoice)
+
+			// Take a payment, returning its receipt
+			invoiceGroup.POST("/:id/payments", middleware.RequirePermission("billing.payment"), invoiceController.RecordPayment)
+
+			// Split an invoice between the patient and their payers
+			invoiceGroup.GET("/:id/eligibility", middleware.RequirePermission("insurance.read"), insurancePolicyController.CheckInvoiceEligibility)
+		}
+
+		// Payer routes
+		payerGroup := protected.Group("/payers")
+		{
+			payerGroup.GET("", middleware.RequirePermission("insurance.read"), payerController.ListPayers)
+			payerGroup.GET("/:id", middleware.RequirePermission("insurance.read"), payerController.GetPayer)
+			payerGroup.POST("", middleware.RequirePermission("insurance.manage"), payerController.CreatePayer)
+			payerGroup.PUT("/:id", middleware.RequirePermission("insurance.manage"), payerController.UpdatePayer)
+		}
+
+		// Receipt routes
+		protected.GET("/receipts/:number", middleware.RequirePermission("billing.read"), invoiceController.GetReceipt)
+
+		// Lab order routes
+		labOrderGroup := protected.Group("/lab-orders")
+		{
+			// Laboratory worklist
+			labOrderGroup.GET("", middleware.RequirePermission("lab.read"), labOrderController.ListLabOrders)
+
+			// Get a lab order with its results
+			labOrderGroup.GET("/:id", middleware.RequirePermission("lab.read"), labOrderController.GetLabOrder)
+
+			// Move an order through collection, resulting and verification
+			labOrderGroup.POST("/:id/collect", middleware.RequirePermission("lab.collect"), labOrderController.CollectSpecimen)
+			labOrderGroup.POST("/:id/results", middleware.RequirePermission("lab.result"), labOrderController.EnterLabResults)
+			labOrderGroup.POST("/:id/verify", middleware.RequirePermission("lab.verify"), labOrderController.VerifyLabResults)
+
+			// Cancel an order before results are entered
+			labOrderGroup.POST("/:id/cancel", middleware.RequirePermission("lab.order"), labOrderController.CancelLabOrder)
+		}
+
+		// Doctor routes
+		doctorGroup := protected.Group("/doctors")
+		{
+			// Daily calendar of a doctor's appointments
+			doctorGroup.GET("/:id/calendar", middleware.RequirePermission("appointment.read"), appointmentController.GetDoctorCalendar)
+
+			// Weekly working hours, slot length and time zone
+			doctorGroup.GET("/:id/schedule", middleware.RequirePermission("schedule.read"), scheduleController.GetSchedule)
+			doctorGroup.PUT("/:id/schedule", middleware.RequirePermission("schedule.manage"), scheduleController.SetSchedule)
 
-			// Update an existing patient (only accessible to receptionists)
-			patientGroup.PUT("/:id", middleware.RoleMiddleware("receptionist"), patientController.UpdatePatient)
+			// One-off changes to availability
+			doctorGroup.POST("/:id/schedule/exceptions", middleware.RequirePermission("schedule.manage"), scheduleController.AddScheduleException)
+			doctorGroup.DELETE("/:id/schedule/exceptions/:exceptionId", middleware.RequirePermission("schedule.manage"), scheduleController.DeleteScheduleException)
 
-			// Delete a patient (only accessible to receptionists)
-			patientGroup.DELETE("/:id", middleware.RoleMiddleware("receptionist"), patientController.DeletePatient)
+			// Leave days
+			doctorGroup.POST("/:id/leave", middleware.RequirePermission("schedule.manage"), scheduleController.AddLeave)
+			doctorGroup.DELETE("/:id/leave/:leaveId", middleware.RequirePermission("schedule.manage"), scheduleController.DeleteLeave)
 
-			// Get a patient by ID (accessible to receptionists and doctors)
-			patientGroup.GET("/:id", middleware.RoleMiddleware("receptionist", "doctor"), patientController.GetPatient)
+			// Free slots for booking
+			doctorGroup.GET("/:id/slots", middleware.RequirePermission("schedule.read"), scheduleController.GetFreeSlots)
 		}
 	}
-}
\ No newline at end of file
+}
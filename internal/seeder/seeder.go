@@ -14,10 +14,10 @@ func SeedUsers() {
 	users := []struct {
 		Username string
 		Password string
-		RoleID   int64
+		Role     string
 	}{
-		{"admin", "@Doktari123", 1},
-		{"receptionist", "@#PaSSwords123", 2},
+		{"admin", "@Doktari123", "admin"},
+		{"receptionist", "@#PaSSwords123", "receptionist"},
 	}
 
 	for _, user := range users {
@@ -41,13 +41,13 @@ func SeedUsers() {
 
 		// Insert the user
 		query := `INSERT INTO users (username, password_hash, role_id, created_at, updated_at)
-			      VALUES ($1, $2, $3, $4, $5)`
+			      VALUES ($1, $2, (SELECT id FROM roles WHERE name = $3), $4, $5)`
 
-		_, err = db.Exec(query, user.Username, string(hashedPassword), user.RoleID, time.Now(), time.Now())
+		_, err = db.Exec(query, user.Username, string(hashedPassword), user.Role, time.Now(), time.Now())
 		if err != nil {
 			log.Fatalf("failed to insert user: %v", err)
 		}
 
 		log.Printf("user %s seeded successfully", user.Username)
 	}
-}
\ No newline at end of file
+}
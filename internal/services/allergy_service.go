@@ -0,0 +1,401 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+
+	"github.com/Okemwag/medihub/internal/models"
+	"github.com/lib/pq"
+)
+
+// Errors returned by AllergyService.
+var (
+	ErrAllergyNotFound       = NewNotFoundError("allergy_not_found", "allergy not found")
+	ErrDuplicateAllergy      = NewConflictError("duplicate_allergy", "the patient already has an active allergy to this substance")
+	ErrAllergyEnteredInError = NewConflictError("allergy_entered_in_error", "allergies entered in error cannot be changed")
+)
+
+// AllergyService provides methods for managing the allergies and adverse reactions of patients.
+type AllergyService struct {
+	db    *sql.DB
+	audit *AuditService
+}
+
+// NewAllergyService creates a new instance of AllergyService.
+//
+// @param db *sql.DB: A database connection.
+// @param audit *AuditService: The service used to audit allergy access.
+// @return *AllergyService: A new AllergyService instance.
+func NewAllergyService(db *sql.DB, audit *AuditService) *AllergyService {
+	return &AllergyService{db: db, audit: audit}
+}
+
+// allergyColumns lists the allergy columns, with the recorder's name, in the order expected by
+// scanAllergy. It must be used with allergyFrom.
+const allergyColumns = `a.id, a.patient_id, a.substance, a.category, COALESCE(a.reaction, ''), a.severity, a.status, COALESCE(a.notes, ''), COALESCE(a.recorded_by, 0), COALESCE(u.name, ''), COALESCE(a.updated_by, 0), a.created_at, a.updated_at`
+
+// allergyFrom joins allergies to the user who recorded them.
+const allergyFrom = `allergies a LEFT JOIN users u ON u.id = a.recorded_by`
+
+// allergySeverityOrder sorts allergies from the most to the least severe.
+const allergySeverityOrder = `CASE a.severity WHEN 'life_threatening' THEN 0 WHEN 'severe' THEN 1 WHEN 'moderate' THEN 2 ELSE 3 END`
+
+// scanAllergy reads a single allergy selected with allergyColumns.
+func scanAllergy(row rowScanner) (*models.Allergy, error) {
+	var allergy models.Allergy
+	err := row.Scan(
+		&allergy.ID,
+		&allergy.PatientID,
+		&allergy.Substance,
+		&allergy.Category,
+		&allergy.Reaction,
+		&allergy.Severity,
+		&allergy.Status,
+		&allergy.Notes,
+		&allergy.RecordedBy,
+		&allergy.RecorderName,
+		&allergy.UpdatedBy,
+		&allergy.CreatedAt,
+		&allergy.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &allergy, nil
+}
+
+// CreateAllergy records an allergy of a patient.
+//
+// @param ctx context.Context: The context for the request.
+// @param patientID int64: The ID of the patient.
+// @param allergy *models.Allergy: The allergy to record; category defaults to other and status to active.
+// @return *models.Allergy: The recorded allergy.
+// @return error: ErrPatientNotFound, ErrDuplicateAllergy, or an error if the operation fails.
+func (s *AllergyService) CreateAllergy(ctx context.Context, patientID int64, allergy *models.Allergy) (*models.Allergy, error) {
+	applyAllergyDefaults(allergy)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := requirePatient(ctx, tx, patientID); err != nil {
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO allergies (patient_id, substance, category, reaction, severity, status, notes, recorded_by, updated_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id
+	`
+	var id int64
+	err = tx.QueryRowContext(ctx, query,
+		patientID,
+		allergy.Substance,
+		allergy.Category,
+		allergy.Reaction,
+		allergy.Severity,
+		allergy.Status,
+		allergy.Notes,
+		allergy.RecordedBy,
+		allergy.UpdatedBy,
+	).Scan(&id)
+	if err != nil {
+		return nil, allergyWriteError("creating", err)
+	}
+
+	created, err := s.getAllergy(ctx, tx, patientID, id, false)
+	if err != nil {
+		return nil, err
+	}
+	err = s.audit.Record(ctx, tx, models.AuditEntry{
+		Action:     "allergy.create",
+		EntityType: "allergy",
+		EntityID:   &id,
+		Changes:    diffFields(nil, created),
+		PatientID:  &patientID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error creating allergy: %v", err)
+		return nil, err
+	}
+	return created, nil
+}
+
+// ListAllergies retrieves a patient's allergies, most severe first.
+//
+// @param ctx context.Context: The context for the request.
+// @param patientID int64: The ID of the patient.
+// @param status string: Only allergies in this status, or every allergy when empty.
+// @return []models.Allergy: The patient's allergies.
+// @return error: ErrPatientNotFound, or an error if the operation fails.
+func (s *AllergyService) ListAllergies(ctx context.Context, patientID int64, status string) ([]models.Allergy, error) {
+	if err := requirePatient(ctx, s.db, patientID); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT ` + allergyColumns + `
+		FROM ` + allergyFrom + `
+		WHERE a.patient_id = $1 AND ($2::text = '' OR a.status = $2)
+		ORDER BY ` + allergySeverityOrder + `, a.substance, a.id
+	`
+	rows, err := s.db.QueryContext(ctx, query, patientID, status)
+	if err != nil {
+		log.Printf("Error listing allergies: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	allergies := []models.Allergy{}
+	for rows.Next() {
+		allergy, err := scanAllergy(rows)
+		if err != nil {
+			log.Printf("Error scanning allergy: %v", err)
+			return nil, err
+		}
+		allergies = append(allergies, *allergy)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating allergies: %v", err)
+		return nil, err
+	}
+
+	err = s.audit.Record(ctx, nil, models.AuditEntry{
+		Action:     "allergy.list",
+		EntityType: "patient",
+		EntityID:   &patientID,
+		Details:    map[string]interface{}{"status": status, "returned": len(allergies)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return allergies, nil
+}
+
+// GetAllergy retrieves one of a patient's allergies.
+//
+// @param ctx context.Context: The context for the request.
+// @param patientID int64: The ID of the patient.
+// @param id int64: The ID of the allergy.
+// @return *models.Allergy: The allergy.
+// @return error: ErrPatientNotFound, ErrAllergyNotFound, or an error if the operation fails.
+func (s *AllergyService) GetAllergy(ctx context.Context, patientID, id int64) (*models.Allergy, error) {
+	if err := requirePatient(ctx, s.db, patientID); err != nil {
+		return nil, err
+	}
+
+	allergy, err := s.getAllergy(ctx, s.db, patientID, id, false)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.audit.Record(ctx, nil, models.AuditEntry{
+		Action:     "allergy.read",
+		EntityType: "allergy",
+		EntityID:   &id,
+		PatientID:  &patientID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return allergy, nil
+}
+
+// UpdateAllergy replaces the details of one of a patient's allergies, e.g. to mark it resolved.
+// Allergies entered in error are kept as recorded and cannot be changed.
+//
+// @param ctx context.Context: The context for the request.
+// @param patientID int64: The ID of the patient.
+// @param id int64: The ID of the allergy.
+// @param allergy *models.Allergy: The new details of the allergy.
+// @param updatedBy int64: The ID of the user updating the allergy.
+// @return *models.Allergy: The updated allergy.
+// @return error: ErrPatientNotFound, ErrAllergyNotFound, ErrAllergyEnteredInError, ErrDuplicateAllergy, or an error if the operation fails.
+func (s *AllergyService) UpdateAllergy(ctx context.Context, patientID, id int64, allergy *models.Allergy, updatedBy int64) (*models.Allergy, error) {
+	applyAllergyDefaults(allergy)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := requirePatient(ctx, tx, patientID); err != nil {
+		return nil, err
+	}
+	current, err := s.getAllergy(ctx, tx, patientID, id, true)
+	if err != nil {
+		return nil, err
+	}
+	if current.Status == models.AllergyEnteredInError {
+		return nil, ErrAllergyEnteredInError
+	}
+
+	query := `
+		UPDATE allergies
+		SET substance = $1, category = $2, reaction = $3, severity = $4, status = $5, notes = $6, updated_by = $7, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $8
+	`
+	_, err = tx.ExecContext(ctx, query,
+		allergy.Substance,
+		allergy.Category,
+		allergy.Reaction,
+		allergy.Severity,
+		allergy.Status,
+		allergy.Notes,
+		updatedBy,
+		id,
+	)
+	if err != nil {
+		return nil, allergyWriteError("updating", err)
+	}
+
+	updated, err := s.getAllergy(ctx, tx, patientID, id, false)
+	if err != nil {
+		return nil, err
+	}
+	err = s.audit.Record(ctx, tx, models.AuditEntry{
+		Action:     "allergy.update",
+		EntityType: "allergy",
+		EntityID:   &id,
+		Changes:    diffFields(current, updated),
+		PatientID:  &patientID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error updating allergy: %v", err)
+		return nil, err
+	}
+	return updated, nil
+}
+
+// DeleteAllergy retires one of a patient's allergies that was recorded by mistake by marking it
+// entered_in_error. The row is kept because prescription alerts may refer to it. Allergies that
+// were recorded correctly but no longer apply should be marked inactive or resolved instead.
+//
+// @param ctx context.Context: The context for the request.
+// @param patientID int64: The ID of the patient.
+// @param id int64: The ID of the allergy.
+// @param deletedBy int64: The ID of the user retiring the allergy.
+// @return error: ErrPatientNotFound, ErrAllergyNotFound, or an error if the operation fails.
+func (s *AllergyService) DeleteAllergy(ctx context.Context, patientID, id int64, deletedBy int64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := requirePatient(ctx, tx, patientID); err != nil {
+		return err
+	}
+	current, err := s.getAllergy(ctx, tx, patientID, id, true)
+	if err != nil {
+		return err
+	}
+	query := `UPDATE allergies SET status = $1, updated_by = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $3`
+	if _, err := tx.ExecContext(ctx, query, models.AllergyEnteredInError, deletedBy, id); err != nil {
+		log.Printf("Error deleting allergy: %v", err)
+		return err
+	}
+
+	updated, err := s.getAllergy(ctx, tx, patientID, id, false)
+	if err != nil {
+		return err
+	}
+	err = s.audit.Record(ctx, tx, models.AuditEntry{
+		Action:     "allergy.delete",
+		EntityType: "allergy",
+		EntityID:   &id,
+		Changes:    diffFields(current, updated),
+		PatientID:  &patientID,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error deleting allergy: %v", err)
+		return err
+	}
+	return nil
+}
+
+// getAllergy loads one of a patient's allergies without auditing the access, optionally locking
+// the row for update.
+func (s *AllergyService) getAllergy(ctx context.Context, db dbtx, patientID, id int64, forUpdate bool) (*models.Allergy, error) {
+	query := `SELECT ` + allergyColumns + ` FROM ` + allergyFrom + ` WHERE a.id = $1 AND a.patient_id = $2`
+	if forUpdate {
+		query += ` FOR UPDATE OF a`
+	}
+	allergy, err := scanAllergy(db.QueryRowContext(ctx, query, id, patientID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrAllergyNotFound
+		}
+		log.Printf("Error retrieving allergy: %v", err)
+		return nil, err
+	}
+	return allergy, nil
+}
+
+// activeAllergies loads the summaries of a patient's active allergies, most severe first.
+func activeAllergies(ctx context.Context, db dbtx, patientID int64) ([]models.AllergySummary, error) {
+	query := `
+		SELECT a.id, a.substance, a.category, COALESCE(a.reaction, ''), a.severity
+		FROM allergies a
+		WHERE a.patient_id = $1 AND a.status = 'active'
+		ORDER BY ` + allergySeverityOrder + `, a.substance
+	`
+	rows, err := db.QueryContext(ctx, query, patientID)
+	if err != nil {
+		log.Printf("Error listing active allergies: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	allergies := []models.AllergySummary{}
+	for rows.Next() {
+		var a models.AllergySummary
+		if err := rows.Scan(&a.ID, &a.Substance, &a.Category, &a.Reaction, &a.Severity); err != nil {
+			log.Printf("Error scanning active allergy: %v", err)
+			return nil, err
+		}
+		allergies = append(allergies, a)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating active allergies: %v", err)
+		return nil, err
+	}
+	return allergies, nil
+}
+
+// applyAllergyDefaults fills in the category and status of an allergy when they are not given.
+func applyAllergyDefaults(allergy *models.Allergy) {
+	if allergy.Category == "" {
+		allergy.Category = "other"
+	}
+	if allergy.Status == "" {
+		allergy.Status = models.AllergyActive
+	}
+}
+
+// allergyWriteError translates violations of the active substance index into ErrDuplicateAllergy.
+func allergyWriteError(operation string, err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return ErrDuplicateAllergy
+	}
+	log.Printf("Error %s allergy: %v", operation, err)
+	return err
+}
@@ -0,0 +1,515 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Okemwag/medihub/internal/models"
+	"github.com/lib/pq"
+)
+
+// Errors returned by AppointmentService.
+var (
+	ErrAppointmentNotFound         = NewNotFoundError("appointment_not_found", "appointment not found")
+	ErrAppointmentConflict         = NewConflictError("appointment_conflict", "the doctor or patient already has an appointment that overlaps this time")
+	ErrInvalidAppointmentTime      = NewValidationError("invalid_appointment_time", "invalid appointment time")
+	ErrInvalidDoctor               = NewValidationError("invalid_doctor", "doctor_id must refer to an active user with the doctor role")
+	ErrInvalidStatusTransition     = NewConflictError("invalid_status_transition", "the appointment cannot move to the requested status")
+	ErrAppointmentNotReschedulable = NewConflictError("appointment_not_reschedulable", "only scheduled appointments can be rescheduled")
+)
+
+// exclusionViolation is the Postgres error code raised when an exclusion constraint rejects a row.
+const exclusionViolation = "23P01"
+
+// appointmentTransitions lists the statuses an appointment may move to from each status through
+// UpdateAppointmentStatus. Cancellation goes through CancelAppointment.
+var appointmentTransitions = map[string][]string{
+	models.AppointmentScheduled: {models.AppointmentCheckedIn, models.AppointmentNoShow},
+	models.AppointmentCheckedIn: {models.AppointmentCompleted},
+}
+
+// AppointmentService provides methods for booking and managing appointments.
+type AppointmentService struct {
+	db    *sql.DB
+	audit *AuditService
+}
+
+// NewAppointmentService creates a new instance of AppointmentService.
+//
+// @param db *sql.DB: A database connection.
+// @param audit *AuditService: The service used to audit appointment access.
+// @return *AppointmentService: A new AppointmentService instance.
+func NewAppointmentService(db *sql.DB, audit *AuditService) *AppointmentService {
+	return &AppointmentService{db: db, audit: audit}
+}
+
+// appointmentColumns lists the appointment columns, with the patient and doctor names, in the
+// order expected by scanAppointment. It must be used with appointmentFrom.
+const appointmentColumns = `a.id, a.patient_id, p.first_name || ' ' || p.last_name, a.doctor_id, COALESCE(u.name, ''), a.starts_at, a.ends_at, a.status, COALESCE(a.reason, ''), COALESCE(a.cancellation_reason, ''), a.cancelled_at, COALESCE(a.created_by, 0), COALESCE(a.updated_by, 0), a.created_at, a.updated_at`
+
+// appointmentFrom joins appointments to the patient and doctor they concern.
+const appointmentFrom = `appointments a JOIN patients p ON p.id = a.patient_id JOIN users u ON u.id = a.doctor_id`
+
+// scanAppointment reads a single appointment selected with appointmentColumns.
+func scanAppointment(row rowScanner) (*models.Appointment, error) {
+	var appointment models.Appointment
+	err := row.Scan(
+		&appointment.ID,
+		&appointment.PatientID,
+		&appointment.PatientName,
+		&appointment.DoctorID,
+		&appointment.DoctorName,
+		&appointment.StartsAt,
+		&appointment.EndsAt,
+		&appointment.Status,
+		&appointment.Reason,
+		&appointment.CancellationReason,
+		&appointment.CancelledAt,
+		&appointment.CreatedBy,
+		&appointment.UpdatedBy,
+		&appointment.CreatedAt,
+		&appointment.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &appointment, nil
+}
+
+// AppointmentListParams describes the filters and pagination for an appointment listing.
+type AppointmentListParams struct {
+	PatientID *int64     `json:"patient_id,omitempty"` // Only appointments of this patient
+	DoctorID  *int64     `json:"doctor_id,omitempty"`  // Only appointments with this doctor
+	Status    string     `json:"status,omitempty"`     // Only appointments in this status
+	From      *time.Time `json:"from,omitempty"`       // Only appointments ending after this time
+	To        *time.Time `json:"to,omitempty"`         // Only appointments starting before this time
+	Page      int        `json:"page"`                 // 1-based page number
+	PageSize  int        `json:"page_size"`            // Number of appointments per page
+}
+
+// AppointmentListResult is a single page of appointments along with the total number of matches.
+type AppointmentListResult struct {
+	Appointments []models.Appointment `json:"data"`
+	Total        int64                `json:"total"`
+	Page         int                  `json:"page"`
+	PageSize     int                  `json:"page_size"`
+}
+
+// CreateAppointment books a new appointment. The doctor and the patient must both be free for
+// the whole period.
+//
+// @param ctx context.Context: The context for the request.
+// @param appointment *models.Appointment: The appointment to book; its status is always scheduled.
+// @return *models.Appointment: The booked appointment.
+// @return error: ErrInvalidAppointmentTime, ErrInvalidDoctor, ErrPatientNotFound, ErrAppointmentConflict, or an error if the operation fails.
+func (s *AppointmentService) CreateAppointment(ctx context.Context, appointment *models.Appointment) (*models.Appointment, error) {
+	if err := validateAppointmentPeriod(appointment.StartsAt, appointment.EndsAt); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := requireDoctor(ctx, tx, appointment.DoctorID); err != nil {
+		return nil, err
+	}
+	if err := requirePatient(ctx, tx, appointment.PatientID); err != nil {
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO appointments (patient_id, doctor_id, starts_at, ends_at, status, reason, created_by, updated_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`
+	var id int64
+	err = tx.QueryRowContext(ctx, query,
+		appointment.PatientID,
+		appointment.DoctorID,
+		appointment.StartsAt,
+		appointment.EndsAt,
+		models.AppointmentScheduled,
+		appointment.Reason,
+		appointment.CreatedBy,
+		appointment.UpdatedBy,
+	).Scan(&id)
+	if err != nil {
+		return nil, appointmentWriteError("creating", err)
+	}
+
+	created, err := s.getAppointment(ctx, tx, id, false)
+	if err != nil {
+		return nil, err
+	}
+	err = s.audit.Record(ctx, tx, models.AuditEntry{
+		Action:     "appointment.create",
+		EntityType: "appointment",
+		EntityID:   &id,
+		Changes:    diffFields(nil, created),
+		PatientID:  &created.PatientID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error creating appointment: %v", err)
+		return nil, err
+	}
+	return created, nil
+}
+
+// GetAppointment retrieves an appointment by ID.
+//
+// @param ctx context.Context: The context for the request.
+// @param id int64: The ID of the appointment.
+// @return *models.Appointment: The appointment.
+// @return error: ErrAppointmentNotFound, or an error if the operation fails.
+func (s *AppointmentService) GetAppointment(ctx context.Context, id int64) (*models.Appointment, error) {
+	appointment, err := s.getAppointment(ctx, s.db, id, false)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.audit.Record(ctx, nil, models.AuditEntry{
+		Action:     "appointment.read",
+		EntityType: "appointment",
+		EntityID:   &id,
+		PatientID:  &appointment.PatientID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return appointment, nil
+}
+
+// RescheduleAppointment moves a scheduled appointment to a new period.
+//
+// @param ctx context.Context: The context for the request.
+// @param id int64: The ID of the appointment.
+// @param startsAt time.Time: The new start time.
+// @param endsAt time.Time: The new end time.
+// @param updatedBy int64: The ID of the user rescheduling the appointment.
+// @return *models.Appointment: The rescheduled appointment.
+// @return error: ErrAppointmentNotFound, ErrAppointmentNotReschedulable, ErrInvalidAppointmentTime, ErrAppointmentConflict, or an error if the operation fails.
+func (s *AppointmentService) RescheduleAppointment(ctx context.Context, id int64, startsAt, endsAt time.Time, updatedBy int64) (*models.Appointment, error) {
+	if err := validateAppointmentPeriod(startsAt, endsAt); err != nil {
+		return nil, err
+	}
+
+	return s.updateAppointment(ctx, id, "appointment.reschedule", func(tx *sql.Tx, current *models.Appointment) error {
+		if current.Status != models.AppointmentScheduled {
+			return ErrAppointmentNotReschedulable
+		}
+		query := `UPDATE appointments SET starts_at = $1, ends_at = $2, updated_by = $3, updated_at = CURRENT_TIMESTAMP WHERE id = $4`
+		if _, err := tx.ExecContext(ctx, query, startsAt, endsAt, updatedBy, id); err != nil {
+			return appointmentWriteError("rescheduling", err)
+		}
+		return nil
+	})
+}
+
+// CancelAppointment cancels an appointment that has not yet been completed, freeing its time.
+//
+// @param ctx context.Context: The context for the request.
+// @param id int64: The ID of the appointment.
+// @param reason string: Why the appointment was cancelled.
+// @param cancelledBy int64: The ID of the user cancelling the appointment.
+// @return *models.Appointment: The cancelled appointment.
+// @return error: ErrAppointmentNotFound, ErrInvalidStatusTransition, or an error if the operation fails.
+func (s *AppointmentService) CancelAppointment(ctx context.Context, id int64, reason string, cancelledBy int64) (*models.Appointment, error) {
+	return s.updateAppointment(ctx, id, "appointment.cancel", func(tx *sql.Tx, current *models.Appointment) error {
+		if current.Status != models.AppointmentScheduled && current.Status != models.AppointmentCheckedIn {
+			return fmt.Errorf("%w: %s appointments cannot be cancelled", ErrInvalidStatusTransition, current.Status)
+		}
+		query := `
+			UPDATE appointments
+			SET status = $1, cancellation_reason = $2, cancelled_at = CURRENT_TIMESTAMP, updated_by = $3, updated_at = CURRENT_TIMESTAMP
+			WHERE id = $4
+		`
+		if _, err := tx.ExecContext(ctx, query, models.AppointmentCancelled, reason, cancelledBy, id); err != nil {
+			return appointmentWriteError("cancelling", err)
+		}
+		return nil
+	})
+}
+
+// UpdateAppointmentStatus records that a patient checked in, was seen, or did not show up.
+//
+// @param ctx context.Context: The context for the request.
+// @param id int64: The ID of the appointment.
+// @param status string: The new status: checked_in, completed or no_show.
+// @param updatedBy int64: The ID of the user updating the appointment.
+// @return *models.Appointment: The updated appointment.
+// @return error: ErrAppointmentNotFound, ErrInvalidStatusTransition, or an error if the operation fails.
+func (s *AppointmentService) UpdateAppointmentStatus(ctx context.Context, id int64, status string, updatedBy int64) (*models.Appointment, error) {
+	return s.updateAppointment(ctx, id, "appointment.status", func(tx *sql.Tx, current *models.Appointment) error {
+		allowed := false
+		for _, next := range appointmentTransitions[current.Status] {
+			if next == status {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return fmt.Errorf("%w: %s to %s", ErrInvalidStatusTransition, current.Status, status)
+		}
+		query := `UPDATE appointments SET status = $1, updated_by = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $3`
+		if _, err := tx.ExecContext(ctx, query, status, updatedBy, id); err != nil {
+			return appointmentWriteError("updating", err)
+		}
+		return nil
+	})
+}
+
+// updateAppointment locks an appointment, applies a change to it and audits the result.
+func (s *AppointmentService) updateAppointment(ctx context.Context, id int64, action string, apply func(tx *sql.Tx, current *models.Appointment) error) (*models.Appointment, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	current, err := s.getAppointment(ctx, tx, id, true)
+	if err != nil {
+		return nil, err
+	}
+	if err := apply(tx, current); err != nil {
+		return nil, err
+	}
+
+	updated, err := s.getAppointment(ctx, tx, id, false)
+	if err != nil {
+		return nil, err
+	}
+	err = s.audit.Record(ctx, tx, models.AuditEntry{
+		Action:     action,
+		EntityType: "appointment",
+		EntityID:   &id,
+		Changes:    diffFields(current, updated),
+		PatientID:  &updated.PatientID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error updating appointment: %v", err)
+		return nil, err
+	}
+	return updated, nil
+}
+
+// ListAppointments retrieves a filtered page of appointments ordered by start time.
+//
+// @param ctx context.Context: The context for the request.
+// @param params AppointmentListParams: The filters and pagination to apply.
+// @return *AppointmentListResult: The requested page of appointments and the total number of matches.
+// @return error: An error if the operation fails.
+func (s *AppointmentService) ListAppointments(ctx context.Context, params AppointmentListParams) (*AppointmentListResult, error) {
+	if params.Page < 1 {
+		params.Page = 1
+	}
+	if params.PageSize < 1 {
+		params.PageSize = DefaultPageSize
+	}
+	if params.PageSize > MaxPageSize {
+		params.PageSize = MaxPageSize
+	}
+
+	var conditions []string
+	var args []interface{}
+	add := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if params.PatientID != nil {
+		add("a.patient_id = $%d", *params.PatientID)
+	}
+	if params.DoctorID != nil {
+		add("a.doctor_id = $%d", *params.DoctorID)
+	}
+	if params.Status != "" {
+		add("a.status = $%d", params.Status)
+	}
+	if params.From != nil {
+		add("a.ends_at > $%d", *params.From)
+	}
+	if params.To != nil {
+		add("a.starts_at < $%d", *params.To)
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int64
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM appointments a `+where, args...).Scan(&total); err != nil {
+		log.Printf("Error counting appointments: %v", err)
+		return nil, err
+	}
+
+	args = append(args, params.PageSize, (params.Page-1)*params.PageSize)
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM %s
+		%s
+		ORDER BY a.starts_at, a.id
+		LIMIT $%d OFFSET $%d
+	`, appointmentColumns, appointmentFrom, where, len(args)-1, len(args))
+	appointments, err := s.queryAppointments(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.audit.Record(ctx, nil, models.AuditEntry{
+		Action:     "appointment.list",
+		EntityType: "appointment",
+		Details:    map[string]interface{}{"params": params, "returned": len(appointments)},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &AppointmentListResult{Appointments: appointments, Total: total, Page: params.Page, PageSize: params.PageSize}, nil
+}
+
+// DoctorCalendar retrieves a doctor's appointments for one day, including cancelled ones, ordered
+// by start time.
+//
+// @param ctx context.Context: The context for the request.
+// @param doctorID int64: The ID of the doctor.
+// @param day time.Time: Midnight at the start of the day, in the clinic's time zone.
+// @return []models.Appointment: The doctor's appointments that overlap the day.
+// @return error: ErrInvalidDoctor, or an error if the operation fails.
+func (s *AppointmentService) DoctorCalendar(ctx context.Context, doctorID int64, day time.Time) ([]models.Appointment, error) {
+	if err := requireDoctor(ctx, s.db, doctorID); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT ` + appointmentColumns + `
+		FROM ` + appointmentFrom + `
+		WHERE a.doctor_id = $1 AND a.starts_at < $3 AND a.ends_at > $2
+		ORDER BY a.starts_at, a.id
+	`
+	appointments, err := s.queryAppointments(ctx, query, doctorID, day, day.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.audit.Record(ctx, nil, models.AuditEntry{
+		Action:     "appointment.calendar",
+		EntityType: "user",
+		EntityID:   &doctorID,
+		Details:    map[string]interface{}{"date": day.Format("2006-01-02"), "returned": len(appointments)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return appointments, nil
+}
+
+// queryAppointments runs a query selecting appointmentColumns and collects the results.
+func (s *AppointmentService) queryAppointments(ctx context.Context, query string, args ...interface{}) ([]models.Appointment, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Printf("Error listing appointments: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	appointments := []models.Appointment{}
+	for rows.Next() {
+		appointment, err := scanAppointment(rows)
+		if err != nil {
+			log.Printf("Error scanning appointment: %v", err)
+			return nil, err
+		}
+		appointments = append(appointments, *appointment)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating appointments: %v", err)
+		return nil, err
+	}
+	return appointments, nil
+}
+
+// getAppointment loads an appointment without auditing the access, optionally locking the row for update.
+func (s *AppointmentService) getAppointment(ctx context.Context, db dbtx, id int64, forUpdate bool) (*models.Appointment, error) {
+	query := `SELECT ` + appointmentColumns + ` FROM ` + appointmentFrom + ` WHERE a.id = $1`
+	if forUpdate {
+		query += ` FOR UPDATE OF a`
+	}
+	appointment, err := scanAppointment(db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrAppointmentNotFound
+		}
+		log.Printf("Error retrieving appointment: %v", err)
+		return nil, err
+	}
+	return appointment, nil
+}
+
+// validateAppointmentPeriod checks that an appointment ends after it starts.
+func validateAppointmentPeriod(startsAt, endsAt time.Time) error {
+	if startsAt.IsZero() || endsAt.IsZero() {
+		return fmt.Errorf("%w: starts_at and ends_at are required", ErrInvalidAppointmentTime)
+	}
+	if !endsAt.After(startsAt) {
+		return fmt.Errorf("%w: ends_at must be after starts_at", ErrInvalidAppointmentTime)
+	}
+	return nil
+}
+
+// requireDoctor checks that a user exists, is active and has the doctor role.
+func requireDoctor(ctx context.Context, db dbtx, userID int64) error {
+	query := `
+		SELECT 1
+		FROM users u
+		JOIN roles r ON r.id = u.role_id
+		WHERE u.id = $1 AND u.active AND r.name = 'doctor'
+	`
+	var found int
+	if err := db.QueryRowContext(ctx, query, userID).Scan(&found); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrInvalidDoctor
+		}
+		log.Printf("Error checking doctor: %v", err)
+		return err
+	}
+	return nil
+}
+
+// requirePatient checks that a patient exists and has not been deleted.
+func requirePatient(ctx context.Context, db dbtx, patientID int64) error {
+	var found int
+	err := db.QueryRowContext(ctx, `SELECT 1 FROM patients WHERE id = $1 AND deleted_at IS NULL`, patientID).Scan(&found)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrPatientNotFound
+		}
+		log.Printf("Error checking patient: %v", err)
+		return err
+	}
+	return nil
+}
+
+// appointmentWriteError translates exclusion constraint violations into ErrAppointmentConflict.
+func appointmentWriteError(operation string, err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == exclusionViolation {
+		return ErrAppointmentConflict
+	}
+	log.Printf("Error %s appointment: %v", operation, err)
+	return err
+}
@@ -0,0 +1,299 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/Okemwag/medihub/internal/models"
+	"github.com/Okemwag/medihub/internal/requestctx"
+)
+
+// dbtx is implemented by both *sql.DB and *sql.Tx so that audit entries can be written in the
+// same transaction as the change they describe.
+type dbtx interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// AuditService records and queries entries in the append-only audit_log table.
+//
+// Every service that handles patient data records each call through Record, reads included, so
+// that the audit trail shows who looked at a record as well as who changed it.
+type AuditService struct {
+	db *sql.DB
+}
+
+// NewAuditService creates a new instance of AuditService.
+//
+// @param db *sql.DB: A database connection.
+// @return *AuditService: A new AuditService instance.
+func NewAuditService(db *sql.DB) *AuditService {
+	return &AuditService{db: db}
+}
+
+// Record appends an entry to the audit log, attributing it to the principal and request stored in ctx.
+//
+// @param ctx context.Context: The context for the request.
+// @param db dbtx: The connection or transaction to write with; nil uses the service's connection.
+// @param entry models.AuditEntry: The action, entity and changes to record.
+// @return error: An error if the entry could not be written.
+func (s *AuditService) Record(ctx context.Context, db dbtx, entry models.AuditEntry) error {
+	if db == nil {
+		db = s.db
+	}
+	if principal, ok := requestctx.PrincipalFrom(ctx); ok {
+		entry.ActorUserID = &principal.UserID
+		entry.ActorRole = principal.Role
+	}
+	if metadata, ok := requestctx.MetadataFrom(ctx); ok {
+		entry.RequestID = metadata.RequestID
+		entry.ClientIP = metadata.ClientIP
+	}
+	if entry.PatientID == nil && entry.EntityType == "patient" {
+		entry.PatientID = entry.EntityID
+	}
+
+	changes, err := jsonColumn(entry.Changes, len(entry.Changes) > 0)
+	if err != nil {
+		return err
+	}
+	details, err := jsonColumn(entry.Details, len(entry.Details) > 0)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO audit_log (actor_user_id, actor_role, action, entity_type, entity_id, patient_id, changes, details, request_id, client_ip)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+	_, err = db.ExecContext(ctx, query,
+		entry.ActorUserID,
+		entry.ActorRole,
+		entry.Action,
+		entry.EntityType,
+		entry.EntityID,
+		entry.PatientID,
+		changes,
+		details,
+		entry.RequestID,
+		entry.ClientIP,
+	)
+	if err != nil {
+		log.Printf("Error recording audit entry %s: %v", entry.Action, err)
+		return err
+	}
+	return nil
+}
+
+// AuditQuery describes the filters and pagination for an audit log query.
+type AuditQuery struct {
+	EntityType string     // Only entries about this entity type, e.g. "patient"
+	EntityID   *int64     // Only entries about this entity
+	PatientID  *int64     // Only entries concerning this patient, whatever their entity type
+	UserID     *int64     // Only entries made by this user
+	Action     string     // Only entries with this action, e.g. "patient.read"
+	From       *time.Time // Inclusive lower bound on occurred_at
+	To         *time.Time // Inclusive upper bound on occurred_at
+	Page       int        // 1-based page number
+	PageSize   int        // Number of entries per page
+}
+
+// AuditPage is a single page of audit entries along with the total number of matches.
+type AuditPage struct {
+	Entries  []models.AuditEntry `json:"data"`
+	Total    int64               `json:"total"`
+	Page     int                 `json:"page"`
+	PageSize int                 `json:"page_size"`
+}
+
+// Query retrieves audit entries matching the given filters, most recent first.
+//
+// @param ctx context.Context: The context for the request.
+// @param q AuditQuery: The filters and pagination to apply.
+// @return *AuditPage: The requested page of entries and the total number of matches.
+// @return error: An error if the operation fails.
+func (s *AuditService) Query(ctx context.Context, q AuditQuery) (*AuditPage, error) {
+	if q.Page < 1 {
+		q.Page = 1
+	}
+	if q.PageSize < 1 {
+		q.PageSize = DefaultPageSize
+	}
+	if q.PageSize > MaxPageSize {
+		q.PageSize = MaxPageSize
+	}
+
+	var conditions []string
+	var args []interface{}
+	add := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if q.EntityType != "" {
+		add("entity_type = $%d", q.EntityType)
+	}
+	if q.EntityID != nil {
+		add("entity_id = $%d", *q.EntityID)
+	}
+	if q.PatientID != nil {
+		add("patient_id = $%d", *q.PatientID)
+	}
+	if q.UserID != nil {
+		add("actor_user_id = $%d", *q.UserID)
+	}
+	if q.Action != "" {
+		add("action = $%d", q.Action)
+	}
+	if q.From != nil {
+		add("occurred_at >= $%d", *q.From)
+	}
+	if q.To != nil {
+		add("occurred_at <= $%d", *q.To)
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int64
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM audit_log `+where, args...).Scan(&total); err != nil {
+		log.Printf("Error counting audit entries: %v", err)
+		return nil, err
+	}
+
+	args = append(args, q.PageSize, (q.Page-1)*q.PageSize)
+	query := fmt.Sprintf(`
+		SELECT id, occurred_at, actor_user_id, COALESCE(actor_role, ''), action, entity_type, entity_id,
+			patient_id, changes, details, COALESCE(request_id, ''), COALESCE(client_ip, '')
+		FROM audit_log
+		%s
+		ORDER BY occurred_at DESC, id DESC
+		LIMIT $%d OFFSET $%d
+	`, where, len(args)-1, len(args))
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Printf("Error querying audit entries: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]models.AuditEntry, 0, q.PageSize)
+	for rows.Next() {
+		var entry models.AuditEntry
+		var changes, details []byte
+		if err := rows.Scan(
+			&entry.ID,
+			&entry.OccurredAt,
+			&entry.ActorUserID,
+			&entry.ActorRole,
+			&entry.Action,
+			&entry.EntityType,
+			&entry.EntityID,
+			&entry.PatientID,
+			&changes,
+			&details,
+			&entry.RequestID,
+			&entry.ClientIP,
+		); err != nil {
+			log.Printf("Error scanning audit entry: %v", err)
+			return nil, err
+		}
+		if changes != nil {
+			if err := json.Unmarshal(changes, &entry.Changes); err != nil {
+				return nil, err
+			}
+		}
+		if details != nil {
+			if err := json.Unmarshal(details, &entry.Details); err != nil {
+				return nil, err
+			}
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating audit entries: %v", err)
+		return nil, err
+	}
+
+	return &AuditPage{Entries: entries, Total: total, Page: q.Page, PageSize: q.PageSize}, nil
+}
+
+// jsonColumn encodes v for a JSONB column, or returns NULL when present is false.
+func jsonColumn(v interface{}, present bool) (sql.NullString, error) {
+	if !present {
+		return sql.NullString{}, nil
+	}
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(encoded), Valid: true}, nil
+}
+
+// auditIgnoredFields are bookkeeping fields that are never reported as changes.
+var auditIgnoredFields = map[string]bool{
+	"id":         true,
+	"created_by": true,
+	"updated_by": true,
+	"created_at": true,
+	"updated_at": true,
+	"version":    true,
+}
+
+// diffFields compares two values of the same struct type field by field and returns the changed
+// fields keyed by their JSON names. A nil before reports every field as newly set; a nil after
+// reports every field as removed.
+func diffFields(before, after interface{}) map[string]models.FieldChange {
+	var b, a reflect.Value
+	if before != nil {
+		b = reflect.Indirect(reflect.ValueOf(before))
+	}
+	if after != nil {
+		a = reflect.Indirect(reflect.ValueOf(after))
+	}
+	var typ reflect.Type
+	if a.IsValid() {
+		typ = a.Type()
+	} else {
+		typ = b.Type()
+	}
+
+	changes := map[string]models.FieldChange{}
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if !field.IsExported() || name == "" || name == "-" || auditIgnoredFields[name] {
+			continue
+		}
+
+		var from, to interface{}
+		if b.IsValid() {
+			from = b.Field(i).Interface()
+		}
+		if a.IsValid() {
+			to = a.Field(i).Interface()
+		}
+		if fieldsEqual(from, to) {
+			continue
+		}
+		changes[name] = models.FieldChange{From: from, To: to}
+	}
+	return changes
+}
+
+// fieldsEqual compares two field values, treating times as equal when they denote the same instant.
+func fieldsEqual(x, y interface{}) bool {
+	if tx, ok := x.(time.Time); ok {
+		if ty, ok := y.(time.Time); ok {
+			return tx.Equal(ty)
+		}
+	}
+	return reflect.DeepEqual(x, y)
+}
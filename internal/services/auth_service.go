@@ -1,41 +1,64 @@
 package services
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"log"
 	"time"
 
-	"github.com/golang-jwt/jwt/v4"
 	"github.com/Okemwag/medihub/pkg/database"
+	"github.com/golang-jwt/jwt/v4"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// Errors returned by AuthService.
+var (
+	ErrInvalidCredentials  = NewUnauthorizedError("invalid_credentials", "invalid username or password")
+	ErrAccountDeactivated  = NewUnauthorizedError("account_deactivated", "account is deactivated")
+	ErrInvalidRefreshToken = NewUnauthorizedError("invalid_refresh_token", "invalid or expired refresh token")
+	ErrRefreshTokenReused  = NewUnauthorizedError("refresh_token_reused", "refresh token reuse detected; all sessions in this family have been revoked")
+)
+
 // AuthService provides methods for user authentication and token management.
 type AuthService struct {
-	db          *sql.DB          // Database connection
-	jwtSecret   string           // Secret key for signing JWT tokens
-	tokenExpiry time.Duration    // Expiry duration for JWT tokens
+	db                 *sql.DB                 // Database connection
+	jwtSecret          string                  // Secret key for signing JWT tokens
+	accessTokenExpiry  time.Duration           // Expiry duration for JWT access tokens
+	refreshTokenExpiry time.Duration           // Expiry duration for refresh tokens
+	revocations        *TokenRevocationService // Store of revoked access tokens
 }
 
 // NewAuthService creates a new instance of AuthService.
 //
 // @param jwtSecret string: The secret key used for signing JWT tokens.
-// @param tokenExpiry time.Duration: The duration for which the JWT token is valid.
+// @param accessTokenExpiry time.Duration: The duration for which a JWT access token is valid.
+// @param refreshTokenExpiry time.Duration: The duration for which a refresh token is valid.
+// @param revocations *TokenRevocationService: The store used to revoke access tokens on logout.
 // @return *AuthService: A new AuthService instance.
-func NewAuthService(jwtSecret string, tokenExpiry time.Duration) *AuthService {
+func NewAuthService(jwtSecret string, accessTokenExpiry, refreshTokenExpiry time.Duration, revocations *TokenRevocationService) *AuthService {
 	return &AuthService{
-		db:          database.DB,
-		jwtSecret:   jwtSecret,
-		tokenExpiry: tokenExpiry,
+		db:                 database.DB,
+		jwtSecret:          jwtSecret,
+		accessTokenExpiry:  accessTokenExpiry,
+		refreshTokenExpiry: refreshTokenExpiry,
+		revocations:        revocations,
 	}
 }
 
 // LoginResponse represents the response structure for a successful login.
 type LoginResponse struct {
-	Token  string `json:"token"`  // JWT token for authenticated user
-	Name   string `json:"name"`   // Full name of the user
-	UserID int64  `json:"user_id"` // ID of the user
-	Role   string `json:"role"`   // Role of the user
+	Token        string `json:"token"`         // JWT access token for authenticated user
+	RefreshToken string `json:"refresh_token"` // Opaque token used to obtain a new access token
+	ExpiresIn    int64  `json:"expires_in"`    // Lifetime of the access token in seconds
+	Name         string `json:"name"`          // Full name of the user
+	UserID       int64  `json:"user_id"`       // ID of the user
+	Role         string `json:"role"`          // Role of the user
 }
 
 // Login authenticates a user and generates a JWT token upon successful authentication.
@@ -43,55 +66,238 @@ type LoginResponse struct {
 // @param username string: The username of the user.
 // @param password string: The password of the user.
 // @return LoginResponse: The response containing the JWT token and user details.
-// @return error: An error if authentication fails or token generation fails.
+// @return error: ErrInvalidCredentials, ErrAccountDeactivated, or an error if token generation fails.
 func (s *AuthService) Login(username, password string) (LoginResponse, error) {
 	var storedPassword string
 	var role string
 	var userID int64
 	var name string
+	var active bool
 
 	// Query the database for the user's credentials and details
-	query := `SELECT id, name, password_hash, role_id FROM users WHERE username = $1`
-	err := s.db.QueryRow(query, username).Scan(&userID, &name, &storedPassword, &role)
+	query := `
+		SELECT u.id, u.name, u.password_hash, COALESCE(r.name, ''), u.active
+		FROM users u
+		LEFT JOIN roles r ON r.id = u.role_id
+		WHERE u.username = $1
+	`
+	err := s.db.QueryRow(query, username).Scan(&userID, &name, &storedPassword, &role, &active)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return LoginResponse{}, errors.New("invalid username or password")
+			return LoginResponse{}, ErrInvalidCredentials
 		}
-		return LoginResponse{}, errors.New("failed to authenticate: " + err.Error())
+		return LoginResponse{}, fmt.Errorf("failed to authenticate: %w", err)
 	}
 
 	// Compare the provided password with the stored hashed password
 	if err := bcrypt.CompareHashAndPassword([]byte(storedPassword), []byte(password)); err != nil {
-		return LoginResponse{}, errors.New("invalid username or password")
+		return LoginResponse{}, ErrInvalidCredentials
+	}
+	if !active {
+		return LoginResponse{}, ErrAccountDeactivated
+	}
+
+	// Start a new refresh token family for this login session
+	familyID, err := randomToken(16)
+	if err != nil {
+		return LoginResponse{}, fmt.Errorf("failed to generate token: %w", err)
+	}
+	return s.issueTokens(s.db, userID, name, role, familyID, nil)
+}
+
+// Refresh exchanges a refresh token for a new access token and a new refresh token.
+//
+// Each refresh token can be used once. Presenting a refresh token that has already been
+// rotated is treated as theft: every token in its family is revoked and the caller must
+// log in again.
+//
+// @param refreshToken string: The refresh token issued by Login or a previous Refresh.
+// @return LoginResponse: The response containing the new tokens and user details.
+// @return error: ErrInvalidRefreshToken, ErrRefreshTokenReused, or an error if token generation fails.
+func (s *AuthService) Refresh(refreshToken string) (LoginResponse, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return LoginResponse{}, fmt.Errorf("failed to refresh token: %w", err)
+	}
+	defer tx.Rollback()
+
+	var (
+		tokenID   int64
+		familyID  string
+		expiresAt time.Time
+		revokedAt sql.NullTime
+		userID    int64
+		name      string
+		role      string
+	)
+	query := `
+		SELECT t.id, t.family_id, t.expires_at, t.revoked_at, u.id, u.name, COALESCE(r.name, '')
+		FROM refresh_tokens t
+		JOIN users u ON u.id = t.user_id
+		LEFT JOIN roles r ON r.id = u.role_id
+		WHERE t.token_hash = $1 AND u.active
+		FOR UPDATE OF t
+	`
+	err = tx.QueryRow(query, hashToken(refreshToken)).Scan(&tokenID, &familyID, &expiresAt, &revokedAt, &userID, &name, &role)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return LoginResponse{}, ErrInvalidRefreshToken
+		}
+		return LoginResponse{}, fmt.Errorf("failed to refresh token: %w", err)
+	}
+
+	// A token that was already rotated or revoked is being replayed: revoke the whole family
+	if revokedAt.Valid {
+		if _, err := tx.Exec(`UPDATE refresh_tokens SET revoked_at = CURRENT_TIMESTAMP WHERE family_id = $1 AND revoked_at IS NULL`, familyID); err != nil {
+			return LoginResponse{}, fmt.Errorf("failed to revoke token family: %w", err)
+		}
+		if err := tx.Commit(); err != nil {
+			return LoginResponse{}, fmt.Errorf("failed to revoke token family: %w", err)
+		}
+		log.Printf("Refresh token reuse detected for user %d, family %s revoked", userID, familyID)
+		return LoginResponse{}, ErrRefreshTokenReused
+	}
+
+	if time.Now().After(expiresAt) {
+		return LoginResponse{}, ErrInvalidRefreshToken
+	}
+
+	response, err := s.issueTokens(tx, userID, name, role, familyID, &tokenID)
+	if err != nil {
+		return LoginResponse{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return LoginResponse{}, fmt.Errorf("failed to refresh token: %w", err)
+	}
+	return response, nil
+}
+
+// Logout ends a single session: the presented access token is revoked and the refresh token
+// family it belongs to can no longer be used.
+//
+// @param ctx context.Context: The context for the request.
+// @param userID int64: The ID of the user logging out.
+// @param tokenID string: The jti of the access token used for the request.
+// @param sessionID string: The sid of the access token, i.e. its refresh token family.
+// @param expiresAt time.Time: When the access token expires.
+// @return error: An error if the operation fails.
+func (s *AuthService) Logout(ctx context.Context, userID int64, tokenID, sessionID string, expiresAt time.Time) error {
+	if err := s.revocations.RevokeToken(ctx, tokenID, userID, expiresAt); err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+
+	query := `UPDATE refresh_tokens SET revoked_at = CURRENT_TIMESTAMP WHERE family_id = $1 AND user_id = $2 AND revoked_at IS NULL`
+	if _, err := s.db.ExecContext(ctx, query, sessionID, userID); err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+	return nil
+}
+
+// LogoutAll ends every session of a user: all access tokens issued so far are revoked along with
+// all outstanding refresh tokens.
+//
+// @param ctx context.Context: The context for the request.
+// @param userID int64: The ID of the user whose sessions are revoked.
+// @return error: An error if the operation fails.
+func (s *AuthService) LogoutAll(ctx context.Context, userID int64) error {
+	if err := s.revocations.RevokeAllForUser(ctx, userID); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	query := `UPDATE refresh_tokens SET revoked_at = CURRENT_TIMESTAMP WHERE user_id = $1 AND revoked_at IS NULL`
+	if _, err := s.db.ExecContext(ctx, query, userID); err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+	return nil
+}
+
+// execer is implemented by both *sql.DB and *sql.Tx.
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// issueTokens generates an access token and persists a new refresh token in the given family.
+// When previousID is set, the previous refresh token is marked as rotated and linked to the new one.
+func (s *AuthService) issueTokens(db execer, userID int64, name, role, familyID string, previousID *int64) (LoginResponse, error) {
+	accessToken, err := s.generateJWT(userID, role, familyID)
+	if err != nil {
+		return LoginResponse{}, fmt.Errorf("failed to generate token: %w", err)
 	}
 
-	// Generate a JWT token for the authenticated user
-	token, err := s.generateJWT(userID, role)
+	refreshToken, err := randomToken(32)
 	if err != nil {
-		return LoginResponse{}, errors.New("failed to generate token: " + err.Error())
+		return LoginResponse{}, fmt.Errorf("failed to generate token: %w", err)
 	}
 
-	// Return the token and user details in the response
+	var newID int64
+	query := `
+		INSERT INTO refresh_tokens (user_id, token_hash, family_id, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`
+	err = db.QueryRow(query, userID, hashToken(refreshToken), familyID, time.Now().Add(s.refreshTokenExpiry)).Scan(&newID)
+	if err != nil {
+		return LoginResponse{}, fmt.Errorf("failed to store refresh token: %w", err)
+	}
+
+	if previousID != nil {
+		_, err := db.Exec(`UPDATE refresh_tokens SET revoked_at = CURRENT_TIMESTAMP, replaced_by = $1 WHERE id = $2`, newID, *previousID)
+		if err != nil {
+			return LoginResponse{}, fmt.Errorf("failed to rotate refresh token: %w", err)
+		}
+	}
+
+	// Return the tokens and user details in the response
 	return LoginResponse{
-		Token:  token,
-		Name:   name,
-		UserID: userID,
-		Role:   role,
+		Token:        accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(s.accessTokenExpiry.Seconds()),
+		Name:         name,
+		UserID:       userID,
+		Role:         role,
 	}, nil
 }
 
 // generateJWT generates a JWT token for the given user ID and role.
 //
+// Every token carries a unique jti so it can be revoked individually, and the sid of the
+// refresh token family (session) it was issued for.
+//
 // @param userID int64: The ID of the user.
 // @param role string: The role of the user.
+// @param sessionID string: The refresh token family the token belongs to.
 // @return string: The generated JWT token.
 // @return error: An error if token generation fails.
-func (s *AuthService) generateJWT(userID int64, role string) (string, error) {
+func (s *AuthService) generateJWT(userID int64, role, sessionID string) (string, error) {
+	jti, err := randomToken(16)
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
 	claims := jwt.MapClaims{
 		"user_id": userID,
 		"role":    role,
-		"exp":     time.Now().Add(s.tokenExpiry).Unix(),
+		"jti":     jti,
+		"sid":     sessionID,
+		"iat":     now.Unix(),
+		"exp":     now.Add(s.accessTokenExpiry).Unix(),
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString([]byte(s.jwtSecret))
-}
\ No newline at end of file
+}
+
+// randomToken returns n cryptographically random bytes encoded as URL-safe base64.
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken returns the hex-encoded SHA-256 digest under which a refresh token is stored.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
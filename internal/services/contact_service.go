@@ -0,0 +1,413 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/Okemwag/medihub/internal/models"
+	"github.com/Okemwag/medihub/internal/validation"
+	"github.com/lib/pq"
+)
+
+// Errors returned by ContactService.
+var (
+	ErrContactNotFound    = NewNotFoundError("contact_not_found", "contact not found")
+	ErrDuplicateNextOfKin = NewConflictError("duplicate_next_of_kin", "the patient already has a next of kin")
+	ErrGuardianNotAllowed = NewValidationError("guardian_not_allowed", fmt.Sprintf("guardians can only be recorded for patients under %d", models.AgeOfMajority))
+)
+
+// ContactService provides methods for managing the next of kin, emergency contacts and guardians
+// of patients.
+type ContactService struct {
+	db    *sql.DB
+	audit *AuditService
+}
+
+// NewContactService creates a new instance of ContactService.
+//
+// @param db *sql.DB: A database connection.
+// @param audit *AuditService: The service used to audit contact access.
+// @return *ContactService: A new ContactService instance.
+func NewContactService(db *sql.DB, audit *AuditService) *ContactService {
+	return &ContactService{db: db, audit: audit}
+}
+
+// contactColumns lists the contact columns in the order expected by scanContact.
+const contactColumns = `id, patient_id, name, relationship, contact_number, COALESCE(alternate_number, ''), COALESCE(email, ''), COALESCE(address, ''), next_of_kin, emergency_contact, guardian, may_receive_information, may_consent_to_treatment, COALESCE(notes, ''), COALESCE(created_by, 0), COALESCE(updated_by, 0), created_at, updated_at`
+
+// scanContact reads a single contact selected with contactColumns.
+func scanContact(row rowScanner) (*models.PatientContact, error) {
+	var c models.PatientContact
+	err := row.Scan(
+		&c.ID,
+		&c.PatientID,
+		&c.Name,
+		&c.Relationship,
+		&c.ContactNumber,
+		&c.AlternateNumber,
+		&c.Email,
+		&c.Address,
+		&c.NextOfKin,
+		&c.EmergencyContact,
+		&c.Guardian,
+		&c.MayReceiveInformation,
+		&c.MayConsentToTreatment,
+		&c.Notes,
+		&c.CreatedBy,
+		&c.UpdatedBy,
+		&c.CreatedAt,
+		&c.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// CreateContact adds a next of kin, emergency contact or guardian to a patient.
+//
+// @param ctx context.Context: The context for the request.
+// @param patientID int64: The ID of the patient.
+// @param contact *models.PatientContact: The contact to record.
+// @return *models.PatientContact: The recorded contact.
+// @return error: ErrPatientNotFound, a validation error when the contact has no role, ErrGuardianNotAllowed, ErrDuplicateNextOfKin, or an error if the operation fails.
+func (s *ContactService) CreateContact(ctx context.Context, patientID int64, contact *models.PatientContact) (*models.PatientContact, error) {
+	if err := prepareContact(contact); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	minor, err := patientIsMinor(ctx, tx, patientID)
+	if err != nil {
+		return nil, err
+	}
+	if contact.Guardian && !minor {
+		return nil, ErrGuardianNotAllowed
+	}
+
+	query := `
+		INSERT INTO patient_contacts (
+			patient_id, name, relationship, contact_number, alternate_number, email, address,
+			next_of_kin, emergency_contact, guardian, may_receive_information, may_consent_to_treatment,
+			notes, created_by, updated_by
+		)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''), NULLIF($7, ''), $8, $9, $10, $11, $12, NULLIF($13, ''), $14, $15)
+		RETURNING id
+	`
+	var id int64
+	err = tx.QueryRowContext(ctx, query,
+		patientID,
+		contact.Name,
+		contact.Relationship,
+		contact.ContactNumber,
+		contact.AlternateNumber,
+		contact.Email,
+		contact.Address,
+		contact.NextOfKin,
+		contact.EmergencyContact,
+		contact.Guardian,
+		contact.MayReceiveInformation,
+		contact.MayConsentToTreatment,
+		contact.Notes,
+		contact.CreatedBy,
+		contact.UpdatedBy,
+	).Scan(&id)
+	if err != nil {
+		return nil, contactWriteError("creating", err)
+	}
+
+	created, err := getContact(ctx, tx, patientID, id, false)
+	if err != nil {
+		return nil, err
+	}
+	err = s.audit.Record(ctx, tx, models.AuditEntry{
+		Action:     "contact.create",
+		EntityType: "contact",
+		EntityID:   &id,
+		Changes:    diffFields(nil, created),
+		PatientID:  &patientID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error creating contact: %v", err)
+		return nil, err
+	}
+	return created, nil
+}
+
+// ListContacts retrieves a patient's contacts: the next of kin first, then guardians and
+// emergency contacts, each by name.
+//
+// @param ctx context.Context: The context for the request.
+// @param patientID int64: The ID of the patient.
+// @return []models.PatientContact: The patient's contacts.
+// @return error: ErrPatientNotFound, or an error if the operation fails.
+func (s *ContactService) ListContacts(ctx context.Context, patientID int64) ([]models.PatientContact, error) {
+	if err := requirePatient(ctx, s.db, patientID); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT ` + contactColumns + `
+		FROM patient_contacts
+		WHERE patient_id = $1
+		ORDER BY next_of_kin DESC, guardian DESC, emergency_contact DESC, name, id
+	`
+	rows, err := s.db.QueryContext(ctx, query, patientID)
+	if err != nil {
+		log.Printf("Error listing contacts: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	contacts := []models.PatientContact{}
+	for rows.Next() {
+		contact, err := scanContact(rows)
+		if err != nil {
+			log.Printf("Error scanning contact: %v", err)
+			return nil, err
+		}
+		contacts = append(contacts, *contact)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating contacts: %v", err)
+		return nil, err
+	}
+
+	err = s.audit.Record(ctx, nil, models.AuditEntry{
+		Action:     "contact.list",
+		EntityType: "patient",
+		EntityID:   &patientID,
+		Details:    map[string]interface{}{"returned": len(contacts)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return contacts, nil
+}
+
+// GetContact retrieves one of a patient's contacts.
+//
+// @param ctx context.Context: The context for the request.
+// @param patientID int64: The ID of the patient.
+// @param id int64: The ID of the contact.
+// @return *models.PatientContact: The contact.
+// @return error: ErrContactNotFound, or an error if the operation fails.
+func (s *ContactService) GetContact(ctx context.Context, patientID, id int64) (*models.PatientContact, error) {
+	contact, err := getContact(ctx, s.db, patientID, id, false)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.audit.Record(ctx, nil, models.AuditEntry{
+		Action:     "contact.read",
+		EntityType: "contact",
+		EntityID:   &id,
+		PatientID:  &patientID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return contact, nil
+}
+
+// UpdateContact replaces the details of one of a patient's contacts, including their roles and
+// consent flags. Guardians recorded while the patient was a minor are kept once the patient comes
+// of age, but a contact cannot be made a guardian of an adult.
+//
+// @param ctx context.Context: The context for the request.
+// @param patientID int64: The ID of the patient.
+// @param id int64: The ID of the contact.
+// @param contact *models.PatientContact: The new details of the contact.
+// @param updatedBy int64: The ID of the user updating the contact.
+// @return *models.PatientContact: The updated contact.
+// @return error: ErrContactNotFound, a validation error when the contact has no role, ErrGuardianNotAllowed, ErrDuplicateNextOfKin, or an error if the operation fails.
+func (s *ContactService) UpdateContact(ctx context.Context, patientID, id int64, contact *models.PatientContact, updatedBy int64) (*models.PatientContact, error) {
+	if err := prepareContact(contact); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	current, err := getContact(ctx, tx, patientID, id, true)
+	if err != nil {
+		return nil, err
+	}
+	if contact.Guardian && !current.Guardian {
+		minor, err := patientIsMinor(ctx, tx, patientID)
+		if err != nil {
+			return nil, err
+		}
+		if !minor {
+			return nil, ErrGuardianNotAllowed
+		}
+	}
+
+	query := `
+		UPDATE patient_contacts
+		SET name = $1, relationship = $2, contact_number = $3, alternate_number = NULLIF($4, ''), email = NULLIF($5, ''), address = NULLIF($6, ''),
+			next_of_kin = $7, emergency_contact = $8, guardian = $9, may_receive_information = $10, may_consent_to_treatment = $11,
+			notes = NULLIF($12, ''), updated_by = $13, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $14
+	`
+	_, err = tx.ExecContext(ctx, query,
+		contact.Name,
+		contact.Relationship,
+		contact.ContactNumber,
+		contact.AlternateNumber,
+		contact.Email,
+		contact.Address,
+		contact.NextOfKin,
+		contact.EmergencyContact,
+		contact.Guardian,
+		contact.MayReceiveInformation,
+		contact.MayConsentToTreatment,
+		contact.Notes,
+		updatedBy,
+		id,
+	)
+	if err != nil {
+		return nil, contactWriteError("updating", err)
+	}
+
+	updated, err := getContact(ctx, tx, patientID, id, false)
+	if err != nil {
+		return nil, err
+	}
+	err = s.audit.Record(ctx, tx, models.AuditEntry{
+		Action:     "contact.update",
+		EntityType: "contact",
+		EntityID:   &id,
+		Changes:    diffFields(current, updated),
+		PatientID:  &patientID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error updating contact: %v", err)
+		return nil, err
+	}
+	return updated, nil
+}
+
+// DeleteContact removes one of a patient's contacts.
+//
+// @param ctx context.Context: The context for the request.
+// @param patientID int64: The ID of the patient.
+// @param id int64: The ID of the contact.
+// @return error: ErrContactNotFound, or an error if the operation fails.
+func (s *ContactService) DeleteContact(ctx context.Context, patientID, id int64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	current, err := getContact(ctx, tx, patientID, id, true)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM patient_contacts WHERE id = $1`, id); err != nil {
+		log.Printf("Error deleting contact: %v", err)
+		return err
+	}
+
+	err = s.audit.Record(ctx, tx, models.AuditEntry{
+		Action:     "contact.delete",
+		EntityType: "contact",
+		EntityID:   &id,
+		Changes:    diffFields(current, nil),
+		PatientID:  &patientID,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error deleting contact: %v", err)
+		return err
+	}
+	return nil
+}
+
+// getContact loads one of a patient's contacts without auditing the access, optionally locking
+// the row for update.
+func getContact(ctx context.Context, db dbtx, patientID, id int64, forUpdate bool) (*models.PatientContact, error) {
+	query := `SELECT ` + contactColumns + ` FROM patient_contacts WHERE id = $1 AND patient_id = $2`
+	if forUpdate {
+		query += ` FOR UPDATE`
+	}
+	contact, err := scanContact(db.QueryRowContext(ctx, query, id, patientID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrContactNotFound
+		}
+		log.Printf("Error retrieving contact: %v", err)
+		return nil, err
+	}
+	return contact, nil
+}
+
+// patientIsMinor reports whether a patient is under the age of majority today.
+func patientIsMinor(ctx context.Context, db dbtx, patientID int64) (bool, error) {
+	query := `SELECT date_of_birth > CURRENT_DATE - make_interval(years => $2) FROM patients WHERE id = $1 AND deleted_at IS NULL`
+	var minor bool
+	err := db.QueryRowContext(ctx, query, patientID, models.AgeOfMajority).Scan(&minor)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, ErrPatientNotFound
+		}
+		log.Printf("Error checking patient age: %v", err)
+		return false, err
+	}
+	return minor, nil
+}
+
+// prepareContact trims the free-text details of a contact and checks that it has at least one role.
+func prepareContact(contact *models.PatientContact) error {
+	contact.Name = strings.TrimSpace(contact.Name)
+	contact.Email = strings.TrimSpace(contact.Email)
+	contact.Address = strings.TrimSpace(contact.Address)
+	contact.Notes = strings.TrimSpace(contact.Notes)
+
+	var fields []validation.FieldError
+	if contact.Name == "" {
+		fields = append(fields, validation.FieldError{Field: "name", Message: "must not be blank"})
+	}
+	if !contact.NextOfKin && !contact.EmergencyContact && !contact.Guardian {
+		fields = append(fields, validation.FieldError{Field: "next_of_kin", Message: "at least one of next_of_kin, emergency_contact or guardian must be set"})
+	}
+
+	if len(fields) > 0 {
+		return &Error{Kind: KindValidation, Code: "invalid_contact", Message: "invalid contact", Fields: fields}
+	}
+	return nil
+}
+
+// contactWriteError translates violations of the next of kin index into ErrDuplicateNextOfKin.
+func contactWriteError(operation string, err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return ErrDuplicateNextOfKin
+	}
+	log.Printf("Error %s contact: %v", operation, err)
+	return err
+}
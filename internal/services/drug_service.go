@@ -0,0 +1,357 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/Okemwag/medihub/internal/models"
+	"github.com/lib/pq"
+)
+
+// Errors returned by DrugService.
+var (
+	ErrDrugNotFound             = NewNotFoundError("drug_not_found", "drug not found")
+	ErrDuplicateDrug            = NewConflictError("duplicate_drug", "a drug with this name, form and strength already exists")
+	ErrInteractionNotFound      = NewNotFoundError("interaction_not_found", "drug interaction not found")
+	ErrDuplicateInteraction     = NewConflictError("duplicate_interaction", "an interaction between these substances already exists")
+	ErrInvalidInteractionTarget = NewValidationError("invalid_interaction", "an interaction needs two different substances")
+)
+
+// likeEscaper escapes the wildcard characters of LIKE patterns.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// DrugService maintains the local drug catalogue and the table of known drug interactions.
+type DrugService struct {
+	db    *sql.DB
+	audit *AuditService
+}
+
+// NewDrugService creates a new instance of DrugService.
+//
+// @param db *sql.DB: A database connection.
+// @param audit *AuditService: The service used to audit catalogue changes.
+// @return *DrugService: A new DrugService instance.
+func NewDrugService(db *sql.DB, audit *AuditService) *DrugService {
+	return &DrugService{db: db, audit: audit}
+}
+
+// drugColumns lists the drug columns in the order expected by scanDrug.
+const drugColumns = `d.id, d.name, d.generic_name, COALESCE(d.drug_class, ''), COALESCE(d.form, ''), COALESCE(d.strength, ''), d.active, d.created_at, d.updated_at`
+
+// scanDrug reads a single drug selected with drugColumns.
+func scanDrug(row rowScanner) (*models.Drug, error) {
+	var drug models.Drug
+	err := row.Scan(&drug.ID, &drug.Name, &drug.GenericName, &drug.DrugClass, &drug.Form, &drug.Strength, &drug.Active, &drug.CreatedAt, &drug.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &drug, nil
+}
+
+// SearchDrugs finds drugs whose name or generic name contains the search term, closest matches first.
+//
+// @param ctx context.Context: The context for the request.
+// @param q string: The search term; every drug matches when empty.
+// @param includeInactive bool: Whether to include drugs that can no longer be prescribed.
+// @param limit int: The maximum number of drugs to return.
+// @return []models.Drug: The matching drugs.
+// @return error: An error if the operation fails.
+func (s *DrugService) SearchDrugs(ctx context.Context, q string, includeInactive bool, limit int) ([]models.Drug, error) {
+	if limit < 1 {
+		limit = DefaultSearchLimit
+	}
+	if limit > MaxSearchLimit {
+		limit = MaxSearchLimit
+	}
+	q = strings.TrimSpace(q)
+
+	query := `
+		SELECT ` + drugColumns + `
+		FROM drugs d
+		WHERE ($1 = '' OR d.name ILIKE '%' || $2 || '%' OR d.generic_name ILIKE '%' || $2 || '%')
+			AND ($3 OR d.active)
+		ORDER BY GREATEST(similarity(d.name, $1), similarity(d.generic_name, $1)) DESC, d.name, d.id
+		LIMIT $4
+	`
+	rows, err := s.db.QueryContext(ctx, query, q, likeEscaper.Replace(q), includeInactive, limit)
+	if err != nil {
+		log.Printf("Error searching drugs: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	drugs := []models.Drug{}
+	for rows.Next() {
+		drug, err := scanDrug(rows)
+		if err != nil {
+			log.Printf("Error scanning drug: %v", err)
+			return nil, err
+		}
+		drugs = append(drugs, *drug)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating drugs: %v", err)
+		return nil, err
+	}
+	return drugs, nil
+}
+
+// GetDrug retrieves a drug by ID.
+//
+// @param ctx context.Context: The context for the request.
+// @param id int64: The ID of the drug.
+// @return *models.Drug: The drug.
+// @return error: ErrDrugNotFound, or an error if the operation fails.
+func (s *DrugService) GetDrug(ctx context.Context, id int64) (*models.Drug, error) {
+	return getDrug(ctx, s.db, id)
+}
+
+// CreateDrug adds a drug to the catalogue. New drugs are always active.
+//
+// @param ctx context.Context: The context for the request.
+// @param drug *models.Drug: The drug to add.
+// @return *models.Drug: The added drug.
+// @return error: ErrDuplicateDrug, or an error if the operation fails.
+func (s *DrugService) CreateDrug(ctx context.Context, drug *models.Drug) (*models.Drug, error) {
+	query := `
+		INSERT INTO drugs (name, generic_name, drug_class, form, strength, active)
+		VALUES ($1, $2, $3, $4, $5, TRUE)
+		RETURNING id
+	`
+	var id int64
+	err := s.db.QueryRowContext(ctx, query,
+		strings.TrimSpace(drug.Name),
+		strings.TrimSpace(drug.GenericName),
+		strings.TrimSpace(drug.DrugClass),
+		drug.Form,
+		drug.Strength,
+	).Scan(&id)
+	if err != nil {
+		return nil, catalogueWriteError("creating drug", err, ErrDuplicateDrug)
+	}
+
+	created, err := getDrug(ctx, s.db, id)
+	if err != nil {
+		return nil, err
+	}
+	err = s.audit.Record(ctx, nil, models.AuditEntry{
+		Action:     "drug.create",
+		EntityType: "drug",
+		EntityID:   &id,
+		Changes:    diffFields(nil, created),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// UpdateDrug replaces the details of a drug, including whether it can still be prescribed.
+//
+// @param ctx context.Context: The context for the request.
+// @param id int64: The ID of the drug.
+// @param drug *models.Drug: The new details of the drug.
+// @return *models.Drug: The updated drug.
+// @return error: ErrDrugNotFound, ErrDuplicateDrug, or an error if the operation fails.
+func (s *DrugService) UpdateDrug(ctx context.Context, id int64, drug *models.Drug) (*models.Drug, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	current, err := getDrug(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		UPDATE drugs
+		SET name = $1, generic_name = $2, drug_class = $3, form = $4, strength = $5, active = $6, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $7
+	`
+	_, err = tx.ExecContext(ctx, query,
+		strings.TrimSpace(drug.Name),
+		strings.TrimSpace(drug.GenericName),
+		strings.TrimSpace(drug.DrugClass),
+		drug.Form,
+		drug.Strength,
+		drug.Active,
+		id,
+	)
+	if err != nil {
+		return nil, catalogueWriteError("updating drug", err, ErrDuplicateDrug)
+	}
+
+	updated, err := getDrug(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	err = s.audit.Record(ctx, tx, models.AuditEntry{
+		Action:     "drug.update",
+		EntityType: "drug",
+		EntityID:   &id,
+		Changes:    diffFields(current, updated),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error updating drug: %v", err)
+		return nil, err
+	}
+	return updated, nil
+}
+
+// ListInteractions retrieves the known drug interactions, optionally only those involving a substance.
+//
+// @param ctx context.Context: The context for the request.
+// @param substance string: Only interactions involving this generic name or drug class, or all when empty.
+// @return []models.DrugInteraction: The interactions, ordered by substance.
+// @return error: An error if the operation fails.
+func (s *DrugService) ListInteractions(ctx context.Context, substance string) ([]models.DrugInteraction, error) {
+	substance = normalizeSubstance(substance)
+	query := `
+		SELECT id, substance_a, substance_b, severity, COALESCE(description, ''), created_at
+		FROM drug_interactions
+		WHERE $1 = '' OR substance_a = $1 OR substance_b = $1
+		ORDER BY substance_a, substance_b
+	`
+	return queryInteractions(ctx, s.db, query, substance)
+}
+
+// CreateInteraction adds a known interaction between two substances.
+//
+// @param ctx context.Context: The context for the request.
+// @param interaction *models.DrugInteraction: The interaction to add; substances may be generic names or drug classes.
+// @return *models.DrugInteraction: The added interaction, with its substances normalized.
+// @return error: ErrInvalidInteractionTarget, ErrDuplicateInteraction, or an error if the operation fails.
+func (s *DrugService) CreateInteraction(ctx context.Context, interaction *models.DrugInteraction) (*models.DrugInteraction, error) {
+	a, b := normalizeSubstance(interaction.SubstanceA), normalizeSubstance(interaction.SubstanceB)
+	if a == b {
+		return nil, ErrInvalidInteractionTarget
+	}
+	if b < a {
+		a, b = b, a
+	}
+	interaction.SubstanceA, interaction.SubstanceB = a, b
+
+	query := `
+		INSERT INTO drug_interactions (substance_a, substance_b, severity, description)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`
+	err := s.db.QueryRowContext(ctx, query, a, b, interaction.Severity, interaction.Description).Scan(&interaction.ID, &interaction.CreatedAt)
+	if err != nil {
+		return nil, catalogueWriteError("creating drug interaction", err, ErrDuplicateInteraction)
+	}
+
+	err = s.audit.Record(ctx, nil, models.AuditEntry{
+		Action:     "drug_interaction.create",
+		EntityType: "drug_interaction",
+		EntityID:   &interaction.ID,
+		Changes:    diffFields(nil, interaction),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return interaction, nil
+}
+
+// DeleteInteraction removes a drug interaction.
+//
+// @param ctx context.Context: The context for the request.
+// @param id int64: The ID of the interaction.
+// @return error: ErrInteractionNotFound, or an error if the operation fails.
+func (s *DrugService) DeleteInteraction(ctx context.Context, id int64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		DELETE FROM drug_interactions WHERE id = $1
+		RETURNING id, substance_a, substance_b, severity, COALESCE(description, ''), created_at
+	`
+	deleted, err := queryInteractions(ctx, tx, query, id)
+	if err != nil {
+		return err
+	}
+	if len(deleted) == 0 {
+		return ErrInteractionNotFound
+	}
+
+	err = s.audit.Record(ctx, tx, models.AuditEntry{
+		Action:     "drug_interaction.delete",
+		EntityType: "drug_interaction",
+		EntityID:   &id,
+		Changes:    diffFields(deleted[0], nil),
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error deleting drug interaction: %v", err)
+		return err
+	}
+	return nil
+}
+
+// getDrug loads a drug by ID.
+func getDrug(ctx context.Context, db dbtx, id int64) (*models.Drug, error) {
+	drug, err := scanDrug(db.QueryRowContext(ctx, `SELECT `+drugColumns+` FROM drugs d WHERE d.id = $1`, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrDrugNotFound
+		}
+		log.Printf("Error retrieving drug: %v", err)
+		return nil, err
+	}
+	return drug, nil
+}
+
+// queryInteractions runs a query selecting drug interaction columns and collects the results.
+func queryInteractions(ctx context.Context, db dbtx, query string, args ...interface{}) ([]models.DrugInteraction, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Printf("Error listing drug interactions: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	interactions := []models.DrugInteraction{}
+	for rows.Next() {
+		var i models.DrugInteraction
+		if err := rows.Scan(&i.ID, &i.SubstanceA, &i.SubstanceB, &i.Severity, &i.Description, &i.CreatedAt); err != nil {
+			log.Printf("Error scanning drug interaction: %v", err)
+			return nil, err
+		}
+		interactions = append(interactions, i)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating drug interactions: %v", err)
+		return nil, err
+	}
+	return interactions, nil
+}
+
+// normalizeSubstance puts a generic name or drug class in the form stored in drug_interactions.
+func normalizeSubstance(substance string) string {
+	return strings.ToLower(strings.TrimSpace(substance))
+}
+
+// catalogueWriteError translates unique violations into the given conflict error.
+func catalogueWriteError(operation string, err error, conflict error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return conflict
+	}
+	log.Printf("Error %s: %v", operation, err)
+	return err
+}
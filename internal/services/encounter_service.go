@@ -0,0 +1,502 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/Okemwag/medihub/internal/models"
+)
+
+// Errors returned by EncounterService.
+var (
+	ErrEncounterNotFound           = NewNotFoundError("encounter_not_found", "encounter not found")
+	ErrEncounterSigned             = NewConflictError("encounter_signed", "signed encounters cannot be changed; add an addendum instead")
+	ErrEncounterNotSigned          = NewConflictError("encounter_not_signed", "addenda can only be added to signed encounters; edit the draft instead")
+	ErrNotAttendingDoctor          = NewForbiddenError("not_attending_doctor", "only the attending doctor can edit or sign this encounter")
+	ErrInvalidEncounterAppointment = NewValidationError("invalid_encounter_appointment", "appointment_id must refer to an appointment of the same patient")
+)
+
+// EncounterService provides methods for documenting clinical encounters.
+type EncounterService struct {
+	db    *sql.DB
+	audit *AuditService
+}
+
+// NewEncounterService creates a new instance of EncounterService.
+//
+// @param db *sql.DB: A database connection.
+// @param audit *AuditService: The service used to audit encounter access.
+// @return *EncounterService: A new EncounterService instance.
+func NewEncounterService(db *sql.DB, audit *AuditService) *EncounterService {
+	return &EncounterService{db: db, audit: audit}
+}
+
+// encounterColumns lists the encounter columns, with the doctor's name, in the order expected by
+// scanEncounter. It must be used with encounterFrom.
+const encounterColumns = `e.id, e.patient_id, e.doctor_id, COALESCE(u.name, ''), e.appointment_id, e.visit_date, COALESCE(e.chief_complaint, ''), COALESCE(e.subjective, ''), COALESCE(e.objective, ''), COALESCE(e.assessment, ''), COALESCE(e.plan, ''), e.diagnoses, e.status, e.signed_at, e.signed_by, COALESCE(e.created_by, 0), COALESCE(e.updated_by, 0), e.created_at, e.updated_at`
+
+// encounterFrom joins encounters to their attending doctor.
+const encounterFrom = `encounters e JOIN users u ON u.id = e.doctor_id`
+
+// scanEncounter reads a single encounter selected with encounterColumns.
+func scanEncounter(row rowScanner) (*models.Encounter, error) {
+	var encounter models.Encounter
+	var diagnoses []byte
+	err := row.Scan(
+		&encounter.ID,
+		&encounter.PatientID,
+		&encounter.DoctorID,
+		&encounter.DoctorName,
+		&encounter.AppointmentID,
+		&encounter.VisitDate,
+		&encounter.ChiefComplaint,
+		&encounter.Subjective,
+		&encounter.Objective,
+		&encounter.Assessment,
+		&encounter.Plan,
+		&diagnoses,
+		&encounter.Status,
+		&encounter.SignedAt,
+		&encounter.SignedBy,
+		&encounter.CreatedBy,
+		&encounter.UpdatedBy,
+		&encounter.CreatedAt,
+		&encounter.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(diagnoses, &encounter.Diagnoses); err != nil {
+		return nil, fmt.Errorf("decoding diagnoses of encounter %d: %w", encounter.ID, err)
+	}
+	return &encounter, nil
+}
+
+// EncounterListResult is a single page of a patient's encounters along with the total number of encounters.
+type EncounterListResult struct {
+	Encounters []models.Encounter `json:"data"`
+	Total      int64              `json:"total"`
+	Page       int                `json:"page"`
+	PageSize   int                `json:"page_size"`
+}
+
+// CreateEncounter starts a draft encounter for a patient.
+//
+// @param ctx context.Context: The context for the request.
+// @param encounter *models.Encounter: The encounter to create; its status is always draft.
+// @return *models.Encounter: The created encounter.
+// @return error: ErrPatientNotFound, ErrInvalidDoctor, ErrInvalidEncounterAppointment, or an error if the operation fails.
+func (s *EncounterService) CreateEncounter(ctx context.Context, encounter *models.Encounter) (*models.Encounter, error) {
+	diagnoses, err := encodeDiagnoses(encounter)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := requirePatient(ctx, tx, encounter.PatientID); err != nil {
+		return nil, err
+	}
+	if err := requireDoctor(ctx, tx, encounter.DoctorID); err != nil {
+		return nil, err
+	}
+	if err := requireEncounterAppointment(ctx, tx, encounter.AppointmentID, encounter.PatientID); err != nil {
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO encounters (patient_id, doctor_id, appointment_id, visit_date, chief_complaint, subjective, objective, assessment, plan, diagnoses, status, created_by, updated_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING id
+	`
+	var id int64
+	err = tx.QueryRowContext(ctx, query,
+		encounter.PatientID,
+		encounter.DoctorID,
+		encounter.AppointmentID,
+		encounter.VisitDate,
+		encounter.ChiefComplaint,
+		encounter.Subjective,
+		encounter.Objective,
+		encounter.Assessment,
+		encounter.Plan,
+		diagnoses,
+		models.EncounterDraft,
+		encounter.CreatedBy,
+		encounter.UpdatedBy,
+	).Scan(&id)
+	if err != nil {
+		log.Printf("Error creating encounter: %v", err)
+		return nil, err
+	}
+
+	created, err := s.getEncounter(ctx, tx, id, false)
+	if err != nil {
+		return nil, err
+	}
+	err = s.audit.Record(ctx, tx, models.AuditEntry{
+		Action:     "encounter.create",
+		EntityType: "encounter",
+		EntityID:   &id,
+		Changes:    diffFields(nil, created),
+		PatientID:  &created.PatientID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error creating encounter: %v", err)
+		return nil, err
+	}
+	return created, nil
+}
+
+// GetEncounter retrieves an encounter by ID, with its vital signs and addenda.
+//
+// @param ctx context.Context: The context for the request.
+// @param id int64: The ID of the encounter.
+// @return *models.Encounter: The encounter.
+// @return error: ErrEncounterNotFound, or an error if the operation fails.
+func (s *EncounterService) GetEncounter(ctx context.Context, id int64) (*models.Encounter, error) {
+	encounter, err := s.getEncounter(ctx, s.db, id, false)
+	if err != nil {
+		return nil, err
+	}
+	if encounter.Vitals, err = queryVitalSigns(ctx, s.db, `WHERE v.encounter_id = $1`, id); err != nil {
+		return nil, err
+	}
+	if encounter.Addenda, err = s.listAddenda(ctx, id); err != nil {
+		return nil, err
+	}
+
+	err = s.audit.Record(ctx, nil, models.AuditEntry{
+		Action:     "encounter.read",
+		EntityType: "encounter",
+		EntityID:   &id,
+		PatientID:  &encounter.PatientID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return encounter, nil
+}
+
+// ListPatientEncounters retrieves a page of a patient's encounters, most recent visit first.
+// Vital signs and addenda are not included; fetch an encounter individually to see them.
+//
+// @param ctx context.Context: The context for the request.
+// @param patientID int64: The ID of the patient.
+// @param page int: The 1-based page number.
+// @param pageSize int: The number of encounters per page.
+// @return *EncounterListResult: The requested page of encounters and the total number of encounters.
+// @return error: ErrPatientNotFound, or an error if the operation fails.
+func (s *EncounterService) ListPatientEncounters(ctx context.Context, patientID int64, page, pageSize int) (*EncounterListResult, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = DefaultPageSize
+	}
+	if pageSize > MaxPageSize {
+		pageSize = MaxPageSize
+	}
+
+	if err := requirePatient(ctx, s.db, patientID); err != nil {
+		return nil, err
+	}
+
+	var total int64
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM encounters WHERE patient_id = $1`, patientID).Scan(&total); err != nil {
+		log.Printf("Error counting encounters: %v", err)
+		return nil, err
+	}
+
+	query := `
+		SELECT ` + encounterColumns + `
+		FROM ` + encounterFrom + `
+		WHERE e.patient_id = $1
+		ORDER BY e.visit_date DESC, e.id DESC
+		LIMIT $2 OFFSET $3
+	`
+	rows, err := s.db.QueryContext(ctx, query, patientID, pageSize, (page-1)*pageSize)
+	if err != nil {
+		log.Printf("Error listing encounters: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	encounters := []models.Encounter{}
+	for rows.Next() {
+		encounter, err := scanEncounter(rows)
+		if err != nil {
+			log.Printf("Error scanning encounter: %v", err)
+			return nil, err
+		}
+		encounters = append(encounters, *encounter)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating encounters: %v", err)
+		return nil, err
+	}
+
+	err = s.audit.Record(ctx, nil, models.AuditEntry{
+		Action:     "encounter.list",
+		EntityType: "patient",
+		EntityID:   &patientID,
+		Details:    map[string]interface{}{"page": page, "page_size": pageSize, "returned": len(encounters)},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &EncounterListResult{Encounters: encounters, Total: total, Page: page, PageSize: pageSize}, nil
+}
+
+// UpdateEncounter replaces the notes and diagnoses of a draft encounter. Only the
+// attending doctor can edit an encounter.
+//
+// @param ctx context.Context: The context for the request.
+// @param id int64: The ID of the encounter.
+// @param changes *models.Encounter: The new visit date, notes and diagnoses.
+// @param updatedBy int64: The ID of the user editing the encounter.
+// @return *models.Encounter: The updated encounter.
+// @return error: ErrEncounterNotFound, ErrEncounterSigned, ErrNotAttendingDoctor, ErrInvalidEncounterAppointment, or an error if the operation fails.
+func (s *EncounterService) UpdateEncounter(ctx context.Context, id int64, changes *models.Encounter, updatedBy int64) (*models.Encounter, error) {
+	diagnoses, err := encodeDiagnoses(changes)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.updateEncounter(ctx, id, updatedBy, "encounter.update", func(tx *sql.Tx, current *models.Encounter) error {
+		if err := requireEncounterAppointment(ctx, tx, changes.AppointmentID, current.PatientID); err != nil {
+			return err
+		}
+		query := `
+			UPDATE encounters
+			SET appointment_id = $1, visit_date = $2, chief_complaint = $3, subjective = $4, objective = $5, assessment = $6, plan = $7,
+				diagnoses = $8, updated_by = $9, updated_at = CURRENT_TIMESTAMP
+			WHERE id = $10
+		`
+		_, err := tx.ExecContext(ctx, query,
+			changes.AppointmentID,
+			changes.VisitDate,
+			changes.ChiefComplaint,
+			changes.Subjective,
+			changes.Objective,
+			changes.Assessment,
+			changes.Plan,
+			diagnoses,
+			updatedBy,
+			id,
+		)
+		if err != nil {
+			log.Printf("Error updating encounter: %v", err)
+		}
+		return err
+	})
+}
+
+// SignEncounter signs a draft encounter, after which it can no longer be changed. Only the
+// attending doctor can sign an encounter.
+//
+// @param ctx context.Context: The context for the request.
+// @param id int64: The ID of the encounter.
+// @param signedBy int64: The ID of the doctor signing the encounter.
+// @return *models.Encounter: The signed encounter.
+// @return error: ErrEncounterNotFound, ErrEncounterSigned, ErrNotAttendingDoctor, or an error if the operation fails.
+func (s *EncounterService) SignEncounter(ctx context.Context, id int64, signedBy int64) (*models.Encounter, error) {
+	return s.updateEncounter(ctx, id, signedBy, "encounter.sign", func(tx *sql.Tx, current *models.Encounter) error {
+		query := `
+			UPDATE encounters
+			SET status = $1, signed_at = CURRENT_TIMESTAMP, signed_by = $2, updated_by = $2, updated_at = CURRENT_TIMESTAMP
+			WHERE id = $3
+		`
+		if _, err := tx.ExecContext(ctx, query, models.EncounterSigned, signedBy, id); err != nil {
+			log.Printf("Error signing encounter: %v", err)
+			return err
+		}
+		return nil
+	})
+}
+
+// updateEncounter locks a draft encounter, checks that userID is its attending doctor, applies a
+// change to it and audits the result.
+func (s *EncounterService) updateEncounter(ctx context.Context, id, userID int64, action string, apply func(tx *sql.Tx, current *models.Encounter) error) (*models.Encounter, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	current, err := s.getEncounter(ctx, tx, id, true)
+	if err != nil {
+		return nil, err
+	}
+	if current.Status == models.EncounterSigned {
+		return nil, ErrEncounterSigned
+	}
+	if current.DoctorID != userID {
+		return nil, ErrNotAttendingDoctor
+	}
+	if err := apply(tx, current); err != nil {
+		return nil, err
+	}
+
+	updated, err := s.getEncounter(ctx, tx, id, false)
+	if err != nil {
+		return nil, err
+	}
+	err = s.audit.Record(ctx, tx, models.AuditEntry{
+		Action:     action,
+		EntityType: "encounter",
+		EntityID:   &id,
+		Changes:    diffFields(current, updated),
+		PatientID:  &updated.PatientID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error updating encounter: %v", err)
+		return nil, err
+	}
+	return updated, nil
+}
+
+// AddAddendum appends a note to a signed encounter.
+//
+// @param ctx context.Context: The context for the request.
+// @param encounterID int64: The ID of the encounter.
+// @param content string: The text of the addendum.
+// @param authorID int64: The ID of the user writing the addendum.
+// @return *models.EncounterAddendum: The stored addendum.
+// @return error: ErrEncounterNotFound, ErrEncounterNotSigned, or an error if the operation fails.
+func (s *EncounterService) AddAddendum(ctx context.Context, encounterID int64, content string, authorID int64) (*models.EncounterAddendum, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	encounter, err := s.getEncounter(ctx, tx, encounterID, false)
+	if err != nil {
+		return nil, err
+	}
+	if encounter.Status != models.EncounterSigned {
+		return nil, ErrEncounterNotSigned
+	}
+
+	addendum := models.EncounterAddendum{EncounterID: encounterID, AuthorID: authorID, Content: content}
+	query := `
+		INSERT INTO encounter_addenda (encounter_id, author_id, content)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at, (SELECT COALESCE(name, '') FROM users WHERE id = $2)
+	`
+	err = tx.QueryRowContext(ctx, query, encounterID, authorID, content).Scan(&addendum.ID, &addendum.CreatedAt, &addendum.AuthorName)
+	if err != nil {
+		log.Printf("Error adding encounter addendum: %v", err)
+		return nil, err
+	}
+
+	err = s.audit.Record(ctx, tx, models.AuditEntry{
+		Action:     "encounter.addendum",
+		EntityType: "encounter",
+		EntityID:   &encounterID,
+		Changes:    diffFields(nil, addendum),
+		PatientID:  &encounter.PatientID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error adding encounter addendum: %v", err)
+		return nil, err
+	}
+	return &addendum, nil
+}
+
+// getEncounter loads an encounter without its addenda and without auditing the access, optionally
+// locking the row for update.
+func (s *EncounterService) getEncounter(ctx context.Context, db dbtx, id int64, forUpdate bool) (*models.Encounter, error) {
+	query := `SELECT ` + encounterColumns + ` FROM ` + encounterFrom + ` WHERE e.id = $1`
+	if forUpdate {
+		query += ` FOR UPDATE OF e`
+	}
+	encounter, err := scanEncounter(db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrEncounterNotFound
+		}
+		log.Printf("Error retrieving encounter: %v", err)
+		return nil, err
+	}
+	return encounter, nil
+}
+
+// listAddenda loads the addenda of an encounter, oldest first.
+func (s *EncounterService) listAddenda(ctx context.Context, encounterID int64) ([]models.EncounterAddendum, error) {
+	query := `
+		SELECT a.id, a.encounter_id, a.author_id, COALESCE(u.name, ''), a.content, a.created_at
+		FROM encounter_addenda a
+		JOIN users u ON u.id = a.author_id
+		WHERE a.encounter_id = $1
+		ORDER BY a.created_at, a.id
+	`
+	rows, err := s.db.QueryContext(ctx, query, encounterID)
+	if err != nil {
+		log.Printf("Error listing encounter addenda: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	addenda := []models.EncounterAddendum{}
+	for rows.Next() {
+		var a models.EncounterAddendum
+		if err := rows.Scan(&a.ID, &a.EncounterID, &a.AuthorID, &a.AuthorName, &a.Content, &a.CreatedAt); err != nil {
+			log.Printf("Error scanning encounter addendum: %v", err)
+			return nil, err
+		}
+		addenda = append(addenda, a)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating encounter addenda: %v", err)
+		return nil, err
+	}
+	return addenda, nil
+}
+
+// encodeDiagnoses serializes an encounter's diagnoses for their JSONB column.
+func encodeDiagnoses(encounter *models.Encounter) (sql.NullString, error) {
+	if encounter.Diagnoses == nil {
+		encounter.Diagnoses = []models.Diagnosis{}
+	}
+	return jsonColumn(encounter.Diagnoses, true)
+}
+
+// requireEncounterAppointment checks that an optional appointment belongs to the patient.
+func requireEncounterAppointment(ctx context.Context, db dbtx, appointmentID *int64, patientID int64) error {
+	if appointmentID == nil {
+		return nil
+	}
+	var found int
+	err := db.QueryRowContext(ctx, `SELECT 1 FROM appointments WHERE id = $1 AND patient_id = $2`, *appointmentID, patientID).Scan(&found)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrInvalidEncounterAppointment
+		}
+		log.Printf("Error checking appointment: %v", err)
+		return err
+	}
+	return nil
+}
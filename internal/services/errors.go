@@ -0,0 +1,86 @@
+package services
+
+import (
+	"github.com/Okemwag/medihub/internal/validation"
+)
+
+// ErrorKind classifies a domain error so that callers can decide how to report it without
+// matching on individual errors.
+type ErrorKind int
+
+const (
+	// KindValidation means the caller supplied invalid input.
+	KindValidation ErrorKind = iota + 1
+	// KindNotFound means the requested entity does not exist.
+	KindNotFound
+	// KindConflict means the request conflicts with the current state of an entity.
+	KindConflict
+	// KindPreconditionFailed means the entity changed since the caller last read it.
+	KindPreconditionFailed
+	// KindUnauthorized means the caller could not be authenticated.
+	KindUnauthorized
+	// KindForbidden means the caller is authenticated but not allowed to perform the operation.
+	KindForbidden
+)
+
+// Error is a domain error with a stable, machine-readable code. Errors that are not of this type
+// are treated as internal failures whose details must not be shown to clients.
+type Error struct {
+	Kind    ErrorKind               // Classification of the error
+	Code    string                  // Stable identifier, e.g. "patient_not_found"
+	Message string                  // Human-readable description, safe to show to clients
+	Fields  []validation.FieldError // Field-level details of a validation error
+	Err     error                   // Underlying cause, if any
+}
+
+// Error returns the message of the error.
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Unwrap returns the underlying cause of the error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// NewValidationError creates an error describing invalid input.
+func NewValidationError(code, message string) *Error {
+	return &Error{Kind: KindValidation, Code: code, Message: message}
+}
+
+// NewNotFoundError creates an error describing a missing entity.
+func NewNotFoundError(code, message string) *Error {
+	return &Error{Kind: KindNotFound, Code: code, Message: message}
+}
+
+// NewConflictError creates an error describing a conflict with the current state of an entity.
+func NewConflictError(code, message string) *Error {
+	return &Error{Kind: KindConflict, Code: code, Message: message}
+}
+
+// NewPreconditionFailedError creates an error describing a stale read.
+func NewPreconditionFailedError(code, message string) *Error {
+	return &Error{Kind: KindPreconditionFailed, Code: code, Message: message}
+}
+
+// NewUnauthorizedError creates an error describing failed authentication.
+func NewUnauthorizedError(code, message string) *Error {
+	return &Error{Kind: KindUnauthorized, Code: code, Message: message}
+}
+
+// NewForbiddenError creates an error describing a denied operation.
+func NewForbiddenError(code, message string) *Error {
+	return &Error{Kind: KindForbidden, Code: code, Message: message}
+}
+
+// InvalidInput wraps a request decoding or struct validation error as a validation error,
+// keeping the field-level details reported by the validator.
+func InvalidInput(err error) *Error {
+	return &Error{
+		Kind:    KindValidation,
+		Code:    "invalid_payload",
+		Message: "invalid request payload",
+		Fields:  validation.FieldErrors(err),
+		Err:     err,
+	}
+}
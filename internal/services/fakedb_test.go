@@ -0,0 +1,88 @@
+package services
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+)
+
+// fakeRows is the result returned by every query run against a database opened with openFakeDB.
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+// fakeDriver serves fixed query results keyed by data source name, so that scanning code can be
+// exercised with the conversions database/sql applies to real driver values.
+type fakeDriver struct {
+	mu      sync.Mutex
+	results map[string]fakeRows
+}
+
+var registerFakeDriver sync.Once
+var fakeDrv = &fakeDriver{results: map[string]fakeRows{}}
+
+// openFakeDB returns a database whose queries all return the given rows.
+func openFakeDB(t *testing.T, columns []string, values ...[]driver.Value) *sql.DB {
+	t.Helper()
+	registerFakeDriver.Do(func() { sql.Register("medihub-fake", fakeDrv) })
+
+	fakeDrv.mu.Lock()
+	fakeDrv.results[t.Name()] = fakeRows{columns: columns, values: values}
+	fakeDrv.mu.Unlock()
+
+	db, err := sql.Open("medihub-fake", t.Name())
+	if err != nil {
+		t.Fatalf("opening fake database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func (d *fakeDriver) Open(name string) (driver.Conn, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	result, ok := d.results[name]
+	if !ok {
+		return nil, errors.New("no fake result registered for " + name)
+	}
+	return &fakeConn{result: result}, nil
+}
+
+type fakeConn struct{ result fakeRows }
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) { return &fakeStmt{result: c.result}, nil }
+func (c *fakeConn) Close() error                        { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions are not supported")
+}
+
+type fakeStmt struct{ result fakeRows }
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+func (s *fakeStmt) Exec([]driver.Value) (driver.Result, error) {
+	return nil, errors.New("exec is not supported")
+}
+func (s *fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	return &fakeRowsCursor{result: s.result}, nil
+}
+
+type fakeRowsCursor struct {
+	result fakeRows
+	next   int
+}
+
+func (r *fakeRowsCursor) Columns() []string { return r.result.columns }
+func (r *fakeRowsCursor) Close() error      { return nil }
+func (r *fakeRowsCursor) Next(dest []driver.Value) error {
+	if r.next >= len(r.result.values) {
+		return io.EOF
+	}
+	copy(dest, r.result.values[r.next])
+	r.next++
+	return nil
+}
@@ -0,0 +1,245 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/Okemwag/medihub/internal/models"
+)
+
+// Errors returned by ICD10Service.
+var (
+	ErrICD10CodeNotFound = NewNotFoundError("icd10_code_not_found", "ICD-10 code not found")
+	ErrInvalidICD10Code  = NewValidationError("invalid_icd10_code", "code must be an active ICD-10 code from the catalogue")
+)
+
+// icd10Pattern matches an ICD-10 code without its dot: a letter, two characters forming the
+// category and up to four characters of subcategory.
+var icd10Pattern = regexp.MustCompile(`^[A-Z][0-9][0-9A-Z][0-9A-Z]{0,4}$`)
+
+// icd10PrefixPattern matches search terms that look like the start of an ICD-10 code.
+var icd10PrefixPattern = regexp.MustCompile(`^[A-Z][0-9][0-9A-Z]{0,5}$`)
+
+// ICD10Service maintains the ICD-10 diagnosis code catalogue.
+type ICD10Service struct {
+	db    *sql.DB
+	audit *AuditService
+}
+
+// NewICD10Service creates a new instance of ICD10Service.
+//
+// @param db *sql.DB: A database connection.
+// @param audit *AuditService: The service used to audit catalogue imports.
+// @return *ICD10Service: A new ICD10Service instance.
+func NewICD10Service(db *sql.DB, audit *AuditService) *ICD10Service {
+	return &ICD10Service{db: db, audit: audit}
+}
+
+// ICD10ImportResult summarizes an import of the ICD-10 catalogue.
+type ICD10ImportResult struct {
+	Imported    int   `json:"imported"`    // Codes added or updated
+	Deactivated int64 `json:"deactivated"` // Codes missing from the file that were marked inactive
+}
+
+// ImportCSV replaces the ICD-10 catalogue with the codes in a CSV file of code and description
+// columns; an optional header row is skipped. Codes may be given with or without their dot.
+// Codes missing from the file are kept, because problem lists may refer to them, but marked
+// inactive. The whole file is rejected if any row is invalid.
+//
+// @param ctx context.Context: The context for the request.
+// @param r io.Reader: The CSV file.
+// @return *ICD10ImportResult: The number of codes imported and deactivated.
+// @return error: An error naming the first invalid row, or an error if the operation fails.
+func (s *ICD10Service) ImportCSV(ctx context.Context, r io.Reader) (*ICD10ImportResult, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// CURRENT_TIMESTAMP is fixed for the transaction, so rows not touched by the import keep an older updated_at
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO icd10_codes (code, description, active)
+		VALUES ($1, $2, TRUE)
+		ON CONFLICT (code) DO UPDATE SET description = EXCLUDED.description, active = TRUE, updated_at = CURRENT_TIMESTAMP
+	`)
+	if err != nil {
+		log.Printf("Error preparing ICD-10 import: %v", err)
+		return nil, err
+	}
+	defer stmt.Close()
+
+	result := &ICD10ImportResult{}
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading ICD-10 file: %w", err)
+		}
+		if len(record) == 1 && strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+		if len(record) < 2 {
+			return nil, fmt.Errorf("line %d: expected code and description columns", line)
+		}
+
+		code, ok := normalizeICD10Code(record[0])
+		if !ok {
+			if line == 1 {
+				continue // Header row
+			}
+			return nil, fmt.Errorf("line %d: invalid ICD-10 code %q", line, record[0])
+		}
+		description := strings.TrimSpace(record[1])
+		if description == "" {
+			return nil, fmt.Errorf("line %d: missing description for %s", line, code)
+		}
+
+		if _, err := stmt.ExecContext(ctx, code, description); err != nil {
+			log.Printf("Error importing ICD-10 code %s: %v", code, err)
+			return nil, err
+		}
+		result.Imported++
+	}
+	if result.Imported == 0 {
+		return nil, errors.New("the ICD-10 file contains no codes")
+	}
+
+	res, err := tx.ExecContext(ctx, `UPDATE icd10_codes SET active = FALSE WHERE active AND updated_at < CURRENT_TIMESTAMP`)
+	if err != nil {
+		log.Printf("Error deactivating ICD-10 codes: %v", err)
+		return nil, err
+	}
+	if result.Deactivated, err = res.RowsAffected(); err != nil {
+		return nil, err
+	}
+
+	err = s.audit.Record(ctx, tx, models.AuditEntry{
+		Action:     "icd10.import",
+		EntityType: "icd10_code",
+		Details:    map[string]interface{}{"imported": result.Imported, "deactivated": result.Deactivated},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error importing ICD-10 codes: %v", err)
+		return nil, err
+	}
+	return result, nil
+}
+
+// SearchCodes finds active ICD-10 codes for autocompletion. Terms that look like a code match
+// codes starting with them; other terms match descriptions, tolerating typos.
+//
+// @param ctx context.Context: The context for the request.
+// @param q string: The search term, e.g. "E11" or "diabetes".
+// @param limit int: The maximum number of codes to return.
+// @return []models.ICD10Code: The matching codes, best matches first.
+// @return error: An error if the operation fails.
+func (s *ICD10Service) SearchCodes(ctx context.Context, q string, limit int) ([]models.ICD10Code, error) {
+	q = strings.TrimSpace(q)
+	if q == "" {
+		return []models.ICD10Code{}, nil
+	}
+	if limit < 1 {
+		limit = DefaultSearchLimit
+	}
+	if limit > MaxSearchLimit {
+		limit = MaxSearchLimit
+	}
+
+	// Match code prefixes only when the term looks like a code; "E11.9", "e119" and "E1" all qualify
+	prefix := strings.ToUpper(strings.ReplaceAll(q, ".", ""))
+	if !icd10PrefixPattern.MatchString(prefix) {
+		prefix = ""
+	} else if len(prefix) > 3 {
+		prefix = prefix[:3] + "." + prefix[3:]
+	}
+
+	query := `
+		SELECT code, description, active, updated_at
+		FROM icd10_codes
+		WHERE active AND (
+			($2 <> '' AND code LIKE $2 || '%')
+			OR description ILIKE '%' || $3 || '%'
+			OR description % $1
+		)
+		ORDER BY ($2 <> '' AND code LIKE $2 || '%') DESC, similarity(description, $1) DESC, code
+		LIMIT $4
+	`
+	rows, err := s.db.QueryContext(ctx, query, q, prefix, likeEscaper.Replace(q), limit)
+	if err != nil {
+		log.Printf("Error searching ICD-10 codes: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	codes := make([]models.ICD10Code, 0, limit)
+	for rows.Next() {
+		var c models.ICD10Code
+		if err := rows.Scan(&c.Code, &c.Description, &c.Active, &c.UpdatedAt); err != nil {
+			log.Printf("Error scanning ICD-10 code: %v", err)
+			return nil, err
+		}
+		codes = append(codes, c)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating ICD-10 codes: %v", err)
+		return nil, err
+	}
+	return codes, nil
+}
+
+// GetCode retrieves an ICD-10 code, whether or not it is still active.
+//
+// @param ctx context.Context: The context for the request.
+// @param code string: The code, with or without its dot.
+// @return *models.ICD10Code: The code.
+// @return error: ErrICD10CodeNotFound, or an error if the operation fails.
+func (s *ICD10Service) GetCode(ctx context.Context, code string) (*models.ICD10Code, error) {
+	normalized, ok := normalizeICD10Code(code)
+	if !ok {
+		return nil, ErrICD10CodeNotFound
+	}
+
+	var c models.ICD10Code
+	query := `SELECT code, description, active, updated_at FROM icd10_codes WHERE code = $1`
+	err := s.db.QueryRowContext(ctx, query, normalized).Scan(&c.Code, &c.Description, &c.Active, &c.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrICD10CodeNotFound
+		}
+		log.Printf("Error retrieving ICD-10 code: %v", err)
+		return nil, err
+	}
+	return &c, nil
+}
+
+// normalizeICD10Code puts a code in its dotted upper-case form, e.g. "e119" becomes "E11.9",
+// reporting whether it is a well-formed ICD-10 code.
+func normalizeICD10Code(code string) (string, bool) {
+	code = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(code), ".", ""))
+	if !icd10Pattern.MatchString(code) {
+		return "", false
+	}
+	if len(code) > 3 {
+		code = code[:3] + "." + code[3:]
+	}
+	return code, true
+}
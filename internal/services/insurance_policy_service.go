@@ -0,0 +1,599 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Okemwag/medihub/internal/models"
+	"github.com/Okemwag/medihub/internal/validation"
+	"github.com/lib/pq"
+)
+
+// Errors returned by InsurancePolicyService.
+var (
+	ErrPolicyNotFound         = NewNotFoundError("policy_not_found", "insurance policy not found")
+	ErrDuplicatePolicy        = NewConflictError("duplicate_policy", "the patient already has a policy with this payer and member number")
+	ErrInvalidPayer           = NewValidationError("invalid_payer", "payer_id must refer to an active payer")
+	ErrInvalidEligibilityDate = NewValidationError("invalid_eligibility_date", "date must be in the format YYYY-MM-DD")
+)
+
+// InsurancePolicyService provides methods for managing patients' insurance policies and checking
+// their eligibility for cover.
+type InsurancePolicyService struct {
+	db    *sql.DB
+	audit *AuditService
+}
+
+// NewInsurancePolicyService creates a new instance of InsurancePolicyService.
+//
+// @param db *sql.DB: A database connection.
+// @param audit *AuditService: The service used to audit policy access.
+// @return *InsurancePolicyService: A new InsurancePolicyService instance.
+func NewInsurancePolicyService(db *sql.DB, audit *AuditService) *InsurancePolicyService {
+	return &InsurancePolicyService{db: db, audit: audit}
+}
+
+// policyColumns lists the policy columns, with the payer's name, in the order expected by
+// scanPolicy. It must be used with policyFrom.
+const policyColumns = `ip.id, ip.patient_id, ip.payer_id, py.name, ip.member_number, COALESCE(ip.scheme, ''), ip.relationship, COALESCE(ip.principal_name, ''), to_char(ip.valid_from, 'YYYY-MM-DD'), COALESCE(to_char(ip.valid_until, 'YYYY-MM-DD'), ''), ip.copay_type, ip.copay_amount, ip.copay_percent, ip.cover_limit, ip.active, COALESCE(ip.notes, ''), COALESCE(ip.created_by, 0), COALESCE(ip.updated_by, 0), ip.created_at, ip.updated_at`
+
+// policyFrom joins policies to their payer.
+const policyFrom = `insurance_policies ip JOIN payers py ON py.id = ip.payer_id`
+
+// scanPolicy reads a single policy selected with policyColumns, followed by any extra columns.
+func scanPolicy(row rowScanner, extra ...interface{}) (*models.InsurancePolicy, error) {
+	var p models.InsurancePolicy
+	dest := []interface{}{
+		&p.ID,
+		&p.PatientID,
+		&p.PayerID,
+		&p.PayerName,
+		&p.MemberNumber,
+		&p.Scheme,
+		&p.Relationship,
+		&p.PrincipalName,
+		&p.ValidFrom,
+		&p.ValidUntil,
+		&p.CopayType,
+		&p.CopayAmount,
+		&p.CopayPercent,
+		&p.CoverLimit,
+		&p.Active,
+		&p.Notes,
+		&p.CreatedBy,
+		&p.UpdatedBy,
+		&p.CreatedAt,
+		&p.UpdatedAt,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// CreatePolicy records an insurance policy for a patient. New policies are always active.
+//
+// @param ctx context.Context: The context for the request.
+// @param patientID int64: The ID of the patient.
+// @param policy *models.InsurancePolicy: The policy to record; relationship defaults to principal and copay_type to none.
+// @return *models.InsurancePolicy: The recorded policy.
+// @return error: ErrPatientNotFound, ErrInvalidPayer, a validation error for inconsistent dates or co-pay rules, ErrDuplicatePolicy, or an error if the operation fails.
+func (s *InsurancePolicyService) CreatePolicy(ctx context.Context, patientID int64, policy *models.InsurancePolicy) (*models.InsurancePolicy, error) {
+	if err := preparePolicy(policy); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := requirePatient(ctx, tx, patientID); err != nil {
+		return nil, err
+	}
+	if err := requireActivePayer(ctx, tx, policy.PayerID); err != nil {
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO insurance_policies (patient_id, payer_id, member_number, scheme, relationship, principal_name, valid_from, valid_until, copay_type, copay_amount, copay_percent, cover_limit, active, notes, created_by, updated_by)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, NULLIF($6, ''), $7::date, NULLIF($8, '')::date, $9, $10, $11, $12, TRUE, NULLIF($13, ''), $14, $15)
+		RETURNING id
+	`
+	var id int64
+	err = tx.QueryRowContext(ctx, query,
+		patientID,
+		policy.PayerID,
+		policy.MemberNumber,
+		policy.Scheme,
+		policy.Relationship,
+		policy.PrincipalName,
+		policy.ValidFrom,
+		policy.ValidUntil,
+		policy.CopayType,
+		policy.CopayAmount,
+		policy.CopayPercent,
+		policy.CoverLimit,
+		policy.Notes,
+		policy.CreatedBy,
+		policy.UpdatedBy,
+	).Scan(&id)
+	if err != nil {
+		return nil, policyWriteError("creating", err)
+	}
+
+	created, err := getPolicy(ctx, tx, patientID, id, false)
+	if err != nil {
+		return nil, err
+	}
+	err = s.audit.Record(ctx, tx, models.AuditEntry{
+		Action:     "insurance_policy.create",
+		EntityType: "insurance_policy",
+		EntityID:   &id,
+		Changes:    diffFields(nil, created),
+		PatientID:  &patientID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error creating insurance policy: %v", err)
+		return nil, err
+	}
+	return created, nil
+}
+
+// ListPolicies retrieves a patient's insurance policies, active and most recent first.
+//
+// @param ctx context.Context: The context for the request.
+// @param patientID int64: The ID of the patient.
+// @return []models.InsurancePolicy: The patient's policies.
+// @return error: ErrPatientNotFound, or an error if the operation fails.
+func (s *InsurancePolicyService) ListPolicies(ctx context.Context, patientID int64) ([]models.InsurancePolicy, error) {
+	if err := requirePatient(ctx, s.db, patientID); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT ` + policyColumns + `
+		FROM ` + policyFrom + `
+		WHERE ip.patient_id = $1
+		ORDER BY ip.active DESC, ip.valid_from DESC, ip.id DESC
+	`
+	rows, err := s.db.QueryContext(ctx, query, patientID)
+	if err != nil {
+		log.Printf("Error listing insurance policies: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	policies := []models.InsurancePolicy{}
+	for rows.Next() {
+		p, err := scanPolicy(rows)
+		if err != nil {
+			log.Printf("Error scanning insurance policy: %v", err)
+			return nil, err
+		}
+		policies = append(policies, *p)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating insurance policies: %v", err)
+		return nil, err
+	}
+
+	err = s.audit.Record(ctx, nil, models.AuditEntry{
+		Action:     "insurance_policy.list",
+		EntityType: "patient",
+		EntityID:   &patientID,
+		Details:    map[string]interface{}{"returned": len(policies)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
+
+// GetPolicy retrieves one of a patient's insurance policies.
+//
+// @param ctx context.Context: The context for the request.
+// @param patientID int64: The ID of the patient.
+// @param id int64: The ID of the policy.
+// @return *models.InsurancePolicy: The policy.
+// @return error: ErrPolicyNotFound, or an error if the operation fails.
+func (s *InsurancePolicyService) GetPolicy(ctx context.Context, patientID, id int64) (*models.InsurancePolicy, error) {
+	policy, err := getPolicy(ctx, s.db, patientID, id, false)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.audit.Record(ctx, nil, models.AuditEntry{
+		Action:     "insurance_policy.read",
+		EntityType: "insurance_policy",
+		EntityID:   &id,
+		PatientID:  &patientID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// UpdatePolicy replaces the details of one of a patient's insurance policies, e.g. to renew it
+// or suspend cover. The payer may only be changed to an active payer.
+//
+// @param ctx context.Context: The context for the request.
+// @param patientID int64: The ID of the patient.
+// @param id int64: The ID of the policy.
+// @param policy *models.InsurancePolicy: The new details of the policy.
+// @param updatedBy int64: The ID of the user updating the policy.
+// @return *models.InsurancePolicy: The updated policy.
+// @return error: ErrPolicyNotFound, ErrInvalidPayer, a validation error for inconsistent dates or co-pay rules, ErrDuplicatePolicy, or an error if the operation fails.
+func (s *InsurancePolicyService) UpdatePolicy(ctx context.Context, patientID, id int64, policy *models.InsurancePolicy, updatedBy int64) (*models.InsurancePolicy, error) {
+	if err := preparePolicy(policy); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	current, err := getPolicy(ctx, tx, patientID, id, true)
+	if err != nil {
+		return nil, err
+	}
+	if policy.PayerID != current.PayerID {
+		if err := requireActivePayer(ctx, tx, policy.PayerID); err != nil {
+			return nil, err
+		}
+	}
+
+	query := `
+		UPDATE insurance_policies
+		SET payer_id = $1, member_number = $2, scheme = NULLIF($3, ''), relationship = $4, principal_name = NULLIF($5, ''),
+			valid_from = $6::date, valid_until = NULLIF($7, '')::date, copay_type = $8, copay_amount = $9, copay_percent = $10,
+			cover_limit = $11, active = $12, notes = NULLIF($13, ''), updated_by = $14, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $15
+	`
+	_, err = tx.ExecContext(ctx, query,
+		policy.PayerID,
+		policy.MemberNumber,
+		policy.Scheme,
+		policy.Relationship,
+		policy.PrincipalName,
+		policy.ValidFrom,
+		policy.ValidUntil,
+		policy.CopayType,
+		policy.CopayAmount,
+		policy.CopayPercent,
+		policy.CoverLimit,
+		policy.Active,
+		policy.Notes,
+		updatedBy,
+		id,
+	)
+	if err != nil {
+		return nil, policyWriteError("updating", err)
+	}
+
+	updated, err := getPolicy(ctx, tx, patientID, id, false)
+	if err != nil {
+		return nil, err
+	}
+	err = s.audit.Record(ctx, tx, models.AuditEntry{
+		Action:     "insurance_policy.update",
+		EntityType: "insurance_policy",
+		EntityID:   &id,
+		Changes:    diffFields(current, updated),
+		PatientID:  &patientID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error updating insurance policy: %v", err)
+		return nil, err
+	}
+	return updated, nil
+}
+
+// DeletePolicy permanently removes one of a patient's insurance policies. Policies that have
+// lapsed should be kept and marked inactive or given an end date instead.
+//
+// @param ctx context.Context: The context for the request.
+// @param patientID int64: The ID of the patient.
+// @param id int64: The ID of the policy.
+// @return error: ErrPolicyNotFound, or an error if the operation fails.
+func (s *InsurancePolicyService) DeletePolicy(ctx context.Context, patientID, id int64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	current, err := getPolicy(ctx, tx, patientID, id, true)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM insurance_policies WHERE id = $1`, id); err != nil {
+		log.Printf("Error deleting insurance policy: %v", err)
+		return err
+	}
+
+	err = s.audit.Record(ctx, tx, models.AuditEntry{
+		Action:     "insurance_policy.delete",
+		EntityType: "insurance_policy",
+		EntityID:   &id,
+		Changes:    diffFields(current, nil),
+		PatientID:  &patientID,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error deleting insurance policy: %v", err)
+		return err
+	}
+	return nil
+}
+
+// CheckEligibility checks which of a patient's policies cover them on a date, e.g. when they
+// arrive for a visit. When an amount is given, each eligible policy's split of it between the
+// patient and the payer is worked out from its co-pay rules and cover limit.
+//
+// @param ctx context.Context: The context for the request.
+// @param patientID int64: The ID of the patient.
+// @param date string: The date of service in the format YYYY-MM-DD, or today when empty.
+// @param amount *models.Money: The amount to split, or nil to check eligibility only.
+// @return *models.EligibilityCheck: The eligibility of each of the patient's policies.
+// @return error: ErrPatientNotFound, ErrInvalidEligibilityDate, a validation error for a negative amount, or an error if the operation fails.
+func (s *InsurancePolicyService) CheckEligibility(ctx context.Context, patientID int64, date string, amount *models.Money) (*models.EligibilityCheck, error) {
+	if date == "" {
+		date = time.Now().Format("2006-01-02")
+	} else if _, err := time.Parse("2006-01-02", date); err != nil {
+		return nil, ErrInvalidEligibilityDate
+	}
+	if amount != nil && (*amount < 0 || *amount > models.MaxMoney) {
+		return nil, NewValidationError("invalid_eligibility_amount", "amount must be between 0 and "+models.MaxMoney.String())
+	}
+	if err := requirePatient(ctx, s.db, patientID); err != nil {
+		return nil, err
+	}
+
+	check, err := checkEligibility(ctx, s.db, patientID, date, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.audit.Record(ctx, nil, models.AuditEntry{
+		Action:     "insurance_policy.eligibility",
+		EntityType: "patient",
+		EntityID:   &patientID,
+		Details:    map[string]interface{}{"date": date, "eligible": check.Eligible},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return check, nil
+}
+
+// CheckInvoiceEligibility checks which of a patient's policies cover an invoice, as of the day
+// it was raised, and how its total is split between the patient and each payer.
+//
+// @param ctx context.Context: The context for the request.
+// @param invoiceID int64: The ID of the invoice.
+// @return *models.EligibilityCheck: The eligibility of each of the patient's policies for the invoice.
+// @return error: ErrInvoiceNotFound, or an error if the operation fails.
+func (s *InsurancePolicyService) CheckInvoiceEligibility(ctx context.Context, invoiceID int64) (*models.EligibilityCheck, error) {
+	invoice, err := getInvoice(ctx, s.db, invoiceID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	date := invoice.CreatedAt.In(time.Local).Format("2006-01-02")
+	check, err := checkEligibility(ctx, s.db, invoice.PatientID, date, &invoice.Total)
+	if err != nil {
+		return nil, err
+	}
+	check.InvoiceID = &invoiceID
+
+	err = s.audit.Record(ctx, nil, models.AuditEntry{
+		Action:     "insurance_policy.eligibility",
+		EntityType: "invoice",
+		EntityID:   &invoiceID,
+		PatientID:  &invoice.PatientID,
+		Details:    map[string]interface{}{"date": date, "eligible": check.Eligible},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return check, nil
+}
+
+// checkEligibility loads a patient's policies and reports the eligibility of each on a date.
+func checkEligibility(ctx context.Context, db dbtx, patientID int64, date string, amount *models.Money) (*models.EligibilityCheck, error) {
+	query := `
+		SELECT ` + policyColumns + `, py.active
+		FROM ` + policyFrom + `
+		WHERE ip.patient_id = $1
+		ORDER BY ip.active DESC, ip.valid_from DESC, ip.id DESC
+	`
+	rows, err := db.QueryContext(ctx, query, patientID)
+	if err != nil {
+		log.Printf("Error listing insurance policies: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	check := &models.EligibilityCheck{PatientID: patientID, Date: date, Amount: amount, Policies: []models.PolicyEligibility{}}
+	for rows.Next() {
+		var payerActive bool
+		p, err := scanPolicy(rows, &payerActive)
+		if err != nil {
+			log.Printf("Error scanning insurance policy: %v", err)
+			return nil, err
+		}
+		e := policyEligibility(p, payerActive, date, amount)
+		check.Eligible = check.Eligible || e.Eligible
+		check.Policies = append(check.Policies, e)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating insurance policies: %v", err)
+		return nil, err
+	}
+	return check, nil
+}
+
+// policyEligibility reports whether a policy covers its patient on a date and, for an eligible
+// policy and a given amount, the patient's and payer's shares of the amount.
+func policyEligibility(p *models.InsurancePolicy, payerActive bool, date string, amount *models.Money) models.PolicyEligibility {
+	e := models.PolicyEligibility{
+		PolicyID:     p.ID,
+		PayerID:      p.PayerID,
+		PayerName:    p.PayerName,
+		MemberNumber: p.MemberNumber,
+		Scheme:       p.Scheme,
+	}
+	if !payerActive {
+		e.Reasons = append(e.Reasons, "the payer no longer covers patients")
+	}
+	if !p.Active {
+		e.Reasons = append(e.Reasons, "the policy is suspended")
+	}
+	if date < p.ValidFrom {
+		e.Reasons = append(e.Reasons, "cover starts on "+p.ValidFrom)
+	}
+	if p.ValidUntil != "" && date > p.ValidUntil {
+		e.Reasons = append(e.Reasons, "cover ended on "+p.ValidUntil)
+	}
+	e.Eligible = len(e.Reasons) == 0
+	if !e.Eligible || amount == nil {
+		return e
+	}
+
+	var patient models.Money
+	switch p.CopayType {
+	case models.CopayFixed:
+		patient = min(p.CopayAmount, *amount)
+	case models.CopayPercentage:
+		// Round the co-pay to the nearest cent
+		patient = (*amount*models.Money(p.CopayPercent) + 50) / 100
+	}
+	payer := *amount - patient
+	if p.CoverLimit != nil && payer > *p.CoverLimit {
+		patient += payer - *p.CoverLimit
+		payer = *p.CoverLimit
+	}
+	e.PatientShare = &patient
+	e.PayerShare = &payer
+	return e
+}
+
+// getPolicy loads one of a patient's policies without auditing the access, optionally locking
+// the row for update.
+func getPolicy(ctx context.Context, db dbtx, patientID, id int64, forUpdate bool) (*models.InsurancePolicy, error) {
+	query := `SELECT ` + policyColumns + ` FROM ` + policyFrom + ` WHERE ip.id = $1 AND ip.patient_id = $2`
+	if forUpdate {
+		query += ` FOR UPDATE OF ip`
+	}
+	policy, err := scanPolicy(db.QueryRowContext(ctx, query, id, patientID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrPolicyNotFound
+		}
+		log.Printf("Error retrieving insurance policy: %v", err)
+		return nil, err
+	}
+	return policy, nil
+}
+
+// requireActivePayer checks that a payer exists and still covers patients.
+func requireActivePayer(ctx context.Context, db dbtx, payerID int64) error {
+	payer, err := getPayer(ctx, db, payerID)
+	if err != nil {
+		if errors.Is(err, ErrPayerNotFound) {
+			return ErrInvalidPayer
+		}
+		return err
+	}
+	if !payer.Active {
+		return ErrInvalidPayer
+	}
+	return nil
+}
+
+// preparePolicy applies defaults to a policy and checks the rules the request binding cannot,
+// reporting every problem as a field error.
+func preparePolicy(policy *models.InsurancePolicy) error {
+	policy.MemberNumber = strings.ToUpper(strings.TrimSpace(policy.MemberNumber))
+	policy.Scheme = strings.TrimSpace(policy.Scheme)
+	policy.PrincipalName = strings.TrimSpace(policy.PrincipalName)
+	if policy.Relationship == "" {
+		policy.Relationship = models.PolicyPrincipal
+	}
+	if policy.CopayType == "" {
+		policy.CopayType = models.CopayNone
+	}
+
+	var fields []validation.FieldError
+	switch {
+	case policy.Relationship == models.PolicyDependant && policy.PrincipalName == "":
+		fields = append(fields, validation.FieldError{Field: "principal_name", Message: "is required for dependants"})
+	case policy.Relationship == models.PolicyPrincipal && policy.PrincipalName != "":
+		fields = append(fields, validation.FieldError{Field: "principal_name", Message: "is only allowed for dependants"})
+	}
+	if policy.ValidUntil != "" && policy.ValidUntil < policy.ValidFrom {
+		fields = append(fields, validation.FieldError{Field: "valid_until", Message: "must not be before valid_from"})
+	}
+
+	switch policy.CopayType {
+	case models.CopayNone:
+		if policy.CopayAmount != 0 {
+			fields = append(fields, validation.FieldError{Field: "copay_amount", Message: "must be 0 without a co-pay"})
+		}
+		if policy.CopayPercent != 0 {
+			fields = append(fields, validation.FieldError{Field: "copay_percent", Message: "must be 0 without a co-pay"})
+		}
+	case models.CopayFixed:
+		if policy.CopayAmount <= 0 || policy.CopayAmount > models.MaxMoney {
+			fields = append(fields, validation.FieldError{Field: "copay_amount", Message: "must be greater than 0 and at most " + models.MaxMoney.String() + " for a fixed co-pay"})
+		}
+		if policy.CopayPercent != 0 {
+			fields = append(fields, validation.FieldError{Field: "copay_percent", Message: "must be 0 for a fixed co-pay"})
+		}
+	case models.CopayPercentage:
+		if policy.CopayAmount != 0 {
+			fields = append(fields, validation.FieldError{Field: "copay_amount", Message: "must be 0 for a percentage co-pay"})
+		}
+		if policy.CopayPercent < 1 {
+			fields = append(fields, validation.FieldError{Field: "copay_percent", Message: "must be at least 1 for a percentage co-pay"})
+		}
+	}
+	if policy.CoverLimit != nil && (*policy.CoverLimit < 0 || *policy.CoverLimit > models.MaxMoney) {
+		fields = append(fields, validation.FieldError{Field: "cover_limit", Message: "must be between 0 and " + models.MaxMoney.String()})
+	}
+
+	if len(fields) > 0 {
+		return &Error{Kind: KindValidation, Code: "invalid_policy", Message: "invalid insurance policy", Fields: fields}
+	}
+	return nil
+}
+
+// policyWriteError translates violations of the unique member number constraint into ErrDuplicatePolicy.
+func policyWriteError(operation string, err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return ErrDuplicatePolicy
+	}
+	log.Printf("Error %s insurance policy: %v", operation, err)
+	return err
+}
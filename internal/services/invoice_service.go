@@ -0,0 +1,817 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/Okemwag/medihub/internal/models"
+	"github.com/Okemwag/medihub/internal/validation"
+	"github.com/lib/pq"
+)
+
+// Errors returned by InvoiceService.
+var (
+	ErrInvoiceNotFound           = NewNotFoundError("invoice_not_found", "invoice not found")
+	ErrReceiptNotFound           = NewNotFoundError("receipt_not_found", "receipt not found")
+	ErrInvalidInvoiceEncounter   = NewValidationError("invalid_invoice_encounter", "encounter_id must refer to an encounter of the same patient")
+	ErrInvalidInvoiceQuery       = NewValidationError("invalid_invoice_query", "status must be outstanding, draft, issued, partially_paid, paid or void")
+	ErrInvalidInvoiceTransition  = NewConflictError("invalid_invoice_transition", "the invoice cannot move to the requested status")
+	ErrInvoiceNotPayable         = NewConflictError("invoice_not_payable", "only issued invoices with a balance can be paid")
+	ErrPaymentExceedsBalance     = NewConflictError("payment_exceeds_balance", "the payment is more than the balance of the invoice")
+	ErrDuplicatePaymentReference = NewConflictError("duplicate_payment_reference", "a payment with this mobile money reference has already been recorded")
+)
+
+// invoiceTransitions lists the statuses an invoice may be moved to from each status by editing,
+// issuing or voiding it; payments move issued invoices on to partially paid and paid. Drafts can
+// be edited until they are issued.
+var invoiceTransitions = map[string][]string{
+	models.InvoiceDraft:  {models.InvoiceDraft, models.InvoiceIssued, models.InvoiceVoid},
+	models.InvoiceIssued: {models.InvoiceVoid},
+}
+
+// invoiceOutstandingStatuses are the statuses of invoices still awaiting payment.
+var invoiceOutstandingStatuses = []string{models.InvoiceIssued, models.InvoicePartiallyPaid}
+
+// InvoiceService provides methods for billing patients and recording their payments.
+type InvoiceService struct {
+	db    *sql.DB
+	audit *AuditService
+}
+
+// NewInvoiceService creates a new instance of InvoiceService.
+//
+// @param db *sql.DB: A database connection.
+// @param audit *AuditService: The service used to audit billing.
+// @return *InvoiceService: A new InvoiceService instance.
+func NewInvoiceService(db *sql.DB, audit *AuditService) *InvoiceService {
+	return &InvoiceService{db: db, audit: audit}
+}
+
+// invoiceColumns lists the invoice columns, with the patient and creator, in the order expected
+// by scanInvoice. It must be used with invoiceFrom.
+const invoiceColumns = `i.id, i.invoice_number, i.patient_id, p.first_name || ' ' || p.last_name, i.encounter_id, i.status, COALESCE(i.notes, ''), i.total, i.amount_paid, i.created_by, COALESCE(u.name, ''), i.issued_at, i.issued_by, i.voided_at, i.voided_by, COALESCE(i.void_reason, ''), i.created_at, i.updated_at`
+
+// invoiceFrom joins invoices to their patient and creator.
+const invoiceFrom = `invoices i JOIN patients p ON p.id = i.patient_id LEFT JOIN users u ON u.id = i.created_by`
+
+// scanInvoice reads a single invoice selected with invoiceColumns.
+func scanInvoice(row rowScanner) (*models.Invoice, error) {
+	var inv models.Invoice
+	err := row.Scan(
+		&inv.ID,
+		&inv.InvoiceNumber,
+		&inv.PatientID,
+		&inv.PatientName,
+		&inv.EncounterID,
+		&inv.Status,
+		&inv.Notes,
+		&inv.Total,
+		&inv.AmountPaid,
+		&inv.CreatedBy,
+		&inv.CreatedByName,
+		&inv.IssuedAt,
+		&inv.IssuedBy,
+		&inv.VoidedAt,
+		&inv.VoidedBy,
+		&inv.VoidReason,
+		&inv.CreatedAt,
+		&inv.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	inv.Balance = inv.Total - inv.AmountPaid
+	return &inv, nil
+}
+
+// InvoiceListParams describes the filters and pagination for an invoice listing.
+type InvoiceListParams struct {
+	PatientID *int64 `json:"patient_id,omitempty"` // Only invoices of this patient
+	Status    string `json:"status,omitempty"`     // Only invoices in this status; outstanding means issued or partially paid
+	Page      int    `json:"page"`                 // 1-based page number
+	PageSize  int    `json:"page_size"`            // Number of invoices per page
+}
+
+// InvoiceListResult is a single page of invoices along with the total number of matches.
+type InvoiceListResult struct {
+	Invoices []models.Invoice `json:"data"`
+	Total    int64            `json:"total"`
+	Page     int              `json:"page"`
+	PageSize int              `json:"page_size"`
+}
+
+// CreateInvoice creates a draft invoice for a patient. Items for catalogue services are priced
+// from the catalogue; the invoice total is the sum of its items.
+//
+// @param ctx context.Context: The context for the request.
+// @param invoice *models.Invoice: The invoice to create, with its patient, creator and items.
+// @return *models.Invoice: The created invoice with its items.
+// @return error: ErrPatientNotFound, ErrInvalidInvoiceEncounter, a validation error for invalid items, or an error if the operation fails.
+func (s *InvoiceService) CreateInvoice(ctx context.Context, invoice *models.Invoice) (*models.Invoice, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := requirePatient(ctx, tx, invoice.PatientID); err != nil {
+		return nil, err
+	}
+	if err := requirePatientEncounter(ctx, tx, invoice.EncounterID, invoice.PatientID, ErrInvalidInvoiceEncounter); err != nil {
+		return nil, err
+	}
+	items, total, err := buildInvoiceItems(ctx, tx, invoice.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO invoices (patient_id, encounter_id, status, notes, total, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`
+	var id int64
+	err = tx.QueryRowContext(ctx, query,
+		invoice.PatientID,
+		invoice.EncounterID,
+		models.InvoiceDraft,
+		invoice.Notes,
+		total,
+		invoice.CreatedBy,
+	).Scan(&id)
+	if err != nil {
+		log.Printf("Error creating invoice: %v", err)
+		return nil, err
+	}
+	if err := replaceInvoiceItems(ctx, tx, id, items); err != nil {
+		return nil, err
+	}
+
+	created, err := getInvoice(ctx, tx, id, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := attachInvoiceDetails(ctx, tx, created); err != nil {
+		return nil, err
+	}
+	err = s.audit.Record(ctx, tx, models.AuditEntry{
+		Action:     "invoice.create",
+		EntityType: "invoice",
+		EntityID:   &id,
+		Changes:    diffFields(nil, created),
+		PatientID:  &created.PatientID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error creating invoice: %v", err)
+		return nil, err
+	}
+	return created, nil
+}
+
+// GetInvoice retrieves an invoice by ID, with its items and payments.
+//
+// @param ctx context.Context: The context for the request.
+// @param id int64: The ID of the invoice.
+// @return *models.Invoice: The invoice.
+// @return error: ErrInvoiceNotFound, or an error if the operation fails.
+func (s *InvoiceService) GetInvoice(ctx context.Context, id int64) (*models.Invoice, error) {
+	invoice, err := getInvoice(ctx, s.db, id, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := attachInvoiceDetails(ctx, s.db, invoice); err != nil {
+		return nil, err
+	}
+
+	err = s.audit.Record(ctx, nil, models.AuditEntry{
+		Action:     "invoice.read",
+		EntityType: "invoice",
+		EntityID:   &id,
+		PatientID:  &invoice.PatientID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return invoice, nil
+}
+
+// ListInvoices retrieves a page of invoices, most recent first. Items and payments are not
+// included; fetch an invoice individually to see them.
+//
+// @param ctx context.Context: The context for the request.
+// @param params InvoiceListParams: The filters and pagination to apply.
+// @return *InvoiceListResult: The requested page of invoices and the total number of matches.
+// @return error: ErrInvalidInvoiceQuery, or an error if the operation fails.
+func (s *InvoiceService) ListInvoices(ctx context.Context, params InvoiceListParams) (*InvoiceListResult, error) {
+	result, err := s.listInvoices(ctx, &params)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.audit.Record(ctx, nil, models.AuditEntry{
+		Action:     "invoice.list",
+		EntityType: "invoice",
+		Details:    map[string]interface{}{"params": params, "returned": len(result.Invoices)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ListPatientInvoices retrieves a page of a patient's invoices, most recent first.
+//
+// @param ctx context.Context: The context for the request.
+// @param patientID int64: The ID of the patient.
+// @param status string: Only invoices in this status, or all invoices when empty.
+// @param page int: The 1-based page number.
+// @param pageSize int: The number of invoices per page.
+// @return *InvoiceListResult: The requested page of invoices and the total number of invoices.
+// @return error: ErrPatientNotFound, ErrInvalidInvoiceQuery, or an error if the operation fails.
+func (s *InvoiceService) ListPatientInvoices(ctx context.Context, patientID int64, status string, page, pageSize int) (*InvoiceListResult, error) {
+	if err := requirePatient(ctx, s.db, patientID); err != nil {
+		return nil, err
+	}
+
+	params := InvoiceListParams{PatientID: &patientID, Status: status, Page: page, PageSize: pageSize}
+	result, err := s.listInvoices(ctx, &params)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.audit.Record(ctx, nil, models.AuditEntry{
+		Action:     "invoice.list",
+		EntityType: "patient",
+		EntityID:   &patientID,
+		Details:    map[string]interface{}{"status": status, "page": params.Page, "page_size": params.PageSize, "returned": len(result.Invoices)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// UpdateInvoice replaces the encounter, notes and items of a draft invoice.
+//
+// @param ctx context.Context: The context for the request.
+// @param id int64: The ID of the invoice.
+// @param invoice *models.Invoice: The new details of the invoice.
+// @return *models.Invoice: The updated invoice with its items.
+// @return error: ErrInvoiceNotFound, ErrInvalidInvoiceTransition if the invoice is no longer a draft, ErrInvalidInvoiceEncounter, a validation error for invalid items, or an error if the operation fails.
+func (s *InvoiceService) UpdateInvoice(ctx context.Context, id int64, invoice *models.Invoice) (*models.Invoice, error) {
+	return s.updateInvoice(ctx, id, models.InvoiceDraft, "invoice.update", func(tx *sql.Tx, current *models.Invoice) error {
+		if err := requirePatientEncounter(ctx, tx, invoice.EncounterID, current.PatientID, ErrInvalidInvoiceEncounter); err != nil {
+			return err
+		}
+		items, total, err := buildInvoiceItems(ctx, tx, invoice.Items)
+		if err != nil {
+			return err
+		}
+
+		query := `
+			UPDATE invoices
+			SET encounter_id = $1, notes = $2, total = $3, updated_at = CURRENT_TIMESTAMP
+			WHERE id = $4
+		`
+		if _, err := tx.ExecContext(ctx, query, invoice.EncounterID, invoice.Notes, total, id); err != nil {
+			log.Printf("Error updating invoice: %v", err)
+			return err
+		}
+		return replaceInvoiceItems(ctx, tx, id, items)
+	})
+}
+
+// IssueInvoice issues a draft invoice to the patient, after which it can be paid but no longer
+// edited. Invoices with nothing to pay are marked paid straight away.
+//
+// @param ctx context.Context: The context for the request.
+// @param id int64: The ID of the invoice.
+// @param issuedBy int64: The ID of the user issuing the invoice.
+// @return *models.Invoice: The issued invoice.
+// @return error: ErrInvoiceNotFound, ErrInvalidInvoiceTransition, or an error if the operation fails.
+func (s *InvoiceService) IssueInvoice(ctx context.Context, id int64, issuedBy int64) (*models.Invoice, error) {
+	return s.updateInvoice(ctx, id, models.InvoiceIssued, "invoice.issue", func(tx *sql.Tx, current *models.Invoice) error {
+		status := models.InvoiceIssued
+		if current.Total == 0 {
+			status = models.InvoicePaid
+		}
+		query := `
+			UPDATE invoices
+			SET status = $1, issued_at = CURRENT_TIMESTAMP, issued_by = $2, updated_at = CURRENT_TIMESTAMP
+			WHERE id = $3
+		`
+		if _, err := tx.ExecContext(ctx, query, status, issuedBy, id); err != nil {
+			log.Printf("Error issuing invoice: %v", err)
+			return err
+		}
+		return nil
+	})
+}
+
+// VoidInvoice voids an invoice raised in error. Invoices that have received payments cannot be voided.
+//
+// @param ctx context.Context: The context for the request.
+// @param id int64: The ID of the invoice.
+// @param reason string: Why the invoice is voided.
+// @param voidedBy int64: The ID of the user voiding the invoice.
+// @return *models.Invoice: The voided invoice.
+// @return error: ErrInvoiceNotFound, ErrInvalidInvoiceTransition, or an error if the operation fails.
+func (s *InvoiceService) VoidInvoice(ctx context.Context, id int64, reason string, voidedBy int64) (*models.Invoice, error) {
+	return s.updateInvoice(ctx, id, models.InvoiceVoid, "invoice.void", func(tx *sql.Tx, current *models.Invoice) error {
+		query := `
+			UPDATE invoices
+			SET status = $1, voided_at = CURRENT_TIMESTAMP, voided_by = $2, void_reason = $3, updated_at = CURRENT_TIMESTAMP
+			WHERE id = $4
+		`
+		if _, err := tx.ExecContext(ctx, query, models.InvoiceVoid, voidedBy, reason, id); err != nil {
+			log.Printf("Error voiding invoice: %v", err)
+			return err
+		}
+		return nil
+	})
+}
+
+// RecordPayment records a payment against an issued invoice and returns its receipt. Partial
+// payments are allowed, but a payment may not exceed the balance of the invoice.
+//
+// @param ctx context.Context: The context for the request.
+// @param invoiceID int64: The ID of the invoice being paid.
+// @param payment *models.Payment: The payment, with its amount, method, reference and receiver.
+// @return *models.Receipt: The receipt for the payment.
+// @return error: ErrInvoiceNotFound, a validation error for an invalid amount or missing reference, ErrInvoiceNotPayable, ErrPaymentExceedsBalance, ErrDuplicatePaymentReference, or an error if the operation fails.
+func (s *InvoiceService) RecordPayment(ctx context.Context, invoiceID int64, payment *models.Payment) (*models.Receipt, error) {
+	payment.Reference = strings.TrimSpace(payment.Reference)
+	if payment.Method == models.PaymentMobileMoney {
+		payment.Reference = strings.ToUpper(payment.Reference)
+	}
+	if err := validatePayment(payment); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	current, err := getInvoice(ctx, tx, invoiceID, true)
+	if err != nil {
+		return nil, err
+	}
+	if current.Status != models.InvoiceIssued && current.Status != models.InvoicePartiallyPaid {
+		return nil, fmt.Errorf("%w: the invoice is %s", ErrInvoiceNotPayable, current.Status)
+	}
+	status, err := paymentStatus(current.Balance, payment.Amount)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO payments (invoice_id, amount, method, reference, received_by)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5)
+		RETURNING receipt_number
+	`
+	var receiptNumber string
+	err = tx.QueryRowContext(ctx, query, invoiceID, payment.Amount, payment.Method, payment.Reference, payment.ReceivedBy).Scan(&receiptNumber)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return nil, ErrDuplicatePaymentReference
+		}
+		log.Printf("Error recording payment: %v", err)
+		return nil, err
+	}
+
+	query = `
+		UPDATE invoices
+		SET amount_paid = amount_paid + $1, status = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $3
+	`
+	if _, err := tx.ExecContext(ctx, query, payment.Amount, status, invoiceID); err != nil {
+		log.Printf("Error recording payment: %v", err)
+		return nil, err
+	}
+
+	receipt, err := getReceipt(ctx, tx, receiptNumber)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := getInvoice(ctx, tx, invoiceID, false)
+	if err != nil {
+		return nil, err
+	}
+	err = s.audit.Record(ctx, tx, models.AuditEntry{
+		Action:     "invoice.payment",
+		EntityType: "invoice",
+		EntityID:   &invoiceID,
+		PatientID:  &current.PatientID,
+		Changes:    diffFields(current, updated),
+		Details: map[string]interface{}{
+			"receipt_number": receipt.ReceiptNumber,
+			"amount":         receipt.Amount,
+			"method":         receipt.Method,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error recording payment: %v", err)
+		return nil, err
+	}
+	return receipt, nil
+}
+
+// GetReceipt retrieves the receipt for a payment, e.g. to print it again.
+//
+// @param ctx context.Context: The context for the request.
+// @param receiptNumber string: The receipt number, e.g. "RCT-000456".
+// @return *models.Receipt: The receipt.
+// @return error: ErrReceiptNotFound, or an error if the operation fails.
+func (s *InvoiceService) GetReceipt(ctx context.Context, receiptNumber string) (*models.Receipt, error) {
+	receipt, err := getReceipt(ctx, s.db, strings.ToUpper(strings.TrimSpace(receiptNumber)))
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.audit.Record(ctx, nil, models.AuditEntry{
+		Action:     "receipt.read",
+		EntityType: "invoice",
+		EntityID:   &receipt.InvoiceID,
+		PatientID:  &receipt.PatientID,
+		Details:    map[string]interface{}{"receipt_number": receipt.ReceiptNumber},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return receipt, nil
+}
+
+// updateInvoice locks an invoice, checks that it may move to the given status, applies a change
+// to it and audits the result.
+func (s *InvoiceService) updateInvoice(ctx context.Context, id int64, status, action string, apply func(tx *sql.Tx, current *models.Invoice) error) (*models.Invoice, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	current, err := getInvoice(ctx, tx, id, true)
+	if err != nil {
+		return nil, err
+	}
+	allowed := false
+	for _, next := range invoiceTransitions[current.Status] {
+		if next == status {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return nil, fmt.Errorf("%w: %s to %s", ErrInvalidInvoiceTransition, current.Status, status)
+	}
+	if err := attachInvoiceDetails(ctx, tx, current); err != nil {
+		return nil, err
+	}
+	if err := apply(tx, current); err != nil {
+		return nil, err
+	}
+
+	updated, err := getInvoice(ctx, tx, id, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := attachInvoiceDetails(ctx, tx, updated); err != nil {
+		return nil, err
+	}
+	err = s.audit.Record(ctx, tx, models.AuditEntry{
+		Action:     action,
+		EntityType: "invoice",
+		EntityID:   &id,
+		Changes:    diffFields(current, updated),
+		PatientID:  &updated.PatientID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error updating invoice: %v", err)
+		return nil, err
+	}
+	return updated, nil
+}
+
+// listInvoices loads a page of invoices matching the params, most recent first, normalizing the
+// pagination in params.
+func (s *InvoiceService) listInvoices(ctx context.Context, params *InvoiceListParams) (*InvoiceListResult, error) {
+	if params.Page < 1 {
+		params.Page = 1
+	}
+	if params.PageSize < 1 {
+		params.PageSize = DefaultPageSize
+	}
+	if params.PageSize > MaxPageSize {
+		params.PageSize = MaxPageSize
+	}
+
+	var conditions []string
+	var args []interface{}
+	add := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if params.PatientID != nil {
+		add("i.patient_id = $%d", *params.PatientID)
+	}
+	switch params.Status {
+	case "":
+	case "outstanding":
+		add("i.status = ANY($%d)", pq.Array(invoiceOutstandingStatuses))
+	case models.InvoiceDraft, models.InvoiceIssued, models.InvoicePartiallyPaid, models.InvoicePaid, models.InvoiceVoid:
+		add("i.status = $%d", params.Status)
+	default:
+		return nil, ErrInvalidInvoiceQuery
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int64
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM invoices i `+where, args...).Scan(&total); err != nil {
+		log.Printf("Error counting invoices: %v", err)
+		return nil, err
+	}
+
+	args = append(args, params.PageSize, (params.Page-1)*params.PageSize)
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM %s
+		%s
+		ORDER BY i.created_at DESC, i.id DESC
+		LIMIT $%d OFFSET $%d
+	`, invoiceColumns, invoiceFrom, where, len(args)-1, len(args))
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Printf("Error listing invoices: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	invoices := []models.Invoice{}
+	for rows.Next() {
+		invoice, err := scanInvoice(rows)
+		if err != nil {
+			log.Printf("Error scanning invoice: %v", err)
+			return nil, err
+		}
+		invoices = append(invoices, *invoice)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating invoices: %v", err)
+		return nil, err
+	}
+
+	return &InvoiceListResult{Invoices: invoices, Total: total, Page: params.Page, PageSize: params.PageSize}, nil
+}
+
+// getInvoice loads an invoice by ID, without its items and payments, optionally locking it for update.
+func getInvoice(ctx context.Context, db dbtx, id int64, forUpdate bool) (*models.Invoice, error) {
+	query := `SELECT ` + invoiceColumns + ` FROM ` + invoiceFrom + ` WHERE i.id = $1`
+	if forUpdate {
+		query += ` FOR UPDATE OF i`
+	}
+	invoice, err := scanInvoice(db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvoiceNotFound
+		}
+		log.Printf("Error retrieving invoice: %v", err)
+		return nil, err
+	}
+	return invoice, nil
+}
+
+// attachInvoiceDetails loads the items and payments of an invoice.
+func attachInvoiceDetails(ctx context.Context, db dbtx, invoice *models.Invoice) error {
+	query := `
+		SELECT ii.id, ii.service_id, COALESCE(bs.code, ''), ii.description, ii.quantity, ii.unit_price, ii.amount
+		FROM invoice_items ii
+		LEFT JOIN billable_services bs ON bs.id = ii.service_id
+		WHERE ii.invoice_id = $1
+		ORDER BY ii.position
+	`
+	rows, err := db.QueryContext(ctx, query, invoice.ID)
+	if err != nil {
+		log.Printf("Error listing invoice items: %v", err)
+		return err
+	}
+	defer rows.Close()
+
+	invoice.Items = []models.InvoiceItem{}
+	for rows.Next() {
+		var item models.InvoiceItem
+		var unitPrice models.Money
+		if err := rows.Scan(&item.ID, &item.ServiceID, &item.ServiceCode, &item.Description, &item.Quantity, &unitPrice, &item.Amount); err != nil {
+			log.Printf("Error scanning invoice item: %v", err)
+			return err
+		}
+		item.UnitPrice = &unitPrice
+		invoice.Items = append(invoice.Items, item)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating invoice items: %v", err)
+		return err
+	}
+
+	query = `
+		SELECT pm.id, pm.invoice_id, pm.receipt_number, pm.amount, pm.method, COALESCE(pm.reference, ''), pm.received_by, COALESCE(u.name, ''), pm.received_at
+		FROM payments pm
+		LEFT JOIN users u ON u.id = pm.received_by
+		WHERE pm.invoice_id = $1
+		ORDER BY pm.id
+	`
+	rows, err = db.QueryContext(ctx, query, invoice.ID)
+	if err != nil {
+		log.Printf("Error listing payments: %v", err)
+		return err
+	}
+	defer rows.Close()
+
+	invoice.Payments = []models.Payment{}
+	for rows.Next() {
+		var p models.Payment
+		if err := rows.Scan(&p.ID, &p.InvoiceID, &p.ReceiptNumber, &p.Amount, &p.Method, &p.Reference, &p.ReceivedBy, &p.ReceivedByName, &p.ReceivedAt); err != nil {
+			log.Printf("Error scanning payment: %v", err)
+			return err
+		}
+		invoice.Payments = append(invoice.Payments, p)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating payments: %v", err)
+		return err
+	}
+	return nil
+}
+
+// getReceipt loads the receipt for a payment, with the invoice balance right after the payment.
+func getReceipt(ctx context.Context, db dbtx, receiptNumber string) (*models.Receipt, error) {
+	query := `
+		SELECT pm.receipt_number, i.id, i.invoice_number, i.patient_id, p.first_name || ' ' || p.last_name,
+			pm.amount, pm.method, COALESCE(pm.reference, ''), pm.received_at, COALESCE(u.name, ''), i.total,
+			(SELECT SUM(earlier.amount) FROM payments earlier WHERE earlier.invoice_id = pm.invoice_id AND earlier.id <= pm.id)
+		FROM payments pm
+		JOIN invoices i ON i.id = pm.invoice_id
+		JOIN patients p ON p.id = i.patient_id
+		LEFT JOIN users u ON u.id = pm.received_by
+		WHERE pm.receipt_number = $1
+	`
+	var r models.Receipt
+	err := db.QueryRowContext(ctx, query, receiptNumber).Scan(
+		&r.ReceiptNumber,
+		&r.InvoiceID,
+		&r.InvoiceNumber,
+		&r.PatientID,
+		&r.PatientName,
+		&r.Amount,
+		&r.Method,
+		&r.Reference,
+		&r.ReceivedAt,
+		&r.ReceivedByName,
+		&r.InvoiceTotal,
+		&r.PaidToDate,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrReceiptNotFound
+		}
+		log.Printf("Error retrieving receipt: %v", err)
+		return nil, err
+	}
+	r.Balance = r.InvoiceTotal - r.PaidToDate
+	return &r, nil
+}
+
+// buildInvoiceItems prices the items of an invoice, copying the name and price of catalogue
+// services, and totals them. Every problem is reported as a field error.
+func buildInvoiceItems(ctx context.Context, db dbtx, items []models.InvoiceItem) ([]models.InvoiceItem, models.Money, error) {
+	var fields []validation.FieldError
+	built := make([]models.InvoiceItem, 0, len(items))
+	var total models.Money
+	for i, item := range items {
+		field := fmt.Sprintf("items[%d]", i)
+		item.Description = strings.TrimSpace(item.Description)
+
+		if item.ServiceID != nil {
+			if item.UnitPrice != nil {
+				fields = append(fields, validation.FieldError{Field: field + ".unit_price", Message: "is taken from the catalogue for catalogue services"})
+				continue
+			}
+			svc, err := getBillableService(ctx, db, *item.ServiceID)
+			if err != nil && !errors.Is(err, ErrBillableServiceNotFound) {
+				return nil, 0, err
+			}
+			if err != nil || !svc.Active {
+				fields = append(fields, validation.FieldError{Field: field + ".service_id", Message: "must refer to an active service in the catalogue"})
+				continue
+			}
+			price := svc.Price
+			item.UnitPrice = &price
+			item.ServiceCode = svc.Code
+			if item.Description == "" {
+				item.Description = svc.Name
+			}
+		} else {
+			valid := true
+			if item.Description == "" {
+				fields = append(fields, validation.FieldError{Field: field + ".description", Message: "is required for items not in the catalogue"})
+				valid = false
+			}
+			switch {
+			case item.UnitPrice == nil:
+				fields = append(fields, validation.FieldError{Field: field + ".unit_price", Message: "is required for items not in the catalogue"})
+				valid = false
+			case *item.UnitPrice < 0:
+				fields = append(fields, validation.FieldError{Field: field + ".unit_price", Message: "must not be negative"})
+				valid = false
+			case *item.UnitPrice > models.MaxMoney:
+				fields = append(fields, validation.FieldError{Field: field + ".unit_price", Message: "must be at most " + models.MaxMoney.String()})
+				valid = false
+			}
+			if !valid {
+				continue
+			}
+		}
+
+		item.Amount = *item.UnitPrice * models.Money(item.Quantity)
+		total += item.Amount
+		built = append(built, item)
+	}
+	if len(fields) == 0 && total > models.MaxMoney {
+		fields = append(fields, validation.FieldError{Field: "items", Message: "must total at most " + models.MaxMoney.String()})
+	}
+	if len(fields) > 0 {
+		return nil, 0, &Error{Kind: KindValidation, Code: "invalid_invoice_items", Message: "invalid invoice items", Fields: fields}
+	}
+	return built, total, nil
+}
+
+// replaceInvoiceItems stores the items of an invoice, replacing any it had.
+func replaceInvoiceItems(ctx context.Context, tx *sql.Tx, invoiceID int64, items []models.InvoiceItem) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM invoice_items WHERE invoice_id = $1`, invoiceID); err != nil {
+		log.Printf("Error replacing invoice items: %v", err)
+		return err
+	}
+	query := `
+		INSERT INTO invoice_items (invoice_id, position, service_id, description, quantity, unit_price, amount)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	for i, item := range items {
+		_, err := tx.ExecContext(ctx, query, invoiceID, i+1, item.ServiceID, item.Description, item.Quantity, *item.UnitPrice, item.Amount)
+		if err != nil {
+			log.Printf("Error creating invoice item: %v", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// validatePayment checks the amount and reference of a payment.
+func validatePayment(payment *models.Payment) error {
+	var fields []validation.FieldError
+	switch {
+	case payment.Amount <= 0:
+		fields = append(fields, validation.FieldError{Field: "amount", Message: "must be greater than 0"})
+	case payment.Amount > models.MaxMoney:
+		fields = append(fields, validation.FieldError{Field: "amount", Message: "must be at most " + models.MaxMoney.String()})
+	}
+	if payment.Method == models.PaymentMobileMoney && payment.Reference == "" {
+		fields = append(fields, validation.FieldError{Field: "reference", Message: "is required for mobile money payments"})
+	}
+	if len(fields) > 0 {
+		return &Error{Kind: KindValidation, Code: "invalid_payment", Message: "invalid payment", Fields: fields}
+	}
+	return nil
+}
+
+// paymentStatus returns the status of an invoice with the given balance once a payment of amount
+// is received: paid when it settles the balance exactly, partially paid otherwise.
+func paymentStatus(balance, amount models.Money) (string, error) {
+	if amount > balance {
+		return "", fmt.Errorf("%w: the balance is %s", ErrPaymentExceedsBalance, balance)
+	}
+	if amount == balance {
+		return models.InvoicePaid, nil
+	}
+	return models.InvoicePartiallyPaid, nil
+}
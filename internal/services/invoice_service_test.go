@@ -0,0 +1,183 @@
+package services
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Okemwag/medihub/internal/models"
+)
+
+// billableServiceColumnNames names the columns selected with billableServiceColumns.
+var billableServiceColumnNames = []string{"id", "code", "name", "category", "price", "active", "created_at", "updated_at"}
+
+// billableServiceRow returns the values Postgres returns for a catalogue service selected with
+// billableServiceColumns, with the price as NUMERIC text.
+func billableServiceRow(price string, active bool) []driver.Value {
+	now := time.Now()
+	return []driver.Value{int64(1), "CONS-GP", "GP consultation", "consultation", []byte(price), active, now, now}
+}
+
+func money(m models.Money) *models.Money {
+	return &m
+}
+
+func TestBuildInvoiceItems(t *testing.T) {
+	serviceID := int64(1)
+
+	tests := []struct {
+		name       string
+		catalogue  [][]driver.Value // Rows returned when looking up catalogue services
+		items      []models.InvoiceItem
+		wantAmount []models.Money
+		wantTotal  models.Money
+		wantFields []string
+	}{
+		{
+			name:       "items not in the catalogue",
+			items:      []models.InvoiceItem{{Description: " Dressing ", Quantity: 2, UnitPrice: money(25050)}, {Description: "Syringe", Quantity: 3, UnitPrice: money(5)}},
+			wantAmount: []models.Money{50100, 15},
+			wantTotal:  50115,
+		},
+		{
+			name:       "catalogue service priced from NUMERIC",
+			catalogue:  [][]driver.Value{billableServiceRow("1500.50", true)},
+			items:      []models.InvoiceItem{{ServiceID: &serviceID, Quantity: 2}},
+			wantAmount: []models.Money{300100},
+			wantTotal:  300100,
+		},
+		{
+			name:       "unit price at the maximum",
+			items:      []models.InvoiceItem{{Description: "Surgery", Quantity: 1, UnitPrice: money(models.MaxMoney)}},
+			wantAmount: []models.Money{models.MaxMoney},
+			wantTotal:  models.MaxMoney,
+		},
+		{
+			name:       "free item",
+			items:      []models.InvoiceItem{{Description: "Follow-up", Quantity: 1, UnitPrice: money(0)}},
+			wantAmount: []models.Money{0},
+		},
+		{
+			name:       "unit price above the maximum",
+			items:      []models.InvoiceItem{{Description: "Surgery", Quantity: 1, UnitPrice: money(models.MaxMoney + 1)}},
+			wantFields: []string{"items[0].unit_price"},
+		},
+		{
+			name:       "total above the maximum",
+			items:      []models.InvoiceItem{{Description: "Surgery", Quantity: 2, UnitPrice: money(models.MaxMoney)}},
+			wantFields: []string{"items"},
+		},
+		{
+			name:       "negative unit price",
+			items:      []models.InvoiceItem{{Description: "Discount", Quantity: 1, UnitPrice: money(-5)}},
+			wantFields: []string{"items[0].unit_price"},
+		},
+		{
+			name:       "item not in the catalogue without description or price",
+			items:      []models.InvoiceItem{{Description: " ", Quantity: 1}},
+			wantFields: []string{"items[0].description", "items[0].unit_price"},
+		},
+		{
+			name:       "catalogue service with its own price",
+			catalogue:  [][]driver.Value{billableServiceRow("1500.00", true)},
+			items:      []models.InvoiceItem{{ServiceID: &serviceID, Quantity: 1, UnitPrice: money(100)}},
+			wantFields: []string{"items[0].unit_price"},
+		},
+		{
+			name:       "inactive catalogue service",
+			catalogue:  [][]driver.Value{billableServiceRow("1500.00", false)},
+			items:      []models.InvoiceItem{{ServiceID: &serviceID, Quantity: 1}},
+			wantFields: []string{"items[0].service_id"},
+		},
+		{
+			name:       "unknown catalogue service",
+			items:      []models.InvoiceItem{{Description: "Dressing", Quantity: 1, UnitPrice: money(100)}, {ServiceID: &serviceID, Quantity: 1}},
+			wantFields: []string{"items[1].service_id"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openFakeDB(t, billableServiceColumnNames, tt.catalogue...)
+
+			built, total, err := buildInvoiceItems(context.Background(), db, tt.items)
+			if tt.wantFields != nil {
+				var domainErr *Error
+				if !errors.As(err, &domainErr) || domainErr.Kind != KindValidation {
+					t.Fatalf("buildInvoiceItems() error = %v, want a validation error", err)
+				}
+				var fields []string
+				for _, f := range domainErr.Fields {
+					fields = append(fields, f.Field)
+				}
+				if strings.Join(fields, ",") != strings.Join(tt.wantFields, ",") {
+					t.Errorf("buildInvoiceItems() fields = %v, want %v", fields, tt.wantFields)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("buildInvoiceItems() error = %v", err)
+			}
+			if total != tt.wantTotal {
+				t.Errorf("buildInvoiceItems() total = %s, want %s", total, tt.wantTotal)
+			}
+			if len(built) != len(tt.wantAmount) {
+				t.Fatalf("buildInvoiceItems() returned %d items, want %d", len(built), len(tt.wantAmount))
+			}
+			for i, item := range built {
+				if item.Amount != tt.wantAmount[i] {
+					t.Errorf("item %d amount = %s, want %s", i, item.Amount, tt.wantAmount[i])
+				}
+			}
+		})
+	}
+}
+
+func TestBuildInvoiceItemsCopiesCatalogueService(t *testing.T) {
+	serviceID := int64(1)
+	db := openFakeDB(t, billableServiceColumnNames, billableServiceRow("1500.00", true))
+
+	built, _, err := buildInvoiceItems(context.Background(), db, []models.InvoiceItem{{ServiceID: &serviceID, Quantity: 1}})
+	if err != nil {
+		t.Fatalf("buildInvoiceItems() error = %v", err)
+	}
+	item := built[0]
+	if item.Description != "GP consultation" || item.ServiceCode != "CONS-GP" || item.UnitPrice == nil || *item.UnitPrice != 150000 {
+		t.Errorf("buildInvoiceItems() item = %+v, want the catalogue name, code and price", item)
+	}
+}
+
+func TestPaymentStatus(t *testing.T) {
+	// Three partial payments of an invoice of 1000.10 that bring the balance to exactly zero
+	balance := models.Money(100010)
+	payments := []struct {
+		amount models.Money
+		want   string
+	}{
+		{amount: 50000, want: models.InvoicePartiallyPaid},
+		{amount: 50009, want: models.InvoicePartiallyPaid},
+		{amount: 1, want: models.InvoicePaid},
+	}
+	for _, p := range payments {
+		status, err := paymentStatus(balance, p.amount)
+		if err != nil {
+			t.Fatalf("paymentStatus(%s, %s) error = %v", balance, p.amount, err)
+		}
+		if status != p.want {
+			t.Errorf("paymentStatus(%s, %s) = %q, want %q", balance, p.amount, status, p.want)
+		}
+		balance -= p.amount
+	}
+	if balance != 0 {
+		t.Errorf("balance after payments = %s, want 0.00", balance)
+	}
+
+	if _, err := paymentStatus(models.Money(100), models.Money(101)); !errors.Is(err, ErrPaymentExceedsBalance) {
+		t.Errorf("paymentStatus() error = %v, want ErrPaymentExceedsBalance", err)
+	}
+	if _, err := paymentStatus(0, 1); !errors.Is(err, ErrPaymentExceedsBalance) {
+		t.Errorf("paymentStatus() on a settled invoice error = %v, want ErrPaymentExceedsBalance", err)
+	}
+}
@@ -0,0 +1,693 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/Okemwag/medihub/internal/models"
+	"github.com/Okemwag/medihub/internal/validation"
+	"github.com/lib/pq"
+)
+
+// Errors returned by LabOrderService.
+var (
+	ErrLabOrderNotFound          = NewNotFoundError("lab_order_not_found", "lab order not found")
+	ErrInvalidLabTest            = NewValidationError("invalid_lab_test", "test_id must refer to an active lab test in the catalogue")
+	ErrInvalidLabOrderEncounter  = NewValidationError("invalid_lab_order_encounter", "encounter_id must refer to an encounter of the same patient")
+	ErrInvalidLabOrderQuery      = NewValidationError("invalid_lab_order_query", "status must be open, ordered, collected, resulted, verified or cancelled and priority routine, urgent or stat")
+	ErrInvalidLabOrderTransition = NewConflictError("invalid_lab_order_transition", "the lab order cannot move to the requested status")
+	ErrLabResultSelfVerification = NewConflictError("lab_result_self_verification", "results must be verified by someone other than the user who entered them")
+)
+
+// labOrderTransitions lists the statuses a lab order may move to from each status. Results can
+// be corrected by entering them again until they are verified.
+var labOrderTransitions = map[string][]string{
+	models.LabOrderOrdered:   {models.LabOrderCollected, models.LabOrderCancelled},
+	models.LabOrderCollected: {models.LabOrderResulted, models.LabOrderCancelled},
+	models.LabOrderResulted:  {models.LabOrderResulted, models.LabOrderVerified},
+}
+
+// labOrderOpenStatuses are the statuses of orders still awaiting work by the laboratory.
+var labOrderOpenStatuses = []string{models.LabOrderOrdered, models.LabOrderCollected, models.LabOrderResulted}
+
+// LabOrderService provides methods for ordering lab investigations and managing their results.
+type LabOrderService struct {
+	db    *sql.DB
+	audit *AuditService
+}
+
+// NewLabOrderService creates a new instance of LabOrderService.
+//
+// @param db *sql.DB: A database connection.
+// @param audit *AuditService: The service used to audit lab order access.
+// @return *LabOrderService: A new LabOrderService instance.
+func NewLabOrderService(db *sql.DB, audit *AuditService) *LabOrderService {
+	return &LabOrderService{db: db, audit: audit}
+}
+
+// labOrderColumns lists the lab order columns, with the test and ordering doctor, in the order
+// expected by scanLabOrder. It must be used with labOrderFrom.
+const labOrderColumns = `o.id, o.patient_id, o.encounter_id, o.test_id, t.code, t.name, o.priority, o.status, COALESCE(o.clinical_notes, ''), o.ordered_by, COALESCE(u.name, ''), o.collected_at, o.collected_by, o.resulted_at, o.resulted_by, o.verified_at, o.verified_by, o.cancelled_at, o.cancelled_by, COALESCE(o.cancellation_reason, ''), EXISTS (SELECT 1 FROM lab_results r WHERE r.order_id = o.id AND r.flag <> 'normal'), o.created_at, o.updated_at`
+
+// labOrderFrom joins lab orders to their test and ordering doctor.
+const labOrderFrom = `lab_orders o JOIN lab_tests t ON t.id = o.test_id LEFT JOIN users u ON u.id = o.ordered_by`
+
+// labOrderPriorityOrder sorts lab orders from the most to the least urgent.
+const labOrderPriorityOrder = `CASE o.priority WHEN 'stat' THEN 0 WHEN 'urgent' THEN 1 ELSE 2 END`
+
+// scanLabOrder reads a single lab order selected with labOrderColumns.
+func scanLabOrder(row rowScanner) (*models.LabOrder, error) {
+	var o models.LabOrder
+	err := row.Scan(
+		&o.ID,
+		&o.PatientID,
+		&o.EncounterID,
+		&o.TestID,
+		&o.TestCode,
+		&o.TestName,
+		&o.Priority,
+		&o.Status,
+		&o.ClinicalNotes,
+		&o.OrderedBy,
+		&o.OrderedByName,
+		&o.CollectedAt,
+		&o.CollectedBy,
+		&o.ResultedAt,
+		&o.ResultedBy,
+		&o.VerifiedAt,
+		&o.VerifiedBy,
+		&o.CancelledAt,
+		&o.CancelledBy,
+		&o.CancellationReason,
+		&o.Abnormal,
+		&o.CreatedAt,
+		&o.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+// LabOrderListParams describes the filters and pagination for a lab order listing.
+type LabOrderListParams struct {
+	PatientID *int64 `json:"patient_id,omitempty"` // Only orders of this patient
+	OrderedBy *int64 `json:"ordered_by,omitempty"` // Only orders placed by this doctor
+	Status    string `json:"status,omitempty"`     // Only orders in this status; open means not yet verified or cancelled
+	Priority  string `json:"priority,omitempty"`   // Only orders of this priority
+	Page      int    `json:"page"`                 // 1-based page number
+	PageSize  int    `json:"page_size"`            // Number of orders per page
+}
+
+// LabOrderListResult is a single page of lab orders along with the total number of matches.
+type LabOrderListResult struct {
+	Orders   []models.LabOrder `json:"data"`
+	Total    int64             `json:"total"`
+	Page     int               `json:"page"`
+	PageSize int               `json:"page_size"`
+}
+
+// CreateLabOrder orders a lab investigation for a patient.
+//
+// @param ctx context.Context: The context for the request.
+// @param order *models.LabOrder: The order to place; priority defaults to routine and status is always ordered.
+// @return *models.LabOrder: The placed order.
+// @return error: ErrPatientNotFound, ErrInvalidDoctor, ErrInvalidLabTest, ErrInvalidLabOrderEncounter, or an error if the operation fails.
+func (s *LabOrderService) CreateLabOrder(ctx context.Context, order *models.LabOrder) (*models.LabOrder, error) {
+	if order.Priority == "" {
+		order.Priority = "routine"
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := requirePatient(ctx, tx, order.PatientID); err != nil {
+		return nil, err
+	}
+	if err := requireDoctor(ctx, tx, order.OrderedBy); err != nil {
+		return nil, err
+	}
+	if err := requirePatientEncounter(ctx, tx, order.EncounterID, order.PatientID, ErrInvalidLabOrderEncounter); err != nil {
+		return nil, err
+	}
+	test, err := getLabTest(ctx, tx, order.TestID)
+	if err != nil {
+		if errors.Is(err, ErrLabTestNotFound) {
+			return nil, ErrInvalidLabTest
+		}
+		return nil, err
+	}
+	if !test.Active {
+		return nil, ErrInvalidLabTest
+	}
+
+	query := `
+		INSERT INTO lab_orders (patient_id, encounter_id, test_id, priority, status, clinical_notes, ordered_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`
+	var id int64
+	err = tx.QueryRowContext(ctx, query,
+		order.PatientID,
+		order.EncounterID,
+		order.TestID,
+		order.Priority,
+		models.LabOrderOrdered,
+		order.ClinicalNotes,
+		order.OrderedBy,
+	).Scan(&id)
+	if err != nil {
+		log.Printf("Error creating lab order: %v", err)
+		return nil, err
+	}
+
+	created, err := getLabOrder(ctx, tx, id, false)
+	if err != nil {
+		return nil, err
+	}
+	err = s.audit.Record(ctx, tx, models.AuditEntry{
+		Action:     "lab_order.create",
+		EntityType: "lab_order",
+		EntityID:   &id,
+		Changes:    diffFields(nil, created),
+		PatientID:  &created.PatientID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error creating lab order: %v", err)
+		return nil, err
+	}
+	return created, nil
+}
+
+// GetLabOrder retrieves a lab order by ID, with its results.
+//
+// @param ctx context.Context: The context for the request.
+// @param id int64: The ID of the order.
+// @return *models.LabOrder: The order.
+// @return error: ErrLabOrderNotFound, or an error if the operation fails.
+func (s *LabOrderService) GetLabOrder(ctx context.Context, id int64) (*models.LabOrder, error) {
+	order, err := getLabOrder(ctx, s.db, id, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := attachLabResults(ctx, s.db, []*models.LabOrder{order}); err != nil {
+		return nil, err
+	}
+
+	err = s.audit.Record(ctx, nil, models.AuditEntry{
+		Action:     "lab_order.read",
+		EntityType: "lab_order",
+		EntityID:   &id,
+		PatientID:  &order.PatientID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// ListLabOrders retrieves a page of lab orders, most urgent and oldest first, as a worklist for
+// the laboratory. Results are not included; fetch an order individually to see them.
+//
+// @param ctx context.Context: The context for the request.
+// @param params LabOrderListParams: The filters and pagination to apply.
+// @return *LabOrderListResult: The requested page of orders and the total number of matches.
+// @return error: ErrInvalidLabOrderQuery, or an error if the operation fails.
+func (s *LabOrderService) ListLabOrders(ctx context.Context, params LabOrderListParams) (*LabOrderListResult, error) {
+	result, err := s.listLabOrders(ctx, &params, labOrderPriorityOrder+`, o.created_at, o.id`)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.audit.Record(ctx, nil, models.AuditEntry{
+		Action:     "lab_order.list",
+		EntityType: "lab_order",
+		Details:    map[string]interface{}{"params": params, "returned": len(result.Orders)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ListPatientLabOrders retrieves a page of a patient's lab orders with their results, most recent first.
+//
+// @param ctx context.Context: The context for the request.
+// @param patientID int64: The ID of the patient.
+// @param status string: Only orders in this status, or all orders when empty.
+// @param page int: The 1-based page number.
+// @param pageSize int: The number of orders per page.
+// @return *LabOrderListResult: The requested page of orders and the total number of orders.
+// @return error: ErrPatientNotFound, ErrInvalidLabOrderQuery, or an error if the operation fails.
+func (s *LabOrderService) ListPatientLabOrders(ctx context.Context, patientID int64, status string, page, pageSize int) (*LabOrderListResult, error) {
+	if err := requirePatient(ctx, s.db, patientID); err != nil {
+		return nil, err
+	}
+
+	params := LabOrderListParams{PatientID: &patientID, Status: status, Page: page, PageSize: pageSize}
+	result, err := s.listLabOrders(ctx, &params, `o.created_at DESC, o.id DESC`)
+	if err != nil {
+		return nil, err
+	}
+	orders := make([]*models.LabOrder, len(result.Orders))
+	for i := range result.Orders {
+		orders[i] = &result.Orders[i]
+	}
+	if err := attachLabResults(ctx, s.db, orders); err != nil {
+		return nil, err
+	}
+
+	err = s.audit.Record(ctx, nil, models.AuditEntry{
+		Action:     "lab_order.list",
+		EntityType: "patient",
+		EntityID:   &patientID,
+		Details:    map[string]interface{}{"status": status, "page": params.Page, "page_size": params.PageSize, "returned": len(result.Orders)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// CollectSpecimen records that the specimen for a lab order has been collected.
+//
+// @param ctx context.Context: The context for the request.
+// @param id int64: The ID of the order.
+// @param collectedBy int64: The ID of the user who collected the specimen.
+// @return *models.LabOrder: The updated order.
+// @return error: ErrLabOrderNotFound, ErrInvalidLabOrderTransition, or an error if the operation fails.
+func (s *LabOrderService) CollectSpecimen(ctx context.Context, id int64, collectedBy int64) (*models.LabOrder, error) {
+	return s.updateLabOrder(ctx, id, models.LabOrderCollected, "lab_order.collect", func(tx *sql.Tx, current *models.LabOrder) error {
+		query := `
+			UPDATE lab_orders
+			SET status = $1, collected_at = CURRENT_TIMESTAMP, collected_by = $2, updated_at = CURRENT_TIMESTAMP
+			WHERE id = $3
+		`
+		if _, err := tx.ExecContext(ctx, query, models.LabOrderCollected, collectedBy, id); err != nil {
+			log.Printf("Error collecting lab order specimen: %v", err)
+			return err
+		}
+		return nil
+	})
+}
+
+// EnterResults records the results of a lab order, replacing any results entered before. Every
+// analyte of the test must be given a result; each is flagged against the analyte's reference
+// and critical ranges.
+//
+// @param ctx context.Context: The context for the request.
+// @param id int64: The ID of the order.
+// @param entries []models.LabResultEntry: One result per analyte of the test.
+// @param resultedBy int64: The ID of the user entering the results.
+// @return *models.LabOrder: The updated order with its results.
+// @return error: ErrLabOrderNotFound, ErrInvalidLabOrderTransition, a validation error for invalid results, or an error if the operation fails.
+func (s *LabOrderService) EnterResults(ctx context.Context, id int64, entries []models.LabResultEntry, resultedBy int64) (*models.LabOrder, error) {
+	return s.updateLabOrder(ctx, id, models.LabOrderResulted, "lab_order.result", func(tx *sql.Tx, current *models.LabOrder) error {
+		analytes, err := labTestAnalytes(ctx, tx, current.TestID)
+		if err != nil {
+			return err
+		}
+		results, err := buildLabResults(analytes, entries)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM lab_results WHERE order_id = $1`, id); err != nil {
+			log.Printf("Error replacing lab results: %v", err)
+			return err
+		}
+		query := `
+			INSERT INTO lab_results (order_id, position, analyte_code, analyte_name, value, value_text, unit, reference_low, reference_high, reference_text, flag, comment)
+			VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), NULLIF($7, ''), $8, $9, NULLIF($10, ''), NULLIF($11, ''), NULLIF($12, ''))
+		`
+		for i, r := range results {
+			_, err := tx.ExecContext(ctx, query,
+				id,
+				i+1,
+				r.AnalyteCode,
+				r.AnalyteName,
+				r.Value,
+				r.ValueText,
+				r.Unit,
+				r.ReferenceLow,
+				r.ReferenceHigh,
+				r.ReferenceText,
+				r.Flag,
+				r.Comment,
+			)
+			if err != nil {
+				log.Printf("Error creating lab result: %v", err)
+				return err
+			}
+		}
+
+		query = `
+			UPDATE lab_orders
+			SET status = $1, resulted_at = CURRENT_TIMESTAMP, resulted_by = $2, updated_at = CURRENT_TIMESTAMP
+			WHERE id = $3
+		`
+		if _, err := tx.ExecContext(ctx, query, models.LabOrderResulted, resultedBy, id); err != nil {
+			log.Printf("Error resulting lab order: %v", err)
+			return err
+		}
+		return nil
+	})
+}
+
+// VerifyResults verifies the results of a lab order, after which they can no longer be changed.
+// The results must be verified by someone other than the user who entered them.
+//
+// @param ctx context.Context: The context for the request.
+// @param id int64: The ID of the order.
+// @param verifiedBy int64: The ID of the user verifying the results.
+// @return *models.LabOrder: The verified order with its results.
+// @return error: ErrLabOrderNotFound, ErrInvalidLabOrderTransition, ErrLabResultSelfVerification, or an error if the operation fails.
+func (s *LabOrderService) VerifyResults(ctx context.Context, id int64, verifiedBy int64) (*models.LabOrder, error) {
+	return s.updateLabOrder(ctx, id, models.LabOrderVerified, "lab_order.verify", func(tx *sql.Tx, current *models.LabOrder) error {
+		if current.ResultedBy != nil && *current.ResultedBy == verifiedBy {
+			return ErrLabResultSelfVerification
+		}
+		query := `
+			UPDATE lab_orders
+			SET status = $1, verified_at = CURRENT_TIMESTAMP, verified_by = $2, updated_at = CURRENT_TIMESTAMP
+			WHERE id = $3
+		`
+		if _, err := tx.ExecContext(ctx, query, models.LabOrderVerified, verifiedBy, id); err != nil {
+			log.Printf("Error verifying lab order: %v", err)
+			return err
+		}
+		return nil
+	})
+}
+
+// CancelLabOrder cancels a lab order whose results have not been entered yet.
+//
+// @param ctx context.Context: The context for the request.
+// @param id int64: The ID of the order.
+// @param reason string: Why the order is cancelled.
+// @param cancelledBy int64: The ID of the user cancelling the order.
+// @return *models.LabOrder: The cancelled order.
+// @return error: ErrLabOrderNotFound, ErrInvalidLabOrderTransition, or an error if the operation fails.
+func (s *LabOrderService) CancelLabOrder(ctx context.Context, id int64, reason string, cancelledBy int64) (*models.LabOrder, error) {
+	return s.updateLabOrder(ctx, id, models.LabOrderCancelled, "lab_order.cancel", func(tx *sql.Tx, current *models.LabOrder) error {
+		query := `
+			UPDATE lab_orders
+			SET status = $1, cancelled_at = CURRENT_TIMESTAMP, cancelled_by = $2, cancellation_reason = $3, updated_at = CURRENT_TIMESTAMP
+			WHERE id = $4
+		`
+		if _, err := tx.ExecContext(ctx, query, models.LabOrderCancelled, cancelledBy, reason, id); err != nil {
+			log.Printf("Error cancelling lab order: %v", err)
+			return err
+		}
+		return nil
+	})
+}
+
+// updateLabOrder locks a lab order, checks that it may move to the given status, applies a change
+// to it and audits the result.
+func (s *LabOrderService) updateLabOrder(ctx context.Context, id int64, status, action string, apply func(tx *sql.Tx, current *models.LabOrder) error) (*models.LabOrder, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	current, err := getLabOrder(ctx, tx, id, true)
+	if err != nil {
+		return nil, err
+	}
+	allowed := false
+	for _, next := range labOrderTransitions[current.Status] {
+		if next == status {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return nil, fmt.Errorf("%w: %s to %s", ErrInvalidLabOrderTransition, current.Status, status)
+	}
+	if err := attachLabResults(ctx, tx, []*models.LabOrder{current}); err != nil {
+		return nil, err
+	}
+	if err := apply(tx, current); err != nil {
+		return nil, err
+	}
+
+	updated, err := getLabOrder(ctx, tx, id, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := attachLabResults(ctx, tx, []*models.LabOrder{updated}); err != nil {
+		return nil, err
+	}
+	err = s.audit.Record(ctx, tx, models.AuditEntry{
+		Action:     action,
+		EntityType: "lab_order",
+		EntityID:   &id,
+		Changes:    diffFields(current, updated),
+		PatientID:  &updated.PatientID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error updating lab order: %v", err)
+		return nil, err
+	}
+	return updated, nil
+}
+
+// listLabOrders loads a page of lab orders matching the params in the given order, normalizing
+// the pagination in params.
+func (s *LabOrderService) listLabOrders(ctx context.Context, params *LabOrderListParams, orderBy string) (*LabOrderListResult, error) {
+	if params.Page < 1 {
+		params.Page = 1
+	}
+	if params.PageSize < 1 {
+		params.PageSize = DefaultPageSize
+	}
+	if params.PageSize > MaxPageSize {
+		params.PageSize = MaxPageSize
+	}
+
+	var conditions []string
+	var args []interface{}
+	add := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if params.PatientID != nil {
+		add("o.patient_id = $%d", *params.PatientID)
+	}
+	if params.OrderedBy != nil {
+		add("o.ordered_by = $%d", *params.OrderedBy)
+	}
+	switch params.Status {
+	case "":
+	case "open":
+		add("o.status = ANY($%d)", pq.Array(labOrderOpenStatuses))
+	case models.LabOrderOrdered, models.LabOrderCollected, models.LabOrderResulted, models.LabOrderVerified, models.LabOrderCancelled:
+		add("o.status = $%d", params.Status)
+	default:
+		return nil, ErrInvalidLabOrderQuery
+	}
+	switch params.Priority {
+	case "":
+	case "routine", "urgent", "stat":
+		add("o.priority = $%d", params.Priority)
+	default:
+		return nil, ErrInvalidLabOrderQuery
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int64
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM lab_orders o `+where, args...).Scan(&total); err != nil {
+		log.Printf("Error counting lab orders: %v", err)
+		return nil, err
+	}
+
+	args = append(args, params.PageSize, (params.Page-1)*params.PageSize)
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM %s
+		%s
+		ORDER BY %s
+		LIMIT $%d OFFSET $%d
+	`, labOrderColumns, labOrderFrom, where, orderBy, len(args)-1, len(args))
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Printf("Error listing lab orders: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	orders := []models.LabOrder{}
+	for rows.Next() {
+		order, err := scanLabOrder(rows)
+		if err != nil {
+			log.Printf("Error scanning lab order: %v", err)
+			return nil, err
+		}
+		orders = append(orders, *order)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating lab orders: %v", err)
+		return nil, err
+	}
+
+	return &LabOrderListResult{Orders: orders, Total: total, Page: params.Page, PageSize: params.PageSize}, nil
+}
+
+// getLabOrder loads a lab order by ID, without its results, optionally locking it for update.
+func getLabOrder(ctx context.Context, db dbtx, id int64, forUpdate bool) (*models.LabOrder, error) {
+	query := `SELECT ` + labOrderColumns + ` FROM ` + labOrderFrom + ` WHERE o.id = $1`
+	if forUpdate {
+		query += ` FOR UPDATE OF o`
+	}
+	order, err := scanLabOrder(db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrLabOrderNotFound
+		}
+		log.Printf("Error retrieving lab order: %v", err)
+		return nil, err
+	}
+	return order, nil
+}
+
+// attachLabResults loads the results of the given lab orders in their reporting order.
+func attachLabResults(ctx context.Context, db dbtx, orders []*models.LabOrder) error {
+	if len(orders) == 0 {
+		return nil
+	}
+	byID := make(map[int64]*models.LabOrder, len(orders))
+	ids := make([]int64, 0, len(orders))
+	for _, o := range orders {
+		byID[o.ID] = o
+		ids = append(ids, o.ID)
+	}
+
+	query := `
+		SELECT id, order_id, analyte_code, analyte_name, value, COALESCE(value_text, ''), COALESCE(unit, ''), reference_low, reference_high, COALESCE(reference_text, ''), COALESCE(flag, ''), COALESCE(comment, '')
+		FROM lab_results
+		WHERE order_id = ANY($1)
+		ORDER BY order_id, position
+	`
+	rows, err := db.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		log.Printf("Error listing lab results: %v", err)
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var r models.LabResult
+		var orderID int64
+		err := rows.Scan(&r.ID, &orderID, &r.AnalyteCode, &r.AnalyteName, &r.Value, &r.ValueText, &r.Unit, &r.ReferenceLow, &r.ReferenceHigh, &r.ReferenceText, &r.Flag, &r.Comment)
+		if err != nil {
+			log.Printf("Error scanning lab result: %v", err)
+			return err
+		}
+		byID[orderID].Results = append(byID[orderID].Results, r)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating lab results: %v", err)
+		return err
+	}
+	return nil
+}
+
+// buildLabResults matches result entries to the analytes of a test and flags them, reporting
+// every problem as a field error.
+func buildLabResults(analytes []models.LabAnalyte, entries []models.LabResultEntry) ([]models.LabResult, error) {
+	var fields []validation.FieldError
+	entered := map[string]models.LabResultEntry{}
+	known := map[string]bool{}
+	for _, a := range analytes {
+		known[a.Code] = true
+	}
+	for i, e := range entries {
+		field := fmt.Sprintf("results[%d]", i)
+		code := strings.ToUpper(strings.TrimSpace(e.AnalyteCode))
+		_, dup := entered[code]
+		switch {
+		case !known[code]:
+			fields = append(fields, validation.FieldError{Field: field + ".analyte_code", Message: "is not an analyte of the ordered test"})
+		case dup:
+			fields = append(fields, validation.FieldError{Field: field + ".analyte_code", Message: "is given more than once"})
+		case e.Value == nil && strings.TrimSpace(e.ValueText) == "":
+			fields = append(fields, validation.FieldError{Field: field + ".value", Message: "value or value_text is required"})
+		default:
+			entered[code] = e
+		}
+	}
+
+	results := make([]models.LabResult, 0, len(analytes))
+	for _, a := range analytes {
+		e, ok := entered[a.Code]
+		if !ok {
+			fields = append(fields, validation.FieldError{Field: "results", Message: "missing a result for " + a.Code})
+			continue
+		}
+		r := models.LabResult{
+			AnalyteCode:   a.Code,
+			AnalyteName:   a.Name,
+			Value:         e.Value,
+			ValueText:     strings.TrimSpace(e.ValueText),
+			Unit:          a.Unit,
+			ReferenceLow:  a.ReferenceLow,
+			ReferenceHigh: a.ReferenceHigh,
+			ReferenceText: a.ReferenceText,
+			Comment:       strings.TrimSpace(e.Comment),
+		}
+		r.Flag = labResultFlag(a, r)
+		results = append(results, r)
+	}
+
+	if len(fields) > 0 {
+		return nil, &Error{Kind: KindValidation, Code: "invalid_lab_results", Message: "invalid lab results", Fields: fields}
+	}
+	return results, nil
+}
+
+// labResultFlag flags a result against the reference and critical ranges of its analyte. It
+// returns an empty flag when the analyte has no range to compare the result with.
+func labResultFlag(analyte models.LabAnalyte, result models.LabResult) string {
+	if result.Value != nil {
+		v := *result.Value
+		switch {
+		case analyte.CriticalLow != nil && v < *analyte.CriticalLow:
+			return models.LabFlagCriticalLow
+		case analyte.CriticalHigh != nil && v > *analyte.CriticalHigh:
+			return models.LabFlagCriticalHigh
+		case analyte.ReferenceLow != nil && v < *analyte.ReferenceLow:
+			return models.LabFlagLow
+		case analyte.ReferenceHigh != nil && v > *analyte.ReferenceHigh:
+			return models.LabFlagHigh
+		case analyte.ReferenceLow != nil || analyte.ReferenceHigh != nil:
+			return models.LabFlagNormal
+		}
+	}
+	if result.ValueText != "" && analyte.ReferenceText != "" {
+		if strings.EqualFold(result.ValueText, analyte.ReferenceText) {
+			return models.LabFlagNormal
+		}
+		return models.LabFlagAbnormal
+	}
+	return ""
+}
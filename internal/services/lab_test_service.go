@@ -0,0 +1,309 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/Okemwag/medihub/internal/models"
+	"github.com/Okemwag/medihub/internal/validation"
+)
+
+// Errors returned by LabTestService.
+var (
+	ErrLabTestNotFound  = NewNotFoundError("lab_test_not_found", "lab test not found")
+	ErrDuplicateLabTest = NewConflictError("duplicate_lab_test", "a lab test with this code already exists")
+)
+
+// LabTestService maintains the catalogue of lab tests that can be ordered.
+type LabTestService struct {
+	db    *sql.DB
+	audit *AuditService
+}
+
+// NewLabTestService creates a new instance of LabTestService.
+//
+// @param db *sql.DB: A database connection.
+// @param audit *AuditService: The service used to audit catalogue changes.
+// @return *LabTestService: A new LabTestService instance.
+func NewLabTestService(db *sql.DB, audit *AuditService) *LabTestService {
+	return &LabTestService{db: db, audit: audit}
+}
+
+// ListLabTests retrieves the lab test catalogue, optionally filtered by code or name.
+//
+// @param ctx context.Context: The context for the request.
+// @param q string: Only tests whose code or name contains this term, or all tests when empty.
+// @param includeInactive bool: Whether to include tests that can no longer be ordered.
+// @return []models.LabTest: The tests with their analytes, ordered by name.
+// @return error: An error if the operation fails.
+func (s *LabTestService) ListLabTests(ctx context.Context, q string, includeInactive bool) ([]models.LabTest, error) {
+	q = strings.TrimSpace(q)
+	query := `
+		SELECT id, code, name, COALESCE(specimen, ''), active, created_at, updated_at
+		FROM lab_tests
+		WHERE ($1 = '' OR code ILIKE '%' || $2 || '%' OR name ILIKE '%' || $2 || '%')
+			AND ($3 OR active)
+		ORDER BY name, id
+	`
+	rows, err := s.db.QueryContext(ctx, query, q, likeEscaper.Replace(q), includeInactive)
+	if err != nil {
+		log.Printf("Error listing lab tests: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	tests := []models.LabTest{}
+	for rows.Next() {
+		var t models.LabTest
+		if err := rows.Scan(&t.ID, &t.Code, &t.Name, &t.Specimen, &t.Active, &t.CreatedAt, &t.UpdatedAt); err != nil {
+			log.Printf("Error scanning lab test: %v", err)
+			return nil, err
+		}
+		tests = append(tests, t)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating lab tests: %v", err)
+		return nil, err
+	}
+
+	for i := range tests {
+		if tests[i].Analytes, err = labTestAnalytes(ctx, s.db, tests[i].ID); err != nil {
+			return nil, err
+		}
+	}
+	return tests, nil
+}
+
+// GetLabTest retrieves a lab test by ID, with its analytes.
+//
+// @param ctx context.Context: The context for the request.
+// @param id int64: The ID of the test.
+// @return *models.LabTest: The test.
+// @return error: ErrLabTestNotFound, or an error if the operation fails.
+func (s *LabTestService) GetLabTest(ctx context.Context, id int64) (*models.LabTest, error) {
+	return getLabTest(ctx, s.db, id)
+}
+
+// CreateLabTest adds a test to the catalogue. New tests are always active.
+//
+// @param ctx context.Context: The context for the request.
+// @param test *models.LabTest: The test to add, with its analytes.
+// @return *models.LabTest: The added test.
+// @return error: A validation error for inconsistent analytes, ErrDuplicateLabTest, or an error if the operation fails.
+func (s *LabTestService) CreateLabTest(ctx context.Context, test *models.LabTest) (*models.LabTest, error) {
+	if err := validateAnalytes(test.Analytes); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO lab_tests (code, name, specimen, active)
+		VALUES ($1, $2, $3, TRUE)
+		RETURNING id
+	`
+	var id int64
+	err = tx.QueryRowContext(ctx, query, strings.ToUpper(strings.TrimSpace(test.Code)), strings.TrimSpace(test.Name), test.Specimen).Scan(&id)
+	if err != nil {
+		return nil, catalogueWriteError("creating lab test", err, ErrDuplicateLabTest)
+	}
+	if err := replaceAnalytes(ctx, tx, id, test.Analytes); err != nil {
+		return nil, err
+	}
+
+	created, err := getLabTest(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	err = s.audit.Record(ctx, tx, models.AuditEntry{
+		Action:     "lab_test.create",
+		EntityType: "lab_test",
+		EntityID:   &id,
+		Changes:    diffFields(nil, created),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error creating lab test: %v", err)
+		return nil, err
+	}
+	return created, nil
+}
+
+// UpdateLabTest replaces the details and analytes of a test, including whether it can still be
+// ordered. Results already entered keep the analyte details they were entered with.
+//
+// @param ctx context.Context: The context for the request.
+// @param id int64: The ID of the test.
+// @param test *models.LabTest: The new details of the test.
+// @return *models.LabTest: The updated test.
+// @return error: ErrLabTestNotFound, a validation error for inconsistent analytes, ErrDuplicateLabTest, or an error if the operation fails.
+func (s *LabTestService) UpdateLabTest(ctx context.Context, id int64, test *models.LabTest) (*models.LabTest, error) {
+	if err := validateAnalytes(test.Analytes); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	current, err := getLabTest(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		UPDATE lab_tests
+		SET code = $1, name = $2, specimen = $3, active = $4, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $5
+	`
+	_, err = tx.ExecContext(ctx, query, strings.ToUpper(strings.TrimSpace(test.Code)), strings.TrimSpace(test.Name), test.Specimen, test.Active, id)
+	if err != nil {
+		return nil, catalogueWriteError("updating lab test", err, ErrDuplicateLabTest)
+	}
+	if err := replaceAnalytes(ctx, tx, id, test.Analytes); err != nil {
+		return nil, err
+	}
+
+	updated, err := getLabTest(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	err = s.audit.Record(ctx, tx, models.AuditEntry{
+		Action:     "lab_test.update",
+		EntityType: "lab_test",
+		EntityID:   &id,
+		Changes:    diffFields(current, updated),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error updating lab test: %v", err)
+		return nil, err
+	}
+	return updated, nil
+}
+
+// getLabTest loads a lab test with its analytes.
+func getLabTest(ctx context.Context, db dbtx, id int64) (*models.LabTest, error) {
+	var t models.LabTest
+	query := `SELECT id, code, name, COALESCE(specimen, ''), active, created_at, updated_at FROM lab_tests WHERE id = $1`
+	err := db.QueryRowContext(ctx, query, id).Scan(&t.ID, &t.Code, &t.Name, &t.Specimen, &t.Active, &t.CreatedAt, &t.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrLabTestNotFound
+		}
+		log.Printf("Error retrieving lab test: %v", err)
+		return nil, err
+	}
+	if t.Analytes, err = labTestAnalytes(ctx, db, id); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// labTestAnalytes loads the analytes of a lab test in their reporting order.
+func labTestAnalytes(ctx context.Context, db dbtx, testID int64) ([]models.LabAnalyte, error) {
+	query := `
+		SELECT code, name, COALESCE(unit, ''), reference_low, reference_high, critical_low, critical_high, COALESCE(reference_text, '')
+		FROM lab_test_analytes
+		WHERE test_id = $1
+		ORDER BY position
+	`
+	rows, err := db.QueryContext(ctx, query, testID)
+	if err != nil {
+		log.Printf("Error listing lab test analytes: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	analytes := []models.LabAnalyte{}
+	for rows.Next() {
+		var a models.LabAnalyte
+		if err := rows.Scan(&a.Code, &a.Name, &a.Unit, &a.ReferenceLow, &a.ReferenceHigh, &a.CriticalLow, &a.CriticalHigh, &a.ReferenceText); err != nil {
+			log.Printf("Error scanning lab test analyte: %v", err)
+			return nil, err
+		}
+		analytes = append(analytes, a)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating lab test analytes: %v", err)
+		return nil, err
+	}
+	return analytes, nil
+}
+
+// replaceAnalytes stores the analytes of a lab test, replacing any it had.
+func replaceAnalytes(ctx context.Context, tx *sql.Tx, testID int64, analytes []models.LabAnalyte) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM lab_test_analytes WHERE test_id = $1`, testID); err != nil {
+		log.Printf("Error replacing lab test analytes: %v", err)
+		return err
+	}
+	query := `
+		INSERT INTO lab_test_analytes (test_id, position, code, name, unit, reference_low, reference_high, critical_low, critical_high, reference_text)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7, $8, $9, NULLIF($10, ''))
+	`
+	for i, a := range analytes {
+		_, err := tx.ExecContext(ctx, query,
+			testID,
+			i+1,
+			strings.ToUpper(strings.TrimSpace(a.Code)),
+			strings.TrimSpace(a.Name),
+			a.Unit,
+			a.ReferenceLow,
+			a.ReferenceHigh,
+			a.CriticalLow,
+			a.CriticalHigh,
+			strings.TrimSpace(a.ReferenceText),
+		)
+		if err != nil {
+			log.Printf("Error creating lab test analyte: %v", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// validateAnalytes checks that analyte codes are unique and that reference and critical ranges
+// are ordered, reporting every problem as a field error.
+func validateAnalytes(analytes []models.LabAnalyte) error {
+	var fields []validation.FieldError
+	seen := map[string]bool{}
+	for i, a := range analytes {
+		field := fmt.Sprintf("analytes[%d]", i)
+		code := strings.ToUpper(strings.TrimSpace(a.Code))
+		if seen[code] {
+			fields = append(fields, validation.FieldError{Field: field + ".code", Message: "is used by more than one analyte"})
+		}
+		seen[code] = true
+
+		if a.ReferenceLow != nil && a.ReferenceHigh != nil && *a.ReferenceLow > *a.ReferenceHigh {
+			fields = append(fields, validation.FieldError{Field: field + ".reference_high", Message: "must not be lower than reference_low"})
+		}
+		if a.CriticalLow != nil && a.ReferenceLow != nil && *a.CriticalLow > *a.ReferenceLow {
+			fields = append(fields, validation.FieldError{Field: field + ".critical_low", Message: "must not be higher than reference_low"})
+		}
+		if a.CriticalHigh != nil && a.ReferenceHigh != nil && *a.CriticalHigh < *a.ReferenceHigh {
+			fields = append(fields, validation.FieldError{Field: field + ".critical_high", Message: "must not be lower than reference_high"})
+		}
+	}
+
+	if len(fields) > 0 {
+		return &Error{Kind: KindValidation, Code: "invalid_analytes", Message: "invalid lab test analytes", Fields: fields}
+	}
+	return nil
+}
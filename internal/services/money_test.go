@@ -0,0 +1,148 @@
+package services
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"testing"
+
+	"github.com/Okemwag/medihub/internal/models"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    models.Money
+		wantErr bool
+	}{
+		{in: "1500", want: 150000},
+		{in: "1500.5", want: 150050},
+		{in: "1500.50", want: 150050},
+		{in: " 20.75 ", want: 2075},
+		{in: "-0.05", want: -5},
+		{in: "0", want: 0},
+		{in: "9999999999.99", want: models.MaxMoney},
+		{in: "1.", wantErr: true},
+		{in: ".5", wantErr: true},
+		{in: "1.234", wantErr: true},
+		{in: "-", wantErr: true},
+		{in: "", wantErr: true},
+		{in: "1e3", wantErr: true},
+		{in: "1,500", wantErr: true},
+		{in: "--1", wantErr: true},
+		{in: "1234567890123456", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := models.ParseMoney(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseMoney(%q) = %d, want an error", tt.in, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseMoney(%q) error = %v", tt.in, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseMoney(%q) = %d, want %d", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	tests := []struct {
+		in   models.Money
+		want string
+	}{
+		{in: 150000, want: "1500.00"},
+		{in: 150050, want: "1500.50"},
+		{in: 7, want: "0.07"},
+		{in: -5, want: "-0.05"},
+		{in: -2075, want: "-20.75"},
+		{in: 0, want: "0.00"},
+		{in: models.MaxMoney, want: "9999999999.99"},
+	}
+	for _, tt := range tests {
+		if got := tt.in.String(); got != tt.want {
+			t.Errorf("Money(%d).String() = %q, want %q", int64(tt.in), got, tt.want)
+		}
+	}
+}
+
+func TestMoneyUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    models.Money
+		wantErr bool
+	}{
+		{name: "JSON number", in: `1500.5`, want: 150050},
+		{name: "JSON string", in: `"1500.5"`, want: 150050},
+		{name: "negative JSON string", in: `"-0.05"`, want: -5},
+		{name: "JSON number with too many decimals", in: `1.234`, wantErr: true},
+		{name: "JSON string with too many decimals", in: `"1.234"`, wantErr: true},
+		{name: "JSON number in exponent form", in: `1e3`, wantErr: true},
+		{name: "JSON boolean", in: `true`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got struct {
+				Amount models.Money `json:"amount"`
+			}
+			err := json.Unmarshal([]byte(`{"amount":`+tt.in+`}`), &got)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Unmarshal(%s) = %d, want an error", tt.in, got.Amount)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unmarshal(%s) error = %v", tt.in, err)
+			}
+			if got.Amount != tt.want {
+				t.Errorf("Unmarshal(%s) = %d, want %d", tt.in, got.Amount, tt.want)
+			}
+		})
+	}
+
+	// null leaves the amount unchanged, so omitted and null amounts behave alike
+	amount := models.Money(100)
+	if err := json.Unmarshal([]byte(`null`), &amount); err != nil || amount != 100 {
+		t.Errorf("Unmarshal(null) = %d, %v, want 100 and no error", amount, err)
+	}
+}
+
+func TestMoneyScan(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     driver.Value
+		want    models.Money
+		wantErr bool
+	}{
+		{name: "NUMERIC as bytes", src: []byte("1500.50"), want: 150050},
+		{name: "NUMERIC as string", src: "-0.05", want: -5},
+		{name: "NUMERIC at the column limit", src: []byte("9999999999.99"), want: models.MaxMoney},
+		{name: "integer", src: int64(12), want: 1200},
+		{name: "NULL", src: nil, wantErr: true},
+		{name: "float", src: 1.5, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got models.Money
+			err := got.Scan(tt.src)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Scan(%v) = %d, want an error", tt.src, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Scan(%v) error = %v", tt.src, err)
+			}
+			if got != tt.want {
+				t.Errorf("Scan(%v) = %d, want %d", tt.src, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// PatchFormat identifies the document format of a partial update.
+type PatchFormat int
+
+const (
+	// MergePatch is an RFC 7396 JSON Merge Patch (application/merge-patch+json).
+	MergePatch PatchFormat = iota
+	// JSONPatch is an RFC 6902 JSON Patch (application/json-patch+json).
+	JSONPatch
+)
+
+// Errors returned when applying a patch.
+var (
+	ErrInvalidPatch    = NewValidationError("invalid_patch", "invalid patch")
+	ErrPatchTestFailed = NewConflictError("patch_test_failed", "patch test operation failed")
+)
+
+// jsonPatchOperation is a single RFC 6902 operation.
+type jsonPatchOperation struct {
+	Op    string           `json:"op"`
+	Path  string           `json:"path"`
+	From  string           `json:"from"`
+	Value *json.RawMessage `json:"value"`
+}
+
+// applyPatch applies a patch in the given format to a flat JSON object document in place.
+// Only top-level members can be addressed, which is all that flat records such as patients need.
+func applyPatch(doc map[string]interface{}, format PatchFormat, patch []byte) error {
+	switch format {
+	case MergePatch:
+		return applyMergePatch(doc, patch)
+	case JSONPatch:
+		return applyJSONPatch(doc, patch)
+	default:
+		return fmt.Errorf("%w: unsupported format", ErrInvalidPatch)
+	}
+}
+
+// applyMergePatch applies an RFC 7396 merge patch: members set to null are removed and all other
+// members replace the target's value.
+func applyMergePatch(doc map[string]interface{}, patch []byte) error {
+	var members map[string]interface{}
+	if err := json.Unmarshal(patch, &members); err != nil || members == nil {
+		return fmt.Errorf("%w: merge patch must be a JSON object", ErrInvalidPatch)
+	}
+	for name, value := range members {
+		if value == nil {
+			delete(doc, name)
+			continue
+		}
+		doc[name] = value
+	}
+	return nil
+}
+
+// applyJSONPatch applies an RFC 6902 patch. Operations are applied in order and the whole patch
+// fails if any operation fails.
+func applyJSONPatch(doc map[string]interface{}, patch []byte) error {
+	var operations []jsonPatchOperation
+	if err := json.Unmarshal(patch, &operations); err != nil {
+		return fmt.Errorf("%w: JSON patch must be an array of operations", ErrInvalidPatch)
+	}
+
+	for i, op := range operations {
+		name, err := patchMember(op.Path)
+		if err != nil {
+			return fmt.Errorf("%w: operation %d: %v", ErrInvalidPatch, i, err)
+		}
+
+		var value interface{}
+		if op.Value != nil {
+			if err := json.Unmarshal(*op.Value, &value); err != nil {
+				return fmt.Errorf("%w: operation %d: invalid value", ErrInvalidPatch, i)
+			}
+		}
+
+		switch op.Op {
+		case "add":
+			if op.Value == nil {
+				return fmt.Errorf("%w: operation %d: add requires a value", ErrInvalidPatch, i)
+			}
+			doc[name] = value
+		case "replace":
+			if op.Value == nil {
+				return fmt.Errorf("%w: operation %d: replace requires a value", ErrInvalidPatch, i)
+			}
+			if _, ok := doc[name]; !ok {
+				return fmt.Errorf("%w: operation %d: path %s does not exist", ErrInvalidPatch, i, op.Path)
+			}
+			doc[name] = value
+		case "remove":
+			if _, ok := doc[name]; !ok {
+				return fmt.Errorf("%w: operation %d: path %s does not exist", ErrInvalidPatch, i, op.Path)
+			}
+			delete(doc, name)
+		case "move", "copy":
+			from, err := patchMember(op.From)
+			if err != nil {
+				return fmt.Errorf("%w: operation %d: %v", ErrInvalidPatch, i, err)
+			}
+			fromValue, ok := doc[from]
+			if !ok {
+				return fmt.Errorf("%w: operation %d: path %s does not exist", ErrInvalidPatch, i, op.From)
+			}
+			if op.Op == "move" {
+				delete(doc, from)
+			}
+			doc[name] = fromValue
+		case "test":
+			if !reflect.DeepEqual(doc[name], value) {
+				return fmt.Errorf("%w: %s", ErrPatchTestFailed, op.Path)
+			}
+		default:
+			return fmt.Errorf("%w: operation %d: unknown op %q", ErrInvalidPatch, i, op.Op)
+		}
+	}
+	return nil
+}
+
+// patchMember resolves a JSON Pointer addressing a top-level member to the member name.
+func patchMember(pointer string) (string, error) {
+	if !strings.HasPrefix(pointer, "/") {
+		return "", fmt.Errorf("path %q must start with /", pointer)
+	}
+	name := pointer[1:]
+	if strings.Contains(name, "/") {
+		return "", fmt.Errorf("path %q: nested paths are not supported", pointer)
+	}
+	return strings.NewReplacer("~1", "/", "~0", "~").Replace(name), nil
+}
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"log"
+
+	"github.com/Okemwag/medihub/internal/models"
+)
+
+// ErrPatientVersionNotFound is returned when a patient has no history entry with the requested version.
+var ErrPatientVersionNotFound = NewNotFoundError("patient_version_not_found", "patient version not found")
+
+// patientVersionColumns lists the patient_versions columns in the order expected by scanPatientVersion.
+const patientVersionColumns = `patient_id, version, operation, first_name, last_name, date_of_birth, gender, contact_number, email, address, medical_history, deleted_at, changed_by, changed_at`
+
+// scanPatientVersion reads a single history entry selected with patientVersionColumns.
+func scanPatientVersion(row rowScanner) (*models.PatientVersion, error) {
+	var version models.PatientVersion
+	snapshot := &models.Patient{}
+	err := row.Scan(
+		&version.PatientID,
+		&version.Version,
+		&version.Operation,
+		&snapshot.FirstName,
+		&snapshot.LastName,
+		&snapshot.DateOfBirth,
+		&snapshot.Gender,
+		&snapshot.ContactNumber,
+		&snapshot.Email,
+		&snapshot.Address,
+		&snapshot.MedicalHistory,
+		&snapshot.DeletedAt,
+		&version.ChangedBy,
+		&version.ChangedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	snapshot.ID = version.PatientID
+	snapshot.Version = version.Version
+	version.Snapshot = snapshot
+	return &version, nil
+}
+
+// recordVersion copies the current state of a patient into patient_versions under its current version number.
+func (s *PatientService) recordVersion(ctx context.Context, db dbtx, id int64, operation string, changedBy int64) error {
+	query := `
+		INSERT INTO patient_versions (patient_id, version, operation, first_name, last_name, date_of_birth, gender, contact_number, email, address, medical_history, deleted_at, changed_by)
+		SELECT id, version, $2, first_name, last_name, date_of_birth, gender, contact_number, email, address, medical_history, deleted_at, $3
+		FROM patients
+		WHERE id = $1
+	`
+	if _, err := db.ExecContext(ctx, query, id, operation, changedBy); err != nil {
+		log.Printf("Error recording patient version: %v", err)
+		return err
+	}
+	return nil
+}
+
+// ListPatientHistory retrieves every version of a patient record, oldest first, with the fields
+// that changed in each version. Snapshots are omitted; use GetPatientVersion for the full record.
+//
+// @param ctx context.Context: The context for the request.
+// @param patientID int64: The ID of the patient.
+// @return []models.PatientVersion: The versions of the patient record.
+// @return error: ErrPatientNotFound if the patient has no history, or an error if the operation fails.
+func (s *PatientService) ListPatientHistory(ctx context.Context, patientID int64) ([]models.PatientVersion, error) {
+	query := `SELECT ` + patientVersionColumns + ` FROM patient_versions WHERE patient_id = $1 ORDER BY version`
+	rows, err := s.db.QueryContext(ctx, query, patientID)
+	if err != nil {
+		log.Printf("Error listing patient history: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := []models.PatientVersion{}
+	var previous *models.Patient
+	for rows.Next() {
+		version, err := scanPatientVersion(rows)
+		if err != nil {
+			log.Printf("Error scanning patient version: %v", err)
+			return nil, err
+		}
+		version.Changes = diffFields(previous, version.Snapshot)
+		previous = version.Snapshot
+		version.Snapshot = nil
+		versions = append(versions, *version)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating patient history: %v", err)
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, ErrPatientNotFound
+	}
+
+	err = s.audit.Record(ctx, nil, models.AuditEntry{Action: "patient.history.read", EntityType: "patient", EntityID: &patientID})
+	if err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// GetPatientVersion retrieves a single version of a patient record along with the fields that
+// changed relative to the previous version.
+//
+// @param ctx context.Context: The context for the request.
+// @param patientID int64: The ID of the patient.
+// @param version int: The version number.
+// @return *models.PatientVersion: The version, including a snapshot of the record.
+// @return error: ErrPatientVersionNotFound, or an error if the operation fails.
+func (s *PatientService) GetPatientVersion(ctx context.Context, patientID int64, version int) (*models.PatientVersion, error) {
+	query := `
+		SELECT ` + patientVersionColumns + `
+		FROM patient_versions
+		WHERE patient_id = $1 AND version IN ($2, $2 - 1)
+		ORDER BY version
+	`
+	rows, err := s.db.QueryContext(ctx, query, patientID, version)
+	if err != nil {
+		log.Printf("Error retrieving patient version: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var previous, current *models.PatientVersion
+	for rows.Next() {
+		v, err := scanPatientVersion(rows)
+		if err != nil {
+			log.Printf("Error scanning patient version: %v", err)
+			return nil, err
+		}
+		if v.Version == version {
+			current = v
+		} else {
+			previous = v
+		}
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating patient versions: %v", err)
+		return nil, err
+	}
+	if current == nil {
+		return nil, ErrPatientVersionNotFound
+	}
+
+	var before *models.Patient
+	if previous != nil {
+		before = previous.Snapshot
+	}
+	current.Changes = diffFields(before, current.Snapshot)
+
+	err = s.audit.Record(ctx, nil, models.AuditEntry{
+		Action:     "patient.history.read",
+		EntityType: "patient",
+		EntityID:   &patientID,
+		Details:    map[string]interface{}{"version": version},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return current, nil
+}
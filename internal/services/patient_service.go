@@ -3,23 +3,189 @@ package services
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
+	"strings"
+	"time"
 
 	"github.com/Okemwag/medihub/internal/models"
+	"github.com/Okemwag/medihub/internal/requestctx"
+	"github.com/Okemwag/medihub/internal/validation"
 )
 
 // PatientService provides methods for managing patient records in the database.
+//
+// Every call, including reads, is recorded in the audit log as an access to protected health information.
 type PatientService struct {
-	db *sql.DB
+	db              *sql.DB
+	audit           *AuditService
+	retentionPeriod time.Duration // How long soft-deleted records are kept before they may be purged
 }
 
 // NewPatientService creates a new instance of PatientService.
 //
 // @param db *sql.DB: A database connection.
+// @param audit *AuditService: The service used to audit patient record access.
+// @param retentionPeriod time.Duration: How long soft-deleted records are kept before they may be purged.
 // @return *PatientService: A new PatientService instance.
-func NewPatientService(db *sql.DB) *PatientService {
-	return &PatientService{db: db}
+func NewPatientService(db *sql.DB, audit *AuditService, retentionPeriod time.Duration) *PatientService {
+	return &PatientService{db: db, audit: audit, retentionPeriod: retentionPeriod}
+}
+
+// Errors returned by PatientService.
+var (
+	ErrPatientNotFound        = NewNotFoundError("patient_not_found", "patient not found")
+	ErrPatientVersionConflict = NewPreconditionFailedError("patient_version_conflict", "patient has been modified since it was last read")
+)
+
+// patientColumns lists the patient columns in the order expected by scanPatient.
+const patientColumns = `id, first_name, last_name, date_of_birth, gender, contact_number, email, address, medical_history, created_by, updated_by, created_at, updated_at, deleted_at, deleted_by, version`
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanPatient reads a single patient selected with patientColumns, followed by any extra columns.
+func scanPatient(row rowScanner, extra ...interface{}) (*models.Patient, error) {
+	var patient models.Patient
+	dest := []interface{}{
+		&patient.ID,
+		&patient.FirstName,
+		&patient.LastName,
+		&patient.DateOfBirth,
+		&patient.Gender,
+		&patient.ContactNumber,
+		&patient.Email,
+		&patient.Address,
+		&patient.MedicalHistory,
+		&patient.CreatedBy,
+		&patient.UpdatedBy,
+		&patient.CreatedAt,
+		&patient.UpdatedAt,
+		&patient.DeletedAt,
+		&patient.DeletedBy,
+		&patient.Version,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	return &patient, nil
+}
+
+// Pagination limits applied to patient listings.
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// patientSortColumns maps the sort keys accepted by ListPatients to database columns.
+var patientSortColumns = map[string]string{
+	"id":            "id",
+	"first_name":    "first_name",
+	"last_name":     "last_name",
+	"date_of_birth": "date_of_birth",
+	"created_at":    "created_at",
+	"updated_at":    "updated_at",
+}
+
+// PatientListParams describes the filters, sorting and pagination for a patient listing.
+type PatientListParams struct {
+	Name          string     `json:"name,omitempty"`           // Case-insensitive match on first or last name
+	Gender        string     `json:"gender,omitempty"`         // Exact (case-insensitive) gender match
+	DOBFrom       *time.Time `json:"dob_from,omitempty"`       // Inclusive lower bound on date of birth
+	DOBTo         *time.Time `json:"dob_to,omitempty"`         // Inclusive upper bound on date of birth
+	DOBBefore     *time.Time `json:"dob_before,omitempty"`     // Exclusive upper bound on date of birth
+	CreatedFrom   *time.Time `json:"created_from,omitempty"`   // Inclusive lower bound on created_at
+	CreatedTo     *time.Time `json:"created_to,omitempty"`     // Inclusive upper bound on created_at
+	CreatedBefore *time.Time `json:"created_before,omitempty"` // Exclusive upper bound on created_at, e.g. the day after a date-only bound
+	Sort          string     `json:"sort,omitempty"`           // Sort key, prefixed with "-" for descending order
+	Page          int        `json:"page"`                     // 1-based page number
+	PageSize      int        `json:"page_size"`                // Number of records per page
+}
+
+// PatientListResult is a single page of patients along with the total number of matches.
+type PatientListResult struct {
+	Patients []models.Patient `json:"data"`
+	Total    int64            `json:"total"`
+	Page     int              `json:"page"`
+	PageSize int              `json:"page_size"`
+}
+
+// ErrInvalidSort is returned when ListPatients receives an unknown sort key.
+var ErrInvalidSort = NewValidationError("invalid_sort", "invalid sort field")
+
+// normalize applies defaults and bounds to the pagination settings.
+func (p *PatientListParams) normalize() {
+	if p.Page < 1 {
+		p.Page = 1
+	}
+	if p.PageSize < 1 {
+		p.PageSize = DefaultPageSize
+	}
+	if p.PageSize > MaxPageSize {
+		p.PageSize = MaxPageSize
+	}
+	if p.Sort == "" {
+		p.Sort = "-created_at"
+	}
+}
+
+// orderBy translates the sort key into an ORDER BY clause.
+func (p *PatientListParams) orderBy() (string, error) {
+	direction := "ASC"
+	key := p.Sort
+	if strings.HasPrefix(key, "-") {
+		direction = "DESC"
+		key = strings.TrimPrefix(key, "-")
+	}
+	column, ok := patientSortColumns[key]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrInvalidSort, key)
+	}
+	return fmt.Sprintf("ORDER BY %s %s, id %s", column, direction, direction), nil
+}
+
+// where builds the WHERE clause and its positional arguments from the filters.
+func (p *PatientListParams) where() (string, []interface{}) {
+	conditions := []string{"deleted_at IS NULL"}
+	var args []interface{}
+
+	add := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if p.Name != "" {
+		args = append(args, "%"+likeEscaper.Replace(p.Name)+"%")
+		n := len(args)
+		conditions = append(conditions, fmt.Sprintf("(first_name ILIKE $%d OR last_name ILIKE $%d OR (first_name || ' ' || last_name) ILIKE $%d)", n, n, n))
+	}
+	if p.Gender != "" {
+		add("LOWER(gender) = LOWER($%d)", p.Gender)
+	}
+	if p.DOBFrom != nil {
+		add("date_of_birth >= $%d", *p.DOBFrom)
+	}
+	if p.DOBTo != nil {
+		add("date_of_birth <= $%d", *p.DOBTo)
+	}
+	if p.DOBBefore != nil {
+		add("date_of_birth < $%d", *p.DOBBefore)
+	}
+	if p.CreatedFrom != nil {
+		add("created_at >= $%d", *p.CreatedFrom)
+	}
+	if p.CreatedTo != nil {
+		add("created_at <= $%d", *p.CreatedTo)
+	}
+	if p.CreatedBefore != nil {
+		add("created_at < $%d", *p.CreatedBefore)
+	}
+
+	return "WHERE " + strings.Join(conditions, " AND "), args
 }
 
 // CreatePatient adds a new patient record to the database.
@@ -29,13 +195,22 @@ func NewPatientService(db *sql.DB) *PatientService {
 // @return int64: The ID of the newly created patient.
 // @return error: An error if the operation fails.
 func (s *PatientService) CreatePatient(ctx context.Context, patient *models.Patient) (int64, error) {
+	// Gender codes are accepted in any case but stored in lower case
+	patient.Gender = strings.ToLower(patient.Gender)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
 	query := `
 		INSERT INTO patients (first_name, last_name, date_of_birth, gender, contact_number, email, address, medical_history, created_by, updated_by)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 		RETURNING id
 	`
 	var id int64
-	err := s.db.QueryRowContext(ctx, query,
+	err = tx.QueryRowContext(ctx, query,
 		patient.FirstName,
 		patient.LastName,
 		patient.DateOfBirth,
@@ -51,45 +226,61 @@ func (s *PatientService) CreatePatient(ctx context.Context, patient *models.Pati
 		log.Printf("Error creating patient: %v", err)
 		return 0, err
 	}
+	if err := s.recordVersion(ctx, tx, id, "create", patient.CreatedBy); err != nil {
+		return 0, err
+	}
+
+	err = s.audit.Record(ctx, tx, models.AuditEntry{
+		Action:     "patient.create",
+		EntityType: "patient",
+		EntityID:   &id,
+		Changes:    diffFields(nil, patient),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error creating patient: %v", err)
+		return 0, err
+	}
 	return id, nil
 }
 
-// GetPatient retrieves a patient record from the database by ID.
+// GetPatient retrieves a patient record from the database by ID. Callers holding allergy.read also
+// get a summary of the patient's active allergies, audited as an allergy list; for anyone else
+// Allergies is nil.
 //
 // @param ctx context.Context: The context for the request.
 // @param id int64: The ID of the patient to retrieve.
-// @return *models.Patient: The patient record.
+// @return *models.PatientRecord: The patient record.
 // @return error: An error if the patient is not found or the operation fails.
-func (s *PatientService) GetPatient(ctx context.Context, id int64) (*models.Patient, error) {
-	query := `
-		SELECT id, first_name, last_name, date_of_birth, gender, contact_number, email, address, medical_history, created_by, updated_by, created_at, updated_at
-		FROM patients
-		WHERE id = $1
-	`
-	var patient models.Patient
-	err := s.db.QueryRowContext(ctx, query, id).Scan(
-		&patient.ID,
-		&patient.FirstName,
-		&patient.LastName,
-		&patient.DateOfBirth,
-		&patient.Gender,
-		&patient.ContactNumber,
-		&patient.Email,
-		&patient.Address,
-		&patient.MedicalHistory,
-		&patient.CreatedBy,
-		&patient.UpdatedBy,
-		&patient.CreatedAt,
-		&patient.UpdatedAt,
-	)
+func (s *PatientService) GetPatient(ctx context.Context, id int64) (*models.PatientRecord, error) {
+	patient, err := s.getPatient(ctx, s.db, id, false)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil, errors.New("patient not found")
-		}
-		log.Printf("Error retrieving patient: %v", err)
 		return nil, err
 	}
-	return &patient, nil
+
+	if err := s.audit.Record(ctx, nil, models.AuditEntry{Action: "patient.read", EntityType: "patient", EntityID: &id}); err != nil {
+		return nil, err
+	}
+	record := &models.PatientRecord{Patient: *patient}
+
+	if principal, ok := requestctx.PrincipalFrom(ctx); ok && principal.HasPermission("allergy.read") {
+		if record.Allergies, err = activeAllergies(ctx, s.db, id); err != nil {
+			return nil, err
+		}
+		err = s.audit.Record(ctx, nil, models.AuditEntry{
+			Action:     "allergy.list",
+			EntityType: "patient",
+			EntityID:   &id,
+			Details:    map[string]interface{}{"status": "active", "returned": len(record.Allergies)},
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return record, nil
 }
 
 // UpdatePatient updates an existing patient record in the database.
@@ -97,14 +288,152 @@ func (s *PatientService) GetPatient(ctx context.Context, id int64) (*models.Pati
 // @param ctx context.Context: The context for the request.
 // @param id int64: The ID of the patient to update.
 // @param patient *models.Patient: The updated patient data.
-// @return error: An error if the operation fails.
-func (s *PatientService) UpdatePatient(ctx context.Context, id int64, patient *models.Patient) error {
+// @param expectedVersion int: The version the caller last read.
+// @return int: The new version of the patient record.
+// @return error: ErrPatientVersionConflict if the record changed since expectedVersion, or an error if the operation fails.
+func (s *PatientService) UpdatePatient(ctx context.Context, id int64, patient *models.Patient, expectedVersion int) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// Lock the current row so the version check and recorded diff reflect exactly what this update replaced
+	current, err := s.getPatient(ctx, tx, id, true)
+	if err != nil {
+		return 0, err
+	}
+	if current.Version != expectedVersion {
+		return 0, ErrPatientVersionConflict
+	}
+
+	version, err := s.writePatient(ctx, tx, current, patient, "update")
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error updating patient: %v", err)
+		return 0, err
+	}
+	return version, nil
+}
+
+// patientPatchableFields are the JSON members of a patient that a partial update may change.
+var patientPatchableFields = map[string]bool{
+	"first_name":      true,
+	"last_name":       true,
+	"date_of_birth":   true,
+	"gender":          true,
+	"contact_number":  true,
+	"email":           true,
+	"address":         true,
+	"medical_history": true,
+}
+
+// PatchPatient applies a partial update to a patient record so that only the fields named in the
+// patch change. Both RFC 7396 merge patches and RFC 6902 JSON patches are supported.
+//
+// @param ctx context.Context: The context for the request.
+// @param id int64: The ID of the patient to update.
+// @param format PatchFormat: The format of the patch document.
+// @param patch []byte: The patch document.
+// @param expectedVersion int: The version the caller last read.
+// @param updatedBy int64: The ID of the user making the change.
+// @return *models.Patient: The updated patient record.
+// @return error: ErrInvalidPatch, ErrPatchTestFailed, ErrPatientVersionConflict, or an error if the operation fails.
+func (s *PatientService) PatchPatient(ctx context.Context, id int64, format PatchFormat, patch []byte, expectedVersion int, updatedBy int64) (*models.Patient, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	current, err := s.getPatient(ctx, tx, id, true)
+	if err != nil {
+		return nil, err
+	}
+	if current.Version != expectedVersion {
+		return nil, ErrPatientVersionConflict
+	}
+
+	patched, err := patchPatient(current, format, patch)
+	if err != nil {
+		return nil, err
+	}
+	patched.UpdatedBy = updatedBy
+
+	if _, err := s.writePatient(ctx, tx, current, patched, "patch"); err != nil {
+		return nil, err
+	}
+
+	updated, err := s.getPatient(ctx, tx, id, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error patching patient: %v", err)
+		return nil, err
+	}
+	return updated, nil
+}
+
+// patchPatient applies a patch to the patchable fields of a patient and returns the result.
+func patchPatient(current *models.Patient, format PatchFormat, patch []byte) (*models.Patient, error) {
+	encoded, err := json.Marshal(current)
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(encoded, &doc); err != nil {
+		return nil, err
+	}
+	for name := range doc {
+		if !patientPatchableFields[name] {
+			delete(doc, name)
+		}
+	}
+
+	if err := applyPatch(doc, format, patch); err != nil {
+		return nil, err
+	}
+	for name := range doc {
+		if !patientPatchableFields[name] {
+			return nil, fmt.Errorf("%w: field %s cannot be changed", ErrInvalidPatch, name)
+		}
+	}
+
+	encoded, err = json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	var patched models.Patient
+	if err := json.Unmarshal(encoded, &patched); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	if err := validation.ValidateStruct(&patched); err != nil {
+		return nil, InvalidInput(err)
+	}
+
+	patched.ID = current.ID
+	patched.CreatedBy = current.CreatedBy
+	patched.CreatedAt = current.CreatedAt
+	return &patched, nil
+}
+
+// writePatient overwrites the patient's fields, records the new version and audits each changed
+// field. The caller must hold the row lock on current within tx.
+func (s *PatientService) writePatient(ctx context.Context, tx dbtx, current, patient *models.Patient, action string) (int, error) {
+	patient.Gender = strings.ToLower(patient.Gender)
+
 	query := `
 		UPDATE patients
-		SET first_name = $1, last_name = $2, date_of_birth = $3, gender = $4, contact_number = $5, email = $6, address = $7, medical_history = $8, updated_by = $9, updated_at = CURRENT_TIMESTAMP
+		SET first_name = $1, last_name = $2, date_of_birth = $3, gender = $4, contact_number = $5, email = $6, address = $7, medical_history = $8, updated_by = $9, updated_at = CURRENT_TIMESTAMP, version = version + 1
 		WHERE id = $10
+		RETURNING version
 	`
-	_, err := s.db.ExecContext(ctx, query,
+	var version int
+	err := tx.QueryRowContext(ctx, query,
 		patient.FirstName,
 		patient.LastName,
 		patient.DateOfBirth,
@@ -114,26 +443,378 @@ func (s *PatientService) UpdatePatient(ctx context.Context, id int64, patient *m
 		patient.Address,
 		patient.MedicalHistory,
 		patient.UpdatedBy,
-		id,
-	)
+		current.ID,
+	).Scan(&version)
 	if err != nil {
 		log.Printf("Error updating patient: %v", err)
-		return err
+		return 0, err
 	}
-	return nil
+	if err := s.recordVersion(ctx, tx, current.ID, action, patient.UpdatedBy); err != nil {
+		return 0, err
+	}
+
+	err = s.audit.Record(ctx, tx, models.AuditEntry{
+		Action:     "patient." + action,
+		EntityType: "patient",
+		EntityID:   &current.ID,
+		Changes:    diffFields(current, patient),
+	})
+	if err != nil {
+		return 0, err
+	}
+	return version, nil
 }
 
-// DeletePatient removes a patient record from the database by ID.
+// DeletePatient soft-deletes a patient record so it is hidden from reads and listings while it
+// is retained. The record can be brought back with RestorePatient until it is purged.
 //
 // @param ctx context.Context: The context for the request.
 // @param id int64: The ID of the patient to delete.
-// @return error: An error if the operation fails.
-func (s *PatientService) DeletePatient(ctx context.Context, id int64) error {
-	query := `DELETE FROM patients WHERE id = $1`
-	_, err := s.db.ExecContext(ctx, query, id)
+// @param deletedBy int64: The ID of the user deleting the record.
+// @return error: An error if the patient is not found or the operation fails.
+func (s *PatientService) DeletePatient(ctx context.Context, id int64, deletedBy int64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := s.getPatient(ctx, tx, id, true); err != nil {
+		return err
+	}
+
+	query := `UPDATE patients SET deleted_at = CURRENT_TIMESTAMP, deleted_by = $1, version = version + 1 WHERE id = $2`
+	if _, err := tx.ExecContext(ctx, query, deletedBy, id); err != nil {
+		log.Printf("Error deleting patient: %v", err)
+		return err
+	}
+	if err := s.recordVersion(ctx, tx, id, "delete", deletedBy); err != nil {
+		return err
+	}
+
+	if err := s.audit.Record(ctx, tx, models.AuditEntry{Action: "patient.delete", EntityType: "patient", EntityID: &id}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
 		log.Printf("Error deleting patient: %v", err)
 		return err
 	}
 	return nil
-}
\ No newline at end of file
+}
+
+// RestorePatient brings back a soft-deleted patient record.
+//
+// @param ctx context.Context: The context for the request.
+// @param id int64: The ID of the patient to restore.
+// @param restoredBy int64: The ID of the user restoring the record.
+// @return error: An error if no deleted patient with this ID exists or the operation fails.
+func (s *PatientService) RestorePatient(ctx context.Context, id int64, restoredBy int64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE patients
+		SET deleted_at = NULL, deleted_by = NULL, updated_by = $1, updated_at = CURRENT_TIMESTAMP, version = version + 1
+		WHERE id = $2 AND deleted_at IS NOT NULL
+	`
+	result, err := tx.ExecContext(ctx, query, restoredBy, id)
+	if err != nil {
+		log.Printf("Error restoring patient: %v", err)
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrPatientNotFound
+	}
+	if err := s.recordVersion(ctx, tx, id, "restore", restoredBy); err != nil {
+		return err
+	}
+
+	if err := s.audit.Record(ctx, tx, models.AuditEntry{Action: "patient.restore", EntityType: "patient", EntityID: &id}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error restoring patient: %v", err)
+		return err
+	}
+	return nil
+}
+
+// PurgeDeletedPatients permanently removes patient records that were soft-deleted longer ago than
+// the retention period, along with their version history, contacts and insurance policies.
+//
+// Patients with clinical or billing records (appointments, encounters, vital signs, allergies,
+// prescriptions, lab orders, problems or invoices) are never purged: those records must be kept
+// for their own retention periods, so the patient they belong to stays soft-deleted.
+//
+// @param ctx context.Context: The context for the request.
+// @return []int64: The IDs of the purged patients.
+// @return error: An error if the operation fails.
+func (s *PatientService) PurgeDeletedPatients(ctx context.Context) ([]int64, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		DELETE FROM patients p
+		WHERE p.deleted_at IS NOT NULL AND p.deleted_at < $1
+			AND NOT EXISTS (SELECT 1 FROM appointments WHERE patient_id = p.id)
+			AND NOT EXISTS (SELECT 1 FROM encounters WHERE patient_id = p.id)
+			AND NOT EXISTS (SELECT 1 FROM vital_signs WHERE patient_id = p.id)
+			AND NOT EXISTS (SELECT 1 FROM allergies WHERE patient_id = p.id)
+			AND NOT EXISTS (SELECT 1 FROM prescriptions WHERE patient_id = p.id)
+			AND NOT EXISTS (SELECT 1 FROM lab_orders WHERE patient_id = p.id)
+			AND NOT EXISTS (SELECT 1 FROM patient_problems WHERE patient_id = p.id)
+			AND NOT EXISTS (SELECT 1 FROM invoices WHERE patient_id = p.id)
+		RETURNING p.id
+	`
+	rows, err := tx.QueryContext(ctx, query, time.Now().Add(-s.retentionPeriod))
+	if err != nil {
+		log.Printf("Error purging patients: %v", err)
+		return nil, err
+	}
+	ids := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			log.Printf("Error scanning purged patient: %v", err)
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating purged patients: %v", err)
+		return nil, err
+	}
+
+	for _, id := range ids {
+		id := id
+		err := s.audit.Record(ctx, tx, models.AuditEntry{
+			Action:     "patient.purge",
+			EntityType: "patient",
+			EntityID:   &id,
+			Details:    map[string]interface{}{"retention_period": s.retentionPeriod.String()},
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error purging patients: %v", err)
+		return nil, err
+	}
+	return ids, nil
+}
+
+// getPatient loads a patient that has not been deleted without auditing the access, optionally
+// locking the row for update.
+func (s *PatientService) getPatient(ctx context.Context, db dbtx, id int64, forUpdate bool) (*models.Patient, error) {
+	query := `SELECT ` + patientColumns + ` FROM patients WHERE id = $1 AND deleted_at IS NULL`
+	if forUpdate {
+		query += ` FOR UPDATE`
+	}
+	patient, err := scanPatient(db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrPatientNotFound
+		}
+		log.Printf("Error retrieving patient: %v", err)
+		return nil, err
+	}
+	return patient, nil
+}
+
+// ListPatients retrieves a filtered, sorted page of patient records.
+//
+// @param ctx context.Context: The context for the request.
+// @param params PatientListParams: The filters, sort order and pagination to apply.
+// @return *PatientListResult: The requested page of patients and the total number of matches.
+// @return error: An error if the sort key is invalid or the operation fails.
+func (s *PatientService) ListPatients(ctx context.Context, params PatientListParams) (*PatientListResult, error) {
+	params.normalize()
+
+	orderBy, err := params.orderBy()
+	if err != nil {
+		return nil, err
+	}
+	where, args := params.where()
+
+	var total int64
+	countQuery := `SELECT COUNT(*) FROM patients ` + where
+	if err := s.db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+		log.Printf("Error counting patients: %v", err)
+		return nil, err
+	}
+
+	args = append(args, params.PageSize, (params.Page-1)*params.PageSize)
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM patients
+		%s
+		%s
+		LIMIT $%d OFFSET $%d
+	`, patientColumns, where, orderBy, len(args)-1, len(args))
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Printf("Error listing patients: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	patients := make([]models.Patient, 0, params.PageSize)
+	for rows.Next() {
+		patient, err := scanPatient(rows)
+		if err != nil {
+			log.Printf("Error scanning patient: %v", err)
+			return nil, err
+		}
+		patients = append(patients, *patient)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating patients: %v", err)
+		return nil, err
+	}
+
+	err = s.audit.Record(ctx, nil, models.AuditEntry{
+		Action:     "patient.list",
+		EntityType: "patient",
+		Details: map[string]interface{}{
+			"filters":     params,
+			"patient_ids": patientIDs(patients),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &PatientListResult{
+		Patients: patients,
+		Total:    total,
+		Page:     params.Page,
+		PageSize: params.PageSize,
+	}, nil
+}
+
+// Limits applied to patient search results.
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 50
+)
+
+// PatientSearchResult is a patient matched by SearchPatients together with its relevance score.
+type PatientSearchResult struct {
+	models.Patient
+	Score float64 `json:"score"` // Relevance score; higher is a better match
+}
+
+// SearchPatients finds patients by partial name, phone number or email, tolerating typos.
+//
+// Full-text matches on the search_vector column are combined with trigram similarity on the
+// full name and email, and a digits-only substring match on the contact number, so that
+// "jon smth", "0712 345" and "jsmith@" all find John Smith.
+//
+// @param ctx context.Context: The context for the request.
+// @param q string: The search term.
+// @param limit int: The maximum number of results to return.
+// @return []PatientSearchResult: The matching patients ordered by relevance.
+// @return error: An error if the operation fails.
+func (s *PatientService) SearchPatients(ctx context.Context, q string, limit int) ([]PatientSearchResult, error) {
+	q = strings.TrimSpace(q)
+	if q == "" {
+		return []PatientSearchResult{}, nil
+	}
+	if limit < 1 {
+		limit = DefaultSearchLimit
+	}
+	if limit > MaxSearchLimit {
+		limit = MaxSearchLimit
+	}
+
+	// Only use the phone match when the term contains enough digits to be meaningful
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, q)
+	if len(digits) < 3 {
+		digits = ""
+	}
+
+	// The match conditions use exactly the expressions of the trigram indexes so that they can be
+	// used; a NULL email or contact number simply does not match
+	query := `
+		SELECT ` + patientColumns + `, score
+		FROM (
+			SELECT *,
+				ts_rank(search_vector, websearch_to_tsquery('simple', $1)) * 2
+				+ similarity(first_name || ' ' || last_name, $1)
+				+ similarity(COALESCE(email, ''), $1)
+				+ CASE WHEN $2 <> '' AND regexp_replace(COALESCE(contact_number, ''), '\D', '', 'g') LIKE '%' || $2 || '%' THEN 1 ELSE 0 END
+				AS score
+			FROM patients
+			WHERE deleted_at IS NULL AND (
+				search_vector @@ websearch_to_tsquery('simple', $1)
+				OR (first_name || ' ' || last_name) % $1
+				OR email % $1
+				OR ($2 <> '' AND regexp_replace(contact_number, '\D', '', 'g') LIKE '%' || $2 || '%')
+			)
+		) matches
+		ORDER BY score DESC, id
+		LIMIT $3
+	`
+	rows, err := s.db.QueryContext(ctx, query, q, digits, limit)
+	if err != nil {
+		log.Printf("Error searching patients: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make([]PatientSearchResult, 0, limit)
+	for rows.Next() {
+		var score float64
+		patient, err := scanPatient(rows, &score)
+		if err != nil {
+			log.Printf("Error scanning patient search result: %v", err)
+			return nil, err
+		}
+		results = append(results, PatientSearchResult{Patient: *patient, Score: score})
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating patient search results: %v", err)
+		return nil, err
+	}
+
+	ids := make([]int64, len(results))
+	for i, result := range results {
+		ids[i] = result.ID
+	}
+	err = s.audit.Record(ctx, nil, models.AuditEntry{
+		Action:     "patient.search",
+		EntityType: "patient",
+		Details:    map[string]interface{}{"query": q, "patient_ids": ids},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// patientIDs returns the IDs of the given patients, in order.
+func patientIDs(patients []models.Patient) []int64 {
+	ids := make([]int64, len(patients))
+	for i, patient := range patients {
+		ids[i] = patient.ID
+	}
+	return ids
+}
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Okemwag/medihub/internal/models"
+)
+
+// patientRow returns the values Postgres returns for a patient selected with patientColumns.
+func patientRow(gender interface{}) []driver.Value {
+	now := time.Now()
+	return []driver.Value{
+		int64(1), "Jane", "Doe", time.Date(1990, 5, 17, 0, 0, 0, 0, time.UTC), gender,
+		"+254700000000", "jane@example.com", "Nairobi", "", int64(1), int64(1), now, now, nil, nil, int64(2),
+	}
+}
+
+func TestScanPatientWithBlankGender(t *testing.T) {
+	// The gender migration stores an empty string for patients whose gender was never given
+	db := openFakeDB(t, strings.Split(patientColumns, ", "), patientRow(""))
+
+	patient, err := scanPatient(db.QueryRow(`SELECT `+patientColumns+` FROM patients WHERE id = $1`, 1))
+	if err != nil {
+		t.Fatalf("scanPatient() error = %v", err)
+	}
+	if patient.Gender != "" || patient.FirstName != "Jane" || patient.Version != 2 {
+		t.Errorf("scanPatient() = %+v, want Jane at version 2 with no gender", patient)
+	}
+}
+
+func TestScanPatientRejectsNullGender(t *testing.T) {
+	// NULL genders cannot be scanned, which is why the migration replaces them and forbids them
+	db := openFakeDB(t, strings.Split(patientColumns, ", "), patientRow(nil))
+
+	if _, err := scanPatient(db.QueryRow(`SELECT `+patientColumns+` FROM patients WHERE id = $1`, 1)); err == nil {
+		t.Fatal("scanPatient() error = nil for a NULL gender")
+	}
+}
+
+func TestPatchPatientGender(t *testing.T) {
+	current := &models.Patient{
+		ID:          1,
+		FirstName:   "Jane",
+		LastName:    "Doe",
+		DateOfBirth: time.Date(1990, 5, 17, 0, 0, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		name       string
+		gender     string
+		patch      string
+		wantGender string
+		wantErr    bool
+	}{
+		{name: "blank gender is kept", gender: "", patch: `{"first_name":"Janet"}`, wantGender: ""},
+		{name: "gender in another case", gender: "", patch: `{"gender":"Female"}`, wantGender: "Female"},
+		{name: "unknown gender code", gender: "female", patch: `{"gender":"f"}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patient := *current
+			patient.Gender = tt.gender
+
+			patched, err := patchPatient(&patient, MergePatch, []byte(tt.patch))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("patchPatient() error = nil, want a validation error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("patchPatient() error = %v", err)
+			}
+			if patched.Gender != tt.wantGender {
+				t.Errorf("Gender = %q, want %q", patched.Gender, tt.wantGender)
+			}
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/Okemwag/medihub/internal/models"
+)
+
+// Errors returned by PayerService.
+var (
+	ErrPayerNotFound  = NewNotFoundError("payer_not_found", "payer not found")
+	ErrDuplicatePayer = NewConflictError("duplicate_payer", "a payer with this code already exists")
+)
+
+// PayerService maintains the list of insurers and public schemes that cover patients.
+type PayerService struct {
+	db    *sql.DB
+	audit *AuditService
+}
+
+// NewPayerService creates a new instance of PayerService.
+//
+// @param db *sql.DB: A database connection.
+// @param audit *AuditService: The service used to audit payer changes.
+// @return *PayerService: A new PayerService instance.
+func NewPayerService(db *sql.DB, audit *AuditService) *PayerService {
+	return &PayerService{db: db, audit: audit}
+}
+
+// payerColumns lists the payer columns in the order expected by scanPayer.
+const payerColumns = `id, code, name, payer_type, COALESCE(phone, ''), COALESCE(email, ''), active, created_at, updated_at`
+
+// scanPayer reads a single payer selected with payerColumns.
+func scanPayer(row rowScanner) (*models.Payer, error) {
+	var p models.Payer
+	err := row.Scan(&p.ID, &p.Code, &p.Name, &p.PayerType, &p.Phone, &p.Email, &p.Active, &p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// ListPayers retrieves the payers, ordered by name.
+//
+// @param ctx context.Context: The context for the request.
+// @param includeInactive bool: Whether to include payers that no longer cover patients.
+// @return []models.Payer: The payers.
+// @return error: An error if the operation fails.
+func (s *PayerService) ListPayers(ctx context.Context, includeInactive bool) ([]models.Payer, error) {
+	query := `SELECT ` + payerColumns + ` FROM payers WHERE $1 OR active ORDER BY name, id`
+	rows, err := s.db.QueryContext(ctx, query, includeInactive)
+	if err != nil {
+		log.Printf("Error listing payers: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	payers := []models.Payer{}
+	for rows.Next() {
+		p, err := scanPayer(rows)
+		if err != nil {
+			log.Printf("Error scanning payer: %v", err)
+			return nil, err
+		}
+		payers = append(payers, *p)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating payers: %v", err)
+		return nil, err
+	}
+	return payers, nil
+}
+
+// GetPayer retrieves a payer by ID.
+//
+// @param ctx context.Context: The context for the request.
+// @param id int64: The ID of the payer.
+// @return *models.Payer: The payer.
+// @return error: ErrPayerNotFound, or an error if the operation fails.
+func (s *PayerService) GetPayer(ctx context.Context, id int64) (*models.Payer, error) {
+	return getPayer(ctx, s.db, id)
+}
+
+// CreatePayer adds a payer. New payers are always active.
+//
+// @param ctx context.Context: The context for the request.
+// @param payer *models.Payer: The payer to add.
+// @return *models.Payer: The added payer.
+// @return error: ErrDuplicatePayer, or an error if the operation fails.
+func (s *PayerService) CreatePayer(ctx context.Context, payer *models.Payer) (*models.Payer, error) {
+	query := `
+		INSERT INTO payers (code, name, payer_type, phone, email, active)
+		VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), TRUE)
+		RETURNING id
+	`
+	var id int64
+	err := s.db.QueryRowContext(ctx, query,
+		strings.ToUpper(strings.TrimSpace(payer.Code)),
+		strings.TrimSpace(payer.Name),
+		payer.PayerType,
+		strings.TrimSpace(payer.Phone),
+		strings.TrimSpace(payer.Email),
+	).Scan(&id)
+	if err != nil {
+		return nil, catalogueWriteError("creating payer", err, ErrDuplicatePayer)
+	}
+
+	created, err := getPayer(ctx, s.db, id)
+	if err != nil {
+		return nil, err
+	}
+	err = s.audit.Record(ctx, nil, models.AuditEntry{
+		Action:     "payer.create",
+		EntityType: "payer",
+		EntityID:   &id,
+		Changes:    diffFields(nil, created),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// UpdatePayer replaces the details of a payer, including whether it still covers patients.
+//
+// @param ctx context.Context: The context for the request.
+// @param id int64: The ID of the payer.
+// @param payer *models.Payer: The new details of the payer.
+// @return *models.Payer: The updated payer.
+// @return error: ErrPayerNotFound, ErrDuplicatePayer, or an error if the operation fails.
+func (s *PayerService) UpdatePayer(ctx context.Context, id int64, payer *models.Payer) (*models.Payer, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	current, err := getPayer(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		UPDATE payers
+		SET code = $1, name = $2, payer_type = $3, phone = NULLIF($4, ''), email = NULLIF($5, ''), active = $6, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $7
+	`
+	_, err = tx.ExecContext(ctx, query,
+		strings.ToUpper(strings.TrimSpace(payer.Code)),
+		strings.TrimSpace(payer.Name),
+		payer.PayerType,
+		strings.TrimSpace(payer.Phone),
+		strings.TrimSpace(payer.Email),
+		payer.Active,
+		id,
+	)
+	if err != nil {
+		return nil, catalogueWriteError("updating payer", err, ErrDuplicatePayer)
+	}
+
+	updated, err := getPayer(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	err = s.audit.Record(ctx, tx, models.AuditEntry{
+		Action:     "payer.update",
+		EntityType: "payer",
+		EntityID:   &id,
+		Changes:    diffFields(current, updated),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error updating payer: %v", err)
+		return nil, err
+	}
+	return updated, nil
+}
+
+// getPayer loads a payer by ID.
+func getPayer(ctx context.Context, db dbtx, id int64) (*models.Payer, error) {
+	query := `SELECT ` + payerColumns + ` FROM payers WHERE id = $1`
+	p, err := scanPayer(db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrPayerNotFound
+		}
+		log.Printf("Error retrieving payer: %v", err)
+		return nil, err
+	}
+	return p, nil
+}
@@ -0,0 +1,256 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Okemwag/medihub/internal/models"
+)
+
+// Errors returned by PermissionService.
+var (
+	ErrUnknownPermission = NewValidationError("unknown_permission", "unknown permission")
+	ErrRoleManageLockout = NewConflictError("role_manage_lockout", "role.manage cannot be revoked from the admin role")
+)
+
+// roleManagePermission is the permission needed to change role permissions; it is never revoked
+// from adminRole so that permissions can always be repaired through the API.
+const roleManagePermission = "role.manage"
+
+// PermissionService resolves the permissions granted to a role from the role_permissions table
+// and lets administrators grant and revoke them.
+//
+// Resolved permission sets are cached in-process for a limited time so that authorization checks
+// do not hit the database on every request. Grants and revocations made through this service
+// invalidate the cached entry of the role at once; changes made directly in the database become
+// visible once the cached entry expires, or immediately after Invalidate/InvalidateAll is called.
+// Other instances pick up any change when their own cached entry expires.
+type PermissionService struct {
+	db       *sql.DB                     // Database connection
+	audit    *AuditService               // Records every change to role permissions
+	cacheTTL time.Duration               // How long a resolved permission set stays cached
+	mu       sync.RWMutex                // Guards cache
+	cache    map[string]cachedPermission // Cached permission sets keyed by lower-cased role name
+}
+
+// cachedPermission is a permission set resolved for a role and the time it expires from the cache.
+type cachedPermission struct {
+	permissions map[string]struct{}
+	expiresAt   time.Time
+}
+
+// NewPermissionService creates a new instance of PermissionService.
+//
+// @param db *sql.DB: A database connection.
+// @param audit *AuditService: The service used to audit permission changes.
+// @param cacheTTL time.Duration: How long resolved permissions are cached before being reloaded.
+// @return *PermissionService: A new PermissionService instance.
+func NewPermissionService(db *sql.DB, audit *AuditService, cacheTTL time.Duration) *PermissionService {
+	return &PermissionService{
+		db:       db,
+		audit:    audit,
+		cacheTTL: cacheTTL,
+		cache:    make(map[string]cachedPermission),
+	}
+}
+
+// PermissionsForRole returns the names of all permissions granted to the given role.
+//
+// @param ctx context.Context: The context for the request.
+// @param role string: The role name.
+// @return []string: The permission names.
+// @return error: An error if the permissions could not be resolved.
+func (s *PermissionService) PermissionsForRole(ctx context.Context, role string) ([]string, error) {
+	permissions, err := s.resolve(ctx, role)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(permissions))
+	for name := range permissions {
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// ListRolePermissions returns the names of the permissions granted to a role, sorted, as stored
+// in the database rather than as cached.
+//
+// @param ctx context.Context: The context for the request.
+// @param role string: The role name.
+// @return []string: The permission names.
+// @return error: ErrUnknownRole, or an error if the operation fails.
+func (s *PermissionService) ListRolePermissions(ctx context.Context, role string) ([]string, error) {
+	if _, err := lookupID(ctx, s.db, `SELECT id FROM roles WHERE LOWER(name) = LOWER($1)`, role, ErrUnknownRole); err != nil {
+		return nil, err
+	}
+	s.Invalidate(role)
+	names, err := s.PermissionsForRole(ctx, role)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// GrantPermission grants a permission to a role. Granting a permission the role already holds
+// has no effect.
+//
+// @param ctx context.Context: The context for the request.
+// @param role string: The role name.
+// @param permission string: The permission name, e.g. "patient.update".
+// @return error: ErrUnknownRole, ErrUnknownPermission, or an error if the operation fails.
+func (s *PermissionService) GrantPermission(ctx context.Context, role, permission string) error {
+	return s.changeRolePermission(ctx, role, permission, "role.grant_permission", `
+		INSERT INTO role_permissions (role_id, permission_id) VALUES ($1, $2) ON CONFLICT DO NOTHING
+	`)
+}
+
+// RevokePermission revokes a permission from a role. Revoking a permission the role does not
+// hold has no effect. role.manage is never revoked from the admin role.
+//
+// @param ctx context.Context: The context for the request.
+// @param role string: The role name.
+// @param permission string: The permission name, e.g. "patient.update".
+// @return error: ErrUnknownRole, ErrUnknownPermission, ErrRoleManageLockout, or an error if the operation fails.
+func (s *PermissionService) RevokePermission(ctx context.Context, role, permission string) error {
+	if strings.EqualFold(role, adminRole) && permission == roleManagePermission {
+		return ErrRoleManageLockout
+	}
+	return s.changeRolePermission(ctx, role, permission, "role.revoke_permission", `
+		DELETE FROM role_permissions WHERE role_id = $1 AND permission_id = $2
+	`)
+}
+
+// ReloadPermissions drops every cached permission set on this instance, e.g. after
+// role_permissions was changed directly in the database.
+//
+// @param ctx context.Context: The context for the request.
+// @return error: An error if the reload could not be audited.
+func (s *PermissionService) ReloadPermissions(ctx context.Context) error {
+	s.InvalidateAll()
+	return s.audit.Record(ctx, nil, models.AuditEntry{Action: "role.reload_permissions", EntityType: "role"})
+}
+
+// Invalidate drops the cached permissions for a role so they are reloaded on the next check.
+//
+// @param role string: The role name.
+func (s *PermissionService) Invalidate(role string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.cache, strings.ToLower(role))
+}
+
+// InvalidateAll drops every cached permission set.
+func (s *PermissionService) InvalidateAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cache = make(map[string]cachedPermission)
+}
+
+// changeRolePermission runs a grant or revoke statement for a role and permission, audits it and
+// invalidates the role's cached permissions.
+func (s *PermissionService) changeRolePermission(ctx context.Context, role, permission, action, query string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	roleID, err := lookupID(ctx, tx, `SELECT id FROM roles WHERE LOWER(name) = LOWER($1)`, role, ErrUnknownRole)
+	if err != nil {
+		return err
+	}
+	permissionID, err := lookupID(ctx, tx, `SELECT id FROM permissions WHERE name = $1`, permission, ErrUnknownPermission)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx, query, roleID, permissionID)
+	if err != nil {
+		log.Printf("Error changing permissions of role %s: %v", role, err)
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return nil
+	}
+
+	err = s.audit.Record(ctx, tx, models.AuditEntry{
+		Action:     action,
+		EntityType: "role",
+		EntityID:   &roleID,
+		Details:    map[string]interface{}{"role": role, "permission": permission},
+	})
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	s.Invalidate(role)
+	return nil
+}
+
+// lookupID runs a query selecting a single ID by name, returning notFound when there is no match.
+func lookupID(ctx context.Context, db dbtx, query, name string, notFound error) (int64, error) {
+	var id int64
+	if err := db.QueryRowContext(ctx, query, name).Scan(&id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, notFound
+		}
+		return 0, err
+	}
+	return id, nil
+}
+
+// resolve returns the permission set for a role, loading it from the database when it is not cached.
+func (s *PermissionService) resolve(ctx context.Context, role string) (map[string]struct{}, error) {
+	key := strings.ToLower(role)
+
+	s.mu.RLock()
+	entry, ok := s.cache[key]
+	s.mu.RUnlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.permissions, nil
+	}
+
+	query := `
+		SELECT p.name
+		FROM role_permissions rp
+		JOIN roles r ON r.id = rp.role_id
+		JOIN permissions p ON p.id = rp.permission_id
+		WHERE LOWER(r.name) = $1
+	`
+	rows, err := s.db.QueryContext(ctx, query, key)
+	if err != nil {
+		log.Printf("Error loading permissions for role %s: %v", role, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	permissions := make(map[string]struct{})
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			log.Printf("Error scanning permission: %v", err)
+			return nil, err
+		}
+		permissions[name] = struct{}{}
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating permissions: %v", err)
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.cache[key] = cachedPermission{permissions: permissions, expiresAt: time.Now().Add(s.cacheTTL)}
+	s.mu.Unlock()
+
+	return permissions, nil
+}
@@ -0,0 +1,655 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Okemwag/medihub/internal/models"
+	"github.com/Okemwag/medihub/internal/validation"
+	"github.com/lib/pq"
+)
+
+// Errors returned by PrescriptionService.
+var (
+	ErrPrescriptionNotFound           = NewNotFoundError("prescription_not_found", "prescription not found")
+	ErrPrescriptionNotActive          = NewConflictError("prescription_not_active", "the prescription has already been discontinued")
+	ErrInvalidDrug                    = NewValidationError("invalid_drug", "drug_id must refer to an active drug in the catalogue")
+	ErrInvalidPrescriptionEncounter   = NewValidationError("invalid_prescription_encounter", "encounter_id must refer to an encounter of the same patient")
+	ErrInvalidPrescriptionStatusQuery = NewValidationError("invalid_status", "status must be active, current or discontinued")
+)
+
+// PrescriptionService provides methods for prescribing medications and checking them against
+// the patient's allergies, current medications and the drug interaction table.
+type PrescriptionService struct {
+	db    *sql.DB
+	audit *AuditService
+}
+
+// NewPrescriptionService creates a new instance of PrescriptionService.
+//
+// @param db *sql.DB: A database connection.
+// @param audit *AuditService: The service used to audit prescription access.
+// @return *PrescriptionService: A new PrescriptionService instance.
+func NewPrescriptionService(db *sql.DB, audit *AuditService) *PrescriptionService {
+	return &PrescriptionService{db: db, audit: audit}
+}
+
+// prescriptionColumns lists the prescription columns, with the drug and prescriber names, in the
+// order expected by scanPrescription. It must be used with prescriptionFrom.
+const prescriptionColumns = `p.id, p.patient_id, p.encounter_id, p.drug_id, d.name, d.generic_name, p.dose, p.route, p.frequency, p.duration_days, COALESCE(p.instructions, ''), to_char(p.starts_on, 'YYYY-MM-DD'), to_char(p.ends_on, 'YYYY-MM-DD'), p.status, COALESCE(p.override_reason, ''), p.alerts, p.prescriber_id, COALESCE(u.name, ''), p.discontinued_at, p.discontinued_by, COALESCE(p.discontinued_reason, ''), p.created_at, p.updated_at`
+
+// prescriptionFrom joins prescriptions to their drug and prescriber.
+const prescriptionFrom = `prescriptions p JOIN drugs d ON d.id = p.drug_id LEFT JOIN users u ON u.id = p.prescriber_id`
+
+// scanPrescription reads a single prescription selected with prescriptionColumns.
+func scanPrescription(row rowScanner) (*models.Prescription, error) {
+	var p models.Prescription
+	var alerts []byte
+	err := row.Scan(
+		&p.ID,
+		&p.PatientID,
+		&p.EncounterID,
+		&p.DrugID,
+		&p.DrugName,
+		&p.GenericName,
+		&p.Dose,
+		&p.Route,
+		&p.Frequency,
+		&p.DurationDays,
+		&p.Instructions,
+		&p.StartsOn,
+		&p.EndsOn,
+		&p.Status,
+		&p.OverrideReason,
+		&alerts,
+		&p.PrescriberID,
+		&p.PrescriberName,
+		&p.DiscontinuedAt,
+		&p.DiscontinuedBy,
+		&p.DiscontinuedReason,
+		&p.CreatedAt,
+		&p.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(alerts, &p.Alerts); err != nil {
+		return nil, fmt.Errorf("decoding alerts of prescription %d: %w", p.ID, err)
+	}
+	return &p, nil
+}
+
+// PrescriptionCheck is the result of checking a prescription before it is written.
+type PrescriptionCheck struct {
+	Alerts  []models.PrescriptionAlert `json:"alerts"`
+	Blocked bool                       `json:"blocked"` // Whether any alert prevents the prescription
+}
+
+// CheckPrescription reports the safety alerts a prescription would raise without writing it.
+//
+// @param ctx context.Context: The context for the request.
+// @param patientID int64: The ID of the patient.
+// @param prescription *models.Prescription: The prescription to check.
+// @return *PrescriptionCheck: The alerts raised by the prescription.
+// @return error: ErrPatientNotFound, ErrInvalidDrug, or an error if the operation fails.
+func (s *PrescriptionService) CheckPrescription(ctx context.Context, patientID int64, prescription *models.Prescription) (*PrescriptionCheck, error) {
+	startsOn, err := prescriptionStart(prescription)
+	if err != nil {
+		return nil, err
+	}
+	if err := requirePatient(ctx, s.db, patientID); err != nil {
+		return nil, err
+	}
+	drug, err := requireActiveDrug(ctx, s.db, prescription.DrugID)
+	if err != nil {
+		return nil, err
+	}
+
+	alerts, err := prescriptionAlerts(ctx, s.db, patientID, drug, startsOn, prescriptionEnd(startsOn, prescription.DurationDays))
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.audit.Record(ctx, nil, models.AuditEntry{
+		Action:     "prescription.check",
+		EntityType: "patient",
+		EntityID:   &patientID,
+		Details:    map[string]interface{}{"drug_id": drug.ID, "alerts": len(alerts)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &PrescriptionCheck{Alerts: alerts, Blocked: hasBlockingAlert(alerts)}, nil
+}
+
+// CreatePrescription prescribes a medication to a patient. The prescription is refused when it
+// raises a blocking alert, or when it raises warnings and no override reason is given; the
+// warnings that were overridden are stored with the prescription.
+//
+// @param ctx context.Context: The context for the request.
+// @param prescription *models.Prescription: The prescription to write; it starts today unless starts_on is given.
+// @return *models.Prescription: The written prescription.
+// @return error: ErrPatientNotFound, ErrInvalidDoctor, ErrInvalidDrug, ErrInvalidPrescriptionEncounter, a conflict listing the alerts, or an error if the operation fails.
+func (s *PrescriptionService) CreatePrescription(ctx context.Context, prescription *models.Prescription) (*models.Prescription, error) {
+	startsOn, err := prescriptionStart(prescription)
+	if err != nil {
+		return nil, err
+	}
+	endsOn := prescriptionEnd(startsOn, prescription.DurationDays)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := requirePatient(ctx, tx, prescription.PatientID); err != nil {
+		return nil, err
+	}
+	if err := requireDoctor(ctx, tx, prescription.PrescriberID); err != nil {
+		return nil, err
+	}
+	if err := requirePatientEncounter(ctx, tx, prescription.EncounterID, prescription.PatientID, ErrInvalidPrescriptionEncounter); err != nil {
+		return nil, err
+	}
+	drug, err := requireActiveDrug(ctx, tx, prescription.DrugID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Serialize prescribing for the patient so that concurrent prescriptions are checked against each other
+	if _, err := tx.ExecContext(ctx, `SELECT 1 FROM patients WHERE id = $1 FOR UPDATE`, prescription.PatientID); err != nil {
+		log.Printf("Error locking patient: %v", err)
+		return nil, err
+	}
+
+	alerts, err := prescriptionAlerts(ctx, tx, prescription.PatientID, drug, startsOn, endsOn)
+	if err != nil {
+		return nil, err
+	}
+	overrideReason := strings.TrimSpace(prescription.OverrideReason)
+	if err := alertError(alerts, overrideReason); err != nil {
+		return nil, err
+	}
+	encodedAlerts, err := jsonColumn(alerts, true)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO prescriptions (patient_id, encounter_id, drug_id, dose, route, frequency, duration_days, instructions, starts_on, ends_on, status, override_reason, alerts, prescriber_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NULLIF($12, ''), $13, $14)
+		RETURNING id
+	`
+	var id int64
+	err = tx.QueryRowContext(ctx, query,
+		prescription.PatientID,
+		prescription.EncounterID,
+		drug.ID,
+		prescription.Dose,
+		prescription.Route,
+		prescription.Frequency,
+		prescription.DurationDays,
+		prescription.Instructions,
+		startsOn.Format("2006-01-02"),
+		endsOn.Format("2006-01-02"),
+		models.PrescriptionActive,
+		overrideReason,
+		encodedAlerts,
+		prescription.PrescriberID,
+	).Scan(&id)
+	if err != nil {
+		log.Printf("Error creating prescription: %v", err)
+		return nil, err
+	}
+
+	created, err := getPrescription(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	err = s.audit.Record(ctx, tx, models.AuditEntry{
+		Action:     "prescription.create",
+		EntityType: "prescription",
+		EntityID:   &id,
+		Changes:    diffFields(nil, created),
+		PatientID:  &created.PatientID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error creating prescription: %v", err)
+		return nil, err
+	}
+	return created, nil
+}
+
+// GetPrescription retrieves a prescription by ID.
+//
+// @param ctx context.Context: The context for the request.
+// @param id int64: The ID of the prescription.
+// @return *models.Prescription: The prescription.
+// @return error: ErrPrescriptionNotFound, or an error if the operation fails.
+func (s *PrescriptionService) GetPrescription(ctx context.Context, id int64) (*models.Prescription, error) {
+	prescription, err := getPrescription(ctx, s.db, id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.audit.Record(ctx, nil, models.AuditEntry{
+		Action:     "prescription.read",
+		EntityType: "prescription",
+		EntityID:   &id,
+		PatientID:  &prescription.PatientID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return prescription, nil
+}
+
+// ListPatientPrescriptions retrieves a patient's prescriptions, most recent first.
+//
+// @param ctx context.Context: The context for the request.
+// @param patientID int64: The ID of the patient.
+// @param status string: active, discontinued, current (active and not yet ended), or empty for all prescriptions.
+// @return []models.Prescription: The prescriptions.
+// @return error: ErrPatientNotFound, ErrInvalidPrescriptionStatusQuery, or an error if the operation fails.
+func (s *PrescriptionService) ListPatientPrescriptions(ctx context.Context, patientID int64, status string) ([]models.Prescription, error) {
+	var filter string
+	switch status {
+	case "":
+	case models.PrescriptionActive, models.PrescriptionDiscontinued:
+		filter = ` AND p.status = '` + status + `'`
+	case "current":
+		filter = ` AND p.status = 'active' AND p.ends_on >= CURRENT_DATE`
+	default:
+		return nil, ErrInvalidPrescriptionStatusQuery
+	}
+
+	if err := requirePatient(ctx, s.db, patientID); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT ` + prescriptionColumns + `
+		FROM ` + prescriptionFrom + `
+		WHERE p.patient_id = $1` + filter + `
+		ORDER BY p.starts_on DESC, p.id DESC
+	`
+	rows, err := s.db.QueryContext(ctx, query, patientID)
+	if err != nil {
+		log.Printf("Error listing prescriptions: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	prescriptions := []models.Prescription{}
+	for rows.Next() {
+		prescription, err := scanPrescription(rows)
+		if err != nil {
+			log.Printf("Error scanning prescription: %v", err)
+			return nil, err
+		}
+		prescriptions = append(prescriptions, *prescription)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating prescriptions: %v", err)
+		return nil, err
+	}
+
+	err = s.audit.Record(ctx, nil, models.AuditEntry{
+		Action:     "prescription.list",
+		EntityType: "patient",
+		EntityID:   &patientID,
+		Details:    map[string]interface{}{"status": status, "returned": len(prescriptions)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return prescriptions, nil
+}
+
+// DiscontinuePrescription stops an active prescription before its end date.
+//
+// @param ctx context.Context: The context for the request.
+// @param id int64: The ID of the prescription.
+// @param reason string: Why the prescription is stopped.
+// @param discontinuedBy int64: The ID of the user stopping the prescription.
+// @return *models.Prescription: The discontinued prescription.
+// @return error: ErrPrescriptionNotFound, ErrPrescriptionNotActive, or an error if the operation fails.
+func (s *PrescriptionService) DiscontinuePrescription(ctx context.Context, id int64, reason string, discontinuedBy int64) (*models.Prescription, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	current, err := getPrescription(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if current.Status != models.PrescriptionActive {
+		return nil, ErrPrescriptionNotActive
+	}
+
+	query := `
+		UPDATE prescriptions
+		SET status = $1, discontinued_at = CURRENT_TIMESTAMP, discontinued_by = $2, discontinued_reason = $3, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $4
+	`
+	if _, err := tx.ExecContext(ctx, query, models.PrescriptionDiscontinued, discontinuedBy, reason, id); err != nil {
+		log.Printf("Error discontinuing prescription: %v", err)
+		return nil, err
+	}
+
+	updated, err := getPrescription(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	err = s.audit.Record(ctx, tx, models.AuditEntry{
+		Action:     "prescription.discontinue",
+		EntityType: "prescription",
+		EntityID:   &id,
+		Changes:    diffFields(current, updated),
+		PatientID:  &updated.PatientID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error discontinuing prescription: %v", err)
+		return nil, err
+	}
+	return updated, nil
+}
+
+// getPrescription loads a prescription by ID.
+func getPrescription(ctx context.Context, db dbtx, id int64) (*models.Prescription, error) {
+	query := `SELECT ` + prescriptionColumns + ` FROM ` + prescriptionFrom + ` WHERE p.id = $1`
+	prescription, err := scanPrescription(db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrPrescriptionNotFound
+		}
+		log.Printf("Error retrieving prescription: %v", err)
+		return nil, err
+	}
+	return prescription, nil
+}
+
+// requireActiveDrug loads a drug that can be prescribed.
+func requireActiveDrug(ctx context.Context, db dbtx, drugID int64) (*models.Drug, error) {
+	drug, err := getDrug(ctx, db, drugID)
+	if err != nil {
+		if errors.Is(err, ErrDrugNotFound) {
+			return nil, ErrInvalidDrug
+		}
+		return nil, err
+	}
+	if !drug.Active {
+		return nil, ErrInvalidDrug
+	}
+	return drug, nil
+}
+
+// requirePatientEncounter checks that an optional encounter belongs to the patient, returning
+// invalid when it does not.
+func requirePatientEncounter(ctx context.Context, db dbtx, encounterID *int64, patientID int64, invalid error) error {
+	if encounterID == nil {
+		return nil
+	}
+	var found int
+	err := db.QueryRowContext(ctx, `SELECT 1 FROM encounters WHERE id = $1 AND patient_id = $2`, *encounterID, patientID).Scan(&found)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return invalid
+		}
+		log.Printf("Error checking encounter: %v", err)
+		return err
+	}
+	return nil
+}
+
+// prescriptionStart parses the start date of a prescription, defaulting to today.
+func prescriptionStart(prescription *models.Prescription) (time.Time, error) {
+	if prescription.StartsOn == "" {
+		now := time.Now()
+		return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local), nil
+	}
+	startsOn, err := time.ParseInLocation("2006-01-02", prescription.StartsOn, time.Local)
+	if err != nil {
+		return time.Time{}, NewValidationError("invalid_starts_on", "starts_on must be a date in the format YYYY-MM-DD")
+	}
+	return startsOn, nil
+}
+
+// prescriptionEnd returns the last day of a prescription taken for the given number of days.
+func prescriptionEnd(startsOn time.Time, durationDays int) time.Time {
+	return startsOn.AddDate(0, 0, durationDays-1)
+}
+
+// currentMedication is a prescription that overlaps the period of a new prescription.
+type currentMedication struct {
+	id          int64
+	name        string
+	genericName string
+	drugClass   string
+}
+
+// prescriptionAlerts checks a drug against the patient's active allergies and the medications
+// the patient takes during the given period.
+func prescriptionAlerts(ctx context.Context, db dbtx, patientID int64, drug *models.Drug, startsOn, endsOn time.Time) ([]models.PrescriptionAlert, error) {
+	alerts := []models.PrescriptionAlert{}
+
+	allergies, err := activeAllergies(ctx, db, patientID)
+	if err != nil {
+		return nil, err
+	}
+	for _, allergy := range allergies {
+		if allergy.Category != "drug" && allergy.Category != "other" {
+			continue
+		}
+		if !substanceMatches(allergy.Substance, drug.Name, drug.GenericName, drug.DrugClass) {
+			continue
+		}
+		severity := models.AlertWarning
+		if allergy.Severity == "severe" || allergy.Severity == "life_threatening" {
+			severity = models.AlertBlock
+		}
+		id := allergy.ID
+		alerts = append(alerts, models.PrescriptionAlert{
+			Type:      models.AlertAllergy,
+			Severity:  severity,
+			Message:   fmt.Sprintf("patient has a %s allergy to %s", strings.ReplaceAll(allergy.Severity, "_", "-"), allergy.Substance),
+			RelatedID: &id,
+		})
+	}
+
+	medications, err := currentMedications(ctx, db, patientID, startsOn, endsOn)
+	if err != nil {
+		return nil, err
+	}
+	if len(medications) == 0 {
+		return alerts, nil
+	}
+
+	drugSubstances := substancesOf(drug.GenericName, drug.DrugClass)
+	substances := append([]string{}, drugSubstances...)
+	for _, m := range medications {
+		id := m.id
+		switch {
+		case normalizeSubstance(m.genericName) == normalizeSubstance(drug.GenericName):
+			alerts = append(alerts, models.PrescriptionAlert{
+				Type:      models.AlertDuplicateTherapy,
+				Severity:  models.AlertBlock,
+				Message:   fmt.Sprintf("patient is already taking %s (%s)", m.name, m.genericName),
+				RelatedID: &id,
+			})
+		case drug.DrugClass != "" && normalizeSubstance(m.drugClass) == normalizeSubstance(drug.DrugClass):
+			alerts = append(alerts, models.PrescriptionAlert{
+				Type:      models.AlertDuplicateTherapy,
+				Severity:  models.AlertWarning,
+				Message:   fmt.Sprintf("patient is already taking %s from the same class (%s)", m.name, m.drugClass),
+				RelatedID: &id,
+			})
+		}
+		substances = append(substances, substancesOf(m.genericName, m.drugClass)...)
+	}
+
+	interactions, err := queryInteractions(ctx, db, `
+		SELECT id, substance_a, substance_b, severity, COALESCE(description, ''), created_at
+		FROM drug_interactions
+		WHERE substance_a = ANY($1) AND substance_b = ANY($1)
+		ORDER BY substance_a, substance_b
+	`, pq.Array(substances))
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range medications {
+		medicationSubstances := substancesOf(m.genericName, m.drugClass)
+		for _, interaction := range interactions {
+			if !interactionBetween(interaction, drugSubstances, medicationSubstances) {
+				continue
+			}
+			severity := models.AlertWarning
+			if interaction.Severity == "contraindicated" {
+				severity = models.AlertBlock
+			}
+			message := fmt.Sprintf("%s interaction with %s (%s and %s)", interaction.Severity, m.name, interaction.SubstanceA, interaction.SubstanceB)
+			if interaction.Description != "" {
+				message += ": " + interaction.Description
+			}
+			id := m.id
+			alerts = append(alerts, models.PrescriptionAlert{
+				Type:      models.AlertInteraction,
+				Severity:  severity,
+				Message:   message,
+				RelatedID: &id,
+			})
+		}
+	}
+	return alerts, nil
+}
+
+// currentMedications loads the active prescriptions of a patient that overlap the given period.
+func currentMedications(ctx context.Context, db dbtx, patientID int64, startsOn, endsOn time.Time) ([]currentMedication, error) {
+	query := `
+		SELECT p.id, d.name, d.generic_name, COALESCE(d.drug_class, '')
+		FROM prescriptions p
+		JOIN drugs d ON d.id = p.drug_id
+		WHERE p.patient_id = $1 AND p.status = 'active' AND p.ends_on >= $2::date AND p.starts_on <= $3::date
+		ORDER BY p.id
+	`
+	rows, err := db.QueryContext(ctx, query, patientID, startsOn.Format("2006-01-02"), endsOn.Format("2006-01-02"))
+	if err != nil {
+		log.Printf("Error listing current medications: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var medications []currentMedication
+	for rows.Next() {
+		var m currentMedication
+		if err := rows.Scan(&m.id, &m.name, &m.genericName, &m.drugClass); err != nil {
+			log.Printf("Error scanning current medication: %v", err)
+			return nil, err
+		}
+		medications = append(medications, m)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating current medications: %v", err)
+		return nil, err
+	}
+	return medications, nil
+}
+
+// substancesOf returns the normalized, non-empty substances a drug is known by in the interaction table.
+func substancesOf(names ...string) []string {
+	var substances []string
+	for _, name := range names {
+		if s := normalizeSubstance(name); s != "" {
+			substances = append(substances, s)
+		}
+	}
+	return substances
+}
+
+// substanceMatches reports whether an allergy substance names one of the given names, or is
+// named by it, e.g. a "penicillin" allergy matches the "penicillins" class.
+func substanceMatches(substance string, names ...string) bool {
+	substance = normalizeSubstance(substance)
+	if substance == "" {
+		return false
+	}
+	for _, name := range substancesOf(names...) {
+		if strings.Contains(name, substance) || strings.Contains(substance, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// interactionBetween reports whether an interaction pairs one of the substances of a new drug
+// with one of the substances of a current medication.
+func interactionBetween(interaction models.DrugInteraction, drug, medication []string) bool {
+	contains := func(substances []string, s string) bool {
+		for _, candidate := range substances {
+			if candidate == s {
+				return true
+			}
+		}
+		return false
+	}
+	return (contains(drug, interaction.SubstanceA) && contains(medication, interaction.SubstanceB)) ||
+		(contains(drug, interaction.SubstanceB) && contains(medication, interaction.SubstanceA))
+}
+
+// hasBlockingAlert reports whether any of the alerts prevents a prescription.
+func hasBlockingAlert(alerts []models.PrescriptionAlert) bool {
+	for _, alert := range alerts {
+		if alert.Severity == models.AlertBlock {
+			return true
+		}
+	}
+	return false
+}
+
+// alertError returns the error that prevents a prescription with the given alerts, if any.
+// Warnings are overridden by giving a reason; blocking alerts cannot be overridden.
+func alertError(alerts []models.PrescriptionAlert, overrideReason string) error {
+	if len(alerts) == 0 {
+		return nil
+	}
+	blocked := hasBlockingAlert(alerts)
+	if !blocked && overrideReason != "" {
+		return nil
+	}
+
+	fields := make([]validation.FieldError, 0, len(alerts))
+	for _, alert := range alerts {
+		fields = append(fields, validation.FieldError{Field: "drug_id", Message: alert.Severity + ": " + alert.Message})
+	}
+	if blocked {
+		return &Error{
+			Kind:    KindConflict,
+			Code:    "prescription_blocked",
+			Message: "the prescription is blocked by a safety check",
+			Fields:  fields,
+		}
+	}
+	return &Error{
+		Kind:    KindConflict,
+		Code:    "prescription_warnings",
+		Message: "the prescription raised warnings; give an override_reason to prescribe anyway",
+		Fields:  fields,
+	}
+}
@@ -0,0 +1,408 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/Okemwag/medihub/internal/models"
+	"github.com/Okemwag/medihub/internal/validation"
+	"github.com/lib/pq"
+)
+
+// Errors returned by ProblemService.
+var (
+	ErrProblemNotFound  = NewNotFoundError("problem_not_found", "problem not found")
+	ErrDuplicateProblem = NewConflictError("duplicate_problem", "the patient already has an active problem with this code")
+)
+
+// ProblemService provides methods for managing patients' coded problem lists.
+type ProblemService struct {
+	db    *sql.DB
+	audit *AuditService
+}
+
+// NewProblemService creates a new instance of ProblemService.
+//
+// @param db *sql.DB: A database connection.
+// @param audit *AuditService: The service used to audit problem list access.
+// @return *ProblemService: A new ProblemService instance.
+func NewProblemService(db *sql.DB, audit *AuditService) *ProblemService {
+	return &ProblemService{db: db, audit: audit}
+}
+
+// problemColumns lists the problem columns, with the code description and the recorder's name, in
+// the order expected by scanProblem. It must be used with problemFrom.
+const problemColumns = `pp.id, pp.patient_id, pp.code, c.description, COALESCE(pp.notes, ''), pp.status, COALESCE(to_char(pp.onset_date, 'YYYY-MM-DD'), ''), COALESCE(to_char(pp.resolved_date, 'YYYY-MM-DD'), ''), COALESCE(pp.recorded_by, 0), COALESCE(u.name, ''), COALESCE(pp.updated_by, 0), pp.created_at, pp.updated_at`
+
+// problemFrom joins problems to their code and the user who recorded them.
+const problemFrom = `patient_problems pp JOIN icd10_codes c ON c.code = pp.code LEFT JOIN users u ON u.id = pp.recorded_by`
+
+// scanProblem reads a single problem selected with problemColumns.
+func scanProblem(row rowScanner) (*models.Problem, error) {
+	var p models.Problem
+	err := row.Scan(
+		&p.ID,
+		&p.PatientID,
+		&p.Code,
+		&p.CodeDescription,
+		&p.Notes,
+		&p.Status,
+		&p.OnsetDate,
+		&p.ResolvedDate,
+		&p.RecordedBy,
+		&p.RecorderName,
+		&p.UpdatedBy,
+		&p.CreatedAt,
+		&p.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// CreateProblem adds a coded problem to a patient's problem list.
+//
+// @param ctx context.Context: The context for the request.
+// @param patientID int64: The ID of the patient.
+// @param problem *models.Problem: The problem to record; status defaults to active.
+// @return *models.Problem: The recorded problem.
+// @return error: ErrPatientNotFound, ErrInvalidICD10Code, a validation error for inconsistent dates, ErrDuplicateProblem, or an error if the operation fails.
+func (s *ProblemService) CreateProblem(ctx context.Context, patientID int64, problem *models.Problem) (*models.Problem, error) {
+	if err := prepareProblem(problem); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := requirePatient(ctx, tx, patientID); err != nil {
+		return nil, err
+	}
+	if err := requireActiveICD10Code(ctx, tx, problem.Code); err != nil {
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO patient_problems (patient_id, code, notes, status, onset_date, resolved_date, recorded_by, updated_by)
+		VALUES ($1, $2, $3, $4, NULLIF($5, '')::date, NULLIF($6, '')::date, $7, $8)
+		RETURNING id
+	`
+	var id int64
+	err = tx.QueryRowContext(ctx, query,
+		patientID,
+		problem.Code,
+		problem.Notes,
+		problem.Status,
+		problem.OnsetDate,
+		problem.ResolvedDate,
+		problem.RecordedBy,
+		problem.UpdatedBy,
+	).Scan(&id)
+	if err != nil {
+		return nil, problemWriteError("creating", err)
+	}
+
+	created, err := s.getProblem(ctx, tx, patientID, id, false)
+	if err != nil {
+		return nil, err
+	}
+	err = s.audit.Record(ctx, tx, models.AuditEntry{
+		Action:     "problem.create",
+		EntityType: "problem",
+		EntityID:   &id,
+		Changes:    diffFields(nil, created),
+		PatientID:  &patientID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error creating problem: %v", err)
+		return nil, err
+	}
+	return created, nil
+}
+
+// ListProblems retrieves a patient's problem list, active problems first and most recent onset first.
+//
+// @param ctx context.Context: The context for the request.
+// @param patientID int64: The ID of the patient.
+// @param status string: Only problems in this status, or every problem when empty.
+// @return []models.Problem: The patient's problems.
+// @return error: ErrPatientNotFound, or an error if the operation fails.
+func (s *ProblemService) ListProblems(ctx context.Context, patientID int64, status string) ([]models.Problem, error) {
+	if err := requirePatient(ctx, s.db, patientID); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT ` + problemColumns + `
+		FROM ` + problemFrom + `
+		WHERE pp.patient_id = $1 AND ($2::text = '' OR pp.status = $2)
+		ORDER BY CASE pp.status WHEN 'active' THEN 0 WHEN 'inactive' THEN 1 ELSE 2 END, pp.onset_date DESC NULLS LAST, pp.id DESC
+	`
+	rows, err := s.db.QueryContext(ctx, query, patientID, status)
+	if err != nil {
+		log.Printf("Error listing problems: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	problems := []models.Problem{}
+	for rows.Next() {
+		problem, err := scanProblem(rows)
+		if err != nil {
+			log.Printf("Error scanning problem: %v", err)
+			return nil, err
+		}
+		problems = append(problems, *problem)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating problems: %v", err)
+		return nil, err
+	}
+
+	err = s.audit.Record(ctx, nil, models.AuditEntry{
+		Action:     "problem.list",
+		EntityType: "patient",
+		EntityID:   &patientID,
+		Details:    map[string]interface{}{"status": status, "returned": len(problems)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return problems, nil
+}
+
+// GetProblem retrieves one of a patient's problems.
+//
+// @param ctx context.Context: The context for the request.
+// @param patientID int64: The ID of the patient.
+// @param id int64: The ID of the problem.
+// @return *models.Problem: The problem.
+// @return error: ErrProblemNotFound, or an error if the operation fails.
+func (s *ProblemService) GetProblem(ctx context.Context, patientID, id int64) (*models.Problem, error) {
+	problem, err := s.getProblem(ctx, s.db, patientID, id, false)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.audit.Record(ctx, nil, models.AuditEntry{
+		Action:     "problem.read",
+		EntityType: "problem",
+		EntityID:   &id,
+		PatientID:  &patientID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return problem, nil
+}
+
+// UpdateProblem replaces the details of one of a patient's problems, e.g. to mark it resolved.
+// The code of a problem may only be changed to another active code.
+//
+// @param ctx context.Context: The context for the request.
+// @param patientID int64: The ID of the patient.
+// @param id int64: The ID of the problem.
+// @param problem *models.Problem: The new details of the problem.
+// @param updatedBy int64: The ID of the user updating the problem.
+// @return *models.Problem: The updated problem.
+// @return error: ErrProblemNotFound, ErrInvalidICD10Code, a validation error for inconsistent dates, ErrDuplicateProblem, or an error if the operation fails.
+func (s *ProblemService) UpdateProblem(ctx context.Context, patientID, id int64, problem *models.Problem, updatedBy int64) (*models.Problem, error) {
+	if err := prepareProblem(problem); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	current, err := s.getProblem(ctx, tx, patientID, id, true)
+	if err != nil {
+		return nil, err
+	}
+	if problem.Code != current.Code {
+		if err := requireActiveICD10Code(ctx, tx, problem.Code); err != nil {
+			return nil, err
+		}
+	}
+
+	query := `
+		UPDATE patient_problems
+		SET code = $1, notes = $2, status = $3, onset_date = NULLIF($4, '')::date, resolved_date = NULLIF($5, '')::date, updated_by = $6, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $7
+	`
+	_, err = tx.ExecContext(ctx, query,
+		problem.Code,
+		problem.Notes,
+		problem.Status,
+		problem.OnsetDate,
+		problem.ResolvedDate,
+		updatedBy,
+		id,
+	)
+	if err != nil {
+		return nil, problemWriteError("updating", err)
+	}
+
+	updated, err := s.getProblem(ctx, tx, patientID, id, false)
+	if err != nil {
+		return nil, err
+	}
+	err = s.audit.Record(ctx, tx, models.AuditEntry{
+		Action:     "problem.update",
+		EntityType: "problem",
+		EntityID:   &id,
+		Changes:    diffFields(current, updated),
+		PatientID:  &patientID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error updating problem: %v", err)
+		return nil, err
+	}
+	return updated, nil
+}
+
+// DeleteProblem retires one of a patient's problems that was recorded by mistake by marking it
+// entered_in_error, keeping the row for the audit trail. Problems that were recorded correctly but
+// no longer apply should be marked inactive or resolved instead.
+//
+// @param ctx context.Context: The context for the request.
+// @param patientID int64: The ID of the patient.
+// @param id int64: The ID of the problem.
+// @param deletedBy int64: The ID of the user retiring the problem.
+// @return error: ErrProblemNotFound, or an error if the operation fails.
+func (s *ProblemService) DeleteProblem(ctx context.Context, patientID, id int64, deletedBy int64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	current, err := s.getProblem(ctx, tx, patientID, id, true)
+	if err != nil {
+		return err
+	}
+	query := `UPDATE patient_problems SET status = $1, updated_by = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $3`
+	if _, err := tx.ExecContext(ctx, query, models.ProblemEnteredInError, deletedBy, id); err != nil {
+		log.Printf("Error deleting problem: %v", err)
+		return err
+	}
+
+	updated, err := s.getProblem(ctx, tx, patientID, id, false)
+	if err != nil {
+		return err
+	}
+	err = s.audit.Record(ctx, tx, models.AuditEntry{
+		Action:     "problem.delete",
+		EntityType: "problem",
+		EntityID:   &id,
+		Changes:    diffFields(current, updated),
+		PatientID:  &patientID,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error deleting problem: %v", err)
+		return err
+	}
+	return nil
+}
+
+// getProblem loads one of a patient's problems without auditing the access, optionally locking
+// the row for update.
+func (s *ProblemService) getProblem(ctx context.Context, db dbtx, patientID, id int64, forUpdate bool) (*models.Problem, error) {
+	query := `SELECT ` + problemColumns + ` FROM ` + problemFrom + ` WHERE pp.id = $1 AND pp.patient_id = $2`
+	if forUpdate {
+		query += ` FOR UPDATE OF pp`
+	}
+	problem, err := scanProblem(db.QueryRowContext(ctx, query, id, patientID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrProblemNotFound
+		}
+		log.Printf("Error retrieving problem: %v", err)
+		return nil, err
+	}
+	return problem, nil
+}
+
+// requireActiveICD10Code checks that a code is an active entry of the ICD-10 catalogue.
+func requireActiveICD10Code(ctx context.Context, db dbtx, code string) error {
+	var active bool
+	err := db.QueryRowContext(ctx, `SELECT active FROM icd10_codes WHERE code = $1`, code).Scan(&active)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrInvalidICD10Code
+		}
+		log.Printf("Error checking ICD-10 code: %v", err)
+		return err
+	}
+	if !active {
+		return ErrInvalidICD10Code
+	}
+	return nil
+}
+
+// prepareProblem normalizes the code of a problem, fills in its status and resolution date and
+// checks that its dates are consistent, reporting every problem as a field error.
+func prepareProblem(problem *models.Problem) error {
+	code, ok := normalizeICD10Code(problem.Code)
+	if !ok {
+		return ErrInvalidICD10Code
+	}
+	problem.Code = code
+	if problem.Status == "" {
+		problem.Status = models.ProblemActive
+	}
+	today := time.Now().Format("2006-01-02")
+	if problem.Status == models.ProblemResolved && problem.ResolvedDate == "" {
+		problem.ResolvedDate = today
+	}
+
+	// Dates are validated as YYYY-MM-DD when binding, so they compare correctly as strings
+	var fields []validation.FieldError
+	if problem.OnsetDate > today {
+		fields = append(fields, validation.FieldError{Field: "onset_date", Message: "must not be in the future"})
+	}
+	switch {
+	case problem.ResolvedDate != "" && problem.Status != models.ProblemResolved:
+		fields = append(fields, validation.FieldError{Field: "resolved_date", Message: "is only allowed for resolved problems"})
+	case problem.ResolvedDate > today:
+		fields = append(fields, validation.FieldError{Field: "resolved_date", Message: "must not be in the future"})
+	case problem.OnsetDate != "" && problem.ResolvedDate != "" && problem.ResolvedDate < problem.OnsetDate:
+		fields = append(fields, validation.FieldError{Field: "resolved_date", Message: "must not be before onset_date"})
+	}
+
+	if len(fields) > 0 {
+		return &Error{Kind: KindValidation, Code: "invalid_problem", Message: "invalid problem", Fields: fields}
+	}
+	return nil
+}
+
+// problemWriteError translates violations of the active code index into ErrDuplicateProblem.
+func problemWriteError(operation string, err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return ErrDuplicateProblem
+	}
+	log.Printf("Error %s problem: %v", operation, err)
+	return err
+}
@@ -0,0 +1,585 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/Okemwag/medihub/internal/models"
+)
+
+// MaxSlotRangeDays bounds the number of days FreeSlots computes in one call.
+const MaxSlotRangeDays = 31
+
+// Errors returned by ScheduleService.
+var (
+	ErrScheduleNotFound          = NewNotFoundError("schedule_not_found", "the doctor has no schedule")
+	ErrScheduleExceptionNotFound = NewNotFoundError("schedule_exception_not_found", "schedule exception not found")
+	ErrLeaveNotFound             = NewNotFoundError("leave_not_found", "leave not found")
+	ErrInvalidSchedule           = NewValidationError("invalid_schedule", "invalid schedule")
+	ErrInvalidSlotRange          = NewValidationError("invalid_slot_range", "invalid date range")
+)
+
+// ScheduleService manages doctors' working hours, exceptions and leave, and computes the slots
+// in which they can be booked.
+type ScheduleService struct {
+	db    *sql.DB
+	audit *AuditService
+}
+
+// NewScheduleService creates a new instance of ScheduleService.
+//
+// @param db *sql.DB: A database connection.
+// @param audit *AuditService: The service used to audit schedule changes.
+// @return *ScheduleService: A new ScheduleService instance.
+func NewScheduleService(db *sql.DB, audit *AuditService) *ScheduleService {
+	return &ScheduleService{db: db, audit: audit}
+}
+
+// GetSchedule retrieves a doctor's schedule along with exceptions and leave that have not yet passed.
+//
+// @param ctx context.Context: The context for the request.
+// @param doctorID int64: The ID of the doctor.
+// @return *models.DoctorSchedule: The doctor's schedule.
+// @return error: ErrScheduleNotFound, or an error if the operation fails.
+func (s *ScheduleService) GetSchedule(ctx context.Context, doctorID int64) (*models.DoctorSchedule, error) {
+	schedule, err := s.getSchedule(ctx, s.db, doctorID)
+	if err != nil {
+		return nil, err
+	}
+	loc, err := time.LoadLocation(schedule.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	today := time.Now().In(loc).Format("2006-01-02")
+
+	if schedule.Exceptions, err = s.listExceptions(ctx, doctorID, today, ""); err != nil {
+		return nil, err
+	}
+	if schedule.Leave, err = s.listLeave(ctx, doctorID, today, ""); err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// SetSchedule creates or replaces a doctor's slot length, time zone and weekly working hours.
+// Exceptions and leave are kept.
+//
+// @param ctx context.Context: The context for the request.
+// @param doctorID int64: The ID of the doctor.
+// @param schedule *models.DoctorSchedule: The new slot length, time zone and working hours.
+// @param updatedBy int64: The ID of the user changing the schedule.
+// @return *models.DoctorSchedule: The updated schedule.
+// @return error: ErrInvalidDoctor, ErrInvalidSchedule, or an error if the operation fails.
+func (s *ScheduleService) SetSchedule(ctx context.Context, doctorID int64, schedule *models.DoctorSchedule, updatedBy int64) (*models.DoctorSchedule, error) {
+	if _, err := time.LoadLocation(schedule.Timezone); err != nil {
+		return nil, fmt.Errorf("%w: unknown time zone %s", ErrInvalidSchedule, schedule.Timezone)
+	}
+	for i, hours := range schedule.WorkingHours {
+		if err := validateClockRange(hours.StartTime, hours.EndTime); err != nil {
+			return nil, fmt.Errorf("%w: working_hours[%d]: %v", ErrInvalidSchedule, i, err)
+		}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := requireDoctor(ctx, tx, doctorID); err != nil {
+		return nil, err
+	}
+	current, err := s.getSchedule(ctx, tx, doctorID)
+	if err != nil && !errors.Is(err, ErrScheduleNotFound) {
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO doctor_schedules (doctor_id, slot_minutes, timezone, updated_by)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (doctor_id) DO UPDATE
+		SET slot_minutes = EXCLUDED.slot_minutes, timezone = EXCLUDED.timezone, updated_by = EXCLUDED.updated_by, updated_at = CURRENT_TIMESTAMP
+	`
+	if _, err := tx.ExecContext(ctx, query, doctorID, schedule.SlotMinutes, schedule.Timezone, updatedBy); err != nil {
+		log.Printf("Error saving doctor schedule: %v", err)
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM doctor_working_hours WHERE doctor_id = $1`, doctorID); err != nil {
+		log.Printf("Error replacing working hours: %v", err)
+		return nil, err
+	}
+	for _, hours := range schedule.WorkingHours {
+		query := `INSERT INTO doctor_working_hours (doctor_id, weekday, start_time, end_time) VALUES ($1, $2, $3, $4)`
+		if _, err := tx.ExecContext(ctx, query, doctorID, hours.Weekday, hours.StartTime, hours.EndTime); err != nil {
+			log.Printf("Error saving working hours: %v", err)
+			return nil, err
+		}
+	}
+
+	updated, err := s.getSchedule(ctx, tx, doctorID)
+	if err != nil {
+		return nil, err
+	}
+	var before interface{}
+	if current != nil {
+		before = current
+	}
+	err = s.audit.Record(ctx, tx, models.AuditEntry{
+		Action:     "schedule.update",
+		EntityType: "user",
+		EntityID:   &doctorID,
+		Changes:    diffFields(before, updated),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error saving doctor schedule: %v", err)
+		return nil, err
+	}
+	return updated, nil
+}
+
+// AddException records a one-off change to a doctor's availability on a date.
+//
+// @param ctx context.Context: The context for the request.
+// @param doctorID int64: The ID of the doctor.
+// @param exception *models.ScheduleException: The exception to add.
+// @return *models.ScheduleException: The stored exception.
+// @return error: ErrScheduleNotFound, ErrInvalidSchedule, or an error if the operation fails.
+func (s *ScheduleService) AddException(ctx context.Context, doctorID int64, exception *models.ScheduleException) (*models.ScheduleException, error) {
+	switch {
+	case exception.StartTime == nil && exception.EndTime == nil:
+		if exception.Available {
+			return nil, fmt.Errorf("%w: available exceptions need a start_time and end_time", ErrInvalidSchedule)
+		}
+	case exception.StartTime == nil || exception.EndTime == nil:
+		return nil, fmt.Errorf("%w: start_time and end_time must be given together", ErrInvalidSchedule)
+	default:
+		if err := validateClockRange(*exception.StartTime, *exception.EndTime); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidSchedule, err)
+		}
+	}
+
+	if _, err := s.getSchedule(ctx, s.db, doctorID); err != nil {
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO doctor_schedule_exceptions (doctor_id, date, start_time, end_time, available, reason, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`
+	err := s.db.QueryRowContext(ctx, query,
+		doctorID,
+		exception.Date,
+		exception.StartTime,
+		exception.EndTime,
+		exception.Available,
+		exception.Reason,
+		exception.CreatedBy,
+	).Scan(&exception.ID, &exception.CreatedAt)
+	if err != nil {
+		log.Printf("Error adding schedule exception: %v", err)
+		return nil, err
+	}
+	exception.DoctorID = doctorID
+
+	err = s.audit.Record(ctx, nil, models.AuditEntry{
+		Action:     "schedule.exception.create",
+		EntityType: "user",
+		EntityID:   &doctorID,
+		Changes:    diffFields(nil, exception),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return exception, nil
+}
+
+// DeleteException removes a schedule exception.
+//
+// @param ctx context.Context: The context for the request.
+// @param doctorID int64: The ID of the doctor.
+// @param id int64: The ID of the exception.
+// @return error: ErrScheduleExceptionNotFound, or an error if the operation fails.
+func (s *ScheduleService) DeleteException(ctx context.Context, doctorID, id int64) error {
+	return s.deleteOverride(ctx, "doctor_schedule_exceptions", "schedule.exception.delete", doctorID, id, ErrScheduleExceptionNotFound)
+}
+
+// AddLeave records a range of days on which a doctor cannot be booked.
+//
+// @param ctx context.Context: The context for the request.
+// @param doctorID int64: The ID of the doctor.
+// @param leave *models.DoctorLeave: The leave to add.
+// @return *models.DoctorLeave: The stored leave.
+// @return error: ErrScheduleNotFound, ErrInvalidSchedule, or an error if the operation fails.
+func (s *ScheduleService) AddLeave(ctx context.Context, doctorID int64, leave *models.DoctorLeave) (*models.DoctorLeave, error) {
+	if leave.EndsOn < leave.StartsOn {
+		return nil, fmt.Errorf("%w: ends_on must not be before starts_on", ErrInvalidSchedule)
+	}
+	if _, err := s.getSchedule(ctx, s.db, doctorID); err != nil {
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO doctor_leave (doctor_id, starts_on, ends_on, reason, created_by)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`
+	err := s.db.QueryRowContext(ctx, query, doctorID, leave.StartsOn, leave.EndsOn, leave.Reason, leave.CreatedBy).Scan(&leave.ID, &leave.CreatedAt)
+	if err != nil {
+		log.Printf("Error adding leave: %v", err)
+		return nil, err
+	}
+	leave.DoctorID = doctorID
+
+	err = s.audit.Record(ctx, nil, models.AuditEntry{
+		Action:     "schedule.leave.create",
+		EntityType: "user",
+		EntityID:   &doctorID,
+		Changes:    diffFields(nil, leave),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return leave, nil
+}
+
+// DeleteLeave removes a leave entry.
+//
+// @param ctx context.Context: The context for the request.
+// @param doctorID int64: The ID of the doctor.
+// @param id int64: The ID of the leave entry.
+// @return error: ErrLeaveNotFound, or an error if the operation fails.
+func (s *ScheduleService) DeleteLeave(ctx context.Context, doctorID, id int64) error {
+	return s.deleteOverride(ctx, "doctor_leave", "schedule.leave.delete", doctorID, id, ErrLeaveNotFound)
+}
+
+// deleteOverride deletes an exception or leave entry belonging to a doctor and audits the deletion.
+func (s *ScheduleService) deleteOverride(ctx context.Context, table, action string, doctorID, id int64, notFound error) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM `+table+` WHERE id = $1 AND doctor_id = $2`, id, doctorID)
+	if err != nil {
+		log.Printf("Error deleting from %s: %v", table, err)
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return notFound
+	}
+
+	return s.audit.Record(ctx, nil, models.AuditEntry{
+		Action:     action,
+		EntityType: "user",
+		EntityID:   &doctorID,
+		Details:    map[string]interface{}{"id": id},
+	})
+}
+
+// FreeSlots lists the slots in which a doctor can be booked between two dates, inclusive. Days are
+// interpreted in the doctor's time zone; only the year, month and day of from and to are used.
+// A slot is free when it lies within working hours or an available exception, is not blocked by
+// leave or an unavailable exception, does not overlap an active appointment, and has not started.
+//
+// @param ctx context.Context: The context for the request.
+// @param doctorID int64: The ID of the doctor.
+// @param from time.Time: The first day.
+// @param to time.Time: The last day.
+// @return []models.Slot: The free slots, in chronological order.
+// @return error: ErrScheduleNotFound, ErrInvalidSlotRange, or an error if the operation fails.
+func (s *ScheduleService) FreeSlots(ctx context.Context, doctorID int64, from, to time.Time) ([]models.Slot, error) {
+	schedule, err := s.getSchedule(ctx, s.db, doctorID)
+	if err != nil {
+		return nil, err
+	}
+	loc, err := time.LoadLocation(schedule.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	first := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, loc)
+	last := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, loc)
+	if last.Before(first) {
+		return nil, fmt.Errorf("%w: to must not be before from", ErrInvalidSlotRange)
+	}
+	if last.Sub(first) >= MaxSlotRangeDays*24*time.Hour {
+		return nil, fmt.Errorf("%w: at most %d days can be requested", ErrInvalidSlotRange, MaxSlotRangeDays)
+	}
+	end := last.AddDate(0, 0, 1)
+
+	exceptions, err := s.listExceptions(ctx, doctorID, first.Format("2006-01-02"), last.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	leave, err := s.listLeave(ctx, doctorID, first.Format("2006-01-02"), last.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	busy, err := s.busyPeriods(ctx, doctorID, first, end)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	slotLength := time.Duration(schedule.SlotMinutes) * time.Minute
+	slots := []models.Slot{}
+	for day := first; day.Before(end); day = day.AddDate(0, 0, 1) {
+		date := day.Format("2006-01-02")
+		if onLeave(leave, date) {
+			continue
+		}
+
+		var open, blocked []period
+		for _, hours := range schedule.WorkingHours {
+			if time.Weekday(hours.Weekday) == day.Weekday() {
+				open = append(open, clockPeriod(day, hours.StartTime, hours.EndTime))
+			}
+		}
+		for _, exception := range exceptions {
+			if exception.Date != date {
+				continue
+			}
+			p := period{start: day, end: day.AddDate(0, 0, 1)}
+			if exception.StartTime != nil && exception.EndTime != nil {
+				p = clockPeriod(day, *exception.StartTime, *exception.EndTime)
+			}
+			if exception.Available {
+				open = append(open, p)
+			} else {
+				blocked = append(blocked, p)
+			}
+		}
+		blocked = append(blocked, busy...)
+
+		for _, free := range subtractPeriods(mergePeriods(open), blocked) {
+			for start := free.start; !start.Add(slotLength).After(free.end); start = start.Add(slotLength) {
+				if start.Before(now) {
+					continue
+				}
+				slots = append(slots, models.Slot{StartsAt: start, EndsAt: start.Add(slotLength)})
+			}
+		}
+	}
+	return slots, nil
+}
+
+// getSchedule loads a doctor's slot length, time zone and working hours.
+func (s *ScheduleService) getSchedule(ctx context.Context, db dbtx, doctorID int64) (*models.DoctorSchedule, error) {
+	schedule := models.DoctorSchedule{DoctorID: doctorID}
+	query := `SELECT slot_minutes, timezone, updated_by, updated_at FROM doctor_schedules WHERE doctor_id = $1`
+	err := db.QueryRowContext(ctx, query, doctorID).Scan(&schedule.SlotMinutes, &schedule.Timezone, &schedule.UpdatedBy, &schedule.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrScheduleNotFound
+		}
+		log.Printf("Error retrieving doctor schedule: %v", err)
+		return nil, err
+	}
+
+	query = `
+		SELECT weekday, to_char(start_time, 'HH24:MI'), to_char(end_time, 'HH24:MI')
+		FROM doctor_working_hours
+		WHERE doctor_id = $1
+		ORDER BY weekday, start_time
+	`
+	rows, err := db.QueryContext(ctx, query, doctorID)
+	if err != nil {
+		log.Printf("Error retrieving working hours: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	schedule.WorkingHours = []models.WorkingHours{}
+	for rows.Next() {
+		var hours models.WorkingHours
+		if err := rows.Scan(&hours.Weekday, &hours.StartTime, &hours.EndTime); err != nil {
+			log.Printf("Error scanning working hours: %v", err)
+			return nil, err
+		}
+		schedule.WorkingHours = append(schedule.WorkingHours, hours)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating working hours: %v", err)
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+// listExceptions loads a doctor's exceptions dated between from and to (YYYY-MM-DD); an empty to
+// means no upper bound.
+func (s *ScheduleService) listExceptions(ctx context.Context, doctorID int64, from, to string) ([]models.ScheduleException, error) {
+	query := `
+		SELECT id, doctor_id, to_char(date, 'YYYY-MM-DD'), to_char(start_time, 'HH24:MI'), to_char(end_time, 'HH24:MI'), available, COALESCE(reason, ''), created_by, created_at
+		FROM doctor_schedule_exceptions
+		WHERE doctor_id = $1 AND date >= $2 AND ($3::text = '' OR date <= NULLIF($3::text, '')::date)
+		ORDER BY date, start_time NULLS FIRST
+	`
+	rows, err := s.db.QueryContext(ctx, query, doctorID, from, to)
+	if err != nil {
+		log.Printf("Error listing schedule exceptions: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	exceptions := []models.ScheduleException{}
+	for rows.Next() {
+		var e models.ScheduleException
+		if err := rows.Scan(&e.ID, &e.DoctorID, &e.Date, &e.StartTime, &e.EndTime, &e.Available, &e.Reason, &e.CreatedBy, &e.CreatedAt); err != nil {
+			log.Printf("Error scanning schedule exception: %v", err)
+			return nil, err
+		}
+		exceptions = append(exceptions, e)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating schedule exceptions: %v", err)
+		return nil, err
+	}
+	return exceptions, nil
+}
+
+// listLeave loads a doctor's leave overlapping the days between from and to (YYYY-MM-DD); an empty
+// to means no upper bound.
+func (s *ScheduleService) listLeave(ctx context.Context, doctorID int64, from, to string) ([]models.DoctorLeave, error) {
+	query := `
+		SELECT id, doctor_id, to_char(starts_on, 'YYYY-MM-DD'), to_char(ends_on, 'YYYY-MM-DD'), COALESCE(reason, ''), created_by, created_at
+		FROM doctor_leave
+		WHERE doctor_id = $1 AND ends_on >= $2 AND ($3::text = '' OR starts_on <= NULLIF($3::text, '')::date)
+		ORDER BY starts_on
+	`
+	rows, err := s.db.QueryContext(ctx, query, doctorID, from, to)
+	if err != nil {
+		log.Printf("Error listing leave: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	leave := []models.DoctorLeave{}
+	for rows.Next() {
+		var l models.DoctorLeave
+		if err := rows.Scan(&l.ID, &l.DoctorID, &l.StartsOn, &l.EndsOn, &l.Reason, &l.CreatedBy, &l.CreatedAt); err != nil {
+			log.Printf("Error scanning leave: %v", err)
+			return nil, err
+		}
+		leave = append(leave, l)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating leave: %v", err)
+		return nil, err
+	}
+	return leave, nil
+}
+
+// busyPeriods loads the periods of a doctor's active appointments that overlap [from, to).
+func (s *ScheduleService) busyPeriods(ctx context.Context, doctorID int64, from, to time.Time) ([]period, error) {
+	query := `
+		SELECT starts_at, ends_at
+		FROM appointments
+		WHERE doctor_id = $1 AND status IN ('scheduled', 'checked_in') AND starts_at < $3 AND ends_at > $2
+	`
+	rows, err := s.db.QueryContext(ctx, query, doctorID, from, to)
+	if err != nil {
+		log.Printf("Error listing booked appointments: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var busy []period
+	for rows.Next() {
+		var p period
+		if err := rows.Scan(&p.start, &p.end); err != nil {
+			log.Printf("Error scanning booked appointment: %v", err)
+			return nil, err
+		}
+		busy = append(busy, p)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating booked appointments: %v", err)
+		return nil, err
+	}
+	return busy, nil
+}
+
+// period is a half-open interval of time [start, end).
+type period struct {
+	start, end time.Time
+}
+
+// clockPeriod converts HH:MM start and end times into a period on the given day. Times are
+// validated before they are stored, so parse errors cannot occur here.
+func clockPeriod(day time.Time, start, end string) period {
+	at := func(clock string) time.Time {
+		t, _ := time.Parse("15:04", clock)
+		return time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), 0, 0, day.Location())
+	}
+	return period{start: at(start), end: at(end)}
+}
+
+// validateClockRange checks that two HH:MM times are valid and in order.
+func validateClockRange(start, end string) error {
+	s, err := time.Parse("15:04", start)
+	if err != nil {
+		return fmt.Errorf("invalid start_time %q", start)
+	}
+	e, err := time.Parse("15:04", end)
+	if err != nil {
+		return fmt.Errorf("invalid end_time %q", end)
+	}
+	if !e.After(s) {
+		return errors.New("end_time must be after start_time")
+	}
+	return nil
+}
+
+// onLeave reports whether a date (YYYY-MM-DD) falls within any leave entry.
+func onLeave(leave []models.DoctorLeave, date string) bool {
+	for _, l := range leave {
+		if l.StartsOn <= date && date <= l.EndsOn {
+			return true
+		}
+	}
+	return false
+}
+
+// mergePeriods sorts periods and joins those that overlap or touch.
+func mergePeriods(periods []period) []period {
+	sort.Slice(periods, func(i, j int) bool { return periods[i].start.Before(periods[j].start) })
+	var merged []period
+	for _, p := range periods {
+		if n := len(merged); n > 0 && !p.start.After(merged[n-1].end) {
+			if p.end.After(merged[n-1].end) {
+				merged[n-1].end = p.end
+			}
+			continue
+		}
+		merged = append(merged, p)
+	}
+	return merged
+}
+
+// subtractPeriods removes every blocked period from the open periods.
+func subtractPeriods(open, blocked []period) []period {
+	for _, b := range blocked {
+		var remaining []period
+		for _, o := range open {
+			if !b.start.Before(o.end) || !b.end.After(o.start) {
+				remaining = append(remaining, o)
+				continue
+			}
+			if b.start.After(o.start) {
+				remaining = append(remaining, period{start: o.start, end: b.start})
+			}
+			if b.end.Before(o.end) {
+				remaining = append(remaining, period{start: b.end, end: o.end})
+			}
+		}
+		open = remaining
+	}
+	return open
+}
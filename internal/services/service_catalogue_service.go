@@ -0,0 +1,235 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/Okemwag/medihub/internal/models"
+	"github.com/Okemwag/medihub/internal/validation"
+)
+
+// Errors returned by ServiceCatalogueService.
+var (
+	ErrBillableServiceNotFound  = NewNotFoundError("billable_service_not_found", "service not found")
+	ErrDuplicateBillableService = NewConflictError("duplicate_billable_service", "a service with this code already exists")
+)
+
+// ServiceCatalogueService maintains the catalogue of priced services that can be billed.
+type ServiceCatalogueService struct {
+	db    *sql.DB
+	audit *AuditService
+}
+
+// NewServiceCatalogueService creates a new instance of ServiceCatalogueService.
+//
+// @param db *sql.DB: A database connection.
+// @param audit *AuditService: The service used to audit catalogue changes.
+// @return *ServiceCatalogueService: A new ServiceCatalogueService instance.
+func NewServiceCatalogueService(db *sql.DB, audit *AuditService) *ServiceCatalogueService {
+	return &ServiceCatalogueService{db: db, audit: audit}
+}
+
+// billableServiceColumns lists the service columns in the order expected by scanBillableService.
+const billableServiceColumns = `id, code, name, COALESCE(category, ''), price, active, created_at, updated_at`
+
+// scanBillableService reads a single service selected with billableServiceColumns.
+func scanBillableService(row rowScanner) (*models.BillableService, error) {
+	var svc models.BillableService
+	err := row.Scan(&svc.ID, &svc.Code, &svc.Name, &svc.Category, &svc.Price, &svc.Active, &svc.CreatedAt, &svc.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &svc, nil
+}
+
+// ListServices retrieves the service catalogue, optionally filtered by code, name or category.
+//
+// @param ctx context.Context: The context for the request.
+// @param q string: Only services whose code or name contains this term, or all services when empty.
+// @param category string: Only services in this category, or all categories when empty.
+// @param includeInactive bool: Whether to include services that can no longer be billed.
+// @return []models.BillableService: The services, ordered by category and name.
+// @return error: An error if the operation fails.
+func (s *ServiceCatalogueService) ListServices(ctx context.Context, q, category string, includeInactive bool) ([]models.BillableService, error) {
+	q = strings.TrimSpace(q)
+	query := `
+		SELECT ` + billableServiceColumns + `
+		FROM billable_services
+		WHERE ($1 = '' OR code ILIKE '%' || $2 || '%' OR name ILIKE '%' || $2 || '%')
+			AND ($3 = '' OR LOWER(category) = LOWER($3))
+			AND ($4 OR active)
+		ORDER BY category NULLS LAST, name, id
+	`
+	rows, err := s.db.QueryContext(ctx, query, q, likeEscaper.Replace(q), strings.TrimSpace(category), includeInactive)
+	if err != nil {
+		log.Printf("Error listing services: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	services := []models.BillableService{}
+	for rows.Next() {
+		svc, err := scanBillableService(rows)
+		if err != nil {
+			log.Printf("Error scanning service: %v", err)
+			return nil, err
+		}
+		services = append(services, *svc)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating services: %v", err)
+		return nil, err
+	}
+	return services, nil
+}
+
+// GetService retrieves a service by ID.
+//
+// @param ctx context.Context: The context for the request.
+// @param id int64: The ID of the service.
+// @return *models.BillableService: The service.
+// @return error: ErrBillableServiceNotFound, or an error if the operation fails.
+func (s *ServiceCatalogueService) GetService(ctx context.Context, id int64) (*models.BillableService, error) {
+	return getBillableService(ctx, s.db, id)
+}
+
+// CreateService adds a service to the catalogue. New services are always active.
+//
+// @param ctx context.Context: The context for the request.
+// @param svc *models.BillableService: The service to add.
+// @return *models.BillableService: The added service.
+// @return error: A validation error for a negative price, ErrDuplicateBillableService, or an error if the operation fails.
+func (s *ServiceCatalogueService) CreateService(ctx context.Context, svc *models.BillableService) (*models.BillableService, error) {
+	if err := validateBillableService(svc); err != nil {
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO billable_services (code, name, category, price, active)
+		VALUES ($1, $2, NULLIF($3, ''), $4, TRUE)
+		RETURNING id
+	`
+	var id int64
+	err := s.db.QueryRowContext(ctx, query,
+		strings.ToUpper(strings.TrimSpace(svc.Code)),
+		strings.TrimSpace(svc.Name),
+		strings.ToLower(strings.TrimSpace(svc.Category)),
+		svc.Price,
+	).Scan(&id)
+	if err != nil {
+		return nil, catalogueWriteError("creating service", err, ErrDuplicateBillableService)
+	}
+
+	created, err := getBillableService(ctx, s.db, id)
+	if err != nil {
+		return nil, err
+	}
+	err = s.audit.Record(ctx, nil, models.AuditEntry{
+		Action:     "billable_service.create",
+		EntityType: "billable_service",
+		EntityID:   &id,
+		Changes:    diffFields(nil, created),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// UpdateService replaces the details of a service, including its price and whether it can still
+// be billed. Invoice items already added keep the price they were added with.
+//
+// @param ctx context.Context: The context for the request.
+// @param id int64: The ID of the service.
+// @param svc *models.BillableService: The new details of the service.
+// @return *models.BillableService: The updated service.
+// @return error: ErrBillableServiceNotFound, a validation error for an invalid price, ErrDuplicateBillableService, or an error if the operation fails.
+func (s *ServiceCatalogueService) UpdateService(ctx context.Context, id int64, svc *models.BillableService) (*models.BillableService, error) {
+	if err := validateBillableService(svc); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	current, err := getBillableService(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		UPDATE billable_services
+		SET code = $1, name = $2, category = NULLIF($3, ''), price = $4, active = $5, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $6
+	`
+	_, err = tx.ExecContext(ctx, query,
+		strings.ToUpper(strings.TrimSpace(svc.Code)),
+		strings.TrimSpace(svc.Name),
+		strings.ToLower(strings.TrimSpace(svc.Category)),
+		svc.Price,
+		svc.Active,
+		id,
+	)
+	if err != nil {
+		return nil, catalogueWriteError("updating service", err, ErrDuplicateBillableService)
+	}
+
+	updated, err := getBillableService(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	err = s.audit.Record(ctx, tx, models.AuditEntry{
+		Action:     "billable_service.update",
+		EntityType: "billable_service",
+		EntityID:   &id,
+		Changes:    diffFields(current, updated),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error updating service: %v", err)
+		return nil, err
+	}
+	return updated, nil
+}
+
+// getBillableService loads a service from the catalogue.
+func getBillableService(ctx context.Context, db dbtx, id int64) (*models.BillableService, error) {
+	query := `SELECT ` + billableServiceColumns + ` FROM billable_services WHERE id = $1`
+	svc, err := scanBillableService(db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrBillableServiceNotFound
+		}
+		log.Printf("Error retrieving service: %v", err)
+		return nil, err
+	}
+	return svc, nil
+}
+
+// validateBillableService checks the details of a service that the request binding cannot.
+func validateBillableService(svc *models.BillableService) error {
+	var message string
+	switch {
+	case svc.Price < 0:
+		message = "must not be negative"
+	case svc.Price > models.MaxMoney:
+		message = "must be at most " + models.MaxMoney.String()
+	default:
+		return nil
+	}
+	return &Error{
+		Kind:    KindValidation,
+		Code:    "invalid_billable_service",
+		Message: "invalid service",
+		Fields:  []validation.FieldError{{Field: "price", Message: message}},
+	}
+}
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"sync"
+	"time"
+)
+
+// TokenRevocationService records revoked access tokens and per-user session cut-offs.
+//
+// Revocations are persisted in Postgres and mirrored in memory so that the authentication
+// middleware can check every request without a database round trip. The in-memory copy is
+// reloaded periodically so revocations made by other instances are picked up.
+type TokenRevocationService struct {
+	db              *sql.DB              // Database connection
+	refreshInterval time.Duration        // How often the in-memory copy is reloaded from the database
+	mu              sync.RWMutex         // Guards the fields below
+	tokens          map[string]time.Time // Revoked token IDs and when the tokens expire
+	userCutoffs     map[int64]time.Time  // Tokens issued to a user before this time are revoked
+	loadedAt        time.Time            // When the in-memory copy was last reloaded
+}
+
+// NewTokenRevocationService creates a new instance of TokenRevocationService.
+//
+// @param db *sql.DB: A database connection.
+// @param refreshInterval time.Duration: How often revocations are reloaded from the database.
+// @return *TokenRevocationService: A new TokenRevocationService instance.
+func NewTokenRevocationService(db *sql.DB, refreshInterval time.Duration) *TokenRevocationService {
+	return &TokenRevocationService{
+		db:              db,
+		refreshInterval: refreshInterval,
+		tokens:          make(map[string]time.Time),
+		userCutoffs:     make(map[int64]time.Time),
+	}
+}
+
+// IsRevoked reports whether an access token has been revoked, either individually or because all
+// sessions of its user were revoked after it was issued.
+//
+// @param ctx context.Context: The context for the request.
+// @param jti string: The token ID.
+// @param userID int64: The ID of the user the token was issued to.
+// @param issuedAt time.Time: When the token was issued.
+// @return bool: True if the token must be rejected.
+// @return error: An error if the revocation list could not be loaded.
+func (s *TokenRevocationService) IsRevoked(ctx context.Context, jti string, userID int64, issuedAt time.Time) (bool, error) {
+	if err := s.reloadIfStale(ctx); err != nil {
+		return false, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if _, ok := s.tokens[jti]; ok {
+		return true, nil
+	}
+	// Token iat claims have one-second resolution, so a token issued in the same second as the
+	// cutoff may predate it and is rejected too; logging in again works from the next second on
+	if cutoff, ok := s.userCutoffs[userID]; ok && !issuedAt.After(cutoff.Truncate(time.Second)) {
+		return true, nil
+	}
+	return false, nil
+}
+
+// RevokeToken revokes a single access token until it expires.
+//
+// @param ctx context.Context: The context for the request.
+// @param jti string: The token ID.
+// @param userID int64: The ID of the user the token was issued to.
+// @param expiresAt time.Time: When the token expires; the revocation is kept until then.
+// @return error: An error if the operation fails.
+func (s *TokenRevocationService) RevokeToken(ctx context.Context, jti string, userID int64, expiresAt time.Time) error {
+	query := `
+		INSERT INTO revoked_tokens (jti, user_id, expires_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (jti) DO NOTHING
+	`
+	if _, err := s.db.ExecContext(ctx, query, jti, userID, expiresAt); err != nil {
+		log.Printf("Error revoking token: %v", err)
+		return err
+	}
+
+	s.mu.Lock()
+	s.tokens[jti] = expiresAt
+	s.mu.Unlock()
+	return nil
+}
+
+// RevokeAllForUser revokes every access token issued to a user up to and including the current second.
+//
+// @param ctx context.Context: The context for the request.
+// @param userID int64: The ID of the user.
+// @return error: An error if the operation fails.
+func (s *TokenRevocationService) RevokeAllForUser(ctx context.Context, userID int64) error {
+	cutoff := time.Now().Truncate(time.Second)
+	query := `
+		INSERT INTO user_session_revocations (user_id, revoked_before)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET revoked_before = EXCLUDED.revoked_before
+	`
+	if _, err := s.db.ExecContext(ctx, query, userID, cutoff); err != nil {
+		log.Printf("Error revoking sessions for user %d: %v", userID, err)
+		return err
+	}
+
+	s.mu.Lock()
+	s.userCutoffs[userID] = cutoff
+	s.mu.Unlock()
+	return nil
+}
+
+// reloadIfStale reloads the revocation lists from the database once refreshInterval has elapsed.
+func (s *TokenRevocationService) reloadIfStale(ctx context.Context) error {
+	s.mu.RLock()
+	fresh := time.Since(s.loadedAt) < s.refreshInterval
+	s.mu.RUnlock()
+	if fresh {
+		return nil
+	}
+
+	// Expired tokens are rejected by signature validation anyway, so their revocations can go
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM revoked_tokens WHERE expires_at < CURRENT_TIMESTAMP`); err != nil {
+		log.Printf("Error pruning revoked tokens: %v", err)
+		return err
+	}
+
+	tokens := make(map[string]time.Time)
+	rows, err := s.db.QueryContext(ctx, `SELECT jti, expires_at FROM revoked_tokens`)
+	if err != nil {
+		log.Printf("Error loading revoked tokens: %v", err)
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var jti string
+		var expiresAt time.Time
+		if err := rows.Scan(&jti, &expiresAt); err != nil {
+			log.Printf("Error scanning revoked token: %v", err)
+			return err
+		}
+		tokens[jti] = expiresAt
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating revoked tokens: %v", err)
+		return err
+	}
+
+	cutoffs := make(map[int64]time.Time)
+	cutoffRows, err := s.db.QueryContext(ctx, `SELECT user_id, revoked_before FROM user_session_revocations`)
+	if err != nil {
+		log.Printf("Error loading session revocations: %v", err)
+		return err
+	}
+	defer cutoffRows.Close()
+	for cutoffRows.Next() {
+		var userID int64
+		var cutoff time.Time
+		if err := cutoffRows.Scan(&userID, &cutoff); err != nil {
+			log.Printf("Error scanning session revocation: %v", err)
+			return err
+		}
+		cutoffs[userID] = cutoff
+	}
+	if err := cutoffRows.Err(); err != nil {
+		log.Printf("Error iterating session revocations: %v", err)
+		return err
+	}
+
+	s.mu.Lock()
+	s.tokens = tokens
+	s.userCutoffs = cutoffs
+	s.loadedAt = time.Now()
+	s.mu.Unlock()
+	return nil
+}
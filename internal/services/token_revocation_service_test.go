@@ -0,0 +1,44 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// newLoadedRevocationService returns a service whose in-memory revocations are considered fresh,
+// so that IsRevoked does not reload them from the database.
+func newLoadedRevocationService(cutoffs map[int64]time.Time) *TokenRevocationService {
+	s := NewTokenRevocationService(nil, time.Hour)
+	s.userCutoffs = cutoffs
+	s.loadedAt = time.Now()
+	return s
+}
+
+func TestIsRevokedUserCutoff(t *testing.T) {
+	cutoff := time.Date(2025, 2, 7, 9, 0, 0, 600_000_000, time.UTC)
+	s := newLoadedRevocationService(map[int64]time.Time{1: cutoff})
+
+	tests := []struct {
+		name     string
+		userID   int64
+		issuedAt time.Time
+		want     bool
+	}{
+		{name: "issued a second before the cutoff", userID: 1, issuedAt: cutoff.Truncate(time.Second).Add(-time.Second), want: true},
+		{name: "issued in the same second as the cutoff", userID: 1, issuedAt: cutoff.Truncate(time.Second), want: true},
+		{name: "issued after the cutoff", userID: 1, issuedAt: cutoff.Add(time.Second).Truncate(time.Second), want: false},
+		{name: "user without a cutoff", userID: 2, issuedAt: cutoff.Add(-time.Hour), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.IsRevoked(context.Background(), "jti", tt.userID, tt.issuedAt)
+			if err != nil {
+				t.Fatalf("IsRevoked() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("IsRevoked() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
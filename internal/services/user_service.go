@@ -0,0 +1,441 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/Okemwag/medihub/internal/models"
+	"github.com/Okemwag/medihub/internal/requestctx"
+	"github.com/lib/pq"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// MinPasswordLength is the minimum length accepted for staff passwords.
+const MinPasswordLength = 8
+
+// Errors returned by UserService.
+var (
+	ErrUserNotFound  = NewNotFoundError("user_not_found", "user not found")
+	ErrUsernameTaken = NewConflictError("username_taken", "username already exists")
+	ErrUnknownRole   = NewValidationError("unknown_role", "unknown role")
+	ErrWeakPassword  = NewValidationError("weak_password", "password must be at least 8 characters")
+	ErrOwnAccess     = NewConflictError("own_access_change", "administrators cannot change their own role or deactivate themselves")
+	ErrLastAdmin     = NewConflictError("last_admin", "at least one active administrator is required")
+)
+
+// adminRole is the role whose last active holder may not be demoted or deactivated.
+const adminRole = "admin"
+
+// UserService provides methods for administering staff user accounts.
+type UserService struct {
+	db          *sql.DB       // Database connection
+	authService *AuthService  // Used to end the sessions of users whose access changes
+	audit       *AuditService // Records every change to a user account
+}
+
+// NewUserService creates a new instance of UserService.
+//
+// @param db *sql.DB: A database connection.
+// @param authService *AuthService: The service used to revoke sessions when access changes.
+// @param audit *AuditService: The service used to audit account changes.
+// @return *UserService: A new UserService instance.
+func NewUserService(db *sql.DB, authService *AuthService, audit *AuditService) *UserService {
+	return &UserService{db: db, authService: authService, audit: audit}
+}
+
+// CreateUserInput holds the details of a new staff account.
+type CreateUserInput struct {
+	Username string `json:"username" binding:"required"`
+	Name     string `json:"name" binding:"required"`
+	Password string `json:"password" binding:"required"`
+	Role     string `json:"role" binding:"required"`
+}
+
+// UpdateUserInput holds the account fields an administrator may change. Nil fields are left unchanged.
+type UpdateUserInput struct {
+	Name *string `json:"name"`
+	Role *string `json:"role"`
+}
+
+// userColumns lists the user columns in the order expected by scanUser.
+const userColumns = `u.id, u.username, u.name, COALESCE(u.role_id, 0), COALESCE(r.name, ''), u.active, u.deactivated_at, u.created_at, u.updated_at`
+
+// scanUser reads a single user selected with userColumns.
+func scanUser(row rowScanner) (*models.User, error) {
+	var user models.User
+	err := row.Scan(
+		&user.ID,
+		&user.Username,
+		&user.Name,
+		&user.RoleID,
+		&user.Role,
+		&user.Active,
+		&user.DeactivatedAt,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// CreateUser adds a new staff account.
+//
+// @param ctx context.Context: The context for the request.
+// @param input CreateUserInput: The details of the new account.
+// @return *models.User: The created user.
+// @return error: ErrWeakPassword, ErrUnknownRole, ErrUsernameTaken, or an error if the operation fails.
+func (s *UserService) CreateUser(ctx context.Context, input CreateUserInput) (*models.User, error) {
+	if len(input.Password) < MinPasswordLength {
+		return nil, ErrWeakPassword
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	roleID, err := s.roleID(ctx, tx, input.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	var id int64
+	query := `
+		INSERT INTO users (username, name, password_hash, role_id)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`
+	err = tx.QueryRowContext(ctx, query, strings.TrimSpace(input.Username), input.Name, string(hashedPassword), roleID).Scan(&id)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return nil, ErrUsernameTaken
+		}
+		log.Printf("Error creating user: %v", err)
+		return nil, err
+	}
+
+	err = s.audit.Record(ctx, tx, models.AuditEntry{
+		Action:     "user.create",
+		EntityType: "user",
+		EntityID:   &id,
+		Changes: map[string]models.FieldChange{
+			"username": {To: input.Username},
+			"name":     {To: input.Name},
+			"role":     {To: input.Role},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return s.GetUser(ctx, id)
+}
+
+// ListUsers retrieves staff accounts, optionally filtered by role and active state.
+//
+// @param ctx context.Context: The context for the request.
+// @param role string: Only return users with this role; empty returns every role.
+// @param active *bool: Only return active (true) or deactivated (false) users; nil returns both.
+// @return []models.User: The matching users ordered by username.
+// @return error: An error if the operation fails.
+func (s *UserService) ListUsers(ctx context.Context, role string, active *bool) ([]models.User, error) {
+	query := `
+		SELECT ` + userColumns + `
+		FROM users u
+		LEFT JOIN roles r ON r.id = u.role_id
+		WHERE ($1 = '' OR LOWER(r.name) = LOWER($1))
+			AND ($2::BOOLEAN IS NULL OR u.active = $2)
+		ORDER BY u.username
+	`
+	rows, err := s.db.QueryContext(ctx, query, role, active)
+	if err != nil {
+		log.Printf("Error listing users: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			log.Printf("Error scanning user: %v", err)
+			return nil, err
+		}
+		users = append(users, *user)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating users: %v", err)
+		return nil, err
+	}
+	return users, nil
+}
+
+// GetUser retrieves a staff account by ID.
+//
+// @param ctx context.Context: The context for the request.
+// @param id int64: The ID of the user.
+// @return *models.User: The user.
+// @return error: ErrUserNotFound, or an error if the operation fails.
+func (s *UserService) GetUser(ctx context.Context, id int64) (*models.User, error) {
+	return getUser(ctx, s.db, id, false)
+}
+
+// getUser loads a user, optionally locking the row for the rest of the transaction.
+func getUser(ctx context.Context, db dbtx, id int64, forUpdate bool) (*models.User, error) {
+	query := `
+		SELECT ` + userColumns + `
+		FROM users u
+		LEFT JOIN roles r ON r.id = u.role_id
+		WHERE u.id = $1
+	`
+	if forUpdate {
+		query += ` FOR UPDATE OF u`
+	}
+	user, err := scanUser(db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		log.Printf("Error retrieving user: %v", err)
+		return nil, err
+	}
+	return user, nil
+}
+
+// UpdateUser changes the name and/or role of a staff account. Changing the role ends all of the
+// user's sessions so that the new role takes effect immediately. Users cannot change their own
+// role, and the last active administrator cannot be given another role.
+//
+// @param ctx context.Context: The context for the request.
+// @param id int64: The ID of the user.
+// @param input UpdateUserInput: The fields to change.
+// @return *models.User: The updated user.
+// @return error: ErrUserNotFound, ErrUnknownRole, ErrOwnAccess, ErrLastAdmin, or an error if the operation fails.
+func (s *UserService) UpdateUser(ctx context.Context, id int64, input UpdateUserInput) (*models.User, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	current, err := getUser(ctx, tx, id, true)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := map[string]models.FieldChange{}
+	name, roleID := current.Name, current.RoleID
+	if input.Name != nil && *input.Name != current.Name {
+		name = *input.Name
+		changes["name"] = models.FieldChange{From: current.Name, To: name}
+	}
+	if input.Role != nil && !strings.EqualFold(*input.Role, current.Role) {
+		if roleID, err = s.roleID(ctx, tx, *input.Role); err != nil {
+			return nil, err
+		}
+		if isCurrentUser(ctx, id) {
+			return nil, ErrOwnAccess
+		}
+		if strings.EqualFold(current.Role, adminRole) {
+			if err := requireOtherActiveAdmin(ctx, tx, id); err != nil {
+				return nil, err
+			}
+		}
+		changes["role"] = models.FieldChange{From: current.Role, To: *input.Role}
+	}
+	if len(changes) == 0 {
+		return current, nil
+	}
+
+	query := `UPDATE users SET name = $1, role_id = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $3`
+	if _, err := tx.ExecContext(ctx, query, name, roleID, id); err != nil {
+		log.Printf("Error updating user: %v", err)
+		return nil, err
+	}
+	err = s.audit.Record(ctx, tx, models.AuditEntry{Action: "user.update", EntityType: "user", EntityID: &id, Changes: changes})
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	if _, roleChanged := changes["role"]; roleChanged {
+		if err := s.authService.LogoutAll(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+	return s.GetUser(ctx, id)
+}
+
+// SetActive deactivates or reactivates a staff account. Deactivated users cannot log in and all
+// of their sessions are ended. Users cannot deactivate themselves, and the last active
+// administrator cannot be deactivated.
+//
+// @param ctx context.Context: The context for the request.
+// @param id int64: The ID of the user.
+// @param active bool: False to deactivate the account, true to reactivate it.
+// @return error: ErrUserNotFound, ErrOwnAccess, ErrLastAdmin, or an error if the operation fails.
+func (s *UserService) SetActive(ctx context.Context, id int64, active bool) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var previous bool
+	var role string
+	query := `SELECT u.active, COALESCE(r.name, '') FROM users u LEFT JOIN roles r ON r.id = u.role_id WHERE u.id = $1 FOR UPDATE OF u`
+	err = tx.QueryRowContext(ctx, query, id).Scan(&previous, &role)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	if previous == active {
+		return nil
+	}
+	if !active {
+		if isCurrentUser(ctx, id) {
+			return ErrOwnAccess
+		}
+		if strings.EqualFold(role, adminRole) {
+			if err := requireOtherActiveAdmin(ctx, tx, id); err != nil {
+				return err
+			}
+		}
+	}
+
+	query = `
+		UPDATE users
+		SET active = $1, deactivated_at = CASE WHEN $1 THEN NULL ELSE CURRENT_TIMESTAMP END, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2
+	`
+	if _, err := tx.ExecContext(ctx, query, active, id); err != nil {
+		log.Printf("Error changing user active state: %v", err)
+		return err
+	}
+
+	action := "user.reactivate"
+	if !active {
+		action = "user.deactivate"
+	}
+	err = s.audit.Record(ctx, tx, models.AuditEntry{
+		Action:     action,
+		EntityType: "user",
+		EntityID:   &id,
+		Changes:    map[string]models.FieldChange{"active": {From: previous, To: active}},
+	})
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	if !active {
+		return s.authService.LogoutAll(ctx, id)
+	}
+	return nil
+}
+
+// ResetPassword sets a new password for a staff account and ends all of the user's sessions.
+//
+// @param ctx context.Context: The context for the request.
+// @param id int64: The ID of the user.
+// @param password string: The new password.
+// @return error: ErrWeakPassword, ErrUserNotFound, or an error if the operation fails.
+func (s *UserService) ResetPassword(ctx context.Context, id int64, password string) error {
+	if len(password) < MinPasswordLength {
+		return ErrWeakPassword
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `UPDATE users SET password_hash = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`, string(hashedPassword), id)
+	if err != nil {
+		log.Printf("Error resetting password: %v", err)
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrUserNotFound
+	}
+
+	// The password itself is never written to the audit log
+	if err := s.audit.Record(ctx, tx, models.AuditEntry{Action: "user.reset_password", EntityType: "user", EntityID: &id}); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	return s.authService.LogoutAll(ctx, id)
+}
+
+// roleID resolves a role name to its ID.
+func (s *UserService) roleID(ctx context.Context, db dbtx, role string) (int64, error) {
+	var id int64
+	err := db.QueryRowContext(ctx, `SELECT id FROM roles WHERE LOWER(name) = LOWER($1)`, role).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrUnknownRole
+		}
+		return 0, err
+	}
+	return id, nil
+}
+
+// isCurrentUser reports whether id is the user the request is made on behalf of.
+func isCurrentUser(ctx context.Context, id int64) bool {
+	principal, ok := requestctx.PrincipalFrom(ctx)
+	return ok && principal.UserID == id
+}
+
+// requireOtherActiveAdmin returns ErrLastAdmin unless an active administrator other than the given
+// user exists. The other administrators are locked so that concurrent changes cannot remove them
+// all at once.
+func requireOtherActiveAdmin(ctx context.Context, tx *sql.Tx, id int64) error {
+	query := `
+		SELECT u.id
+		FROM users u
+		JOIN roles r ON r.id = u.role_id
+		WHERE LOWER(r.name) = $1 AND u.active AND u.id <> $2
+		FOR UPDATE OF u
+	`
+	rows, err := tx.QueryContext(ctx, query, adminRole, id)
+	if err != nil {
+		log.Printf("Error checking for other administrators: %v", err)
+		return err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		return ErrLastAdmin
+	}
+	return nil
+}
@@ -0,0 +1,396 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Okemwag/medihub/internal/models"
+	"github.com/Okemwag/medihub/internal/validation"
+	"github.com/lib/pq"
+)
+
+// Errors returned by VitalService.
+var (
+	ErrInvalidVitalType       = NewValidationError("invalid_vital_type", "unknown vital sign type")
+	ErrInvalidVitalsEncounter = NewValidationError("invalid_vitals_encounter", "encounter_id must refer to an encounter of the same patient")
+)
+
+// vitalType describes how a vital sign type is stored: its canonical unit, the plausible range of
+// values in that unit, and how to convert other accepted units to it.
+type vitalType struct {
+	unit     string
+	min, max float64
+	convert  map[string]func(float64) float64
+}
+
+// sameUnit converts a value that is already in the canonical unit.
+func sameUnit(v float64) float64 { return v }
+
+// vitalTypeOrder lists the vital sign types in the order their series are returned.
+var vitalTypeOrder = []string{
+	models.VitalSystolicBP,
+	models.VitalDiastolicBP,
+	models.VitalPulse,
+	models.VitalRespiratoryRate,
+	models.VitalTemperature,
+	models.VitalSpO2,
+	models.VitalWeight,
+	models.VitalHeight,
+	models.VitalBMI,
+}
+
+// vitalTypes holds the unit and range rules of every vital sign type.
+var vitalTypes = map[string]vitalType{
+	models.VitalSystolicBP: {unit: "mmHg", min: 40, max: 300, convert: map[string]func(float64) float64{
+		"mmHg": sameUnit,
+		"kPa":  func(v float64) float64 { return v * 7.50062 },
+	}},
+	models.VitalDiastolicBP: {unit: "mmHg", min: 20, max: 200, convert: map[string]func(float64) float64{
+		"mmHg": sameUnit,
+		"kPa":  func(v float64) float64 { return v * 7.50062 },
+	}},
+	models.VitalPulse: {unit: "bpm", min: 20, max: 300, convert: map[string]func(float64) float64{
+		"bpm": sameUnit,
+	}},
+	models.VitalRespiratoryRate: {unit: "breaths/min", min: 4, max: 80, convert: map[string]func(float64) float64{
+		"breaths/min": sameUnit,
+	}},
+	models.VitalTemperature: {unit: "C", min: 25, max: 45, convert: map[string]func(float64) float64{
+		"C": sameUnit,
+		"F": func(v float64) float64 { return (v - 32) * 5 / 9 },
+	}},
+	models.VitalSpO2: {unit: "%", min: 50, max: 100, convert: map[string]func(float64) float64{
+		"%": sameUnit,
+	}},
+	models.VitalWeight: {unit: "kg", min: 0.2, max: 500, convert: map[string]func(float64) float64{
+		"kg": sameUnit,
+		"g":  func(v float64) float64 { return v / 1000 },
+		"lb": func(v float64) float64 { return v * 0.45359237 },
+	}},
+	models.VitalHeight: {unit: "cm", min: 20, max: 280, convert: map[string]func(float64) float64{
+		"cm": sameUnit,
+		"m":  func(v float64) float64 { return v * 100 },
+		"in": func(v float64) float64 { return v * 2.54 },
+	}},
+	models.VitalBMI: {unit: "kg/m2"},
+}
+
+// VitalService provides methods for recording and charting patients' vital signs.
+type VitalService struct {
+	db    *sql.DB
+	audit *AuditService
+}
+
+// NewVitalService creates a new instance of VitalService.
+//
+// @param db *sql.DB: A database connection.
+// @param audit *AuditService: The service used to audit access to vital signs.
+// @return *VitalService: A new VitalService instance.
+func NewVitalService(db *sql.DB, audit *AuditService) *VitalService {
+	return &VitalService{db: db, audit: audit}
+}
+
+// RecordVitals stores a set of measurements taken together. Values are converted to the canonical
+// unit of their type and checked against its plausible range. When weight or height is recorded,
+// BMI is derived from it and the other measurement, taken from the same set or the patient's most
+// recent one.
+//
+// @param ctx context.Context: The context for the request.
+// @param patientID int64: The ID of the patient.
+// @param recording *models.VitalsRecording: The measurements to record.
+// @param recordedBy int64: The ID of the user recording the measurements.
+// @return []models.VitalSign: The stored observations, including any derived BMI.
+// @return error: ErrPatientNotFound, ErrInvalidVitalsEncounter, ErrEncounterSigned, a validation error, or an error if the operation fails.
+func (s *VitalService) RecordVitals(ctx context.Context, patientID int64, recording *models.VitalsRecording, recordedBy int64) ([]models.VitalSign, error) {
+	recordedAt := time.Now()
+	if recording.RecordedAt != nil {
+		recordedAt = *recording.RecordedAt
+	}
+
+	values, err := normalizeMeasurements(recording.Measurements, recordedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := requirePatient(ctx, tx, patientID); err != nil {
+		return nil, err
+	}
+	if recording.EncounterID != nil {
+		if err := requireDraftEncounter(ctx, tx, *recording.EncounterID, patientID); err != nil {
+			return nil, err
+		}
+	}
+
+	_, hasWeight := values[models.VitalWeight]
+	_, hasHeight := values[models.VitalHeight]
+	if hasWeight || hasHeight {
+		weight, err := s.latestValue(ctx, tx, patientID, models.VitalWeight, values, recordedAt)
+		if err != nil {
+			return nil, err
+		}
+		height, err := s.latestValue(ctx, tx, patientID, models.VitalHeight, values, recordedAt)
+		if err != nil {
+			return nil, err
+		}
+		if weight > 0 && height > 0 {
+			meters := height / 100
+			values[models.VitalBMI] = math.Round(weight/(meters*meters)*10) / 10
+		}
+	}
+
+	ids := make([]int64, 0, len(values))
+	query := `
+		INSERT INTO vital_signs (patient_id, encounter_id, type, value, unit, recorded_at, recorded_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`
+	for _, typ := range vitalTypeOrder {
+		value, ok := values[typ]
+		if !ok {
+			continue
+		}
+		var id int64
+		err := tx.QueryRowContext(ctx, query, patientID, recording.EncounterID, typ, value, vitalTypes[typ].unit, recordedAt, recordedBy).Scan(&id)
+		if err != nil {
+			log.Printf("Error recording vital sign: %v", err)
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	recorded, err := queryVitalSigns(ctx, tx, `WHERE v.id = ANY($1)`, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	details := map[string]interface{}{"vital_sign_ids": ids}
+	if recording.EncounterID != nil {
+		details["encounter_id"] = *recording.EncounterID
+	}
+	err = s.audit.Record(ctx, tx, models.AuditEntry{
+		Action:     "vitals.record",
+		EntityType: "patient",
+		EntityID:   &patientID,
+		Details:    details,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error recording vital signs: %v", err)
+		return nil, err
+	}
+	return recorded, nil
+}
+
+// ListVitals retrieves a patient's vital signs as one time series per type.
+//
+// @param ctx context.Context: The context for the request.
+// @param patientID int64: The ID of the patient.
+// @param vitalType string: Only this type, or every type when empty.
+// @param from *time.Time: Only observations recorded at or after this time, if set.
+// @param to *time.Time: Only observations recorded before this time, if set.
+// @return []models.VitalSeries: One series per type with observations, oldest observation first.
+// @return error: ErrPatientNotFound, ErrInvalidVitalType, or an error if the operation fails.
+func (s *VitalService) ListVitals(ctx context.Context, patientID int64, vitalType string, from, to *time.Time) ([]models.VitalSeries, error) {
+	if _, ok := vitalTypes[vitalType]; vitalType != "" && !ok {
+		return nil, fmt.Errorf("%w: %s; expected one of %s", ErrInvalidVitalType, vitalType, strings.Join(vitalTypeOrder, ", "))
+	}
+	if err := requirePatient(ctx, s.db, patientID); err != nil {
+		return nil, err
+	}
+
+	conditions := []string{"v.patient_id = $1"}
+	args := []interface{}{patientID}
+	add := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if vitalType != "" {
+		add("v.type = $%d", vitalType)
+	}
+	if from != nil {
+		add("v.recorded_at >= $%d", *from)
+	}
+	if to != nil {
+		add("v.recorded_at < $%d", *to)
+	}
+	signs, err := queryVitalSigns(ctx, s.db, "WHERE "+strings.Join(conditions, " AND "), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	byType := map[string]*models.VitalSeries{}
+	for _, sign := range signs {
+		series, ok := byType[sign.Type]
+		if !ok {
+			series = &models.VitalSeries{Type: sign.Type, Unit: sign.Unit, Points: []models.VitalPoint{}}
+			byType[sign.Type] = series
+		}
+		series.Points = append(series.Points, models.VitalPoint{
+			ID:          sign.ID,
+			Value:       sign.Value,
+			RecordedAt:  sign.RecordedAt,
+			EncounterID: sign.EncounterID,
+		})
+	}
+	result := []models.VitalSeries{}
+	for _, typ := range vitalTypeOrder {
+		if series, ok := byType[typ]; ok {
+			result = append(result, *series)
+		}
+	}
+
+	err = s.audit.Record(ctx, nil, models.AuditEntry{
+		Action:     "vitals.read",
+		EntityType: "patient",
+		EntityID:   &patientID,
+		Details:    map[string]interface{}{"type": vitalType, "returned": len(signs)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// latestValue returns the value of a type in the recording, or else the patient's most recent
+// value recorded no later than at. It returns zero when there is none.
+func (s *VitalService) latestValue(ctx context.Context, db dbtx, patientID int64, typ string, values map[string]float64, at time.Time) (float64, error) {
+	if value, ok := values[typ]; ok {
+		return value, nil
+	}
+	var value float64
+	query := `
+		SELECT value FROM vital_signs
+		WHERE patient_id = $1 AND type = $2 AND recorded_at <= $3
+		ORDER BY recorded_at DESC, id DESC
+		LIMIT 1
+	`
+	err := db.QueryRowContext(ctx, query, patientID, typ, at).Scan(&value)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Printf("Error retrieving latest %s: %v", typ, err)
+		return 0, err
+	}
+	return value, nil
+}
+
+// normalizeMeasurements converts measurements to their canonical units and validates them,
+// reporting every problem as a field error.
+func normalizeMeasurements(measurements []models.Measurement, recordedAt time.Time) (map[string]float64, error) {
+	var fields []validation.FieldError
+	if recordedAt.After(time.Now().Add(5 * time.Minute)) {
+		fields = append(fields, validation.FieldError{Field: "recorded_at", Message: "must not be in the future"})
+	}
+
+	values := map[string]float64{}
+	for i, m := range measurements {
+		field := fmt.Sprintf("measurements[%d]", i)
+		rules, ok := vitalTypes[m.Type]
+		if !ok || m.Type == models.VitalBMI {
+			fields = append(fields, validation.FieldError{Field: field + ".type", Message: "is not a recordable vital sign type"})
+			continue
+		}
+		if _, dup := values[m.Type]; dup {
+			fields = append(fields, validation.FieldError{Field: field + ".type", Message: "is recorded more than once"})
+			continue
+		}
+
+		unit := m.Unit
+		if unit == "" {
+			unit = rules.unit
+		}
+		convert, ok := rules.convert[unit]
+		if !ok {
+			units := make([]string, 0, len(rules.convert))
+			for u := range rules.convert {
+				units = append(units, u)
+			}
+			sort.Strings(units)
+			fields = append(fields, validation.FieldError{Field: field + ".unit", Message: "must be one of " + strings.Join(units, ", ")})
+			continue
+		}
+
+		value := math.Round(convert(m.Value)*100) / 100
+		if value < rules.min || value > rules.max {
+			fields = append(fields, validation.FieldError{
+				Field:   field + ".value",
+				Message: fmt.Sprintf("must be between %g and %g %s", rules.min, rules.max, rules.unit),
+			})
+			continue
+		}
+		values[m.Type] = value
+	}
+
+	systolic, hasSystolic := values[models.VitalSystolicBP]
+	diastolic, hasDiastolic := values[models.VitalDiastolicBP]
+	if hasSystolic && hasDiastolic && diastolic >= systolic {
+		fields = append(fields, validation.FieldError{Field: "measurements", Message: "diastolic_bp must be lower than systolic_bp"})
+	}
+
+	if len(fields) > 0 {
+		return nil, &Error{Kind: KindValidation, Code: "invalid_vitals", Message: "invalid vital signs", Fields: fields}
+	}
+	return values, nil
+}
+
+// queryVitalSigns loads the vital signs matching a WHERE clause on vital_signs v, oldest first.
+func queryVitalSigns(ctx context.Context, db dbtx, where string, args ...interface{}) ([]models.VitalSign, error) {
+	query := `
+		SELECT v.id, v.patient_id, v.encounter_id, v.type, v.value, v.unit, v.recorded_at, COALESCE(v.recorded_by, 0), COALESCE(u.name, ''), v.created_at
+		FROM vital_signs v
+		LEFT JOIN users u ON u.id = v.recorded_by
+		` + where + `
+		ORDER BY v.recorded_at, v.id
+	`
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Printf("Error listing vital signs: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	signs := []models.VitalSign{}
+	for rows.Next() {
+		var v models.VitalSign
+		if err := rows.Scan(&v.ID, &v.PatientID, &v.EncounterID, &v.Type, &v.Value, &v.Unit, &v.RecordedAt, &v.RecordedBy, &v.RecorderName, &v.CreatedAt); err != nil {
+			log.Printf("Error scanning vital sign: %v", err)
+			return nil, err
+		}
+		signs = append(signs, v)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating vital signs: %v", err)
+		return nil, err
+	}
+	return signs, nil
+}
+
+// requireDraftEncounter checks that an encounter belongs to the patient and has not been signed.
+func requireDraftEncounter(ctx context.Context, db dbtx, encounterID, patientID int64) error {
+	var status string
+	err := db.QueryRowContext(ctx, `SELECT status FROM encounters WHERE id = $1 AND patient_id = $2`, encounterID, patientID).Scan(&status)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrInvalidVitalsEncounter
+		}
+		log.Printf("Error checking encounter: %v", err)
+		return err
+	}
+	if status == models.EncounterSigned {
+		return ErrEncounterSigned
+	}
+	return nil
+}
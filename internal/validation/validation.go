@@ -0,0 +1,152 @@
+// Package validation configures the request validator shared by gin bindings and services, and
+// turns validation failures into field-level error messages.
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// GenderCodes is the set of accepted patient gender codes.
+var GenderCodes = []string{"male", "female", "other", "unknown"}
+
+// MaxAge bounds how far in the past a date of birth may be.
+const MaxAge = 150
+
+var registerOnce sync.Once
+
+// Register installs the custom validation rules on gin's validator and makes field errors report
+// JSON field names. It is safe to call more than once.
+func Register() {
+	registerOnce.Do(func() {
+		v, ok := binding.Validator.Engine().(*validator.Validate)
+		if !ok {
+			panic("validation: unexpected gin validator engine")
+		}
+
+		v.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+			if name == "-" {
+				return ""
+			}
+			return name
+		})
+
+		mustRegister(v, "dob", validateDateOfBirth)
+		mustRegister(v, "gender", validateGender)
+		mustRegister(v, "clock", validateClock)
+	})
+}
+
+func mustRegister(v *validator.Validate, tag string, fn validator.Func) {
+	if err := v.RegisterValidation(tag, fn); err != nil {
+		panic(fmt.Sprintf("validation: registering %s: %v", tag, err))
+	}
+}
+
+// ValidateStruct validates obj against its binding tags, exactly as gin does for request bodies.
+func ValidateStruct(obj interface{}) error {
+	Register()
+	return binding.Validator.ValidateStruct(obj)
+}
+
+// validateDateOfBirth accepts dates that are not in the future and no more than MaxAge years ago.
+func validateDateOfBirth(fl validator.FieldLevel) bool {
+	dob, ok := fl.Field().Interface().(time.Time)
+	if !ok || dob.IsZero() {
+		return false
+	}
+	now := time.Now()
+	return !dob.After(now) && dob.After(now.AddDate(-MaxAge, 0, 0))
+}
+
+// validateGender accepts one of GenderCodes, ignoring case.
+func validateGender(fl validator.FieldLevel) bool {
+	value := fl.Field().String()
+	for _, code := range GenderCodes {
+		if strings.EqualFold(value, code) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateClock accepts a 24-hour time of day in HH:MM format.
+func validateClock(fl validator.FieldLevel) bool {
+	_, err := time.Parse("15:04", fl.Field().String())
+	return err == nil
+}
+
+// FieldError describes why a single field failed validation.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// FieldErrors extracts field-level messages from a validation error. It returns nil when err is
+// not a validation error, e.g. when the request body is not valid JSON.
+func FieldErrors(err error) []FieldError {
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return nil
+	}
+
+	fields := make([]FieldError, 0, len(validationErrors))
+	for _, fe := range validationErrors {
+		fields = append(fields, FieldError{Field: fieldPath(fe), Message: message(fe)})
+	}
+	return fields
+}
+
+// fieldPath returns the JSON path of the failed field relative to the validated struct, e.g.
+// "working_hours[0].start_time" for a field of a nested element.
+func fieldPath(fe validator.FieldError) string {
+	namespace := fe.Namespace()
+	if i := strings.Index(namespace, "."); i >= 0 {
+		return namespace[i+1:]
+	}
+	return fe.Field()
+}
+
+// message renders a human-readable explanation for a failed rule.
+func message(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "max":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at most %s characters", fe.Param())
+		}
+		return "must be at most " + fe.Param()
+	case "min":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at least %s characters", fe.Param())
+		}
+		return "must be at least " + fe.Param()
+	case "email":
+		return "must be a valid email address"
+	case "e164":
+		return "must be a phone number in E.164 format, e.g. +254712345678"
+	case "dob":
+		return fmt.Sprintf("must be a date in the past and within the last %d years", MaxAge)
+	case "gender":
+		return "must be one of " + strings.Join(GenderCodes, ", ")
+	case "clock":
+		return "must be a time of day in HH:MM format"
+	case "datetime":
+		return "must be a date in YYYY-MM-DD format"
+	case "timezone":
+		return "must be an IANA time zone name, e.g. Africa/Nairobi"
+	case "oneof":
+		return "must be one of " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	default:
+		return fmt.Sprintf("failed %s validation", fe.Tag())
+	}
+}
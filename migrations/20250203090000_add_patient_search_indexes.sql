@@ -0,0 +1,20 @@
+-- +goose Up
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+ALTER TABLE patients ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', coalesce(first_name, '') || ' ' || coalesce(last_name, '')), 'A') ||
+    setweight(to_tsvector('simple', coalesce(email, '')), 'B') ||
+    setweight(to_tsvector('simple', coalesce(contact_number, '')), 'B')
+) STORED;
+
+CREATE INDEX idx_patients_search_vector ON patients USING GIN (search_vector);
+CREATE INDEX idx_patients_full_name_trgm ON patients USING GIN ((first_name || ' ' || last_name) gin_trgm_ops);
+CREATE INDEX idx_patients_email_trgm ON patients USING GIN (email gin_trgm_ops);
+CREATE INDEX idx_patients_contact_digits_trgm ON patients USING GIN ((regexp_replace(contact_number, '\D', '', 'g')) gin_trgm_ops);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_patients_contact_digits_trgm;
+DROP INDEX IF EXISTS idx_patients_email_trgm;
+DROP INDEX IF EXISTS idx_patients_full_name_trgm;
+DROP INDEX IF EXISTS idx_patients_search_vector;
+ALTER TABLE patients DROP COLUMN search_vector;
@@ -0,0 +1,33 @@
+-- +goose Up
+CREATE TABLE revoked_tokens (
+    jti VARCHAR(64) PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    revoked_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_revoked_tokens_expires_at ON revoked_tokens (expires_at);
+
+-- Access tokens issued to a user before revoked_before are rejected
+CREATE TABLE user_session_revocations (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    revoked_before TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+INSERT INTO permissions (name, description) VALUES
+    ('session.revoke', 'Revoke all sessions of another user')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+CROSS JOIN permissions p
+WHERE r.name = 'admin' AND p.name = 'session.revoke'
+ON CONFLICT DO NOTHING;
+
+-- +goose Down
+DELETE FROM role_permissions
+WHERE permission_id IN (SELECT id FROM permissions WHERE name = 'session.revoke');
+DELETE FROM permissions WHERE name = 'session.revoke';
+DROP TABLE user_session_revocations;
+DROP TABLE revoked_tokens;
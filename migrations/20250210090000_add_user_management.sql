@@ -0,0 +1,54 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN active BOOLEAN NOT NULL DEFAULT TRUE;
+ALTER TABLE users ADD COLUMN deactivated_at TIMESTAMP WITH TIME ZONE;
+
+CREATE TABLE audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    occurred_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    actor_user_id INTEGER REFERENCES users(id),
+    actor_role VARCHAR(50),
+    action VARCHAR(100) NOT NULL,
+    entity_type VARCHAR(50) NOT NULL,
+    entity_id BIGINT,
+    changes JSONB
+);
+
+CREATE INDEX idx_audit_log_entity ON audit_log (entity_type, entity_id, occurred_at);
+CREATE INDEX idx_audit_log_actor ON audit_log (actor_user_id, occurred_at);
+
+-- +goose StatementBegin
+CREATE FUNCTION audit_log_prevent_modification() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER audit_log_append_only
+    BEFORE UPDATE OR DELETE OR TRUNCATE ON audit_log
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_log_prevent_modification();
+
+INSERT INTO permissions (name, description) VALUES
+    ('user.create', 'Create staff user accounts'),
+    ('user.read', 'View staff user accounts'),
+    ('user.update', 'Change the name or role of staff user accounts'),
+    ('user.deactivate', 'Deactivate and reactivate staff user accounts'),
+    ('user.reset_password', 'Reset the password of staff user accounts')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+CROSS JOIN permissions p
+WHERE r.name = 'admin' AND p.name LIKE 'user.%'
+ON CONFLICT DO NOTHING;
+
+-- +goose Down
+DELETE FROM role_permissions
+WHERE permission_id IN (SELECT id FROM permissions WHERE name LIKE 'user.%');
+DELETE FROM permissions WHERE name LIKE 'user.%';
+DROP TRIGGER audit_log_append_only ON audit_log;
+DROP FUNCTION audit_log_prevent_modification();
+DROP TABLE audit_log;
+ALTER TABLE users DROP COLUMN deactivated_at;
+ALTER TABLE users DROP COLUMN active;
@@ -0,0 +1,26 @@
+-- +goose Up
+ALTER TABLE audit_log ADD COLUMN request_id VARCHAR(64);
+ALTER TABLE audit_log ADD COLUMN client_ip VARCHAR(45);
+ALTER TABLE audit_log ADD COLUMN details JSONB;
+
+CREATE INDEX idx_audit_log_occurred_at ON audit_log (occurred_at);
+
+INSERT INTO permissions (name, description) VALUES
+    ('audit.read', 'Query the audit trail')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+CROSS JOIN permissions p
+WHERE r.name = 'admin' AND p.name = 'audit.read'
+ON CONFLICT DO NOTHING;
+
+-- +goose Down
+DELETE FROM role_permissions
+WHERE permission_id IN (SELECT id FROM permissions WHERE name = 'audit.read');
+DELETE FROM permissions WHERE name = 'audit.read';
+DROP INDEX idx_audit_log_occurred_at;
+ALTER TABLE audit_log DROP COLUMN details;
+ALTER TABLE audit_log DROP COLUMN client_ip;
+ALTER TABLE audit_log DROP COLUMN request_id;
@@ -0,0 +1,27 @@
+-- +goose Up
+ALTER TABLE patients ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE patients ADD COLUMN deleted_by INTEGER REFERENCES users(id);
+
+CREATE INDEX idx_patients_deleted_at ON patients (deleted_at) WHERE deleted_at IS NOT NULL;
+
+INSERT INTO permissions (name, description) VALUES
+    ('patient.restore', 'Restore deleted patient records'),
+    ('patient.purge', 'Permanently remove deleted patient records past their retention period')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+CROSS JOIN permissions p
+WHERE
+    (r.name = 'receptionist' AND p.name = 'patient.restore')
+    OR (r.name = 'admin' AND p.name IN ('patient.restore', 'patient.purge'))
+ON CONFLICT DO NOTHING;
+
+-- +goose Down
+DELETE FROM role_permissions
+WHERE permission_id IN (SELECT id FROM permissions WHERE name IN ('patient.restore', 'patient.purge'));
+DELETE FROM permissions WHERE name IN ('patient.restore', 'patient.purge');
+DROP INDEX idx_patients_deleted_at;
+ALTER TABLE patients DROP COLUMN deleted_by;
+ALTER TABLE patients DROP COLUMN deleted_at;
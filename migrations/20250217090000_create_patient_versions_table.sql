@@ -0,0 +1,29 @@
+-- +goose Up
+ALTER TABLE patients ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+
+CREATE TABLE patient_versions (
+    patient_id INTEGER NOT NULL REFERENCES patients(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    operation VARCHAR(20) NOT NULL,
+    first_name VARCHAR(100) NOT NULL,
+    last_name VARCHAR(100) NOT NULL,
+    date_of_birth DATE NOT NULL,
+    gender VARCHAR(20),
+    contact_number VARCHAR(20),
+    email VARCHAR(255),
+    address TEXT,
+    medical_history TEXT,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    changed_by INTEGER REFERENCES users(id),
+    changed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (patient_id, version)
+);
+
+-- Existing patients start their history at version 1 with their current state
+INSERT INTO patient_versions (patient_id, version, operation, first_name, last_name, date_of_birth, gender, contact_number, email, address, medical_history, deleted_at, changed_by, changed_at)
+SELECT id, version, 'create', first_name, last_name, date_of_birth, gender, contact_number, email, address, medical_history, deleted_at, updated_by, updated_at
+FROM patients;
+
+-- +goose Down
+DROP TABLE patient_versions;
+ALTER TABLE patients DROP COLUMN version;
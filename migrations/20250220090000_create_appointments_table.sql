@@ -0,0 +1,55 @@
+-- +goose Up
+-- btree_gist lets the exclusion constraints combine equality on IDs with range overlap
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+CREATE TABLE appointments (
+    id SERIAL PRIMARY KEY,
+    patient_id INTEGER NOT NULL REFERENCES patients(id),
+    doctor_id INTEGER NOT NULL REFERENCES users(id),
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ends_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'scheduled',
+    reason TEXT,
+    cancellation_reason TEXT,
+    cancelled_at TIMESTAMP WITH TIME ZONE,
+    created_by INTEGER REFERENCES users(id),
+    updated_by INTEGER REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT appointments_valid_period CHECK (ends_at > starts_at),
+    CONSTRAINT appointments_valid_status CHECK (status IN ('scheduled', 'checked_in', 'completed', 'cancelled', 'no_show')),
+    -- Neither a doctor nor a patient can be in two active appointments at once
+    CONSTRAINT appointments_no_doctor_overlap EXCLUDE USING gist (
+        doctor_id WITH =,
+        tstzrange(starts_at, ends_at) WITH &&
+    ) WHERE (status IN ('scheduled', 'checked_in')),
+    CONSTRAINT appointments_no_patient_overlap EXCLUDE USING gist (
+        patient_id WITH =,
+        tstzrange(starts_at, ends_at) WITH &&
+    ) WHERE (status IN ('scheduled', 'checked_in'))
+);
+
+CREATE INDEX idx_appointments_doctor_id ON appointments (doctor_id, starts_at);
+CREATE INDEX idx_appointments_patient_id ON appointments (patient_id, starts_at);
+
+INSERT INTO permissions (name, description) VALUES
+    ('appointment.create', 'Book appointments'),
+    ('appointment.read', 'View appointments and doctor calendars'),
+    ('appointment.update', 'Reschedule appointments and record check-in, completion and no-shows'),
+    ('appointment.cancel', 'Cancel appointments')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+CROSS JOIN permissions p
+WHERE
+    (r.name = 'receptionist' AND p.name IN ('appointment.create', 'appointment.read', 'appointment.update', 'appointment.cancel'))
+    OR (r.name = 'doctor' AND p.name IN ('appointment.read', 'appointment.update'))
+ON CONFLICT DO NOTHING;
+
+-- +goose Down
+DELETE FROM role_permissions
+WHERE permission_id IN (SELECT id FROM permissions WHERE name LIKE 'appointment.%');
+DELETE FROM permissions WHERE name LIKE 'appointment.%';
+DROP TABLE appointments;
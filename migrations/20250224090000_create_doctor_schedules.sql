@@ -0,0 +1,72 @@
+-- +goose Up
+CREATE TABLE doctor_schedules (
+    doctor_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    slot_minutes INTEGER NOT NULL DEFAULT 15 CHECK (slot_minutes > 0),
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    updated_by INTEGER REFERENCES users(id),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Recurring weekly working hours; weekday 0 is Sunday
+CREATE TABLE doctor_working_hours (
+    id SERIAL PRIMARY KEY,
+    doctor_id INTEGER NOT NULL REFERENCES doctor_schedules(doctor_id) ON DELETE CASCADE,
+    weekday SMALLINT NOT NULL CHECK (weekday BETWEEN 0 AND 6),
+    start_time TIME NOT NULL,
+    end_time TIME NOT NULL,
+    CHECK (end_time > start_time)
+);
+
+CREATE INDEX idx_doctor_working_hours_doctor_id ON doctor_working_hours (doctor_id, weekday);
+
+-- One-off changes to a day: extra hours (available) or blocked time (unavailable; the whole day when no times are given)
+CREATE TABLE doctor_schedule_exceptions (
+    id SERIAL PRIMARY KEY,
+    doctor_id INTEGER NOT NULL REFERENCES doctor_schedules(doctor_id) ON DELETE CASCADE,
+    date DATE NOT NULL,
+    start_time TIME,
+    end_time TIME,
+    available BOOLEAN NOT NULL DEFAULT FALSE,
+    reason TEXT,
+    created_by INTEGER REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK ((start_time IS NULL AND end_time IS NULL AND NOT available) OR (start_time IS NOT NULL AND end_time IS NOT NULL AND end_time > start_time))
+);
+
+CREATE INDEX idx_doctor_schedule_exceptions_doctor_id ON doctor_schedule_exceptions (doctor_id, date);
+
+CREATE TABLE doctor_leave (
+    id SERIAL PRIMARY KEY,
+    doctor_id INTEGER NOT NULL REFERENCES doctor_schedules(doctor_id) ON DELETE CASCADE,
+    starts_on DATE NOT NULL,
+    ends_on DATE NOT NULL,
+    reason TEXT,
+    created_by INTEGER REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK (ends_on >= starts_on)
+);
+
+CREATE INDEX idx_doctor_leave_doctor_id ON doctor_leave (doctor_id, starts_on);
+
+INSERT INTO permissions (name, description) VALUES
+    ('schedule.read', 'View doctor schedules and free appointment slots'),
+    ('schedule.manage', 'Change doctor working hours, exceptions and leave')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+CROSS JOIN permissions p
+WHERE
+    (r.name IN ('receptionist', 'doctor') AND p.name = 'schedule.read')
+    OR (r.name IN ('receptionist', 'admin') AND p.name IN ('schedule.read', 'schedule.manage'))
+ON CONFLICT DO NOTHING;
+
+-- +goose Down
+DELETE FROM role_permissions
+WHERE permission_id IN (SELECT id FROM permissions WHERE name IN ('schedule.read', 'schedule.manage'));
+DELETE FROM permissions WHERE name IN ('schedule.read', 'schedule.manage');
+DROP TABLE doctor_leave;
+DROP TABLE doctor_schedule_exceptions;
+DROP TABLE doctor_working_hours;
+DROP TABLE doctor_schedules;
@@ -0,0 +1,90 @@
+-- +goose Up
+CREATE TABLE encounters (
+    id SERIAL PRIMARY KEY,
+    patient_id INTEGER NOT NULL REFERENCES patients(id),
+    doctor_id INTEGER NOT NULL REFERENCES users(id),
+    appointment_id INTEGER REFERENCES appointments(id),
+    visit_date TIMESTAMP WITH TIME ZONE NOT NULL,
+    chief_complaint TEXT,
+    subjective TEXT,
+    objective TEXT,
+    assessment TEXT,
+    plan TEXT,
+    diagnoses JSONB NOT NULL DEFAULT '[]',
+    vitals JSONB,
+    status VARCHAR(20) NOT NULL DEFAULT 'draft',
+    signed_at TIMESTAMP WITH TIME ZONE,
+    signed_by INTEGER REFERENCES users(id),
+    created_by INTEGER REFERENCES users(id),
+    updated_by INTEGER REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT encounters_valid_status CHECK (status IN ('draft', 'signed')),
+    CONSTRAINT encounters_signature CHECK ((status = 'signed') = (signed_at IS NOT NULL AND signed_by IS NOT NULL))
+);
+
+CREATE INDEX idx_encounters_patient_id ON encounters (patient_id, visit_date);
+CREATE INDEX idx_encounters_doctor_id ON encounters (doctor_id, visit_date);
+
+-- Corrections to signed notes; like the notes themselves they can never be changed
+CREATE TABLE encounter_addenda (
+    id SERIAL PRIMARY KEY,
+    encounter_id INTEGER NOT NULL REFERENCES encounters(id),
+    author_id INTEGER NOT NULL REFERENCES users(id),
+    content TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_encounter_addenda_encounter_id ON encounter_addenda (encounter_id, created_at);
+
+-- +goose StatementBegin
+CREATE FUNCTION encounters_prevent_signed_modification() RETURNS trigger AS $$
+BEGIN
+    IF OLD.status = 'signed' THEN
+        RAISE EXCEPTION 'signed encounter % cannot be modified', OLD.id;
+    END IF;
+    IF TG_OP = 'DELETE' THEN
+        RETURN OLD;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER encounters_signed_immutable
+    BEFORE UPDATE OR DELETE ON encounters
+    FOR EACH ROW EXECUTE FUNCTION encounters_prevent_signed_modification();
+
+-- +goose StatementBegin
+CREATE FUNCTION encounter_addenda_prevent_modification() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'encounter_addenda is append-only';
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER encounter_addenda_append_only
+    BEFORE UPDATE OR DELETE OR TRUNCATE ON encounter_addenda
+    FOR EACH STATEMENT EXECUTE FUNCTION encounter_addenda_prevent_modification();
+
+INSERT INTO permissions (name, description) VALUES
+    ('encounter.read', 'View clinical encounters and visit notes'),
+    ('encounter.write', 'Create and edit draft visit notes and add addenda to signed notes'),
+    ('encounter.sign', 'Sign visit notes, making them immutable')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+CROSS JOIN permissions p
+WHERE r.name = 'doctor' AND p.name IN ('encounter.read', 'encounter.write', 'encounter.sign')
+ON CONFLICT DO NOTHING;
+
+-- +goose Down
+DELETE FROM role_permissions
+WHERE permission_id IN (SELECT id FROM permissions WHERE name IN ('encounter.read', 'encounter.write', 'encounter.sign'));
+DELETE FROM permissions WHERE name IN ('encounter.read', 'encounter.write', 'encounter.sign');
+DROP TABLE encounter_addenda;
+DROP FUNCTION encounter_addenda_prevent_modification();
+DROP TABLE encounters;
+DROP FUNCTION encounters_prevent_signed_modification();
@@ -0,0 +1,78 @@
+-- +goose Up
+CREATE TABLE vital_signs (
+    id SERIAL PRIMARY KEY,
+    patient_id INTEGER NOT NULL REFERENCES patients(id),
+    encounter_id INTEGER REFERENCES encounters(id),
+    type VARCHAR(32) NOT NULL,
+    value NUMERIC(7, 2) NOT NULL,
+    unit VARCHAR(16) NOT NULL,
+    recorded_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    recorded_by INTEGER REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT vital_signs_valid_type CHECK (type IN ('systolic_bp', 'diastolic_bp', 'pulse', 'respiratory_rate', 'temperature', 'spo2', 'weight', 'height', 'bmi'))
+);
+
+CREATE INDEX idx_vital_signs_patient_id ON vital_signs (patient_id, type, recorded_at);
+CREATE INDEX idx_vital_signs_encounter_id ON vital_signs (encounter_id);
+
+-- Vitals recorded on encounters are copied to vital_signs, which becomes the only place new vitals
+-- are stored. encounters.vitals is kept as it was, since signed notes must not change, but is
+-- read-only from now on.
+INSERT INTO vital_signs (patient_id, encounter_id, type, value, unit, recorded_at, recorded_by, created_at)
+SELECT e.patient_id, e.id, v.type, (e.vitals ->> v.key)::NUMERIC, v.unit, e.visit_date, e.doctor_id, e.created_at
+FROM encounters e
+CROSS JOIN (VALUES
+    ('systolic_bp', 'systolic_bp', 'mmHg'),
+    ('diastolic_bp', 'diastolic_bp', 'mmHg'),
+    ('pulse', 'pulse', 'bpm'),
+    ('respiratory_rate', 'respiratory_rate', 'breaths/min'),
+    ('temperature', 'temperature', 'C'),
+    ('spo2', 'spo2', '%'),
+    ('weight_kg', 'weight', 'kg'),
+    ('height_cm', 'height', 'cm')
+) AS v (key, type, unit)
+WHERE e.vitals ? v.key;
+
+-- +goose StatementBegin
+CREATE FUNCTION encounters_prevent_vitals_modification() RETURNS trigger AS $$
+BEGIN
+    IF NEW.vitals IS DISTINCT FROM OLD.vitals THEN
+        RAISE EXCEPTION 'encounters.vitals is read-only; record vital signs in vital_signs';
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER encounters_vitals_read_only
+    BEFORE UPDATE ON encounters
+    FOR EACH ROW EXECUTE FUNCTION encounters_prevent_vitals_modification();
+
+COMMENT ON COLUMN encounters.vitals IS 'Legacy vitals recorded before vital_signs existed; read-only, copied to vital_signs';
+
+INSERT INTO roles (name) VALUES ('nurse') ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO permissions (name, description) VALUES
+    ('vitals.read', 'View patient vital signs'),
+    ('vitals.record', 'Record patient vital signs')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+CROSS JOIN permissions p
+WHERE
+    (r.name IN ('doctor', 'nurse') AND p.name IN ('vitals.read', 'vitals.record'))
+    OR (r.name = 'nurse' AND p.name = 'patient.read')
+ON CONFLICT DO NOTHING;
+
+-- +goose Down
+DELETE FROM role_permissions
+WHERE permission_id IN (SELECT id FROM permissions WHERE name IN ('vitals.read', 'vitals.record'))
+    OR role_id IN (SELECT id FROM roles WHERE name = 'nurse');
+DELETE FROM permissions WHERE name IN ('vitals.read', 'vitals.record');
+DELETE FROM roles WHERE name = 'nurse' AND NOT EXISTS (SELECT 1 FROM users u WHERE u.role_id = roles.id);
+DROP TRIGGER encounters_vitals_read_only ON encounters;
+DROP FUNCTION encounters_prevent_vitals_modification();
+COMMENT ON COLUMN encounters.vitals IS NULL;
+DROP TABLE vital_signs;
@@ -0,0 +1,43 @@
+-- +goose Up
+CREATE TABLE allergies (
+    id SERIAL PRIMARY KEY,
+    patient_id INTEGER NOT NULL REFERENCES patients(id),
+    substance VARCHAR(200) NOT NULL,
+    category VARCHAR(20) NOT NULL DEFAULT 'other',
+    reaction TEXT,
+    severity VARCHAR(20) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'active',
+    notes TEXT,
+    recorded_by INTEGER REFERENCES users(id),
+    updated_by INTEGER REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT allergies_valid_category CHECK (category IN ('drug', 'food', 'environmental', 'other')),
+    CONSTRAINT allergies_valid_severity CHECK (severity IN ('mild', 'moderate', 'severe', 'life_threatening')),
+    CONSTRAINT allergies_valid_status CHECK (status IN ('active', 'inactive', 'resolved', 'entered_in_error'))
+);
+
+CREATE INDEX idx_allergies_patient_id ON allergies (patient_id, status);
+
+-- A substance can be listed as an active allergy of a patient only once
+CREATE UNIQUE INDEX idx_allergies_active_substance ON allergies (patient_id, LOWER(substance)) WHERE status = 'active';
+
+INSERT INTO permissions (name, description) VALUES
+    ('allergy.read', 'View patient allergies and adverse reactions'),
+    ('allergy.write', 'Record and update patient allergies and adverse reactions')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+CROSS JOIN permissions p
+WHERE
+    (r.name IN ('doctor', 'nurse') AND p.name IN ('allergy.read', 'allergy.write'))
+    OR (r.name = 'receptionist' AND p.name = 'allergy.read')
+ON CONFLICT DO NOTHING;
+
+-- +goose Down
+DELETE FROM role_permissions
+WHERE permission_id IN (SELECT id FROM permissions WHERE name IN ('allergy.read', 'allergy.write'));
+DELETE FROM permissions WHERE name IN ('allergy.read', 'allergy.write');
+DROP TABLE allergies;
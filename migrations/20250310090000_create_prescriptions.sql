@@ -0,0 +1,81 @@
+-- +goose Up
+CREATE TABLE drugs (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(200) NOT NULL,
+    generic_name VARCHAR(200) NOT NULL,
+    drug_class VARCHAR(100),
+    form VARCHAR(50),
+    strength VARCHAR(50),
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_drugs_product ON drugs (LOWER(name), COALESCE(LOWER(form), ''), COALESCE(LOWER(strength), ''));
+CREATE INDEX idx_drugs_name_trgm ON drugs USING gin (name gin_trgm_ops);
+CREATE INDEX idx_drugs_generic_name_trgm ON drugs USING gin (generic_name gin_trgm_ops);
+
+-- Substances are stored lower-cased with substance_a < substance_b so that each pair appears once
+CREATE TABLE drug_interactions (
+    id SERIAL PRIMARY KEY,
+    substance_a VARCHAR(200) NOT NULL,
+    substance_b VARCHAR(200) NOT NULL,
+    severity VARCHAR(20) NOT NULL,
+    description TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT drug_interactions_ordered CHECK (substance_a < substance_b),
+    CONSTRAINT drug_interactions_valid_severity CHECK (severity IN ('minor', 'moderate', 'major', 'contraindicated')),
+    CONSTRAINT drug_interactions_unique_pair UNIQUE (substance_a, substance_b)
+);
+
+CREATE TABLE prescriptions (
+    id SERIAL PRIMARY KEY,
+    patient_id INTEGER NOT NULL REFERENCES patients(id),
+    encounter_id INTEGER REFERENCES encounters(id),
+    drug_id INTEGER NOT NULL REFERENCES drugs(id),
+    dose VARCHAR(100) NOT NULL,
+    route VARCHAR(20) NOT NULL,
+    frequency VARCHAR(100) NOT NULL,
+    duration_days INTEGER NOT NULL CHECK (duration_days > 0),
+    instructions TEXT,
+    starts_on DATE NOT NULL,
+    ends_on DATE NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'active',
+    override_reason TEXT,
+    alerts JSONB NOT NULL DEFAULT '[]',
+    prescriber_id INTEGER NOT NULL REFERENCES users(id),
+    discontinued_at TIMESTAMP WITH TIME ZONE,
+    discontinued_by INTEGER REFERENCES users(id),
+    discontinued_reason TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT prescriptions_valid_status CHECK (status IN ('active', 'discontinued')),
+    CONSTRAINT prescriptions_valid_period CHECK (ends_on >= starts_on)
+);
+
+CREATE INDEX idx_prescriptions_patient_id ON prescriptions (patient_id, status, ends_on);
+
+INSERT INTO permissions (name, description) VALUES
+    ('drug.read', 'View the drug catalogue and interaction table'),
+    ('drug.manage', 'Maintain the drug catalogue and interaction table'),
+    ('prescription.read', 'View patient prescriptions'),
+    ('prescription.write', 'Prescribe and discontinue medications')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+CROSS JOIN permissions p
+WHERE
+    (r.name = 'doctor' AND p.name IN ('drug.read', 'prescription.read', 'prescription.write'))
+    OR (r.name = 'nurse' AND p.name IN ('drug.read', 'prescription.read'))
+    OR (r.name = 'admin' AND p.name IN ('drug.read', 'drug.manage'))
+ON CONFLICT DO NOTHING;
+
+-- +goose Down
+DELETE FROM role_permissions
+WHERE permission_id IN (SELECT id FROM permissions WHERE name IN ('drug.read', 'drug.manage', 'prescription.read', 'prescription.write'));
+DELETE FROM permissions WHERE name IN ('drug.read', 'drug.manage', 'prescription.read', 'prescription.write');
+DROP TABLE prescriptions;
+DROP TABLE drug_interactions;
+DROP TABLE drugs;
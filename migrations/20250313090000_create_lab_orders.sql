@@ -0,0 +1,123 @@
+-- +goose Up
+CREATE TABLE lab_tests (
+    id SERIAL PRIMARY KEY,
+    code VARCHAR(20) NOT NULL UNIQUE,
+    name VARCHAR(200) NOT NULL,
+    specimen VARCHAR(50),
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE lab_test_analytes (
+    id SERIAL PRIMARY KEY,
+    test_id INTEGER NOT NULL REFERENCES lab_tests(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    code VARCHAR(20) NOT NULL,
+    name VARCHAR(200) NOT NULL,
+    unit VARCHAR(20),
+    reference_low NUMERIC(12,4),
+    reference_high NUMERIC(12,4),
+    critical_low NUMERIC(12,4),
+    critical_high NUMERIC(12,4),
+    reference_text VARCHAR(100),
+    CONSTRAINT lab_test_analytes_unique_code UNIQUE (test_id, code)
+);
+
+CREATE TABLE lab_orders (
+    id SERIAL PRIMARY KEY,
+    patient_id INTEGER NOT NULL REFERENCES patients(id),
+    encounter_id INTEGER REFERENCES encounters(id),
+    test_id INTEGER NOT NULL REFERENCES lab_tests(id),
+    priority VARCHAR(10) NOT NULL DEFAULT 'routine',
+    status VARCHAR(20) NOT NULL DEFAULT 'ordered',
+    clinical_notes TEXT,
+    ordered_by INTEGER NOT NULL REFERENCES users(id),
+    collected_at TIMESTAMP WITH TIME ZONE,
+    collected_by INTEGER REFERENCES users(id),
+    resulted_at TIMESTAMP WITH TIME ZONE,
+    resulted_by INTEGER REFERENCES users(id),
+    verified_at TIMESTAMP WITH TIME ZONE,
+    verified_by INTEGER REFERENCES users(id),
+    cancelled_at TIMESTAMP WITH TIME ZONE,
+    cancelled_by INTEGER REFERENCES users(id),
+    cancellation_reason TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT lab_orders_valid_priority CHECK (priority IN ('routine', 'urgent', 'stat')),
+    CONSTRAINT lab_orders_valid_status CHECK (status IN ('ordered', 'collected', 'resulted', 'verified', 'cancelled'))
+);
+
+CREATE INDEX idx_lab_orders_patient_id ON lab_orders (patient_id, created_at DESC);
+CREATE INDEX idx_lab_orders_worklist ON lab_orders (status, priority, created_at) WHERE status IN ('ordered', 'collected', 'resulted');
+
+-- Results copy the analyte's name, unit and reference range so that later catalogue changes do
+-- not alter reported results
+CREATE TABLE lab_results (
+    id SERIAL PRIMARY KEY,
+    order_id INTEGER NOT NULL REFERENCES lab_orders(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    analyte_code VARCHAR(20) NOT NULL,
+    analyte_name VARCHAR(200) NOT NULL,
+    value NUMERIC(12,4),
+    value_text VARCHAR(200),
+    unit VARCHAR(20),
+    reference_low NUMERIC(12,4),
+    reference_high NUMERIC(12,4),
+    reference_text VARCHAR(100),
+    flag VARCHAR(20),
+    comment TEXT,
+    CONSTRAINT lab_results_unique_analyte UNIQUE (order_id, analyte_code),
+    CONSTRAINT lab_results_has_value CHECK (value IS NOT NULL OR value_text IS NOT NULL)
+);
+
+-- Verified results are final
+-- +goose StatementBegin
+CREATE FUNCTION lab_results_verified_immutable() RETURNS trigger AS $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM lab_orders WHERE id = COALESCE(OLD.order_id, NEW.order_id) AND status = 'verified') THEN
+        RAISE EXCEPTION 'results of verified lab order % cannot be changed', COALESCE(OLD.order_id, NEW.order_id);
+    END IF;
+    RETURN COALESCE(NEW, OLD);
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER lab_results_verified_immutable
+BEFORE INSERT OR UPDATE OR DELETE ON lab_results
+FOR EACH ROW EXECUTE FUNCTION lab_results_verified_immutable();
+
+INSERT INTO roles (name) VALUES ('lab_technician') ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO permissions (name, description) VALUES
+    ('lab.read', 'View lab orders and results'),
+    ('lab.order', 'Order and cancel lab investigations'),
+    ('lab.collect', 'Record specimen collection for lab orders'),
+    ('lab.result', 'Enter lab results'),
+    ('lab.verify', 'Verify lab results'),
+    ('lab.manage', 'Maintain the lab test catalogue')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+CROSS JOIN permissions p
+WHERE
+    (r.name = 'doctor' AND p.name IN ('lab.read', 'lab.order'))
+    OR (r.name = 'nurse' AND p.name IN ('lab.read', 'lab.collect'))
+    OR (r.name = 'lab_technician' AND p.name IN ('lab.read', 'lab.collect', 'lab.result', 'lab.verify', 'patient.read'))
+    OR (r.name = 'admin' AND p.name IN ('lab.read', 'lab.manage'))
+ON CONFLICT DO NOTHING;
+
+-- +goose Down
+DELETE FROM role_permissions
+WHERE permission_id IN (SELECT id FROM permissions WHERE name IN ('lab.read', 'lab.order', 'lab.collect', 'lab.result', 'lab.verify', 'lab.manage'))
+    OR role_id IN (SELECT id FROM roles WHERE name = 'lab_technician');
+DELETE FROM permissions WHERE name IN ('lab.read', 'lab.order', 'lab.collect', 'lab.result', 'lab.verify', 'lab.manage');
+DELETE FROM roles WHERE name = 'lab_technician' AND NOT EXISTS (SELECT 1 FROM users u WHERE u.role_id = roles.id);
+DROP TRIGGER lab_results_verified_immutable ON lab_results;
+DROP FUNCTION lab_results_verified_immutable();
+DROP TABLE lab_results;
+DROP TABLE lab_orders;
+DROP TABLE lab_test_analytes;
+DROP TABLE lab_tests;
@@ -0,0 +1,57 @@
+-- +goose Up
+CREATE TABLE icd10_codes (
+    code VARCHAR(8) PRIMARY KEY,
+    description TEXT NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_icd10_codes_code_prefix ON icd10_codes (code varchar_pattern_ops);
+CREATE INDEX idx_icd10_codes_description_trgm ON icd10_codes USING gin (description gin_trgm_ops);
+
+CREATE TABLE patient_problems (
+    id SERIAL PRIMARY KEY,
+    patient_id INTEGER NOT NULL REFERENCES patients(id),
+    code VARCHAR(8) NOT NULL REFERENCES icd10_codes(code) ON UPDATE CASCADE,
+    notes TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'active',
+    onset_date DATE,
+    resolved_date DATE,
+    recorded_by INTEGER REFERENCES users(id),
+    updated_by INTEGER REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT patient_problems_valid_status CHECK (status IN ('active', 'inactive', 'resolved')),
+    CONSTRAINT patient_problems_resolution CHECK ((status = 'resolved') = (resolved_date IS NOT NULL)),
+    CONSTRAINT patient_problems_valid_period CHECK (resolved_date IS NULL OR onset_date IS NULL OR resolved_date >= onset_date)
+);
+
+CREATE INDEX idx_patient_problems_patient_id ON patient_problems (patient_id, status);
+CREATE INDEX idx_patient_problems_code ON patient_problems (code);
+
+-- A code can be listed as an active problem of a patient only once
+CREATE UNIQUE INDEX idx_patient_problems_active_code ON patient_problems (patient_id, code) WHERE status = 'active';
+
+INSERT INTO permissions (name, description) VALUES
+    ('icd10.read', 'Search the ICD-10 code catalogue'),
+    ('problem.read', 'View patient problem lists'),
+    ('problem.write', 'Record and update patient problem lists')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+CROSS JOIN permissions p
+WHERE
+    (r.name = 'doctor' AND p.name IN ('icd10.read', 'problem.read', 'problem.write'))
+    OR (r.name = 'nurse' AND p.name IN ('icd10.read', 'problem.read'))
+    OR (r.name = 'admin' AND p.name = 'icd10.read')
+ON CONFLICT DO NOTHING;
+
+-- +goose Down
+DELETE FROM role_permissions
+WHERE permission_id IN (SELECT id FROM permissions WHERE name IN ('icd10.read', 'problem.read', 'problem.write'));
+DELETE FROM permissions WHERE name IN ('icd10.read', 'problem.read', 'problem.write');
+DROP TABLE patient_problems;
+DROP TABLE icd10_codes;
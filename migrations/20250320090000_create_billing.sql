@@ -0,0 +1,109 @@
+-- +goose Up
+CREATE TABLE billable_services (
+    id SERIAL PRIMARY KEY,
+    code VARCHAR(20) NOT NULL UNIQUE,
+    name VARCHAR(200) NOT NULL,
+    category VARCHAR(50),
+    price NUMERIC(12,2) NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT billable_services_valid_price CHECK (price >= 0)
+);
+
+CREATE INDEX idx_billable_services_name_trgm ON billable_services USING gin (name gin_trgm_ops);
+
+CREATE SEQUENCE invoice_number_seq;
+CREATE SEQUENCE receipt_number_seq;
+
+CREATE TABLE invoices (
+    id SERIAL PRIMARY KEY,
+    invoice_number VARCHAR(20) NOT NULL UNIQUE DEFAULT ('INV-' || lpad(nextval('invoice_number_seq')::text, 6, '0')),
+    patient_id INTEGER NOT NULL REFERENCES patients(id),
+    encounter_id INTEGER REFERENCES encounters(id),
+    status VARCHAR(20) NOT NULL DEFAULT 'draft',
+    notes TEXT,
+    total NUMERIC(12,2) NOT NULL DEFAULT 0,
+    amount_paid NUMERIC(12,2) NOT NULL DEFAULT 0,
+    created_by INTEGER NOT NULL REFERENCES users(id),
+    issued_at TIMESTAMP WITH TIME ZONE,
+    issued_by INTEGER REFERENCES users(id),
+    voided_at TIMESTAMP WITH TIME ZONE,
+    voided_by INTEGER REFERENCES users(id),
+    void_reason TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT invoices_valid_status CHECK (status IN ('draft', 'issued', 'partially_paid', 'paid', 'void')),
+    CONSTRAINT invoices_valid_amounts CHECK (total >= 0 AND amount_paid >= 0 AND amount_paid <= total),
+    CONSTRAINT invoices_void_unpaid CHECK (status <> 'void' OR amount_paid = 0)
+);
+
+CREATE INDEX idx_invoices_patient_id ON invoices (patient_id, created_at);
+CREATE INDEX idx_invoices_status ON invoices (status, created_at);
+
+CREATE TABLE invoice_items (
+    id SERIAL PRIMARY KEY,
+    invoice_id INTEGER NOT NULL REFERENCES invoices(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    service_id INTEGER REFERENCES billable_services(id),
+    description VARCHAR(200) NOT NULL,
+    quantity INTEGER NOT NULL,
+    unit_price NUMERIC(12,2) NOT NULL,
+    amount NUMERIC(12,2) NOT NULL,
+    CONSTRAINT invoice_items_valid_quantity CHECK (quantity > 0),
+    CONSTRAINT invoice_items_valid_amount CHECK (unit_price >= 0 AND amount = quantity * unit_price)
+);
+
+CREATE INDEX idx_invoice_items_invoice_id ON invoice_items (invoice_id, position);
+
+CREATE TABLE payments (
+    id SERIAL PRIMARY KEY,
+    invoice_id INTEGER NOT NULL REFERENCES invoices(id),
+    receipt_number VARCHAR(20) NOT NULL UNIQUE DEFAULT ('RCT-' || lpad(nextval('receipt_number_seq')::text, 6, '0')),
+    amount NUMERIC(12,2) NOT NULL,
+    method VARCHAR(20) NOT NULL,
+    reference VARCHAR(100),
+    received_by INTEGER NOT NULL REFERENCES users(id),
+    received_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT payments_valid_amount CHECK (amount > 0),
+    CONSTRAINT payments_valid_method CHECK (method IN ('cash', 'card', 'mobile_money')),
+    CONSTRAINT payments_mobile_money_reference CHECK (method <> 'mobile_money' OR reference IS NOT NULL)
+);
+
+CREATE INDEX idx_payments_invoice_id ON payments (invoice_id, id);
+
+-- Mobile money transaction codes are unique, so a transaction cannot be recorded twice
+CREATE UNIQUE INDEX idx_payments_mobile_money_reference ON payments (reference) WHERE method = 'mobile_money';
+
+INSERT INTO roles (name) VALUES ('cashier') ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO permissions (name, description) VALUES
+    ('billing.read', 'View invoices, payments and receipts'),
+    ('billing.invoice', 'Create, edit and issue invoices'),
+    ('billing.payment', 'Record payments against invoices'),
+    ('billing.void', 'Void unpaid invoices'),
+    ('billing.manage', 'Maintain the priced service catalogue')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+CROSS JOIN permissions p
+WHERE
+    (r.name = 'cashier' AND p.name IN ('billing.read', 'billing.invoice', 'billing.payment', 'patient.read'))
+    OR (r.name = 'receptionist' AND p.name IN ('billing.read', 'billing.invoice'))
+    OR (r.name = 'admin' AND p.name IN ('billing.read', 'billing.void', 'billing.manage'))
+ON CONFLICT DO NOTHING;
+
+-- +goose Down
+DELETE FROM role_permissions
+WHERE permission_id IN (SELECT id FROM permissions WHERE name IN ('billing.read', 'billing.invoice', 'billing.payment', 'billing.void', 'billing.manage'))
+    OR role_id IN (SELECT id FROM roles WHERE name = 'cashier');
+DELETE FROM permissions WHERE name IN ('billing.read', 'billing.invoice', 'billing.payment', 'billing.void', 'billing.manage');
+DELETE FROM roles WHERE name = 'cashier' AND NOT EXISTS (SELECT 1 FROM users u WHERE u.role_id = roles.id);
+DROP TABLE payments;
+DROP TABLE invoice_items;
+DROP TABLE invoices;
+DROP TABLE billable_services;
+DROP SEQUENCE receipt_number_seq;
+DROP SEQUENCE invoice_number_seq;
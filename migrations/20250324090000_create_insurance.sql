@@ -0,0 +1,74 @@
+-- +goose Up
+CREATE TABLE payers (
+    id SERIAL PRIMARY KEY,
+    code VARCHAR(20) NOT NULL UNIQUE,
+    name VARCHAR(200) NOT NULL,
+    payer_type VARCHAR(20) NOT NULL,
+    phone VARCHAR(30),
+    email VARCHAR(200),
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT payers_valid_type CHECK (payer_type IN ('public', 'private'))
+);
+
+CREATE TABLE insurance_policies (
+    id SERIAL PRIMARY KEY,
+    patient_id INTEGER NOT NULL REFERENCES patients(id),
+    payer_id INTEGER NOT NULL REFERENCES payers(id),
+    member_number VARCHAR(50) NOT NULL,
+    scheme VARCHAR(100),
+    relationship VARCHAR(20) NOT NULL DEFAULT 'principal',
+    principal_name VARCHAR(200),
+    valid_from DATE NOT NULL,
+    valid_until DATE,
+    copay_type VARCHAR(20) NOT NULL DEFAULT 'none',
+    copay_amount NUMERIC(12,2) NOT NULL DEFAULT 0,
+    copay_percent INTEGER NOT NULL DEFAULT 0,
+    cover_limit NUMERIC(12,2),
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    notes TEXT,
+    created_by INTEGER REFERENCES users(id),
+    updated_by INTEGER REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT insurance_policies_valid_relationship CHECK (relationship IN ('principal', 'dependant')),
+    CONSTRAINT insurance_policies_dependant_principal CHECK (relationship = 'principal' OR principal_name IS NOT NULL),
+    CONSTRAINT insurance_policies_valid_period CHECK (valid_until IS NULL OR valid_until >= valid_from),
+    CONSTRAINT insurance_policies_valid_copay CHECK (
+        (copay_type = 'none' AND copay_amount = 0 AND copay_percent = 0)
+        OR (copay_type = 'fixed' AND copay_amount > 0 AND copay_percent = 0)
+        OR (copay_type = 'percentage' AND copay_amount = 0 AND copay_percent BETWEEN 1 AND 100)
+    ),
+    CONSTRAINT insurance_policies_valid_cover_limit CHECK (cover_limit IS NULL OR cover_limit >= 0),
+    CONSTRAINT insurance_policies_unique_member UNIQUE (patient_id, payer_id, member_number)
+);
+
+CREATE INDEX idx_insurance_policies_patient_id ON insurance_policies (patient_id);
+CREATE INDEX idx_insurance_policies_member_number ON insurance_policies (payer_id, member_number);
+
+-- The Social Health Authority replaced NHIF as the public payer
+INSERT INTO payers (code, name, payer_type) VALUES ('SHA', 'Social Health Authority', 'public') ON CONFLICT (code) DO NOTHING;
+
+INSERT INTO permissions (name, description) VALUES
+    ('insurance.read', 'View payers, patient insurance policies and eligibility'),
+    ('insurance.write', 'Record and update patient insurance policies'),
+    ('insurance.manage', 'Maintain the list of payers')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+CROSS JOIN permissions p
+WHERE
+    (r.name IN ('receptionist', 'cashier') AND p.name IN ('insurance.read', 'insurance.write'))
+    OR (r.name = 'doctor' AND p.name = 'insurance.read')
+    OR (r.name = 'admin' AND p.name IN ('insurance.read', 'insurance.manage'))
+ON CONFLICT DO NOTHING;
+
+-- +goose Down
+DELETE FROM role_permissions
+WHERE permission_id IN (SELECT id FROM permissions WHERE name IN ('insurance.read', 'insurance.write', 'insurance.manage'));
+DELETE FROM permissions WHERE name IN ('insurance.read', 'insurance.write', 'insurance.manage');
+DROP TABLE insurance_policies;
+DROP TABLE payers;
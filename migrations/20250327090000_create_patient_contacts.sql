@@ -0,0 +1,48 @@
+-- +goose Up
+CREATE TABLE patient_contacts (
+    id SERIAL PRIMARY KEY,
+    patient_id INTEGER NOT NULL REFERENCES patients(id),
+    name VARCHAR(200) NOT NULL,
+    relationship VARCHAR(20) NOT NULL,
+    contact_number VARCHAR(20) NOT NULL,
+    alternate_number VARCHAR(20),
+    email VARCHAR(255),
+    address TEXT,
+    next_of_kin BOOLEAN NOT NULL DEFAULT FALSE,
+    emergency_contact BOOLEAN NOT NULL DEFAULT FALSE,
+    guardian BOOLEAN NOT NULL DEFAULT FALSE,
+    may_receive_information BOOLEAN NOT NULL DEFAULT FALSE,
+    may_consent_to_treatment BOOLEAN NOT NULL DEFAULT FALSE,
+    notes TEXT,
+    created_by INTEGER REFERENCES users(id),
+    updated_by INTEGER REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT patient_contacts_valid_relationship CHECK (relationship IN ('spouse', 'partner', 'parent', 'child', 'sibling', 'relative', 'guardian', 'friend', 'other')),
+    CONSTRAINT patient_contacts_has_role CHECK (next_of_kin OR emergency_contact OR guardian)
+);
+
+CREATE INDEX idx_patient_contacts_patient_id ON patient_contacts (patient_id);
+
+-- A patient has at most one next of kin
+CREATE UNIQUE INDEX idx_patient_contacts_next_of_kin ON patient_contacts (patient_id) WHERE next_of_kin;
+
+INSERT INTO permissions (name, description) VALUES
+    ('contact.read', 'View patient next of kin, emergency contacts and guardians'),
+    ('contact.write', 'Record and update patient next of kin, emergency contacts and guardians')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+CROSS JOIN permissions p
+WHERE
+    (r.name IN ('receptionist', 'nurse') AND p.name IN ('contact.read', 'contact.write'))
+    OR (r.name = 'doctor' AND p.name = 'contact.read')
+ON CONFLICT DO NOTHING;
+
+-- +goose Down
+DELETE FROM role_permissions
+WHERE permission_id IN (SELECT id FROM permissions WHERE name IN ('contact.read', 'contact.write'));
+DELETE FROM permissions WHERE name IN ('contact.read', 'contact.write');
+DROP TABLE patient_contacts;
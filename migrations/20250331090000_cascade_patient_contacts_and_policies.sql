@@ -0,0 +1,19 @@
+-- +goose Up
+-- Contacts and insurance policies are part of the patient's demographic record and are purged with
+-- it. Clinical and billing records are not: patients that have any are kept by the purge instead.
+ALTER TABLE patient_contacts DROP CONSTRAINT patient_contacts_patient_id_fkey;
+ALTER TABLE patient_contacts ADD CONSTRAINT patient_contacts_patient_id_fkey
+    FOREIGN KEY (patient_id) REFERENCES patients(id) ON DELETE CASCADE;
+
+ALTER TABLE insurance_policies DROP CONSTRAINT insurance_policies_patient_id_fkey;
+ALTER TABLE insurance_policies ADD CONSTRAINT insurance_policies_patient_id_fkey
+    FOREIGN KEY (patient_id) REFERENCES patients(id) ON DELETE CASCADE;
+
+-- +goose Down
+ALTER TABLE insurance_policies DROP CONSTRAINT insurance_policies_patient_id_fkey;
+ALTER TABLE insurance_policies ADD CONSTRAINT insurance_policies_patient_id_fkey
+    FOREIGN KEY (patient_id) REFERENCES patients(id);
+
+ALTER TABLE patient_contacts DROP CONSTRAINT patient_contacts_patient_id_fkey;
+ALTER TABLE patient_contacts ADD CONSTRAINT patient_contacts_patient_id_fkey
+    FOREIGN KEY (patient_id) REFERENCES patients(id);
@@ -0,0 +1,22 @@
+-- +goose Up
+-- Problems recorded by mistake are retired as entered_in_error instead of being deleted. A resolved
+-- problem retired this way keeps its resolution date.
+ALTER TABLE patient_problems DROP CONSTRAINT patient_problems_valid_status;
+ALTER TABLE patient_problems ADD CONSTRAINT patient_problems_valid_status
+    CHECK (status IN ('active', 'inactive', 'resolved', 'entered_in_error'));
+
+ALTER TABLE patient_problems DROP CONSTRAINT patient_problems_resolution;
+ALTER TABLE patient_problems ADD CONSTRAINT patient_problems_resolution
+    CHECK (status = 'entered_in_error' OR (status = 'resolved') = (resolved_date IS NOT NULL));
+
+-- +goose Down
+-- Problems retired as entered_in_error cannot be represented any more and are removed
+DELETE FROM patient_problems WHERE status = 'entered_in_error';
+
+ALTER TABLE patient_problems DROP CONSTRAINT patient_problems_resolution;
+ALTER TABLE patient_problems ADD CONSTRAINT patient_problems_resolution
+    CHECK ((status = 'resolved') = (resolved_date IS NOT NULL));
+
+ALTER TABLE patient_problems DROP CONSTRAINT patient_problems_valid_status;
+ALTER TABLE patient_problems ADD CONSTRAINT patient_problems_valid_status
+    CHECK (status IN ('active', 'inactive', 'resolved'));
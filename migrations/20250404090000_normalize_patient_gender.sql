@@ -0,0 +1,21 @@
+-- +goose Up
+-- Gender codes are stored in lower case, with an empty string when the gender was not given.
+-- Records created before validation was introduced may hold NULL, mixed-case or abbreviated
+-- values, which would make reading the record or any later update of it fail.
+UPDATE patients SET gender = LOWER(TRIM(COALESCE(gender, '')));
+UPDATE patients SET gender = 'male' WHERE gender = 'm';
+UPDATE patients SET gender = 'female' WHERE gender = 'f';
+UPDATE patients SET gender = 'unknown' WHERE gender NOT IN ('', 'male', 'female', 'other', 'unknown');
+
+ALTER TABLE patients ALTER COLUMN gender SET DEFAULT '';
+ALTER TABLE patients ALTER COLUMN gender SET NOT NULL;
+ALTER TABLE patients ADD CONSTRAINT patients_valid_gender CHECK (gender IN ('', 'male', 'female', 'other', 'unknown'));
+
+-- History snapshots keep the spelling they were taken with, but must be readable too
+UPDATE patient_versions SET gender = '' WHERE gender IS NULL;
+
+-- +goose Down
+-- The original spelling of normalised values is not kept, so only the constraints are undone.
+ALTER TABLE patients DROP CONSTRAINT patients_valid_gender;
+ALTER TABLE patients ALTER COLUMN gender DROP NOT NULL;
+ALTER TABLE patients ALTER COLUMN gender DROP DEFAULT;
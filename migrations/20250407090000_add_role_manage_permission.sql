@@ -0,0 +1,15 @@
+-- +goose Up
+INSERT INTO permissions (name, description) VALUES
+    ('role.manage', 'Grant and revoke role permissions and reload cached permissions')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r CROSS JOIN permissions p
+WHERE r.name = 'admin' AND p.name = 'role.manage'
+ON CONFLICT DO NOTHING;
+
+-- +goose Down
+DELETE FROM role_permissions
+WHERE permission_id IN (SELECT id FROM permissions WHERE name = 'role.manage');
+DELETE FROM permissions WHERE name = 'role.manage';
@@ -0,0 +1,20 @@
+-- +goose Up
+ALTER TABLE audit_log ADD COLUMN patient_id BIGINT;
+
+-- Backfill existing entries: patient entries are about the patient itself, the other patient
+-- sub-resources recorded the owning patient in details. audit_log is append-only, so the trigger
+-- is disabled for the duration of the backfill only.
+ALTER TABLE audit_log DISABLE TRIGGER audit_log_append_only;
+UPDATE audit_log
+SET patient_id = CASE
+    WHEN entity_type = 'patient' THEN entity_id
+    ELSE (details->>'patient_id')::BIGINT
+END
+WHERE entity_type = 'patient' OR details ? 'patient_id';
+ALTER TABLE audit_log ENABLE TRIGGER audit_log_append_only;
+
+CREATE INDEX idx_audit_log_patient ON audit_log (patient_id, occurred_at) WHERE patient_id IS NOT NULL;
+
+-- +goose Down
+DROP INDEX idx_audit_log_patient;
+ALTER TABLE audit_log DROP COLUMN patient_id;
@@ -0,0 +1,33 @@
+-- +goose Up
+-- Installs migrated before the admin role existed seeded the admin user with no usable role, so
+-- the role is created here, granted the administrative permissions introduced by earlier
+-- migrations, and assigned to the seeded admin user.
+INSERT INTO roles (name) VALUES ('admin') ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+CROSS JOIN permissions p
+WHERE r.name = 'admin' AND (
+    p.name LIKE 'user.%'
+    OR p.name IN (
+        'session.revoke', 'audit.read', 'role.manage',
+        'patient.restore', 'patient.purge',
+        'schedule.read', 'schedule.manage',
+        'drug.read', 'drug.manage',
+        'lab.read', 'lab.manage',
+        'icd10.read',
+        'billing.read', 'billing.void', 'billing.manage',
+        'insurance.read', 'insurance.manage'
+    )
+)
+ON CONFLICT DO NOTHING;
+
+UPDATE users
+SET role_id = (SELECT id FROM roles WHERE name = 'admin')
+WHERE username = 'admin'
+  AND role_id IS DISTINCT FROM (SELECT id FROM roles WHERE name = 'admin');
+
+-- +goose Down
+-- The admin role, its permissions and the admin user's role are kept because users may already
+-- depend on them
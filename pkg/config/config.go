@@ -4,6 +4,8 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/Okemwag/medihub/pkg/database"
 	"github.com/joho/godotenv"
@@ -31,3 +33,27 @@ func atoi(value string) int {
 	}
 	return v
 }
+
+// Duration reads a time.Duration such as "15m" or "168h" from the environment, returning fallback when unset.
+func Duration(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Fatalf("Invalid duration value for %s: %v", key, err)
+	}
+	return d
+}
+
+// List reads a comma-separated list such as "10.0.0.1,10.0.0.2" from the environment, returning nil when unset.
+func List(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}